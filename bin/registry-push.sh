@@ -2,7 +2,7 @@
 
 # Default provider value
 provider="aws"
-directory="./images/aws"
+directory="$(dirname "$0")/../pkg/cluster/internal/registry/images/aws"
 
 # Parse command line arguments
 while [[ $# -gt 0 ]]; do
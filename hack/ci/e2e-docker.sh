@@ -0,0 +1,97 @@
+#!/usr/bin/env bash
+# Copyright 2019 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# hack script for running the cloud-provisioner e2e with the docker infra
+# provider: the workload cluster nodes are containers of this (Linux) host,
+# so no cloud account is needed
+# Usage: KEOS_REGISTRY=registry.example.com HELM_REPOSITORY=https://charts.example.com e2e-docker.sh
+
+set -o errexit -o nounset -o pipefail -o xtrace
+
+REPO_ROOT="$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd -P)"
+
+# the registry of the keos images and the repository of the keos charts
+KEOS_REGISTRY="${KEOS_REGISTRY:?set the registry of the keos images}"
+HELM_REPOSITORY="${HELM_REPOSITORY:?set the repository of the keos charts}"
+K8S_VERSION="${K8S_VERSION:-v1.27.3}"
+CLUSTER_NAME="${CLUSTER_NAME:-e2e-docker}"
+
+export CLOUD_PROVISIONER_VAULT_PASSWORD="${CLOUD_PROVISIONER_VAULT_PASSWORD:-e2e-docker}"
+
+# our exit handler (trap)
+cleanup() {
+  # delete the workload cluster, then the local one
+  if [[ -n "${TMP_DIR:-}" ]]; then
+    (cd "${TMP_DIR}" && cloud-provisioner delete workload-cluster --name "${CLUSTER_NAME}" --descriptor cluster.yaml) || true
+    cloud-provisioner delete cluster --name "${CLUSTER_NAME}" || true
+    # remove our tempdir, this needs to be last, or it will prevent the deletion
+    rm -rf "${TMP_DIR:?}"
+  fi
+}
+
+# install cloud-provisioner to a tempdir from this script's checkout
+install_cloud_provisioner() {
+  mkdir -p "${TMP_DIR}/bin"
+  make -C "${REPO_ROOT}" install INSTALL_DIR="${TMP_DIR}/bin"
+  export PATH="${TMP_DIR}/bin:${PATH}"
+}
+
+write_descriptor() {
+  cat <<DESCRIPTOR > "${TMP_DIR}/cluster.yaml"
+apiVersion: installer.stratio.com/v1beta1
+kind: KeosCluster
+metadata:
+  name: ${CLUSTER_NAME}
+spec:
+  infra_provider: docker
+  k8s_version: ${K8S_VERSION}
+  region: local
+  deploy_autoscaler: true
+  external_domain: ${CLUSTER_NAME}.local
+  docker_registries:
+    - url: ${KEOS_REGISTRY}
+      type: generic
+      keos_registry: true
+  helm_repository:
+    url: ${HELM_REPOSITORY}
+  control_plane:
+    managed: false
+    highly_available: false
+    size: docker
+  worker_nodes:
+    - name: worker
+      quantity: 2
+      max_size: 3
+      min_size: 1
+      size: docker
+      zone_distribution: unbalanced
+DESCRIPTOR
+}
+
+main() {
+  # create temp dir and setup cleanup
+  TMP_DIR=$(mktemp -d)
+  trap cleanup INT TERM EXIT
+
+  install_cloud_provisioner
+  write_descriptor
+
+  cd "${TMP_DIR}"
+  cloud-provisioner create cluster --name "${CLUSTER_NAME}" --descriptor cluster.yaml
+  cloud-provisioner get workload-cluster --descriptor cluster.yaml
+  cloud-provisioner diff --descriptor cluster.yaml --exit-code
+}
+
+main
@@ -0,0 +1,51 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// APIVersion is the apiVersion of the descriptors using this layout
+const APIVersion = "installer.stratio.com/v1alpha1"
+
+// KeosCluster contains the fields of a v1alpha1 KeosCluster that changed in
+// later versions, the rest of the spec is kept as it is when converting
+type KeosCluster struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Spec       Spec   `yaml:"spec"`
+}
+
+// Spec contains the v1alpha1 KeosCluster spec fields that changed
+type Spec struct {
+	// ClusterID is the name of the cluster, moved to metadata.name
+	ClusterID string `yaml:"cluster_id"`
+
+	// ExternalDomain takes precedence over Keos.ExternalDomain if both are set
+	ExternalDomain string `yaml:"external_domain,omitempty"`
+
+	Keos Keos `yaml:"keos,omitempty"`
+}
+
+// Keos contains the v1alpha1 KEOS settings
+type Keos struct {
+	// Domain is the internal cluster domain, no longer configurable
+	Domain string `yaml:"domain,omitempty"`
+
+	// ExternalDomain is moved to spec.external_domain, unless it is already set
+	ExternalDomain string `yaml:"external_domain,omitempty"`
+
+	Flavour string `yaml:"flavour,omitempty"`
+	Version string `yaml:"version,omitempty"`
+}
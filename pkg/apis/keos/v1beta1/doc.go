@@ -0,0 +1,24 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1beta1 implements the v1beta1 apiVersion of the cluster
+// descriptor, the latest one.
+//
+// The v1beta1 KeosCluster and ClusterConfig types are commons.KeosCluster and
+// commons.ClusterConfig, with commons.KeosSpec and commons.ClusterConfigSpec
+// as their specs. They are not redefined here because every other descriptor
+// version is converted to v1beta1 before being decoded into them.
+package v1beta1
@@ -0,0 +1,43 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"time"
+
+	internalapply "sigs.k8s.io/kind/pkg/cluster/internal/apply"
+)
+
+// ApplyOption is a Provider.Apply option
+type ApplyOption interface {
+	apply(*internalapply.ApplyParams) error
+}
+
+type applyOptionAdapter func(*internalapply.ApplyParams) error
+
+func (c applyOptionAdapter) apply(o *internalapply.ApplyParams) error {
+	return c(o)
+}
+
+// ApplyWithWait sets how long to wait for the KeosCluster to be reconciled,
+// it is not waited for if zero
+func ApplyWithWait(wait time.Duration) ApplyOption {
+	return applyOptionAdapter(func(o *internalapply.ApplyParams) error {
+		o.Wait = wait
+		return nil
+	})
+}
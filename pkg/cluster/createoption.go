@@ -94,6 +94,57 @@ func CreateWithAvoidCreation(avoidCreation bool) CreateOption {
 	})
 }
 
+// CreateWithSecretsPath sets the ansible-vault encrypted secrets file the
+// credentials missing in it are written to, none if empty
+func CreateWithSecretsPath(secretsPath string) CreateOption {
+	return createOptionAdapter(func(o *internalcreate.ClusterOptions) error {
+		o.SecretsPath = secretsPath
+		return nil
+	})
+}
+
+// CreateWithResume resumes a previously failed creation in the retained local
+// cluster, skipping the phases already recorded in its checkpoint
+func CreateWithResume(resume bool) CreateOption {
+	return createOptionAdapter(func(o *internalcreate.ClusterOptions) error {
+		o.Resume = resume
+		return nil
+	})
+}
+
+// CreateWithDryRun lists the phases to be run without creating anything
+func CreateWithDryRun(dryRun bool) CreateOption {
+	return createOptionAdapter(func(o *internalcreate.ClusterOptions) error {
+		o.DryRun = dryRun
+		return nil
+	})
+}
+
+// CreateWithRenderDir writes the manifests and commands of the phases into the
+// local directory dir instead of creating the cluster
+func CreateWithRenderDir(dir string) CreateOption {
+	return createOptionAdapter(func(o *internalcreate.ClusterOptions) error {
+		o.RenderDir = dir
+		return nil
+	})
+}
+
+// CreateWithSkipPhases sets the phases not to be run
+func CreateWithSkipPhases(phases []string) CreateOption {
+	return createOptionAdapter(func(o *internalcreate.ClusterOptions) error {
+		o.SkipPhases = phases
+		return nil
+	})
+}
+
+// CreateWithOnlyPhase runs a single phase against the existing local cluster
+func CreateWithOnlyPhase(phase string) CreateOption {
+	return createOptionAdapter(func(o *internalcreate.ClusterOptions) error {
+		o.OnlyPhase = phase
+		return nil
+	})
+}
+
 // CreateWithWaitForceDelete removes local cluster container
 func CreateWithForceDelete(forceDelete bool) CreateOption {
 	return createOptionAdapter(func(o *internalcreate.ClusterOptions) error {
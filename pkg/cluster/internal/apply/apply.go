@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apply implements applying the descriptor changes to a running
+// workload cluster
+package apply
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// FieldManager is the server-side apply field manager owning the fields set
+// from the descriptor
+const FieldManager = "cloud-provisioner"
+
+// ApplyParams holds the descriptor to be applied and how
+type ApplyParams struct {
+	KeosCluster   commons.KeosCluster
+	ClusterConfig *commons.ClusterConfig
+	Logger        log.Logger
+	// Client manages the cluster holding the KeosCluster
+	Client kube.Client
+	// Wait is how long to wait for the KeosCluster to be reconciled, it is
+	// not waited for if zero
+	Wait time.Duration
+}
+
+// Cluster server-side applies the ClusterConfig and KeosCluster objects of the
+// descriptor and waits for the keoscluster-controller to reconcile them
+func Cluster(params *ApplyParams) error {
+	status := cli.StatusForLogger(params.Logger)
+	keosCluster := commons.KeosClusterObject(params.KeosCluster, params.ClusterConfig)
+	namespace := keosCluster.Metadata.Namespace
+
+	status.Start("Applying the descriptor to cluster " + keosCluster.Metadata.Name + " 📝")
+	if params.ClusterConfig != nil {
+		if err := apply(params.Client, *params.ClusterConfig, "clusterconfig"); err != nil {
+			status.End(false)
+			return err
+		}
+	}
+	if err := apply(params.Client, keosCluster, "keoscluster"); err != nil {
+		status.End(false)
+		return err
+	}
+	status.End(true)
+
+	if params.Wait == 0 {
+		return nil
+	}
+
+	// The controller reports the generation of the last reconciled spec
+	status.Start("Waiting for the keoscluster-controller to reconcile the changes ⏳")
+	generation, err := params.Client.Get(namespace, "keoscluster", keosCluster.Metadata.Name, "jsonpath={.metadata.generation}")
+	if err != nil {
+		status.End(false)
+		return errors.Wrap(err, "failed to get the keoscluster generation")
+	}
+	condition := "jsonpath={.status.observedGeneration}=" + strings.TrimSpace(generation)
+	err = params.Client.Wait(namespace, "keoscluster", keosCluster.Metadata.Name, condition, params.Wait)
+	if err != nil {
+		status.End(false)
+		return errors.Wrap(err, "failed to wait for the keoscluster to be reconciled")
+	}
+	status.End(true)
+	return nil
+}
+
+// apply server-side applies the object of resource
+func apply(client kube.Client, object interface{}, resource string) error {
+	manifest, err := yaml.Marshal(object)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the "+resource)
+	}
+	if err := client.ServerSideApply("", string(manifest), FieldManager); err != nil {
+		return errors.Wrap(err, "failed to apply the "+resource)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+const serverSideApply = "kubectl apply --server-side --force-conflicts --field-manager=cloud-provisioner -f -"
+
+func newTestKeosCluster() commons.KeosCluster {
+	keosCluster := commons.KeosCluster{APIVersion: "installer.stratio.com/v1beta1", Kind: "KeosCluster"}
+	keosCluster.Metadata.Name = "test"
+	keosCluster.Metadata.Namespace = "cluster-test"
+	keosCluster.Spec.InfraProvider = "aws"
+	keosCluster.Spec.Credentials.AWS.SecretKey = "s3cr3t"
+	return keosCluster
+}
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+	clusterConfig := &commons.ClusterConfig{}
+	clusterConfig.Metadata.Name = "test-config"
+
+	cases := []struct {
+		Name          string
+		ClusterConfig *commons.ClusterConfig
+		Wait          time.Duration
+		Errors        map[string]error
+		Expected      []string
+		ExpectError   bool
+	}{
+		{
+			Name:          "apply and wait",
+			ClusterConfig: clusterConfig,
+			Wait:          5 * time.Minute,
+			Expected: []string{
+				serverSideApply,
+				serverSideApply,
+				"kubectl --namespace cluster-test get keoscluster test -o jsonpath={.metadata.generation}",
+				"kubectl --namespace cluster-test wait keoscluster test --for=jsonpath={.status.observedGeneration}=2 --timeout=5m0s",
+			},
+		},
+		{
+			Name:     "apply without waiting",
+			Expected: []string{serverSideApply},
+		},
+		{
+			Name:        "apply failure",
+			Wait:        5 * time.Minute,
+			Errors:      map[string]error{serverSideApply: &kube.CommandError{ExitCode: 1}},
+			Expected:    []string{serverSideApply},
+			ExpectError: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			client := kube.NewFakeClient("")
+			client.Outputs["kubectl --namespace cluster-test get keoscluster test -o jsonpath={.metadata.generation}"] = "2"
+			for line, err := range tc.Errors {
+				client.Errors[line] = err
+			}
+			err := Cluster(&ApplyParams{
+				KeosCluster:   newTestKeosCluster(),
+				ClusterConfig: tc.ClusterConfig,
+				Logger:        log.NoopLogger{},
+				Client:        client,
+				Wait:          tc.Wait,
+			})
+			assert.ExpectError(t, tc.ExpectError, err)
+			assert.DeepEqual(t, tc.Expected, client.Commands)
+		})
+	}
+}
+
+func TestClusterStripsCredentials(t *testing.T) {
+	t.Parallel()
+	client := kube.NewFakeClient("")
+	clusterConfig := &commons.ClusterConfig{}
+	clusterConfig.Metadata.Name = "test-config"
+	err := Cluster(&ApplyParams{
+		KeosCluster:   newTestKeosCluster(),
+		ClusterConfig: clusterConfig,
+		Logger:        log.NoopLogger{},
+		Client:        client,
+	})
+	assert.ExpectError(t, false, err)
+	manifest := client.Stdins[serverSideApply]
+	assert.BoolEqual(t, false, strings.Contains(manifest, "s3cr3t"))
+	assert.BoolEqual(t, true, strings.Contains(manifest, "name: test-config"))
+	assert.BoolEqual(t, true, strings.Contains(manifest, "namespace: cluster-test"))
+}
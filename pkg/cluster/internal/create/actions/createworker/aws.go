@@ -25,12 +25,11 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
 	"sigs.k8s.io/kind/pkg/cluster/nodes"
 	"sigs.k8s.io/kind/pkg/commons"
 	"sigs.k8s.io/kind/pkg/errors"
-	"sigs.k8s.io/kind/pkg/exec"
 )
 
 //go:embed files/aws/internal-ingress-nginx.yaml
@@ -46,6 +45,13 @@ type AWSBuilder struct {
 	scParameters     commons.SCParameters
 	scProvisioner    string
 	csiNamespace     string
+	// clusterOperatorValues are the helm values of the cluster-operator
+	// with the provider credentials
+	clusterOperatorValues map[string]string
+}
+
+func init() {
+	registerBuilder("aws", func() PBuilder { return newAWSBuilder() })
 }
 
 func newAWSBuilder() *AWSBuilder {
@@ -54,8 +60,8 @@ func newAWSBuilder() *AWSBuilder {
 
 func (b *AWSBuilder) setCapx(managed bool) {
 	b.capxProvider = "aws"
-	b.capxVersion = "v2.2.1"
-	b.capxImageVersion = "v2.2.1"
+	b.capxVersion = commons.GetBOM().InfraProviderVersion(b.capxProvider)
+	b.capxImageVersion = b.capxVersion
 	b.capxName = "capa"
 	b.capxManaged = managed
 	b.csiNamespace = "kube-system"
@@ -70,6 +76,9 @@ func (b *AWSBuilder) setCapxEnvVars(p ProviderParams) {
 		"AWS_B64ENCODED_CREDENTIALS=" + base64.StdEncoding.EncodeToString([]byte(awsCredentials)),
 		"CAPA_EKS_IAM=true",
 	}
+	b.clusterOperatorValues = map[string]string{
+		"secrets.common.credentialsBase64": base64.StdEncoding.EncodeToString([]byte(awsCredentials)),
+	}
 	if p.GithubToken != "" {
 		b.capxEnvVars = append(b.capxEnvVars, "GITHUB_TOKEN="+p.GithubToken)
 	}
@@ -108,6 +117,10 @@ func (b *AWSBuilder) getProvider() Provider {
 		scParameters:     b.scParameters,
 		scProvisioner:    b.scProvisioner,
 		csiNamespace:     b.csiNamespace,
+
+		clusterOperatorValues: b.clusterOperatorValues,
+		capxRestart:           b.capxManaged,
+		helmRepositoryConfig:  configureS3HelmRepository,
 	}
 }
 
@@ -119,19 +132,22 @@ func (b *AWSBuilder) installCloudProvider(n nodes.Node, k string, privateParams
 	} else {
 		podsCidrBlock = "192.168.0.0/16"
 	}
-	c := "helm install aws-cloud-controller-manager /stratio/helm/aws-cloud-controller-manager" +
-		" --kubeconfig " + k +
-		" --namespace kube-system" +
-		" --set args[0]=\"--v=2\"" +
-		" --set args[1]=\"--cloud-provider=aws\"" +
-		" --set args[2]=\"--cluster-cidr=" + podsCidrBlock + "\"" +
-		" --set args[3]=\"--cluster-name=" + keosCluster.Metadata.Name + "\""
-
+	release := kube.HelmRelease{
+		Name:      "aws-cloud-controller-manager",
+		Chart:     "/stratio/helm/aws-cloud-controller-manager",
+		Namespace: "kube-system",
+		Values: map[string]string{
+			"args[0]": "--v=2",
+			"args[1]": "--cloud-provider=aws",
+			"args[2]": "--cluster-cidr=" + podsCidrBlock,
+			"args[3]": "--cluster-name=" + keosCluster.Metadata.Name,
+		},
+	}
 	if privateParams.Private {
-		c += " --set image.repository=" + privateParams.KeosRegUrl + "/provider-aws/cloud-controller-manager"
+		release.Values["image.repository"] = privateParams.KeosRegUrl + "/provider-aws/cloud-controller-manager"
 	}
 
-	_, err := commons.ExecuteCommand(n, c, 5)
+	err := kube.NewClient(n, k).HelmInstall(release)
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy aws-cloud-controller-manager Helm Chart")
 	}
@@ -139,23 +155,25 @@ func (b *AWSBuilder) installCloudProvider(n nodes.Node, k string, privateParams
 }
 
 func (b *AWSBuilder) installCSI(n nodes.Node, k string, privateParams PrivateParams) error {
-	c := "helm install aws-ebs-csi-driver /stratio/helm/aws-ebs-csi-driver" +
-		" --kubeconfig " + k +
-		" --namespace " + b.csiNamespace +
-		" --set controller.podAnnotations.\"cluster-autoscaler\\.kubernetes\\.io/safe-to-evict-local-volumes=socket-dir\""
-
+	release := kube.HelmRelease{
+		Name:      "aws-ebs-csi-driver",
+		Chart:     "/stratio/helm/aws-ebs-csi-driver",
+		Namespace: b.csiNamespace,
+		Values: map[string]string{
+			"controller.podAnnotations.cluster-autoscaler\\.kubernetes\\.io/safe-to-evict-local-volumes": "socket-dir",
+		},
+	}
 	if privateParams.Private {
-		c += " --set image.repository=" + privateParams.KeosRegUrl + "/ebs-csi-driver/aws-ebs-csi-driver" +
-			" --set sidecars.provisioner.image.repository=" + privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/external-provisioner" +
-			" --set sidecars.attacher.image.repository=" + privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/external-attacher" +
-			" --set sidecars.snapshotter.image.repository=" + privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/external-snapshotter/csi-snapshotter" +
-			" --set sidecars.livenessProbe.image.repository=" + privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/livenessprobe" +
-			" --set sidecars.resizer.image.repository=" + privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/external-resizer" +
-			" --set sidecars.nodeDriverRegistrar.image.repository=" + privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/node-driver-registrar" +
-			" --set sidecars.volumemodifier.image.repository=" + privateParams.KeosRegUrl + "/ebs-csi-driver/volume-modifier-for-k8s"
-
+		release.Values["image.repository"] = privateParams.KeosRegUrl + "/ebs-csi-driver/aws-ebs-csi-driver"
+		release.Values["sidecars.provisioner.image.repository"] = privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/external-provisioner"
+		release.Values["sidecars.attacher.image.repository"] = privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/external-attacher"
+		release.Values["sidecars.snapshotter.image.repository"] = privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/external-snapshotter/csi-snapshotter"
+		release.Values["sidecars.livenessProbe.image.repository"] = privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/livenessprobe"
+		release.Values["sidecars.resizer.image.repository"] = privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/external-resizer"
+		release.Values["sidecars.nodeDriverRegistrar.image.repository"] = privateParams.KeosRegUrl + "/eks-distro/kubernetes-csi/node-driver-registrar"
+		release.Values["sidecars.volumemodifier.image.repository"] = privateParams.KeosRegUrl + "/ebs-csi-driver/volume-modifier-for-k8s"
 	}
-	_, err := commons.ExecuteCommand(n, c, 5)
+	err := kube.NewClient(n, k).HelmInstall(release)
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy AWS EBS CSI driver Helm Chart")
 	}
@@ -163,9 +181,36 @@ func (b *AWSBuilder) installCSI(n nodes.Node, k string, privateParams PrivatePar
 }
 
 func createCloudFormationStack(n nodes.Node, envVars []string) error {
-	var c string
-	var err error
+	eksConfigPath, err := writeEKSConfig(n)
+	if err != nil {
+		return err
+	}
 
+	// Run clusterawsadm with the eks.config file previously created (this will create or update the CloudFormation stack in AWS)
+	c := "clusterawsadm bootstrap iam create-cloudformation-stack --config " + eksConfigPath
+	_, err = commons.ExecuteCommand(n, c, 5, envVars)
+	if err != nil {
+		return errors.Wrap(err, "failed to run clusterawsadm")
+	}
+	return nil
+}
+
+func deleteCloudFormationStack(n nodes.Node, envVars []string) error {
+	eksConfigPath, err := writeEKSConfig(n)
+	if err != nil {
+		return err
+	}
+
+	// Run clusterawsadm with the same eks.config file used to create the CloudFormation stack
+	c := "clusterawsadm bootstrap iam delete-cloudformation-stack --config " + eksConfigPath
+	_, err = commons.ExecuteCommand(n, c, 5, envVars)
+	if err != nil {
+		return errors.Wrap(err, "failed to run clusterawsadm")
+	}
+	return nil
+}
+
+func writeEKSConfig(n nodes.Node) (string, error) {
 	eksConfigData := `
 apiVersion: bootstrap.aws.infrastructure.cluster.x-k8s.io/v1beta1
 kind: AWSIAMConfiguration
@@ -185,19 +230,11 @@ spec:
 
 	// Create the eks.config file in the container
 	eksConfigPath := "/kind/eks.config"
-	c = "echo \"" + eksConfigData + "\" > " + eksConfigPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err := writeFile(n, eksConfigPath, eksConfigData)
 	if err != nil {
-		return errors.Wrap(err, "failed to create eks.config")
+		return "", errors.Wrap(err, "failed to create eks.config")
 	}
-
-	// Run clusterawsadm with the eks.config file previously created (this will create or update the CloudFormation stack in AWS)
-	c = "clusterawsadm bootstrap iam create-cloudformation-stack --config " + eksConfigPath
-	_, err = commons.ExecuteCommand(n, c, 5, envVars)
-	if err != nil {
-		return errors.Wrap(err, "failed to run clusterawsadm")
-	}
-	return nil
+	return eksConfigPath, nil
 }
 
 func (b *AWSBuilder) internalNginx(p ProviderParams, networks commons.Networks) (bool, error) {
@@ -224,48 +261,14 @@ func (b *AWSBuilder) internalNginx(p ProviderParams, networks commons.Networks)
 	return false, nil
 }
 
-func (b *AWSBuilder) getRegistryCredentials(p ProviderParams, u string) (string, string, error) {
-	var registryUser = "AWS"
-	var registryPass string
-	var ctx = context.Background()
-
-	region := strings.Split(u, ".")[3]
-	cfg, err := commons.AWSGetConfig(ctx, p.Credentials, region)
-	if err != nil {
-		return "", "", err
-	}
-	svc := ecr.NewFromConfig(cfg)
-	token, err := svc.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
-	if err != nil {
-		return "", "", err
-	}
-	authData := token.AuthorizationData[0].AuthorizationToken
-	data, err := base64.StdEncoding.DecodeString(*authData)
-	if err != nil {
-		return "", "", err
-	}
-	registryPass = strings.SplitN(string(data), ":", 2)[1]
-	return registryUser, registryPass, nil
-}
-
 func (b *AWSBuilder) configureStorageClass(n nodes.Node, k string) error {
-	var c string
 	var err error
-	var cmd exec.Cmd
+	workload := kube.NewClient(n, k)
 
 	if b.capxManaged {
 		// Remove annotation from default storage class
-		c = "kubectl --kubeconfig " + k + ` get sc -o jsonpath='{.items[?(@.metadata.annotations.storageclass\.kubernetes\.io/is-default-class=="true")].metadata.name}'`
-		output, err := commons.ExecuteCommand(n, c, 5)
-		if err != nil {
-			return errors.Wrap(err, "failed to get default storage class")
-		}
-		if strings.TrimSpace(output) != "" && strings.TrimSpace(output) != "No resources found" {
-			c = "kubectl --kubeconfig " + k + " annotate sc " + strings.TrimSpace(output) + " " + defaultScAnnotation + "-"
-			_, err = commons.ExecuteCommand(n, c, 5)
-			if err != nil {
-				return errors.Wrap(err, "failed to remove annotation from default storage class")
-			}
+		if err = unsetDefaultStorageClass(workload); err != nil {
+			return err
 		}
 	}
 
@@ -288,8 +291,7 @@ func (b *AWSBuilder) configureStorageClass(n nodes.Node, k string) error {
 		storageClass = re.ReplaceAllString(storageClass, tags)
 	}
 
-	cmd = n.Command("kubectl", "--kubeconfig", k, "apply", "-f", "-")
-	if err = cmd.SetStdin(strings.NewReader(storageClass)).Run(); err != nil {
+	if err = workload.Apply("", storageClass); err != nil {
 		return errors.Wrap(err, "failed to create default storage class")
 	}
 
@@ -320,13 +322,9 @@ func (b *AWSBuilder) getOverrideVars(p ProviderParams, networks commons.Networks
 func (b *AWSBuilder) postInstallPhase(n nodes.Node, k string) error {
 	var coreDNSPDBName = "coredns"
 
-	c := "kubectl --kubeconfig " + kubeconfigPath + " get pdb " + coreDNSPDBName + " -n kube-system"
-	_, err := commons.ExecuteCommand(n, c, 5)
+	err := ensureCorednsPdb(n, k, coreDNSPDBName)
 	if err != nil {
-		err = installCorednsPdb(n, k)
-		if err != nil {
-			return errors.Wrap(err, "failed to add core dns PDB")
-		}
+		return errors.Wrap(err, "failed to add core dns PDB")
 	}
 	if b.capxManaged {
 		err := patchDeploy(n, k, "kube-system", "coredns", "{\"spec\": {\"template\": {\"metadata\": {\"annotations\": {\""+postInstallAnnotation+"\": \"tmp\"}}}}}")
@@ -342,3 +340,34 @@ func (b *AWSBuilder) postInstallPhase(n nodes.Node, k string) error {
 
 	return nil
 }
+
+// configureS3HelmRepository writes the AWS config and credentials files of
+// the node for the helm s3 plugin to reach a S3 helm repository
+func configureS3HelmRepository(n nodes.Node, keosCluster commons.KeosCluster, providerCredentials map[string]string) error {
+	if !strings.HasPrefix(keosCluster.Spec.HelmRepository.URL, "s3://") {
+		return nil
+	}
+	c := "mkdir -p ~/.aws"
+	_, err := commons.ExecuteCommand(n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to create aws config file")
+	}
+	c = "echo [default] > ~/.aws/config && " +
+		"echo region = " + keosCluster.Spec.Region + " >>  ~/.aws/config"
+	_, err = commons.ExecuteCommand(n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to create aws config file")
+	}
+	awsCredentials := "[default]\naws_access_key_id = " + providerCredentials["AccessKey"] + "\naws_secret_access_key = " + providerCredentials["SecretKey"] + "\n"
+	c = "echo '" + awsCredentials + "' > ~/.aws/credentials"
+	_, err = commons.ExecuteCommand(n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to create aws credentials file")
+	}
+	return nil
+}
+
+func (b *AWSBuilder) setKEOSDescriptor(d *KEOSDescriptor, keosCluster commons.KeosCluster) {
+	d.AWS.Enabled = true
+	d.AWS.EKS = keosCluster.Spec.ControlPlane.Managed
+}
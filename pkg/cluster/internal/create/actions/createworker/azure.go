@@ -20,19 +20,14 @@ import (
 	"context"
 	_ "embed"
 	"encoding/base64"
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"net/url"
 	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
 	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
 	"sigs.k8s.io/kind/pkg/cluster/nodes"
 	"sigs.k8s.io/kind/pkg/commons"
 	"sigs.k8s.io/kind/pkg/errors"
-	"sigs.k8s.io/kind/pkg/exec"
 )
 
 //go:embed files/azure/azure-storage-classes.yaml
@@ -51,6 +46,15 @@ type AzureBuilder struct {
 	scParameters     commons.SCParameters
 	scProvisioner    string
 	csiNamespace     string
+	// clusterOperatorValues are the helm values of the cluster-operator
+	// with the provider credentials
+	clusterOperatorValues map[string]string
+	// capxIdentitySecret is the data of the cluster identity secret of CAPZ
+	capxIdentitySecret map[string]string
+}
+
+func init() {
+	registerBuilder("azure", func() PBuilder { return newAzureBuilder() })
 }
 
 func newAzureBuilder() *AzureBuilder {
@@ -59,8 +63,8 @@ func newAzureBuilder() *AzureBuilder {
 
 func (b *AzureBuilder) setCapx(managed bool) {
 	b.capxProvider = "azure"
-	b.capxVersion = "v1.11.4"
-	b.capxImageVersion = "v1.11.4"
+	b.capxVersion = commons.GetBOM().InfraProviderVersion(b.capxProvider)
+	b.capxImageVersion = b.capxVersion
 	b.capxName = "capz"
 	b.capxManaged = managed
 	b.csiNamespace = "kube-system"
@@ -98,6 +102,13 @@ func (b *AzureBuilder) setCapxEnvVars(p ProviderParams) {
 		"AZURE_SUBSCRIPTION_ID_B64=" + base64.StdEncoding.EncodeToString([]byte(p.Credentials["SubscriptionID"])),
 		"AZURE_TENANT_ID_B64=" + base64.StdEncoding.EncodeToString([]byte(p.Credentials["TenantID"])),
 	}
+	b.clusterOperatorValues = map[string]string{
+		"secrets.azure.clientIDBase64":       base64.StdEncoding.EncodeToString([]byte(p.Credentials["ClientID"])),
+		"secrets.azure.clientSecretBase64":   base64.StdEncoding.EncodeToString([]byte(p.Credentials["ClientSecret"])),
+		"secrets.azure.subscriptionIDBase64": base64.StdEncoding.EncodeToString([]byte(p.Credentials["SubscriptionID"])),
+		"secrets.azure.tenantIDBase64":       base64.StdEncoding.EncodeToString([]byte(p.Credentials["TenantID"])),
+	}
+	b.capxIdentitySecret = map[string]string{"clientSecret": p.Credentials["ClientSecret"]}
 	if p.Managed {
 		b.capxEnvVars = append(b.capxEnvVars, "EXP_MACHINE_POOL=true")
 	}
@@ -117,6 +128,10 @@ func (b *AzureBuilder) getProvider() Provider {
 		scParameters:     b.scParameters,
 		scProvisioner:    b.scProvisioner,
 		csiNamespace:     b.csiNamespace,
+
+		clusterOperatorValues: b.clusterOperatorValues,
+		capxIdentitySecret:    b.capxIdentitySecret,
+		capxDaemonSets:        []string{"capz-nmi"},
 	}
 }
 
@@ -128,16 +143,20 @@ func (b *AzureBuilder) installCloudProvider(n nodes.Node, k string, privateParam
 	} else {
 		podsCidrBlock = "192.168.0.0/16"
 	}
-	c := "helm install cloud-provider-azure /stratio/helm/cloud-provider-azure" +
-		" --kubeconfig " + k +
-		" --namespace kube-system" +
-		" --set infra.clusterName=" + keosCluster.Metadata.Name +
-		" --set 'cloudControllerManager.clusterCIDR=" + podsCidrBlock + "'"
+	release := kube.HelmRelease{
+		Name:      "cloud-provider-azure",
+		Chart:     "/stratio/helm/cloud-provider-azure",
+		Namespace: "kube-system",
+		Values: map[string]string{
+			"infra.clusterName":                  keosCluster.Metadata.Name,
+			"cloudControllerManager.clusterCIDR": podsCidrBlock,
+		},
+	}
 	if privateParams.Private {
-		c += " --set cloudControllerManager.imageRepository=" + privateParams.KeosRegUrl + "/oss/kubernetes" +
-			" --set cloudNodeManager.imageRepository=" + privateParams.KeosRegUrl + "/oss/kubernetes"
+		release.Values["cloudControllerManager.imageRepository"] = privateParams.KeosRegUrl + "/oss/kubernetes"
+		release.Values["cloudNodeManager.imageRepository"] = privateParams.KeosRegUrl + "/oss/kubernetes"
 	}
-	_, err := commons.ExecuteCommand(n, c, 5)
+	err := kube.NewClient(n, k).HelmInstall(release)
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy cloud-provider-azure Helm Chart")
 	}
@@ -145,35 +164,32 @@ func (b *AzureBuilder) installCloudProvider(n nodes.Node, k string, privateParam
 }
 
 func (b *AzureBuilder) installCSI(n nodes.Node, k string, privateParams PrivateParams) error {
-	var c string
-	var err error
-
-	// Deploy disk CSI driver
-	c = "helm install azuredisk-csi-driver /stratio/helm/azuredisk-csi-driver " +
-		" --kubeconfig " + k +
-		" --namespace " + b.csiNamespace +
-		" --set controller.podAnnotations.\"cluster-autoscaler\\.kubernetes\\.io/safe-to-evict-local-volumes=socket-dir\\,azure-cred\""
-
+	workload := kube.NewClient(n, k)
+	values := map[string]string{
+		"controller.podAnnotations.cluster-autoscaler\\.kubernetes\\.io/safe-to-evict-local-volumes": "socket-dir\\,azure-cred",
+	}
 	if privateParams.Private {
-		c += " --set image.baseRepo=" + privateParams.KeosRegUrl
+		values["image.baseRepo"] = privateParams.KeosRegUrl
 	}
 
-	_, err = commons.ExecuteCommand(n, c, 5)
+	// Deploy disk CSI driver
+	err := workload.HelmInstall(kube.HelmRelease{
+		Name:      "azuredisk-csi-driver",
+		Chart:     "/stratio/helm/azuredisk-csi-driver",
+		Namespace: b.csiNamespace,
+		Values:    values,
+	})
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy Azure Disk CSI driver Helm Chart")
 	}
 
 	// Deploy file CSI driver
-	c = "helm install azurefile-csi-driver /stratio/helm/azurefile-csi-driver " +
-		" --kubeconfig " + k +
-		" --namespace " + b.csiNamespace +
-		" --set controller.podAnnotations.\"cluster-autoscaler\\.kubernetes\\.io/safe-to-evict-local-volumes=socket-dir\\,azure-cred\""
-
-	if privateParams.Private {
-		c += " --set image.baseRepo=" + privateParams.KeosRegUrl
-	}
-
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.HelmInstall(kube.HelmRelease{
+		Name:      "azurefile-csi-driver",
+		Chart:     "/stratio/helm/azurefile-csi-driver",
+		Namespace: b.csiNamespace,
+		Values:    values,
+	})
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy Azure File CSI driver Helm Chart")
 	}
@@ -181,70 +197,20 @@ func (b *AzureBuilder) installCSI(n nodes.Node, k string, privateParams PrivateP
 	return nil
 }
 
-func (b *AzureBuilder) getRegistryCredentials(p ProviderParams, u string) (string, string, error) {
-	var registryUser = "00000000-0000-0000-0000-000000000000"
-	var registryPass string
-	var ctx = context.Background()
-	var response map[string]interface{}
-
-	cfg, err := commons.AzureGetConfig(p.Credentials)
-	if err != nil {
-		return "", "", err
-	}
-	aadToken, err := cfg.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com/.default"}})
-	if err != nil {
-		return "", "", err
-	}
-	acrService := strings.Split(u, "/")[0]
-	formData := url.Values{
-		"grant_type":   {"access_token"},
-		"service":      {acrService},
-		"tenant":       {p.Credentials["TenantID"]},
-		"access_token": {aadToken.Token},
-	}
-	jsonResponse, err := http.PostForm(fmt.Sprintf("https://%s/oauth2/exchange", acrService), formData)
-	if err != nil {
-		return "", "", err
-	} else if jsonResponse.StatusCode == http.StatusUnauthorized {
-		return "", "", errors.New("Failed to obtain the ACR token with the provided credentials, please check the roles assigned to the correspondent Azure AD app")
-	}
-	json.NewDecoder(jsonResponse.Body).Decode(&response)
-	if response["access_token"] != nil {
-		registryPass = response["access_token"].(string)
-	} else if response["refresh_token"] != nil {
-		registryPass = response["refresh_token"].(string)
-	} else {
-		return "", "", errors.New("Failed to obtain the ACR token with the provided credentials, please check the roles assigned to the correspondent Azure AD app")
-	}
-	return registryUser, registryPass, nil
-}
-
 func (b *AzureBuilder) configureStorageClass(n nodes.Node, k string) error {
-	var c string
 	var err error
-	var cmd exec.Cmd
+	workload := kube.NewClient(n, k)
 
 	if b.capxManaged {
 		// Remove annotation from default storage class
-		c = "kubectl --kubeconfig " + k + ` get sc -o jsonpath='{.items[?(@.metadata.annotations.storageclass\.kubernetes\.io/is-default-class=="true")].metadata.name}'`
-		output, err := commons.ExecuteCommand(n, c, 5)
-		if err != nil {
-			return errors.Wrap(err, "failed to get default storage class")
-		}
-
-		if strings.TrimSpace(output) != "" && strings.TrimSpace(output) != "No resources found" {
-			c = "kubectl --kubeconfig " + k + " annotate sc " + strings.TrimSpace(output) + " " + defaultScAnnotation + "-"
-			_, err = commons.ExecuteCommand(n, c, 5)
-			if err != nil {
-				return errors.Wrap(err, "failed to remove annotation from default storage class")
-			}
+		if err = unsetDefaultStorageClass(workload); err != nil {
+			return err
 		}
 	}
 
 	if !b.capxManaged {
 		// Create Azure storage classes
-		cmd = n.Command("kubectl", "--kubeconfig", k, "apply", "-f", "-")
-		if err := cmd.SetStdin(strings.NewReader(azureStorageClasses)).Run(); err != nil {
+		if err := workload.Apply("", azureStorageClasses); err != nil {
 			return errors.Wrap(err, "failed to create Azure storage classes")
 		}
 	}
@@ -258,8 +224,7 @@ func (b *AzureBuilder) configureStorageClass(n nodes.Node, k string) error {
 	}
 	storageClass := strings.Replace(string(scBytes), "fsType", "csi.storage.k8s.io/fstype", -1)
 
-	cmd = n.Command("kubectl", "--kubeconfig", k, "apply", "-f", "-")
-	if err = cmd.SetStdin(strings.NewReader(storageClass)).Run(); err != nil {
+	if err = workload.Apply("", storageClass); err != nil {
 		return errors.Wrap(err, "failed to create default storage class")
 	}
 
@@ -274,7 +239,7 @@ func (b *AzureBuilder) internalNginx(p ProviderParams, networks commons.Networks
 	if err != nil {
 		return false, err
 	}
-	networkClientFactory, err := armnetwork.NewClientFactory(p.Credentials["SubscriptionID"], cfg, nil)
+	networkClientFactory, err := armnetwork.NewClientFactory(p.Credentials["SubscriptionID"], cfg, commons.AzureClientOptions())
 	if err != nil {
 		return false, err
 	}
@@ -347,13 +312,15 @@ func (b *AzureBuilder) postInstallPhase(n nodes.Node, k string) error {
 		}
 	}
 
-	c := "kubectl --kubeconfig " + kubeconfigPath + " get pdb " + coreDNSPDBName + " -n kube-system"
-	_, err := commons.ExecuteCommand(n, c, 5)
+	err := ensureCorednsPdb(n, k, coreDNSPDBName)
 	if err != nil {
-		err = installCorednsPdb(n, k)
-		if err != nil {
-			return errors.Wrap(err, "failed to add core dns PDB")
-		}
+		return errors.Wrap(err, "failed to add core dns PDB")
 	}
 	return nil
 }
+
+func (b *AzureBuilder) setKEOSDescriptor(d *KEOSDescriptor, keosCluster commons.KeosCluster) {
+	d.Azure.Enabled = true
+	d.Azure.AKS = keosCluster.Spec.ControlPlane.Managed
+	d.Azure.ResourceGroup = keosCluster.Metadata.Name
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+const (
+	checkpointPath      = "/kind/checkpoint.yaml"
+	localCheckpointPath = "checkpoint.yaml"
+)
+
+// checkpoint records the createworker phases already completed for a cluster,
+// so an interrupted creation can be resumed from the first unfinished phase
+type checkpoint struct {
+	Cluster string   `yaml:"cluster"`
+	Phases  []string `yaml:"phases"`
+	// localPath is the path of the local copy of the checkpoint
+	localPath string
+}
+
+// newCheckpoint returns an empty checkpoint for the given cluster, with its
+// local copy at localPath
+func newCheckpoint(clusterName string, localPath string) *checkpoint {
+	return &checkpoint{Cluster: clusterName, Phases: []string{}, localPath: localPath}
+}
+
+// HasCheckpoint returns true if a creation of the cluster clusterName has
+// left a local checkpoint with completed phases, so it can be resumed
+func HasCheckpoint(clusterName string) bool {
+	raw, err := os.ReadFile(localCheckpointPath)
+	if err != nil {
+		return false
+	}
+	cp := newCheckpoint("", localCheckpointPath)
+	if err := yaml.Unmarshal(raw, cp); err != nil {
+		return false
+	}
+	return cp.Cluster == clusterName && len(cp.Phases) > 0
+}
+
+// RemoveCheckpoint removes the local checkpoint left by an earlier creation,
+// so a new creation does not take it as its own
+func RemoveCheckpoint() error {
+	return newCheckpoint("", localCheckpointPath).clear()
+}
+
+// loadCheckpoint reads the checkpoint stored in the node. The local copy at
+// localPath is not trusted, as it may have been left by another node
+func loadCheckpoint(n nodes.Node, clusterName string, localPath string) (*checkpoint, error) {
+	raw, err := exec.Output(n.Command("cat", checkpointPath))
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("failed to find a checkpoint to resume from in the node")
+	}
+	cp := newCheckpoint(clusterName, localPath)
+	if err := yaml.Unmarshal(raw, cp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the checkpoint")
+	}
+	if cp.Cluster != clusterName {
+		return nil, errors.Errorf("the checkpoint belongs to cluster %q, not to %q", cp.Cluster, clusterName)
+	}
+	return cp, nil
+}
+
+// done returns true if the phase has already been completed
+func (cp *checkpoint) done(phase string) bool {
+	for _, p := range cp.Phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// complete marks the phase as completed and persists the checkpoint
+func (cp *checkpoint) complete(n nodes.Node, phase string) error {
+	if !cp.done(phase) {
+		cp.Phases = append(cp.Phases, phase)
+	}
+	return cp.save(n)
+}
+
+// save writes the checkpoint both inside the node and locally
+func (cp *checkpoint) save(n nodes.Node) error {
+	raw, err := yaml.Marshal(cp)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the checkpoint")
+	}
+	if err := writeFile(n, checkpointPath, string(raw)); err != nil {
+		return errors.Wrap(err, "failed to write the checkpoint in the node")
+	}
+	if err := os.WriteFile(cp.localPath, raw, 0644); err != nil {
+		return errors.Wrap(err, "failed to write the local checkpoint")
+	}
+	return nil
+}
+
+// clear removes the local checkpoint
+func (cp *checkpoint) clear() error {
+	if err := os.Remove(cp.localPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove the local checkpoint")
+	}
+	return nil
+}
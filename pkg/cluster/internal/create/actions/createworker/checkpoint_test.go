@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/create/actions"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// fileNode is a render node whose cat reads back the files written in it
+type fileNode struct {
+	*renderNode
+}
+
+func (f fileNode) Command(name string, args ...string) exec.Cmd {
+	if name == "cat" && len(args) == 1 {
+		return &catCmd{renderCmd: &renderCmd{node: f.renderNode, name: name, args: args}, path: filepath.Join(f.dir, args[0])}
+	}
+	return f.renderNode.Command(name, args...)
+}
+
+// catCmd writes the content of a file of the render directory
+type catCmd struct {
+	*renderCmd
+	path   string
+	stdout io.Writer
+}
+
+func (c *catCmd) SetStdout(w io.Writer) exec.Cmd {
+	c.stdout = w
+	return c
+}
+
+func (c *catCmd) Run() error {
+	raw, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	_, err = c.stdout.Write(raw)
+	return err
+}
+
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "checkpoint.yaml")
+	r, err := newRenderNode(filepath.Join(dir, "render"))
+	assert.ExpectError(t, false, err)
+	n := fileNode{r}
+
+	// the local copy of a checkpoint is not trusted without one in the node
+	assert.ExpectError(t, false, os.WriteFile(localPath, []byte("cluster: test\nphases:\n    - capx-local\n"), 0644))
+	_, err = loadCheckpoint(n, "test", localPath)
+	assert.ExpectError(t, true, err)
+
+	cp := newCheckpoint("test", localPath)
+	assert.ExpectError(t, false, cp.save(n))
+	assert.ExpectError(t, false, cp.complete(n, "capx-local"))
+	assert.ExpectError(t, false, cp.complete(n, "secrets"))
+	assert.ExpectError(t, false, cp.complete(n, "secrets"))
+	assertRendered(t, filepath.Join(dir, "render"), "kind/checkpoint.yaml", "cluster: test\nphases:\n    - capx-local\n    - secrets\n")
+
+	loaded, err := loadCheckpoint(n, "test", localPath)
+	assert.ExpectError(t, false, err)
+	assert.DeepEqual(t, []string{"capx-local", "secrets"}, loaded.Phases)
+	_, err = loadCheckpoint(n, "other", localPath)
+	assert.ExpectError(t, true, err)
+
+	// a resumed run skips the completed phases and records the rest
+	run := []string{}
+	phases := []phase{}
+	for _, name := range []string{"capx-local", "secrets", "cluster-operator", "workload-cluster"} {
+		name := name
+		phases = append(phases, phase{name: name, run: func(p *phaseContext) error {
+			run = append(run, name)
+			if name == "workload-cluster" {
+				return errors.New("failed")
+			}
+			return nil
+		}})
+	}
+	p := &phaseContext{
+		ctx: actions.NewActionContext(log.NoopLogger{}, cli.StatusForLogger(log.NoopLogger{}), nil, nil),
+		n:   n,
+	}
+	assert.ExpectError(t, true, runPhases(p, phases, loaded))
+	assert.DeepEqual(t, []string{"cluster-operator", "workload-cluster"}, run)
+
+	resumed, err := loadCheckpoint(n, "test", localPath)
+	assert.ExpectError(t, false, err)
+	assert.DeepEqual(t, []string{"capx-local", "secrets", "cluster-operator"}, resumed.Phases)
+
+	assert.ExpectError(t, false, resumed.clear())
+	_, err = os.Stat(localPath)
+	assert.BoolEqual(t, true, os.IsNotExist(err))
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// localPathStorageClass is the default storage class of the local path
+// provisioner installed from the kind storage manifest
+const localPathStorageClass = "standard"
+
+// DockerBuilder builds the workload cluster with Cluster API Provider Docker,
+// whose nodes are containers of the local docker host
+type DockerBuilder struct {
+	capxProvider     string
+	capxVersion      string
+	capxImageVersion string
+	capxManaged      bool
+	capxName         string
+	capxEnvVars      []string
+	scParameters     commons.SCParameters
+	scProvisioner    string
+	csiNamespace     string
+}
+
+func init() {
+	registerBuilder("docker", func() PBuilder { return newDockerBuilder() })
+}
+
+func newDockerBuilder() *DockerBuilder {
+	return &DockerBuilder{}
+}
+
+func (b *DockerBuilder) setCapx(managed bool) {
+	b.capxProvider = "docker"
+	b.capxVersion = commons.GetBOM().InfraProviderVersion(b.capxProvider)
+	b.capxImageVersion = b.capxVersion
+	b.capxName = "capd"
+	b.capxManaged = managed
+	b.csiNamespace = "local-path-storage"
+}
+
+func (b *DockerBuilder) setCapxEnvVars(p ProviderParams) {
+	b.capxEnvVars = []string{}
+	if p.GithubToken != "" {
+		b.capxEnvVars = append(b.capxEnvVars, "GITHUB_TOKEN="+p.GithubToken)
+	}
+}
+
+func (b *DockerBuilder) setSC(p ProviderParams) {
+	b.scProvisioner = "rancher.io/local-path"
+}
+
+func (b *DockerBuilder) getProvider() Provider {
+	return Provider{
+		capxProvider:     b.capxProvider,
+		capxVersion:      b.capxVersion,
+		capxImageVersion: b.capxImageVersion,
+		capxManaged:      b.capxManaged,
+		capxName:         b.capxName,
+		capxEnvVars:      b.capxEnvVars,
+		scParameters:     b.scParameters,
+		scProvisioner:    b.scProvisioner,
+		csiNamespace:     b.csiNamespace,
+	}
+}
+
+// installCloudProvider does nothing, the docker nodes need no cloud provider
+func (b *DockerBuilder) installCloudProvider(n nodes.Node, k string, privateParams PrivateParams) error {
+	return nil
+}
+
+// installCSI installs the local path provisioner of the local cluster, which
+// stores the volumes in the node containers
+func (b *DockerBuilder) installCSI(n nodes.Node, k string, privateParams PrivateParams) error {
+	err := kube.NewClient(n, k).ApplyFile("", storageDefaultPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to deploy the local path provisioner")
+	}
+	return nil
+}
+
+func (b *DockerBuilder) configureStorageClass(n nodes.Node, k string) error {
+	workload := kube.NewClient(n, k)
+
+	// Remove annotation from the local path storage class
+	patch := `{"metadata":{"annotations":{"` + defaultScAnnotation + `":null}}}`
+	err := workload.Patch("", "sc", localPathStorageClass, kube.PatchMerge, patch)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove annotation from default storage class")
+	}
+
+	scTemplate.Parameters = b.scParameters
+	scTemplate.Provisioner = b.scProvisioner
+
+	scBytes, err := yaml.Marshal(scTemplate)
+	if err != nil {
+		return err
+	}
+
+	if err = workload.Apply("", string(scBytes)); err != nil {
+		return errors.Wrap(err, "failed to create default storage class")
+	}
+	return nil
+}
+
+// internalNginx returns false, the docker nodes have no private networks
+func (b *DockerBuilder) internalNginx(p ProviderParams, networks commons.Networks) (bool, error) {
+	return false, nil
+}
+
+func (b *DockerBuilder) getOverrideVars(p ProviderParams, networks commons.Networks) (map[string][]byte, error) {
+	return map[string][]byte{}, nil
+}
+
+func (b *DockerBuilder) postInstallPhase(n nodes.Node, k string) error {
+	var coreDNSPDBName = "coredns"
+
+	err := ensureCorednsPdb(n, k, coreDNSPDBName)
+	if err != nil {
+		return errors.Wrap(err, "failed to add core dns PDB")
+	}
+
+	return nil
+}
+
+func (b *DockerBuilder) setKEOSDescriptor(d *KEOSDescriptor, keosCluster commons.KeosCluster) {
+}
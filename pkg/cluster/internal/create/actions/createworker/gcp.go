@@ -20,18 +20,14 @@ import (
 	"context"
 	_ "embed"
 	b64 "encoding/base64"
-	"encoding/json"
-	"net/url"
 	"strings"
 
-	"golang.org/x/oauth2/google"
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/option"
 	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
 	"sigs.k8s.io/kind/pkg/cluster/nodes"
 	"sigs.k8s.io/kind/pkg/commons"
 	"sigs.k8s.io/kind/pkg/errors"
-	"sigs.k8s.io/kind/pkg/exec"
 )
 
 //go:embed files/gcp/internal-ingress-nginx.yaml
@@ -47,6 +43,13 @@ type GCPBuilder struct {
 	scParameters     commons.SCParameters
 	scProvisioner    string
 	csiNamespace     string
+	// clusterOperatorValues are the helm values of the cluster-operator
+	// with the provider credentials
+	clusterOperatorValues map[string]string
+}
+
+func init() {
+	registerBuilder("gcp", func() PBuilder { return newGCPBuilder() })
 }
 
 func newGCPBuilder() *GCPBuilder {
@@ -55,29 +58,20 @@ func newGCPBuilder() *GCPBuilder {
 
 func (b *GCPBuilder) setCapx(managed bool) {
 	b.capxProvider = "gcp"
-	b.capxVersion = "v1.4.0"
-	b.capxImageVersion = "v1.4.0"
+	b.capxVersion = commons.GetBOM().InfraProviderVersion(b.capxProvider)
+	b.capxImageVersion = b.capxVersion
 	b.capxName = "capg"
 	b.capxManaged = managed
 	b.csiNamespace = "kube-system"
 }
 
 func (b *GCPBuilder) setCapxEnvVars(p ProviderParams) {
-	data := map[string]interface{}{
-		"type":                        "service_account",
-		"project_id":                  p.Credentials["ProjectID"],
-		"private_key_id":              p.Credentials["PrivateKeyID"],
-		"private_key":                 p.Credentials["PrivateKey"],
-		"client_email":                p.Credentials["ClientEmail"],
-		"client_id":                   p.Credentials["ClientID"],
-		"auth_uri":                    "https://accounts.google.com/o/oauth2/auth",
-		"token_uri":                   "https://accounts.google.com/o/oauth2/token",
-		"auth_provider_x509_cert_url": "https://www.googleapis.com/oauth2/v1/certs",
-		"client_x509_cert_url":        "https://www.googleapis.com/robot/v1/metadata/x509/" + url.QueryEscape(p.Credentials["ClientEmail"]),
-	}
-	jsonData, _ := json.Marshal(data)
+	credentials := b64.StdEncoding.EncodeToString(commons.GCPCredentialsJSON(p.Credentials))
 	b.capxEnvVars = []string{
-		"GCP_B64ENCODED_CREDENTIALS=" + b64.StdEncoding.EncodeToString([]byte(jsonData)),
+		"GCP_B64ENCODED_CREDENTIALS=" + credentials,
+	}
+	b.clusterOperatorValues = map[string]string{
+		"secrets.common.credentialsBase64": credentials,
 	}
 	if p.Managed {
 		b.capxEnvVars = append(b.capxEnvVars, "EXP_MACHINE_POOL=true")
@@ -118,6 +112,8 @@ func (b *GCPBuilder) getProvider() Provider {
 		scParameters:     b.scParameters,
 		scProvisioner:    b.scProvisioner,
 		csiNamespace:     b.csiNamespace,
+
+		clusterOperatorValues: b.clusterOperatorValues,
 	}
 }
 
@@ -126,14 +122,15 @@ func (b *GCPBuilder) installCloudProvider(n nodes.Node, k string, privateParams
 }
 
 func (b *GCPBuilder) installCSI(n nodes.Node, k string, privateParams PrivateParams) error {
-	var c string
 	var err error
-	var cmd exec.Cmd
+	workload := kube.NewClient(n, k)
 
 	// Create CSI secret in CSI namespace
 	secret, _ := b64.StdEncoding.DecodeString(strings.Split(b.capxEnvVars[0], "GCP_B64ENCODED_CREDENTIALS=")[1])
-	c = "kubectl --kubeconfig " + k + " -n " + b.csiNamespace + " create secret generic cloud-sa --from-literal=cloud-sa.json='" + string(secret) + "'"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.CreateSecret(b.csiNamespace, kube.Secret{
+		Name: "cloud-sa",
+		Data: map[string]string{"cloud-sa.json": string(secret)},
+	})
 	if err != nil {
 		return errors.Wrap(err, "failed to create CSI secret in CSI namespace")
 	}
@@ -144,48 +141,21 @@ func (b *GCPBuilder) installCSI(n nodes.Node, k string, privateParams PrivatePar
 	}
 
 	// Deploy CSI driver
-	cmd = n.Command("kubectl", "--kubeconfig", k, "apply", "-f", "-")
-	if err = cmd.SetStdin(strings.NewReader(csiManifests)).Run(); err != nil {
+	if err = workload.Apply("", csiManifests); err != nil {
 		return errors.Wrap(err, "failed to deploy CSI driver")
 	}
 
 	return nil
 }
 
-func (b *GCPBuilder) getRegistryCredentials(p ProviderParams, u string) (string, string, error) {
-	var registryUser = "oauth2accesstoken"
-	var ctx = context.Background()
-	scope := "https://www.googleapis.com/auth/cloud-platform"
-	key, _ := b64.StdEncoding.DecodeString(strings.Split(b.capxEnvVars[0], "GCP_B64ENCODED_CREDENTIALS=")[1])
-	creds, err := google.CredentialsFromJSON(ctx, key, scope)
-	if err != nil {
-		return "", "", err
-	}
-	token, err := creds.TokenSource.Token()
-	if err != nil {
-		return "", "", err
-	}
-	return registryUser, token.AccessToken, nil
-}
-
 func (b *GCPBuilder) configureStorageClass(n nodes.Node, k string) error {
-	var c string
 	var err error
-	var cmd exec.Cmd
+	workload := kube.NewClient(n, k)
 
 	if b.capxManaged {
 		// Remove annotation from default storage class
-		c = "kubectl --kubeconfig " + k + ` get sc -o jsonpath='{.items[?(@.metadata.annotations.storageclass\.kubernetes\.io/is-default-class=="true")].metadata.name}'`
-		output, err := commons.ExecuteCommand(n, c, 5)
-		if err != nil {
-			return errors.Wrap(err, "failed to get default storage class")
-		}
-		if strings.TrimSpace(output) != "" && strings.TrimSpace(output) != "No resources found" {
-			c = "kubectl --kubeconfig " + k + " annotate sc " + strings.TrimSpace(output) + " " + defaultScAnnotation + "-"
-			_, err = commons.ExecuteCommand(n, c, 5)
-			if err != nil {
-				return errors.Wrap(err, "failed to remove annotation from default storage class")
-			}
+		if err = unsetDefaultStorageClass(workload); err != nil {
+			return err
 		}
 	}
 
@@ -198,8 +168,7 @@ func (b *GCPBuilder) configureStorageClass(n nodes.Node, k string) error {
 	}
 	storageClass := strings.Replace(string(scBytes), "fsType", "csi.storage.k8s.io/fstype", -1)
 
-	cmd = n.Command("kubectl", "--kubeconfig", k, "apply", "-f", "-")
-	if err = cmd.SetStdin(strings.NewReader(storageClass)).Run(); err != nil {
+	if err = workload.Apply("", storageClass); err != nil {
 		return errors.Wrap(err, "failed to create default storage class")
 	}
 
@@ -210,7 +179,10 @@ func (b *GCPBuilder) internalNginx(p ProviderParams, networks commons.Networks)
 	var ctx = context.Background()
 
 	secrets, _ := b64.StdEncoding.DecodeString(strings.Split(b.capxEnvVars[0], "GCP_B64ENCODED_CREDENTIALS=")[1])
-	cfg := option.WithCredentialsJSON(secrets)
+	cfg, err := commons.GCPClientOption(ctx, secrets)
+	if err != nil {
+		return false, err
+	}
 	computeService, err := compute.NewService(ctx, cfg)
 	if err != nil {
 		return false, err
@@ -255,14 +227,15 @@ func (b *GCPBuilder) getOverrideVars(p ProviderParams, networks commons.Networks
 func (b *GCPBuilder) postInstallPhase(n nodes.Node, k string) error {
 	var coreDNSPDBName = "coredns"
 
-	c := "kubectl --kubeconfig " + kubeconfigPath + " get pdb " + coreDNSPDBName + " -n kube-system"
-	_, err := commons.ExecuteCommand(n, c, 5)
+	err := ensureCorednsPdb(n, k, coreDNSPDBName)
 	if err != nil {
-		err = installCorednsPdb(n, k)
-		if err != nil {
-			return errors.Wrap(err, "failed to add core dns PDB")
-		}
+		return errors.Wrap(err, "failed to add core dns PDB")
 	}
 
 	return nil
 }
+
+func (b *GCPBuilder) setKEOSDescriptor(d *KEOSDescriptor, keosCluster commons.KeosCluster) {
+	d.GCP.Enabled = true
+	d.GCP.GKE = keosCluster.Spec.ControlPlane.Managed
+}
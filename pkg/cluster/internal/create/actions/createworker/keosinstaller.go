@@ -90,7 +90,7 @@ type EFSConfig struct {
 	Permissions string `yaml:"permissions"`
 }
 
-func createKEOSDescriptor(keosCluster commons.KeosCluster, storageClass string, creds commons.ClusterCredentials) error {
+func createKEOSDescriptor(infra *Infra, keosCluster commons.KeosCluster, storageClass string, creds commons.ClusterCredentials) error {
 
 	var keosDescriptor KEOSDescriptor
 	var err error
@@ -109,24 +109,8 @@ func createKEOSDescriptor(keosCluster commons.KeosCluster, storageClass string,
 	keosDescriptor.HelmRepository.AuthRequired = keosCluster.Spec.HelmRepository.AuthRequired
 	keosDescriptor.HelmRepository.Type = keosCluster.Spec.HelmRepository.Type
 
-	// AWS
-	if keosCluster.Spec.InfraProvider == "aws" {
-		keosDescriptor.AWS.Enabled = true
-		keosDescriptor.AWS.EKS = keosCluster.Spec.ControlPlane.Managed
-	}
-
-	// Azure
-	if keosCluster.Spec.InfraProvider == "azure" {
-		keosDescriptor.Azure.Enabled = true
-		keosDescriptor.Azure.AKS = keosCluster.Spec.ControlPlane.Managed
-		keosDescriptor.Azure.ResourceGroup = keosCluster.Metadata.Name
-	}
-
-	// GCP
-	if keosCluster.Spec.InfraProvider == "gcp" {
-		keosDescriptor.GCP.Enabled = true
-		keosDescriptor.GCP.GKE = keosCluster.Spec.ControlPlane.Managed
-	}
+	// Infra provider
+	infra.setKEOSDescriptor(&keosDescriptor, keosCluster)
 
 	// Keos
 	keosDescriptor.Keos.ClusterID = keosCluster.Metadata.Name
@@ -138,7 +122,7 @@ func createKEOSDescriptor(keosCluster commons.KeosCluster, storageClass string,
 
 	// Keos - Calico
 	if !keosCluster.Spec.ControlPlane.Managed {
-		if keosCluster.Spec.InfraProvider == "azure" {
+		if p, _ := commons.GetInfraProvider(keosCluster.Spec.InfraProvider); p.CalicoVXLAN {
 			keosDescriptor.Keos.Calico.VXLan = true
 		} else {
 			keosDescriptor.Keos.Calico.Ipip = true
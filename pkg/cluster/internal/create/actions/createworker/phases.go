@@ -0,0 +1,704 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+const allowCommonEgressNetPolPath = "/kind/allow-all-egress_netpol.yaml"
+
+var (
+	isPrivate = condition{"private", func(a *action) bool {
+		return a.clusterConfig != nil && a.clusterConfig.Spec.Private
+	}}
+	isUnmanaged = condition{"unmanaged", func(a *action) bool {
+		return !a.keosCluster.Spec.ControlPlane.Managed
+	}}
+	withCreation = condition{"creation", func(a *action) bool {
+		return !a.avoidCreation
+	}}
+	withIAM = condition{"create-iam", func(a *action) bool {
+		return a.keosCluster.Spec.Security.AWS.CreateIAM
+	}}
+	withProviderIAM = condition{"provider-iam", func(a *action) bool {
+		return a.infraProvider().IAM
+	}}
+	withCloudProvider = condition{"cloud-provider", func(a *action) bool {
+		return a.infraProvider().CloudProvider
+	}}
+	withInternalLBRBAC = condition{"internal-lb-rbac", func(a *action) bool {
+		return a.infraProvider().InternalLBRBAC
+	}}
+	withIMDSNetworkPolicies = condition{"imds-network-policies", func(a *action) bool {
+		return a.infraProvider().IMDSNetworkPolicies
+	}}
+	withMachineDeployments = condition{"machine-deployments", func(a *action) bool {
+		return !a.isMachinePool()
+	}}
+	withAutoscaler = condition{"autoscaler", func(a *action) bool {
+		return a.keosCluster.Spec.DeployAutoscaler
+	}}
+	withDNSForwarders = condition{"dns-forwarders", func(a *action) bool {
+		if a.keosCluster.Spec.ControlPlane.Managed && a.infraProvider().ManagedCoreDNSConfigMap == "" {
+			return false
+		}
+		return len(a.keosCluster.Spec.Dns.Forwarders) > 0
+	}}
+	withCABundle = condition{"ca-bundle", func(a *action) bool {
+		return a.keosCluster.Spec.CABundle != ""
+	}}
+	withManagementPivot = condition{"management-pivot", func(a *action) bool {
+		return !a.moveManagement
+	}}
+	notRendering = condition{"not-render-only", func(a *action) bool {
+		return !a.rendering()
+	}}
+)
+
+// createPhases returns the phases of the workload cluster creation, in order
+func createPhases() []phase {
+	return []phase{
+		{"ca-bundle", "Installing the CA bundle 🔏", []condition{withCABundle}, installCABundle},
+		{"private-cni", "Installing Private CNI 🎖️", []condition{isPrivate}, installPrivateCNI},
+		{"delete-local-storage", "Deleting local storage plugin 🎖️", []condition{isPrivate}, deleteLocalStorage},
+		{"capx-local", "Installing CAPx 🎖️", nil, installCAPxLocal},
+		{"secrets", "Generating secrets file 📝🗝️", nil, generateSecrets},
+		{"cluster-operator", "Installing keos cluster operator 💻", nil, installClusterOperator},
+		{"iam", "[CAPA] Ensuring IAM security 👮", []condition{withCreation, withProviderIAM, withIAM}, ensureIAM},
+		{"workload-cluster", "Creating the workload cluster 💥", []condition{withCreation}, createWorkloadCluster},
+		{"kubeconfig", "Saving the workload cluster kubeconfig 📝", []condition{withCreation, notRendering}, saveKubeconfig},
+		{"cloud-provider", "Installing cloud-provider in workload cluster ☁️", []condition{withCreation, isUnmanaged, withCloudProvider}, installCloudProvider},
+		{"calico", "Installing Calico in workload cluster 🔌", []condition{withCreation, isUnmanaged}, installCalicoCNI},
+		{"csi", "Installing CSI in workload cluster 💾", []condition{withCreation, isUnmanaged}, installCSI},
+		{"internal-lb-rbac", "Creating Kubernetes RBAC for internal loadbalancing 🔐", []condition{withCreation, isUnmanaged, withInternalLBRBAC, notRendering}, createInternalLBRBAC},
+		{"prepare-nodes", "Preparing nodes in workload cluster 📦", []condition{withCreation}, prepareNodes},
+		{"storageclass", "Installing StorageClass in workload cluster 💾", []condition{withCreation}, installStorageClass},
+		{"self-healing", "Enabling workload cluster's self-healing 🏥", []condition{withCreation}, enableWorkloadSelfHealing},
+		{"capx-workload", "Installing CAPx in workload cluster 🎖️", []condition{withCreation}, installCAPxWorkload},
+		{"network-policy", "Configuring Network Policy Engine in workload cluster 🚧", []condition{withCreation, withIMDSNetworkPolicies, withMachineDeployments}, configureNetworkPolicy},
+		{"autoscaler", "Installing cluster-autoscaler in workload cluster 🗚", []condition{withCreation, withAutoscaler, withMachineDeployments}, installAutoscaler},
+		{"cluster-operator-workload", "Installing keos cluster operator in workload cluster 💻", []condition{withCreation}, installClusterOperatorWorkload},
+		{"coredns", "Customizing CoreDNS configuration 🪡", []condition{withCreation, withDNSForwarders}, customizeCoreDNS},
+		{"backup", "Creating cloud-provisioner Objects backup 🗄️", []condition{withCreation, notRendering}, backupObjects},
+		{"move-management", "Moving the management role 🗝️", []condition{withCreation, withManagementPivot, notRendering}, moveManagementRole},
+		{"post-install", "Executing post-install steps 🎖️", []condition{withCreation}, postInstall},
+		{"keos-descriptor", "Generating the KEOS descriptor 📝", []condition{notRendering}, generateKEOSDescriptor},
+	}
+}
+
+func installCABundle(p *phaseContext) error {
+	err := writeFile(p.n, caBundlePath, commons.GetCABundle())
+	if err != nil {
+		return errors.Wrap(err, "failed to write the CA bundle")
+	}
+	// containerd only reads the trusted CAs when it starts
+	c := "update-ca-certificates && systemctl restart containerd"
+	_, err = commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to install the CA bundle")
+	}
+	return nil
+}
+
+func installPrivateCNI(p *phaseContext) error {
+	c := `sed -i 's/@sha256:[[:alnum:]_-].*$//g' ` + cniDefaultFile
+	_, err := commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return err
+	}
+	c = `sed -i 's|docker.io|` + p.keosRegistry.url + `|g' /kind/manifests/default-cni.yaml`
+	_, err = commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return err
+	}
+	c = `sed -i 's/{{ .PodSubnet }}/10.244.0.0\/16/g' /kind/manifests/default-cni.yaml`
+	_, err = commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return err
+	}
+	return p.kube.ApplyFile("", "/kind/manifests/default-cni.yaml")
+}
+
+func deleteLocalStorage(p *phaseContext) error {
+	return p.kube.DeleteFile("", storageDefaultPath)
+}
+
+func installCAPxLocal(p *phaseContext) error {
+	// Create docker-registry secret for keos cluster
+	err := p.kube.CreateSecret("kube-system", kube.Secret{
+		Name: "regcred",
+		DockerRegistry: &kube.DockerRegistryAuth{
+			Server:   strings.Split(p.keosRegistry.url, "/")[0],
+			Username: p.keosRegistry.user,
+			Password: p.keosRegistry.pass,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create docker-registry secret")
+	}
+
+	if p.provider.capxVersion != p.provider.capxImageVersion {
+
+		infraComponents := CAPILocalRepository + "/infrastructure-" + p.provider.capxProvider + "/" + p.provider.capxVersion + "/infrastructure-components.yaml"
+
+		// Create provider-system namespace
+		err = p.kube.CreateNamespace(p.provider.capxName + "-system")
+		if err != nil {
+			return errors.Wrap(err, "failed to create "+p.provider.capxName+"-system namespace")
+		}
+
+		// Create docker-registry secret in provider-system namespace
+		err = p.kube.CreateSecret(p.provider.capxName+"-system", kube.Secret{
+			Name: "regcred",
+			DockerRegistry: &kube.DockerRegistryAuth{
+				Server:   p.keosRegistry.url,
+				Username: p.keosRegistry.user,
+				Password: p.keosRegistry.pass,
+			},
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to create docker-registry secret")
+		}
+
+		// Add imagePullSecrets to infrastructure-components.yaml, once
+		c := "grep -q 'name: regcred' " + infraComponents + " || sed -i '/containers:/i\\      imagePullSecrets:\\n      - name: regcred' " + infraComponents
+		_, err = commons.ExecuteCommand(p.n, c, 5)
+
+		if err != nil {
+			return errors.Wrap(err, "failed to add imagePullSecrets to infrastructure-components.yaml")
+		}
+	}
+
+	if p.privateParams.Private {
+		err = p.provider.deployCertManager(p.n, p.keosRegistry.url, "")
+		if err != nil {
+			return err
+		}
+
+		c := "echo \"images:\" >> /root/.cluster-api/clusterctl.yaml && " +
+			"echo \"  cluster-api:\" >> /root/.cluster-api/clusterctl.yaml && " +
+			"echo \"    repository: " + p.keosRegistry.url + "/cluster-api\" >> /root/.cluster-api/clusterctl.yaml && " +
+			"echo \"  bootstrap-kubeadm:\" >> /root/.cluster-api/clusterctl.yaml && " +
+			"echo \"    repository: " + p.keosRegistry.url + "/cluster-api\" >> /root/.cluster-api/clusterctl.yaml && " +
+			"echo \"  control-plane-kubeadm:\" >> /root/.cluster-api/clusterctl.yaml && " +
+			"echo \"    repository: " + p.keosRegistry.url + "/cluster-api\" >> /root/.cluster-api/clusterctl.yaml && "
+		for _, name := range commons.InfraProviderNames() {
+			infraProvider, _ := commons.GetInfraProvider(name)
+			c += "echo \"  infrastructure-" + name + ":\" >> /root/.cluster-api/clusterctl.yaml && " +
+				"echo \"    repository: " + p.keosRegistry.url + "/" + infraProvider.ControllerImageRepository() + "\" >> /root/.cluster-api/clusterctl.yaml && "
+			if infraProvider.PrivateImageTag {
+				c += "echo \"    tag: " + commons.GetBOM().InfraProviderVersion(name) + "\" >> /root/.cluster-api/clusterctl.yaml && "
+			}
+		}
+		c += "echo \"  cert-manager:\" >> /root/.cluster-api/clusterctl.yaml && " +
+			"echo \"    repository: " + p.keosRegistry.url + "/cert-manager\" >> /root/.cluster-api/clusterctl.yaml "
+
+		_, err = commons.ExecuteCommand(p.n, c, 5)
+
+		if err != nil {
+			return errors.Wrap(err, "failed to add private image registry clusterctl config")
+		}
+
+		for _, name := range commons.InfraProviderNames() {
+			if infraProvider, _ := commons.GetInfraProvider(name); !infraProvider.PrivateImageDigests {
+				continue
+			}
+			c = `sed -i 's/@sha256:[[:alnum:]_-].*$//g' /root/.cluster-api/local-repository/infrastructure-` + name + `/` + commons.GetBOM().InfraProviderVersion(name) + `/infrastructure-components.yaml`
+			_, err = commons.ExecuteCommand(p.n, c, 5)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = p.provider.installCAPXLocal(p.n)
+	if err != nil {
+		return err
+	}
+	if withCABundle.check(p.action) {
+		return p.provider.trustCABundle(p.kube)
+	}
+	return nil
+}
+
+func generateSecrets(p *phaseContext) error {
+	// Rendering leaves the local secrets and descriptor files untouched, and
+	// only an ansible-vault secrets file is completed with the credentials
+	if !p.rendering() {
+		if p.secretsPath != "" {
+			commons.EnsureSecretsFile(p.secretsPath, p.keosCluster.Spec, p.vaultPassword, p.clusterCredentials)
+		}
+
+		commons.RewriteDescriptorFile(p.descriptorPath)
+	}
+
+	// Create namespace for CAPI clusters (it must exists)
+	err := p.kube.CreateNamespace(p.capiClustersNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to create cluster's Namespace")
+	}
+
+	// Create the allow-all-egress network policy file in the container
+	err = writeFile(p.n, allowCommonEgressNetPolPath, allowCommonEgressNetPol)
+	if err != nil {
+		return errors.Wrap(err, "failed to write the allow-all-egress network policy")
+	}
+	return nil
+}
+
+func installClusterOperator(p *phaseContext) error {
+	err := p.provider.deployClusterOperator(p.n, p.privateParams, p.clusterCredentials, p.keosRegistry, p.clusterConfig, "", true, p.helmRegistry)
+	if err != nil {
+		return errors.Wrap(err, "failed to deploy cluster operator")
+	}
+	return nil
+}
+
+func ensureIAM(p *phaseContext) error {
+	err := createCloudFormationStack(p.n, p.provider.capxEnvVars)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the IAM security")
+	}
+	return nil
+}
+
+func createWorkloadCluster(p *phaseContext) error {
+	if p.clusterConfig != nil {
+		// Apply cluster manifests
+		err := p.kube.ApplyFile("", manifestsPath+"/clusterconfig.yaml")
+		if err != nil {
+			return errors.Wrap(err, "failed to apply clusterconfig manifests")
+		}
+	}
+
+	// Apply cluster manifests
+	err := p.kube.ApplyFile("", manifestsPath+"/keoscluster.yaml")
+	if err != nil {
+		return errors.Wrap(err, "failed to apply keoscluster manifests")
+	}
+
+	// Wait for the cluster to be created by the cluster operator
+	err = p.kube.Wait(p.capiClustersNamespace, "cluster", p.keosCluster.Metadata.Name, "", time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for cluster")
+	}
+
+	// Wait for the control plane initialization
+	err = p.kube.Wait(p.capiClustersNamespace, "cluster", p.keosCluster.Metadata.Name, "condition=ControlPlaneInitialized", 25*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the workload cluster")
+	}
+	return nil
+}
+
+func saveKubeconfig(p *phaseContext) error {
+	// Get the workload cluster kubeconfig
+	c := "clusterctl -n " + p.capiClustersNamespace + " get kubeconfig " + p.keosCluster.Metadata.Name + " | tee " + kubeconfigPath
+	kubeconfig, err := commons.ExecuteCommand(p.n, c, 5)
+	if err != nil || kubeconfig == "" {
+		return errors.Wrap(err, "failed to get workload cluster kubeconfig")
+	}
+
+	// Create worker-kubeconfig secret for keos cluster
+	err = p.kube.CreateSecret(p.capiClustersNamespace, kube.Secret{
+		Name:  "worker-kubeconfig",
+		Files: map[string]string{path.Base(kubeconfigPath): kubeconfigPath},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create worker-kubeconfig secret")
+	}
+
+	workKubeconfigBasePath := strings.Split(workKubeconfigPath, "/")[0]
+	_, err = os.Stat(workKubeconfigBasePath)
+	if err != nil {
+		err := os.Mkdir(workKubeconfigBasePath, os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+	err = os.WriteFile(workKubeconfigPath, []byte(kubeconfig), 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the workload cluster kubeconfig")
+	}
+	return nil
+}
+
+func installCloudProvider(p *phaseContext) error {
+	err := p.infra.installCloudProvider(p.n, kubeconfigPath, p.privateParams)
+	if err != nil {
+		return errors.Wrap(err, "failed to install external cloud-provider in workload cluster")
+	}
+	return nil
+}
+
+func installCalicoCNI(p *phaseContext) error {
+	err := installCalico(p.n, kubeconfigPath, p.privateParams, allowCommonEgressNetPolPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to install Calico in workload cluster")
+	}
+	return nil
+}
+
+func installCSI(p *phaseContext) error {
+	err := p.infra.installCSI(p.n, kubeconfigPath, p.privateParams)
+	if err != nil {
+		return errors.Wrap(err, "failed to install CSI in workload cluster")
+	}
+	return nil
+}
+
+// XXX Ref kubernetes/kubernetes#86793 Starting from v1.18, gcp cloud-controller-manager requires RBAC to patch,update service/status (in-tree)
+func createInternalLBRBAC(p *phaseContext) error {
+	requiredInternalNginx, err := p.infra.internalNginx(p.providerParams, p.keosCluster.Spec.Networks)
+	if err != nil {
+		return err
+	}
+
+	if requiredInternalNginx {
+		// Deploy Kubernetes RBAC internal loadbalancing
+		err = p.workload.Apply("", rbacInternalLoadBalancing)
+		if err != nil {
+			return errors.Wrap(err, "failed to the kubernetes RBAC internal loadbalancing")
+		}
+	}
+	return nil
+}
+
+func prepareNodes(p *phaseContext) error {
+	var err error
+
+	if p.provider.capxRestart {
+		capxDeployment := p.provider.capxName + "-controller-manager"
+		err = p.kube.RolloutRestart(p.provider.capxName+"-system", "deployment", capxDeployment)
+		if err != nil {
+			return errors.Wrap(err, "failed to reload "+capxDeployment)
+		}
+	}
+
+	if p.isMachinePool() {
+		// Wait for all the machine pools to be ready
+		err = p.kube.Wait(p.capiClustersNamespace, "mp", "", "condition=Ready", 15*time.Minute)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the worker Cluster")
+		}
+
+		// Wait for container metrics to be available
+		err = p.workload.RolloutStatus("kube-system", "deployment", "metrics-server", 90*time.Second)
+		if err != nil {
+			return errors.Wrap(err, "failed to wait for container metrics to be available")
+		}
+	} else {
+		// Wait for all the machine deployments to be ready
+		err = p.kube.Wait(p.capiClustersNamespace, "md", "", "condition=Ready", 15*time.Minute)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the worker Cluster")
+		}
+	}
+
+	if !p.keosCluster.Spec.ControlPlane.Managed && *p.keosCluster.Spec.ControlPlane.HighlyAvailable {
+		// Wait for all control planes to be ready
+		err = p.kube.Wait(p.capiClustersNamespace, "kubeadmcontrolplanes", p.keosCluster.Metadata.Name+"-control-plane", "jsonpath={.status.readyReplicas}=3", 10*time.Minute)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the worker Cluster")
+		}
+	}
+	return nil
+}
+
+func installStorageClass(p *phaseContext) error {
+	err := p.infra.configureStorageClass(p.n, kubeconfigPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to configure StorageClass in workload cluster")
+	}
+	return nil
+}
+
+func enableWorkloadSelfHealing(p *phaseContext) error {
+	err := enableSelfHealing(p.n, p.keosCluster, p.capiClustersNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to enable workload cluster's self-healing")
+	}
+	return nil
+}
+
+func installCAPxWorkload(p *phaseContext) error {
+	if p.privateParams.Private {
+		err := p.provider.deployCertManager(p.n, p.keosRegistry.url, kubeconfigPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := p.provider.installCAPXWorker(p.n, p.keosCluster, kubeconfigPath, allowCommonEgressNetPolPath)
+	if err != nil {
+		return err
+	}
+	if withCABundle.check(p.action) {
+		err = p.provider.trustCABundle(p.workload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.provider.configCAPIWorker(p.n, p.keosCluster, kubeconfigPath, allowCommonEgressNetPolPath)
+}
+
+func configureNetworkPolicy(p *phaseContext) error {
+	// Use Calico as network policy engine in managed systems
+	if p.keosCluster.Spec.ControlPlane.Managed {
+
+		err := installCalico(p.n, kubeconfigPath, p.privateParams, allowCommonEgressNetPolPath)
+		if err != nil {
+			return errors.Wrap(err, "failed to install Network Policy Engine in workload cluster")
+		}
+	}
+
+	// Allow egress in kube-system Namespace
+	err := p.workload.ApplyFile("kube-system", allowCommonEgressNetPolPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply kube-system egress NetworkPolicy")
+	}
+	denyEgressIMDSGNetPol, err := p.provider.getDenyAllEgressIMDSGNetPol()
+	if err != nil {
+		return err
+	}
+	allowEgressIMDSGNetPol, err := p.provider.getAllowCAPXEgressIMDSGNetPol()
+	if err != nil {
+		return err
+	}
+
+	// Deny CAPA egress to AWS IMDS
+	err = p.workload.Apply("", denyEgressIMDSGNetPol)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply deny IMDS traffic GlobalNetworkPolicy")
+	}
+
+	// Allow CAPA egress to AWS IMDS
+	err = p.workload.Apply("", allowEgressIMDSGNetPol)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply allow CAPX as egress GlobalNetworkPolicy")
+	}
+	return nil
+}
+
+func installAutoscaler(p *phaseContext) error {
+	release := kube.HelmRelease{
+		Name:      "cluster-autoscaler",
+		Chart:     "/stratio/helm/cluster-autoscaler",
+		Namespace: "kube-system",
+		Values: map[string]string{
+			"autoDiscovery.clusterName":         p.keosCluster.Metadata.Name,
+			"autoDiscovery.labels[0].namespace": "cluster-" + p.keosCluster.Metadata.Name,
+			"cloudProvider":                     "clusterapi",
+			"clusterAPIMode":                    "incluster-incluster",
+			"replicaCount":                      "2",
+		},
+	}
+	if p.privateParams.Private {
+		release.Values["image.repository"] = p.keosRegistry.url + "/autoscaling/cluster-autoscaler"
+	}
+
+	err := p.workload.HelmInstall(release)
+	if err != nil {
+		return errors.Wrap(err, "failed to deploy cluster-autoscaler in workload cluster")
+	}
+
+	if !p.moveManagement {
+		autoscalerRBAC, err := getManifest("common", "autoscaler_rbac.tmpl", p.keosCluster)
+		if err != nil {
+			return errors.Wrap(err, "failed to get CA RBAC file")
+		}
+
+		// Create namespace for CAPI clusters (it must exists) in worker cluster
+		err = p.workload.CreateNamespace(p.capiClustersNamespace)
+		if err != nil {
+			return errors.Wrap(err, "failed to create manifests Namespace")
+		}
+
+		err = p.workload.Apply("", autoscalerRBAC)
+		if err != nil {
+			return errors.Wrap(err, "failed to apply CA RBAC")
+		}
+	}
+	return nil
+}
+
+func installClusterOperatorWorkload(p *phaseContext) error {
+	err := p.provider.deployClusterOperator(p.n, p.privateParams, p.clusterCredentials, p.keosRegistry, p.clusterConfig, kubeconfigPath, true, p.helmRegistry)
+	if err != nil {
+		return errors.Wrap(err, "failed to deploy cluster operator in workload cluster")
+	}
+	return nil
+}
+
+func customizeCoreDNS(p *phaseContext) error {
+	err := customCoreDNS(p.n, kubeconfigPath, p.keosCluster)
+	if err != nil {
+		return errors.Wrap(err, "failed to customized CoreDNS configuration")
+	}
+	return nil
+}
+
+func backupObjects(p *phaseContext) error {
+	if _, err := os.Stat(localBackupPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(localBackupPath, 0755); err != nil {
+			return errors.Wrap(err, "failed to create local backup directory")
+		}
+	}
+
+	c := "mkdir -p " + cloudProviderBackupPath + " && chmod -R 0755 " + cloudProviderBackupPath
+	_, err := commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to create cloud-provisioner backup directory")
+	}
+
+	c = "clusterctl move -n " + p.capiClustersNamespace + " --to-directory " + cloudProviderBackupPath
+	_, err = commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to backup cloud-provisioner Objects")
+	}
+
+	for _, path := range PathsToBackupLocally {
+		raw := bytes.Buffer{}
+		cmd := exec.CommandContext(context.Background(), "sh", "-c", "docker cp "+p.n.String()+":"+path+" "+localBackupPath)
+		if err := cmd.SetStdout(&raw).Run(); err != nil {
+			return errors.Wrap(err, "failed to copy "+path+" to local host")
+		}
+	}
+	return nil
+}
+
+func moveManagementRole(p *phaseContext) error {
+	err := p.kube.HelmUninstall("kube-system", "cluster-operator")
+	if err != nil {
+		return errors.Wrap(err, "Uninstalling cluster-operator")
+	}
+
+	// Create namespace, if not exists, for CAPI clusters in worker cluster
+	_, err = p.workload.Get("", "ns", p.capiClustersNamespace, "name")
+	if err != nil {
+		err = p.workload.CreateNamespace(p.capiClustersNamespace)
+		if err != nil {
+			return errors.Wrap(err, "failed to create manifests Namespace")
+		}
+	}
+
+	// Pivot management role to worker cluster
+	c := "clusterctl move -n " + p.capiClustersNamespace + " --to-kubeconfig " + kubeconfigPath
+	_, err = commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to pivot management role to worker cluster")
+	}
+
+	// Wait for keoscluster-controller-manager deployment to be ready
+	err = p.workload.RolloutStatus("kube-system", "deploy", "keoscluster-controller-manager", 5*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for keoscluster controller ready")
+	}
+
+	if p.clusterConfig != nil {
+
+		err = p.kube.Patch(p.capiClustersNamespace, "clusterconfig", p.clusterConfig.Metadata.Name, kube.PatchMerge, `{"metadata":{"ownerReferences":null,"finalizers":null}}`)
+		if err != nil {
+			return errors.Wrap(err, "failed to remove clusterconfig ownerReferences and finalizers")
+		}
+
+		// Move clusterConfig to workload cluster
+		err = moveObject(p, "clusterconfig", p.clusterConfig.Metadata.Name, false)
+		if err != nil {
+			return errors.Wrap(err, "failed to move clusterconfig to workload cluster")
+		}
+
+		// Delete clusterconfig in management cluster
+		err = p.kube.Delete(p.capiClustersNamespace, "clusterconfig", p.clusterConfig.Metadata.Name)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete clusterconfig in management cluster")
+		}
+
+	}
+
+	// Move keoscluster to workload cluster
+	err = moveObject(p, "keoscluster", p.keosCluster.Metadata.Name, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to move keoscluster to workload cluster")
+	}
+
+	err = p.kube.Patch(p.capiClustersNamespace, "keoscluster", p.keosCluster.Metadata.Name, kube.PatchMerge, `{"metadata":{"finalizers":null}}`)
+	if err != nil {
+		return errors.Wrap(err, "failed to scale keoscluster deployment to 1")
+	}
+
+	// Delete keoscluster in management cluster
+	err = p.kube.Delete(p.capiClustersNamespace, "keoscluster", p.keosCluster.Metadata.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete keoscluster in management cluster")
+	}
+
+	err = p.provider.deployClusterOperator(p.n, p.privateParams, p.clusterCredentials, p.keosRegistry, p.clusterConfig, "", false, p.helmRegistry)
+	if err != nil {
+		return errors.Wrap(err, "failed to deploy cluster operator")
+	}
+	return nil
+}
+
+// moveObject copies the object name of resource in the CAPI clusters
+// namespace from the management to the workload cluster, without its status
+// if dropStatus is set
+func moveObject(p *phaseContext, resource string, name string, dropStatus bool) error {
+	raw, err := p.kube.Get(p.capiClustersNamespace, resource, name, "json")
+	if err != nil {
+		return err
+	}
+	if dropStatus {
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+			return errors.Wrap(err, "failed to parse "+resource+" "+name)
+		}
+		delete(obj, "status")
+		out, err := json.Marshal(obj)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal "+resource+" "+name)
+		}
+		raw = string(out)
+	}
+	return p.workload.Apply("", raw)
+}
+
+func postInstall(p *phaseContext) error {
+	return p.infra.postInstallPhase(p.n, kubeconfigPath)
+}
+
+func generateKEOSDescriptor(p *phaseContext) error {
+	err := createKEOSDescriptor(p.infra, p.keosCluster, scName, p.clusterCredentials)
+	if err != nil {
+		return err
+	}
+
+	return override_vars(p.ctx, p.providerParams, p.keosCluster.Spec.Networks, p.infra)
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestPhaseCommands(t *testing.T) {
+	t.Parallel()
+
+	highlyAvailable := true
+	awsUnmanaged := newTestAction("aws", false)
+	awsUnmanaged.keosCluster.Spec.ControlPlane.HighlyAvailable = &highlyAvailable
+
+	eks := newTestAction("aws", true)
+
+	aks := newTestAction("azure", true)
+	aks.clusterConfig = &commons.ClusterConfig{}
+	aks.clusterConfig.Metadata.Name = "test-config"
+
+	cases := []struct {
+		Name               string
+		Action             *action
+		Run                func(p *phaseContext) error
+		ExpectedManagement []string
+		ExpectedWorkload   []string
+	}{
+		{
+			Name:   "create workload cluster",
+			Action: aks,
+			Run:    createWorkloadCluster,
+			ExpectedManagement: []string{
+				"kubectl apply -f /kind/manifests/clusterconfig.yaml",
+				"kubectl apply -f /kind/manifests/keoscluster.yaml",
+				"kubectl --namespace cluster-test get cluster test -o name",
+				"kubectl --namespace cluster-test wait cluster test --for=condition=ControlPlaneInitialized --timeout=25m0s",
+			},
+			ExpectedWorkload: []string{},
+		},
+		{
+			Name:   "prepare unmanaged nodes",
+			Action: awsUnmanaged,
+			Run:    prepareNodes,
+			ExpectedManagement: []string{
+				"kubectl --namespace cluster-test wait md --all --for=condition=Ready --timeout=15m0s",
+				"kubectl --namespace cluster-test wait kubeadmcontrolplanes test-control-plane --for=jsonpath={.status.readyReplicas}=3 --timeout=10m0s",
+			},
+			ExpectedWorkload: []string{},
+		},
+		{
+			Name:   "prepare EKS nodes",
+			Action: eks,
+			Run:    prepareNodes,
+			ExpectedManagement: []string{
+				"kubectl --namespace capa-system rollout restart deployment capa-controller-manager",
+				"kubectl --namespace cluster-test wait md --all --for=condition=Ready --timeout=15m0s",
+			},
+			ExpectedWorkload: []string{},
+		},
+		{
+			Name:   "prepare AKS nodes",
+			Action: aks,
+			Run:    prepareNodes,
+			ExpectedManagement: []string{
+				"kubectl --namespace cluster-test wait mp --all --for=condition=Ready --timeout=15m0s",
+			},
+			ExpectedWorkload: []string{
+				"kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace kube-system rollout status deployment metrics-server --timeout=1m30s",
+			},
+		},
+		{
+			Name:   "delete workload cluster",
+			Action: aks,
+			Run:    deleteWorkloadCluster,
+			ExpectedManagement: []string{
+				"kubectl --namespace cluster-test get keoscluster test --ignore-not-found -o name",
+				"kubectl --namespace cluster-test get clusterconfig test-config --ignore-not-found -o name",
+				"kubectl --namespace cluster-test delete cluster test --ignore-not-found --wait=false",
+				"kubectl --namespace cluster-test wait cluster test --for=delete --timeout=1h0m0s",
+				"kubectl delete ns cluster-test --ignore-not-found",
+			},
+			ExpectedWorkload: []string{},
+		},
+		{
+			Name:               "network policy",
+			Action:             awsUnmanaged,
+			Run:                configureNetworkPolicy,
+			ExpectedManagement: []string{},
+			ExpectedWorkload: []string{
+				"kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace kube-system apply -f /kind/allow-all-egress_netpol.yaml",
+				"kubectl --kubeconfig /kind/worker-cluster.kubeconfig apply -f -",
+				"kubectl --kubeconfig /kind/worker-cluster.kubeconfig apply -f -",
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			management := kube.NewFakeClient("")
+			workload := kube.NewFakeClient(kubeconfigPath)
+			builder, err := getBuilder(tc.Action.keosCluster.Spec.InfraProvider)
+			assert.ExpectError(t, false, err)
+			p := &phaseContext{
+				action:                tc.Action,
+				kube:                  management,
+				workload:              workload,
+				provider:              newInfra(builder).buildProvider(ProviderParams{Managed: tc.Action.keosCluster.Spec.ControlPlane.Managed}),
+				capiClustersNamespace: "cluster-" + tc.Action.keosCluster.Metadata.Name,
+			}
+			assert.ExpectError(t, false, tc.Run(p))
+			assert.DeepEqual(t, tc.ExpectedManagement, append([]string{}, management.Commands...))
+			assert.DeepEqual(t, tc.ExpectedWorkload, append([]string{}, workload.Commands...))
+		})
+	}
+}
+
+func TestTrustCABundle(t *testing.T) {
+	t.Parallel()
+	workload := kube.NewFakeClient(kubeconfigPath)
+	p := Provider{capxName: "capa"}
+	assert.ExpectError(t, false, p.trustCABundle(workload))
+	assert.DeepEqual(t, []string{
+		"kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace capa-system apply -f -",
+		"kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace capa-system patch deploy capa-controller-manager --type=strategic -p " +
+			`{"spec":{"template":{"spec":{"volumes":[{"name":"keos-ca-bundle","configMap":{"name":"keos-ca-bundle"}}],` +
+			`"containers":[{"name":"manager","volumeMounts":[{"name":"keos-ca-bundle","mountPath":"/etc/ssl/certs/keos-ca-bundle.crt","subPath":"ca.crt","readOnly":true}]}]}}}}`,
+		"kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace capa-system rollout status deploy capa-controller-manager --timeout=5m0s",
+	}, append([]string{}, workload.Commands...))
+}
+
+func TestPhaseCommandErrors(t *testing.T) {
+	t.Parallel()
+	a := newTestAction("aws", false)
+	management := kube.NewFakeClient("")
+	management.Errors["kubectl apply -f /kind/manifests/keoscluster.yaml"] = &kube.CommandError{ExitCode: 1}
+	p := &phaseContext{action: a, kube: management, capiClustersNamespace: "cluster-test"}
+	err := createWorkloadCluster(p)
+	assert.ExpectError(t, true, err)
+	assert.DeepEqual(t, []string{"kubectl apply -f /kind/manifests/keoscluster.yaml"}, management.Commands)
+	assert.StringEqual(t, string(kube.ReasonFailed), string(kube.ReasonForError(err)))
+}
+
+func TestRemoveKeosObjects(t *testing.T) {
+	t.Parallel()
+	a := newTestAction("aws", false)
+	workload := kube.NewFakeClient(kubeconfigPath)
+	workload.Outputs["kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace cluster-test get keoscluster test --ignore-not-found -o name"] = "keoscluster.installer.stratio.com/test\n"
+	p := &phaseContext{action: a, workload: workload, capiClustersNamespace: "cluster-test"}
+	assert.ExpectError(t, false, removeKeosObjects(p, p.workload))
+	assert.DeepEqual(t, []string{
+		"kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace cluster-test get keoscluster test --ignore-not-found -o name",
+		`kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace cluster-test patch keoscluster test --type=merge -p {"metadata":{"ownerReferences":null,"finalizers":null}}`,
+		"kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace cluster-test delete keoscluster test --ignore-not-found",
+	}, workload.Commands)
+}
+
+func TestUnsetDefaultStorageClass(t *testing.T) {
+	t.Parallel()
+	workload := kube.NewFakeClient(kubeconfigPath)
+	get := `kubectl --kubeconfig /kind/worker-cluster.kubeconfig get sc -o jsonpath={.items[?(@.metadata.annotations.storageclass\.kubernetes\.io/is-default-class=="true")].metadata.name}`
+	workload.Outputs[get] = "gp2 standard"
+	assert.ExpectError(t, false, unsetDefaultStorageClass(workload))
+	assert.DeepEqual(t, []string{
+		get,
+		`kubectl --kubeconfig /kind/worker-cluster.kubeconfig patch sc gp2 --type=merge -p {"metadata":{"annotations":{"storageclass.kubernetes.io/is-default-class":null}}}`,
+		`kubectl --kubeconfig /kind/worker-cluster.kubeconfig patch sc standard --type=merge -p {"metadata":{"annotations":{"storageclass.kubernetes.io/is-default-class":null}}}`,
+	}, workload.Commands)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/create/actions"
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// PhaseOptions controls which phases of the pipeline are run
+type PhaseOptions struct {
+	// Resume skips the phases recorded in the checkpoint of a previous run
+	Resume bool
+	// DryRun only lists the phases that would be run
+	DryRun bool
+	// Skip lists the phases not to be run
+	Skip []string
+	// Only runs a single phase against an existing cluster
+	Only string
+	// RenderDir, if set, records the commands and manifests of the phases in
+	// this local directory instead of running them
+	RenderDir string
+}
+
+// phase is a named step of the workload cluster creation
+type phase struct {
+	name     string
+	status   string
+	requires []condition
+	run      func(p *phaseContext) error
+}
+
+// condition is a named precondition that must hold for a phase to be run
+type condition struct {
+	name  string
+	check func(a *action) bool
+}
+
+// phaseContext holds the state shared by all the phases of a run
+type phaseContext struct {
+	*action
+	ctx                   *actions.ActionContext
+	n                     nodes.Node
+	kube                  kube.Client // management cluster
+	workload              kube.Client // workload cluster
+	infra                 *Infra
+	provider              Provider
+	providerParams        ProviderParams
+	privateParams         PrivateParams
+	keosRegistry          KeosRegistry
+	helmRegistry          HelmRegistry
+	capiClustersNamespace string
+}
+
+// enabled returns true if all the preconditions of the phase hold
+func (ph phase) enabled(a *action) bool {
+	for _, c := range ph.requires {
+		if !c.check(a) {
+			return false
+		}
+	}
+	return true
+}
+
+// describe returns the phase name along with its preconditions
+func (ph phase) describe() string {
+	if len(ph.requires) == 0 {
+		return ph.name
+	}
+	names := make([]string, 0, len(ph.requires))
+	for _, c := range ph.requires {
+		names = append(names, c.name)
+	}
+	return ph.name + " [" + strings.Join(names, ", ") + "]"
+}
+
+// plan returns, in order, the phases of the pipeline to be run for the action
+func (a *action) plan(pipeline []phase) ([]phase, error) {
+	known := map[string]bool{}
+	for _, ph := range pipeline {
+		known[ph.name] = true
+	}
+	for _, name := range a.phaseOptions.Skip {
+		if !known[name] {
+			return nil, errors.Errorf("unknown phase %q, valid phases are: %s", name, phaseNames(pipeline))
+		}
+	}
+
+	if a.phaseOptions.Only != "" {
+		for _, ph := range pipeline {
+			if ph.name != a.phaseOptions.Only {
+				continue
+			}
+			if !ph.enabled(a) {
+				return nil, errors.Errorf("phase %q does not apply to this cluster", ph.describe())
+			}
+			return []phase{ph}, nil
+		}
+		return nil, errors.Errorf("unknown phase %q, valid phases are: %s", a.phaseOptions.Only, phaseNames(pipeline))
+	}
+
+	planned := []phase{}
+	for _, ph := range pipeline {
+		if !ph.enabled(a) || a.skipped(ph.name) {
+			continue
+		}
+		planned = append(planned, ph)
+	}
+	return planned, nil
+}
+
+// skipped returns true if the phase has been explicitly skipped
+func (a *action) skipped(name string) bool {
+	for _, s := range a.phaseOptions.Skip {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runPhases runs the planned phases, recording each completed phase in the
+// checkpoint (if any) and skipping those it already contains
+func runPhases(p *phaseContext, planned []phase, cp *checkpoint) error {
+	for _, ph := range planned {
+		if cp != nil && cp.done(ph.name) {
+			continue
+		}
+		p.ctx.Status.Start(ph.status)
+		if r, ok := p.n.(*renderNode); ok {
+			if err := r.section(ph.name); err != nil {
+				p.ctx.Status.End(false)
+				return err
+			}
+		}
+		if err := ph.run(p); err != nil {
+			p.ctx.Status.End(false)
+			return err
+		}
+		p.ctx.Status.End(true)
+		if cp != nil {
+			if err := cp.complete(p.n, ph.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// phaseNames returns the comma separated names of the phases
+func phaseNames(phases []phase) string {
+	names := make([]string, 0, len(phases))
+	for _, ph := range phases {
+		names = append(names, ph.name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func newTestAction(infraProvider string, managed bool) *action {
+	a := &action{operation: operationCreate}
+	a.keosCluster.Metadata.Name = "test"
+	a.keosCluster.Spec.InfraProvider = infraProvider
+	a.keosCluster.Spec.ControlPlane.Managed = managed
+	return a
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	awsUnmanaged := newTestAction("aws", false)
+
+	aks := newTestAction("azure", true)
+	aks.keosCluster.Spec.DeployAutoscaler = true
+
+	docker := newTestAction("docker", false)
+	docker.keosCluster.Spec.DeployAutoscaler = true
+
+	gcpPrivate := newTestAction("gcp", false)
+	gcpPrivate.avoidCreation = true
+	gcpPrivate.clusterConfig = &commons.ClusterConfig{Spec: commons.ClusterConfigSpec{Private: true}}
+
+	caBundle := newTestAction("aws", true)
+	caBundle.avoidCreation = true
+	caBundle.keosCluster.Spec.CABundle = "ca-bundle.pem"
+
+	eksWithIAM := newTestAction("aws", true)
+	eksWithIAM.keosCluster.Spec.Security.AWS.CreateIAM = true
+	eksWithIAM.keosCluster.Spec.Dns.Forwarders = []string{"8.8.8.8"}
+	eksWithIAM.moveManagement = true
+
+	skipped := newTestAction("aws", false)
+	skipped.phaseOptions.Skip = []string{"calico", "csi"}
+
+	only := newTestAction("aws", false)
+	only.phaseOptions.Only = "storageclass"
+
+	onlyNotApplying := newTestAction("azure", true)
+	onlyNotApplying.phaseOptions.Only = "calico"
+
+	rendered := newTestAction("gcp", false)
+	rendered.phaseOptions.RenderDir = "render"
+
+	unknownSkip := newTestAction("aws", false)
+	unknownSkip.phaseOptions.Skip = []string{"cilium"}
+
+	unknownOnly := newTestAction("aws", false)
+	unknownOnly.phaseOptions.Only = "cilium"
+
+	teardownNewLocal := newTestAction("aws", false)
+	teardownNewLocal.operation = operationDelete
+	teardownNewLocal.localProvisioned = true
+	teardownNewLocal.deleteIAM = true
+
+	teardownRetainedLocal := newTestAction("gcp", false)
+	teardownRetainedLocal.operation = operationDelete
+	teardownRetainedLocal.deleteIAM = true
+
+	restoreLocal := newTestAction("aws", false)
+	restoreLocal.operation = operationRestore
+
+	restorePivot := newTestAction("azure", true)
+	restorePivot.operation = operationRestore
+	restorePivot.targetKubeconfig = "target.kubeconfig"
+
+	cases := []struct {
+		Name        string
+		Action      *action
+		Expected    []string
+		ExpectError bool
+	}{
+		{
+			Name:   "aws unmanaged",
+			Action: awsUnmanaged,
+			Expected: []string{
+				"capx-local", "secrets", "cluster-operator", "workload-cluster", "kubeconfig",
+				"cloud-provider", "calico", "csi", "prepare-nodes", "storageclass", "self-healing",
+				"capx-workload", "network-policy", "cluster-operator-workload", "backup",
+				"move-management", "post-install", "keos-descriptor",
+			},
+		},
+		{
+			Name:   "azure managed with machine pools",
+			Action: aks,
+			Expected: []string{
+				"capx-local", "secrets", "cluster-operator", "workload-cluster", "kubeconfig",
+				"prepare-nodes", "storageclass", "self-healing", "capx-workload",
+				"cluster-operator-workload", "backup", "move-management", "post-install",
+				"keos-descriptor",
+			},
+		},
+		{
+			Name:   "docker",
+			Action: docker,
+			Expected: []string{
+				"capx-local", "secrets", "cluster-operator", "workload-cluster", "kubeconfig",
+				"calico", "csi", "prepare-nodes", "storageclass", "self-healing", "capx-workload",
+				"autoscaler", "cluster-operator-workload", "backup", "move-management",
+				"post-install", "keos-descriptor",
+			},
+		},
+		{
+			Name:   "private without creation",
+			Action: gcpPrivate,
+			Expected: []string{
+				"private-cni", "delete-local-storage", "capx-local", "secrets", "cluster-operator",
+				"keos-descriptor",
+			},
+		},
+		{
+			Name:   "eks with iam keeping the management",
+			Action: eksWithIAM,
+			Expected: []string{
+				"capx-local", "secrets", "cluster-operator", "iam", "workload-cluster", "kubeconfig",
+				"prepare-nodes", "storageclass", "self-healing", "capx-workload", "network-policy",
+				"cluster-operator-workload", "backup", "post-install", "keos-descriptor",
+			},
+		},
+		{
+			Name:   "skipped phases",
+			Action: skipped,
+			Expected: []string{
+				"capx-local", "secrets", "cluster-operator", "workload-cluster", "kubeconfig",
+				"cloud-provider", "prepare-nodes", "storageclass", "self-healing",
+				"capx-workload", "network-policy", "cluster-operator-workload", "backup",
+				"move-management", "post-install", "keos-descriptor",
+			},
+		},
+		{
+			Name:   "render only",
+			Action: rendered,
+			Expected: []string{
+				"capx-local", "secrets", "cluster-operator", "workload-cluster", "calico", "csi",
+				"prepare-nodes", "storageclass", "self-healing", "capx-workload", "network-policy",
+				"cluster-operator-workload", "post-install",
+			},
+		},
+		{
+			Name:   "CA bundle",
+			Action: caBundle,
+			Expected: []string{
+				"ca-bundle", "capx-local", "secrets", "cluster-operator", "keos-descriptor",
+			},
+		},
+		{
+			Name:     "only one phase",
+			Action:   only,
+			Expected: []string{"storageclass"},
+		},
+		{
+			Name:        "only one phase not applying",
+			Action:      onlyNotApplying,
+			ExpectError: true,
+		},
+		{
+			Name:        "unknown skipped phase",
+			Action:      unknownSkip,
+			ExpectError: true,
+		},
+		{
+			Name:        "unknown only phase",
+			Action:      unknownOnly,
+			ExpectError: true,
+		},
+		{
+			Name:   "teardown from a new local cluster",
+			Action: teardownNewLocal,
+			Expected: []string{
+				"capx-local", "workload-kubeconfig", "move-management-back",
+				"delete-workload-cluster", "delete-iam",
+			},
+		},
+		{
+			Name:   "teardown from the retained local cluster",
+			Action: teardownRetainedLocal,
+			Expected: []string{
+				"move-management-back", "delete-workload-cluster",
+			},
+		},
+		{
+			Name:   "restore keeping the local cluster",
+			Action: restoreLocal,
+			Expected: []string{
+				"capx-local", "restore-objects", "kubeconfig",
+			},
+		},
+		{
+			Name:   "restore into a target cluster",
+			Action: restorePivot,
+			Expected: []string{
+				"capx-local", "restore-objects", "kubeconfig", "pivot",
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			planned, err := tc.Action.plan(tc.Action.pipeline())
+			assert.ExpectError(t, tc.ExpectError, err)
+			if tc.ExpectError {
+				return
+			}
+			names := []string{}
+			for _, ph := range planned {
+				names = append(names, ph.name)
+			}
+			assert.DeepEqual(t, tc.Expected, names)
+		})
+	}
+}
+
+func TestPhaseNamesAreUnique(t *testing.T) {
+	t.Parallel()
+	for _, pipeline := range [][]phase{createPhases(), deletePhases(), restorePhases()} {
+		seen := map[string]bool{}
+		for _, ph := range pipeline {
+			if seen[ph.name] {
+				t.Errorf("duplicated phase %q", ph.name)
+			}
+			seen[ph.name] = true
+		}
+	}
+}
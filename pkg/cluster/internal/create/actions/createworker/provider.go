@@ -19,23 +19,20 @@ package createworker
 import (
 	"bytes"
 	"embed"
-	"encoding/base64"
 	"encoding/json"
-	"fmt"
 	"io"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"time"
 
 	"strings"
 	"text/template"
 
 	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
 	"sigs.k8s.io/kind/pkg/cluster/nodes"
 	"sigs.k8s.io/kind/pkg/commons"
 	"sigs.k8s.io/kind/pkg/errors"
-	"sigs.k8s.io/kind/pkg/exec"
 )
 
 //go:embed templates/*/*
@@ -52,18 +49,16 @@ var stratio_helm_repo string
 //go:embed files/*/*_pdb.yaml
 var commonsPDBFile embed.FS
 
+// priorityClassPatch makes the pods of a workload system-node-critical
+const priorityClassPatch = `{"spec": {"template": {"spec": {"priorityClassName": "system-node-critical"}}}}`
+
 const (
 	CAPICoreProvider         = "cluster-api"
 	CAPIBootstrapProvider    = "kubeadm"
 	CAPIControlPlaneProvider = "kubeadm"
-	CAPIVersion              = "v1.5.3"
 
 	scName = "keos"
 
-	certManagerVersion   = "v1.12.3"
-	clusterOperatorChart = "0.2.0-SNAPSHOT"
-	clusterOperatorImage = "0.2.0-SNAPSHOT"
-
 	postInstallAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict-local-volumes"
 	corednsPdbPath        = "/kind/coredns_pdb.yaml"
 
@@ -91,8 +86,8 @@ type PBuilder interface {
 	configureStorageClass(n nodes.Node, k string) error
 	internalNginx(p ProviderParams, networks commons.Networks) (bool, error)
 	getOverrideVars(p ProviderParams, networks commons.Networks) (map[string][]byte, error)
-	getRegistryCredentials(p ProviderParams, u string) (string, string, error)
 	postInstallPhase(n nodes.Node, k string) error
+	setKEOSDescriptor(d *KEOSDescriptor, keosCluster commons.KeosCluster)
 }
 
 type Provider struct {
@@ -105,6 +100,21 @@ type Provider struct {
 	scParameters     commons.SCParameters
 	scProvisioner    string
 	csiNamespace     string
+	// clusterOperatorValues are the helm values of the cluster-operator
+	// with the provider credentials
+	clusterOperatorValues map[string]string
+	// capxRestart is true if the CAPx controller must be restarted once the
+	// workload cluster is created
+	capxRestart bool
+	// capxIdentitySecret is the data of the cluster identity secret the CAPx
+	// controller reads the provider credentials from, created before it
+	capxIdentitySecret map[string]string
+	// capxDaemonSets are the daemon sets installed along with the CAPx
+	// controller
+	capxDaemonSets []string
+	// helmRepositoryConfig configures the node to reach the helm repository
+	// hosted by the provider, if any
+	helmRepositoryConfig func(n nodes.Node, keosCluster commons.KeosCluster, providerCredentials map[string]string) error
 }
 
 type Node struct {
@@ -151,6 +161,7 @@ type calicoHelmParams struct {
 	KeosRegUrl  string
 	Private     bool
 	Annotations map[string]string
+	BOM         commons.BOM
 }
 
 var scTemplate = DefaultStorageClass{
@@ -169,19 +180,25 @@ var scTemplate = DefaultStorageClass{
 	VolumeBindingMode:    "WaitForFirstConsumer",
 }
 
-func getBuilder(builderType string) PBuilder {
-	if builderType == "aws" {
-		return newAWSBuilder()
-	}
+// builders are the PBuilder constructors of the infra providers, registered
+// from their files along with their commons.InfraProvider
+var builders = map[string]func() PBuilder{}
 
-	if builderType == "gcp" {
-		return newGCPBuilder()
+// registerBuilder makes the PBuilder of the infra provider name available
+func registerBuilder(name string, newBuilder func() PBuilder) {
+	if _, ok := builders[name]; ok {
+		panic("builder " + name + " is already registered")
 	}
+	builders[name] = newBuilder
+}
 
-	if builderType == "azure" {
-		return newAzureBuilder()
+// getBuilder returns the PBuilder of the infra provider builderType
+func getBuilder(builderType string) (PBuilder, error) {
+	newBuilder, ok := builders[builderType]
+	if !ok {
+		return nil, errors.New("there is no cluster builder for the infra provider " + builderType)
 	}
-	return nil
+	return newBuilder(), nil
 }
 
 func newInfra(b PBuilder) *Infra {
@@ -217,14 +234,24 @@ func (i *Infra) getOverrideVars(p ProviderParams, networks commons.Networks) (ma
 	return i.builder.getOverrideVars(p, networks)
 }
 
+// getRegistryCredentials returns the credentials of the registry u hosted by
+// the infra provider
 func (i *Infra) getRegistryCredentials(p ProviderParams, u string) (string, string, error) {
-	return i.builder.getRegistryCredentials(p, u)
+	infraProvider, ok := commons.GetInfraProvider(i.builder.getProvider().capxProvider)
+	if !ok {
+		return "", "", errors.New("the infra provider does not host registries")
+	}
+	return infraProvider.GetRegistryCredentials(p.Credentials, u)
 }
 
 func (i *Infra) postInstallPhase(n nodes.Node, k string) error {
 	return i.builder.postInstallPhase(n, k)
 }
 
+func (i *Infra) setKEOSDescriptor(d *KEOSDescriptor, keosCluster commons.KeosCluster) {
+	i.builder.setKEOSDescriptor(d, keosCluster)
+}
+
 func (p *Provider) getDenyAllEgressIMDSGNetPol() (string, error) {
 	denyAllEgressIMDSGNetPolLocalPath := "files/" + p.capxProvider + "/deny-all-egress-imds_gnetpol.yaml"
 	denyAllEgressIMDSgnpFile, err := denyAllEgressIMDSgnpFiles.Open(denyAllEgressIMDSGNetPolLocalPath)
@@ -270,37 +297,31 @@ func getcapxPDB(commonsPDBLocalPath string) (string, error) {
 }
 
 func (p *Provider) deployCertManager(n nodes.Node, keosRegistryUrl string, kubeconfigPath string) error {
-	c := "kubectl create -f " + CAPILocalRepository + "/cert-manager/" + certManagerVersion + "/cert-manager.crds.yaml"
-	if kubeconfigPath != "" {
-		c += " --kubeconfig " + kubeconfigPath
-	}
-	_, err := commons.ExecuteCommand(n, c, 5)
+	k := kube.NewClient(n, kubeconfigPath)
+
+	err := k.CreateFile("", CAPILocalRepository+"/cert-manager/"+commons.GetBOM().CertManager+"/cert-manager.crds.yaml")
 	if err != nil {
 		return errors.Wrap(err, "failed to create cert-manager crds")
 	}
 
-	c = "kubectl create ns cert-manager"
-	if kubeconfigPath != "" {
-		c += " --kubeconfig " + kubeconfigPath
-	}
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = k.CreateNamespace("cert-manager")
 	if err != nil {
 		return errors.Wrap(err, "failed to create cert-manager namespace")
 	}
 
-	c = "helm install --wait cert-manager /stratio/helm/cert-manager" +
-		" --set namespace=cert-manager" +
-		" --set cainjector.image.repository=" + keosRegistryUrl + "/jetstack/cert-manager-cainjector" +
-		" --set webhook.image.repository=" + keosRegistryUrl + "/jetstack/cert-manager-webhook" +
-		" --set acmesolver.image.repository=" + keosRegistryUrl + "/jetstack/cert-manager-acmesolver" +
-		" --set startupapicheck.image.repository=" + keosRegistryUrl + "/jetstack/cert-manager-ctl" +
-		" --set image.repository=" + keosRegistryUrl + "/jetstack/cert-manager-controller"
-
-	if kubeconfigPath != "" {
-		c += " --kubeconfig " + kubeconfigPath
-	}
-
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = k.HelmInstall(kube.HelmRelease{
+		Name:  "cert-manager",
+		Chart: "/stratio/helm/cert-manager",
+		Values: map[string]string{
+			"namespace":                        "cert-manager",
+			"cainjector.image.repository":      keosRegistryUrl + "/jetstack/cert-manager-cainjector",
+			"webhook.image.repository":         keosRegistryUrl + "/jetstack/cert-manager-webhook",
+			"acmesolver.image.repository":      keosRegistryUrl + "/jetstack/cert-manager-acmesolver",
+			"startupapicheck.image.repository": keosRegistryUrl + "/jetstack/cert-manager-ctl",
+			"image.repository":                 keosRegistryUrl + "/jetstack/cert-manager-controller",
+		},
+		Wait: true,
+	})
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy cert-manager Helm Chart")
 	}
@@ -308,48 +329,21 @@ func (p *Provider) deployCertManager(n nodes.Node, keosRegistryUrl string, kubec
 }
 
 func (p *Provider) deployClusterOperator(n nodes.Node, privateParams PrivateParams, clusterCredentials commons.ClusterCredentials, keosRegistry KeosRegistry, clusterConfig *commons.ClusterConfig, kubeconfigPath string, firstInstallation bool, helmRepoCreds HelmRegistry) error {
-	var c string
 	var err error
 	var helmRepository helmRepository
 	keosCluster := privateParams.KeosCluster
+	k := kube.NewClient(n, kubeconfigPath)
 
-	if firstInstallation && keosCluster.Spec.InfraProvider == "aws" && strings.HasPrefix(keosCluster.Spec.HelmRepository.URL, "s3://") {
-		c = "mkdir -p ~/.aws"
-		_, err = commons.ExecuteCommand(n, c, 5)
-		if err != nil {
-			return errors.Wrap(err, "failed to create aws config file")
-		}
-		c = "echo [default] > ~/.aws/config && " +
-			"echo region = " + keosCluster.Spec.Region + " >>  ~/.aws/config"
-		_, err = commons.ExecuteCommand(n, c, 5)
+	if firstInstallation && p.helmRepositoryConfig != nil {
+		err = p.helmRepositoryConfig(n, keosCluster, clusterCredentials.ProviderCredentials)
 		if err != nil {
-			return errors.Wrap(err, "failed to create aws config file")
-		}
-		awsCredentials := "[default]\naws_access_key_id = " + clusterCredentials.ProviderCredentials["AccessKey"] + "\naws_secret_access_key = " + clusterCredentials.ProviderCredentials["SecretKey"] + "\n"
-		c = "echo '" + awsCredentials + "' > ~/.aws/credentials"
-		_, err = commons.ExecuteCommand(n, c, 5)
-		if err != nil {
-			return errors.Wrap(err, "failed to create aws credentials file")
+			return err
 		}
 	}
 
 	if kubeconfigPath == "" {
 		// Clean keoscluster file
-		keosCluster.Spec.Credentials = commons.Credentials{}
-		keosCluster.Spec.StorageClass = commons.StorageClass{}
-		keosCluster.Spec.Security.AWS = struct {
-			CreateIAM bool "yaml:\"create_iam\" validate:\"boolean\""
-		}{}
-		if keosCluster.Spec.InfraProvider != "azure" || (keosCluster.Spec.InfraProvider == "azure" && !keosCluster.Spec.ControlPlane.Managed) {
-			keosCluster.Spec.ControlPlane.Azure = commons.AzureCP{}
-		}
-		if keosCluster.Spec.InfraProvider != "aws" || (keosCluster.Spec.InfraProvider == "aws" && !keosCluster.Spec.ControlPlane.Managed) {
-			keosCluster.Spec.ControlPlane.AWS = commons.AWSCP{}
-		}
-		if keosCluster.Spec.ControlPlane.Managed {
-			keosCluster.Spec.ControlPlane.HighlyAvailable = nil
-		}
-		keosCluster.Spec.Keos = commons.Keos{}
+		keosCluster = commons.KeosClusterObject(keosCluster, clusterConfig)
 
 		if clusterConfig != nil {
 			clusterConfigYAML, err := yaml.Marshal(clusterConfig)
@@ -357,46 +351,45 @@ func (p *Provider) deployClusterOperator(n nodes.Node, privateParams PrivatePara
 				return err
 			}
 			// Write keoscluster file
-			c = "echo '" + string(clusterConfigYAML) + "' > " + manifestsPath + "/clusterconfig.yaml"
-			_, err = commons.ExecuteCommand(n, c, 5)
+			err = writeFile(n, manifestsPath+"/clusterconfig.yaml", string(clusterConfigYAML))
 			if err != nil {
 				return errors.Wrap(err, "failed to write the keoscluster file")
 			}
-			keosCluster.Spec.ClusterConfigRef.Name = clusterConfig.Metadata.Name
 		}
 		keosClusterYAML, err := yaml.Marshal(keosCluster)
 		if err != nil {
 			return err
 		}
 		// Write keoscluster file
-		c = "echo '" + string(keosClusterYAML) + "' > " + manifestsPath + "/keoscluster.yaml"
-		_, err = commons.ExecuteCommand(n, c, 5)
+		err = writeFile(n, manifestsPath+"/keoscluster.yaml", string(keosClusterYAML))
 		if err != nil {
 			return errors.Wrap(err, "failed to write the keoscluster file")
 		}
 		// Add helm repository
 		helmRepository.url = keosCluster.Spec.HelmRepository.URL
+		repo := kube.HelmRepository{URL: helmRepoCreds.URL}
+		if keosCluster.Spec.CABundle != "" {
+			repo.CAFile = caBundlePath
+		}
 		if strings.HasPrefix(keosCluster.Spec.HelmRepository.URL, "oci://") {
 			stratio_helm_repo = helmRepoCreds.URL
-			urlLogin := strings.Split(strings.Split(keosCluster.Spec.HelmRepository.URL, "//")[1], "/")[0]
-
-			c = "helm registry login " + urlLogin + " --username " + helmRepoCreds.User + " --password " + helmRepoCreds.Pass
-			_, err = commons.ExecuteCommand(n, c, 5)
+			repo.URL = strings.Split(strings.Split(keosCluster.Spec.HelmRepository.URL, "//")[1], "/")[0]
+			repo.Username = helmRepoCreds.User
+			repo.Password = helmRepoCreds.Pass
+			err = k.HelmRegistryLogin(repo)
 			if err != nil {
 				return errors.Wrap(err, "failed to add and authenticate to helm repository: "+helmRepoCreds.URL)
 			}
-		} else if keosCluster.Spec.HelmRepository.AuthRequired {
-			helmRepository.user = clusterCredentials.HelmRepositoryCredentials["User"]
-			helmRepository.pass = clusterCredentials.HelmRepositoryCredentials["Pass"]
+		} else {
 			stratio_helm_repo = "stratio-helm-repo"
-			c = "helm repo add " + stratio_helm_repo + " " + helmRepoCreds.URL + " --username " + helmRepoCreds.User + " --password " + helmRepoCreds.Pass
-			_, err = commons.ExecuteCommand(n, c, 5)
-			if err != nil {
-				return errors.Wrap(err, "failed to add and authenticate to helm repository: "+helmRepository.url)
+			repo.Name = stratio_helm_repo
+			if keosCluster.Spec.HelmRepository.AuthRequired {
+				helmRepository.user = clusterCredentials.HelmRepositoryCredentials["User"]
+				helmRepository.pass = clusterCredentials.HelmRepositoryCredentials["Pass"]
+				repo.Username = helmRepoCreds.User
+				repo.Password = helmRepoCreds.Pass
 			}
-		} else {
-			c = "helm repo add " + stratio_helm_repo + " " + helmRepoCreds.URL
-			_, err = commons.ExecuteCommand(n, c, 5)
+			err = k.HelmRepoAdd(repo)
 			if err != nil {
 				return errors.Wrap(err, "failed to add helm repository: "+helmRepoCreds.URL)
 			}
@@ -404,9 +397,7 @@ func (p *Provider) deployClusterOperator(n nodes.Node, privateParams PrivatePara
 
 		if firstInstallation {
 			// Pull cluster-operator helm chart
-			c = "helm pull " + stratio_helm_repo + "/cluster-operator --version " + clusterOperatorChart +
-				" --untar --untardir /stratio/helm"
-			_, err = commons.ExecuteCommand(n, c, 5)
+			err = k.HelmPull(repo, stratio_helm_repo+"/cluster-operator", commons.GetBOM().ClusterOperator.Chart, "/stratio/helm")
 			if err != nil {
 				return errors.Wrap(err, "failed to pull cluster-operator helm chart")
 			}
@@ -419,51 +410,48 @@ func (p *Provider) deployClusterOperator(n nodes.Node, privateParams PrivatePara
 		if err != nil {
 			return errors.Wrap(err, "failed to marshal docker registries credentials")
 		}
-		c = "kubectl -n kube-system create secret generic keoscluster-registries --from-literal=credentials='" + string(jsonDockerRegistriesCredentials) + "'"
-		if kubeconfigPath != "" {
-			c = c + " --kubeconfig " + kubeconfigPath
-		}
-		_, err = commons.ExecuteCommand(n, c, 5)
+		err = k.CreateSecret("kube-system", kube.Secret{
+			Name: "keoscluster-registries",
+			Data: map[string]string{"credentials": string(jsonDockerRegistriesCredentials)},
+		})
 		if err != nil {
 			return errors.Wrap(err, "failed to create keoscluster-registries secret")
 		}
 	}
 
 	// Deploy cluster-operator chart
-	c = "helm install --wait cluster-operator /stratio/helm/cluster-operator" +
-		" --namespace kube-system" +
-		" --set provider=" + keosCluster.Spec.InfraProvider +
-		" --set app.containers.controllerManager.image.tag=" + clusterOperatorImage +
-		" --set app.containers.controllerManager.image.registry=" + keosRegistry.url +
-		" --set app.containers.controllerManager.image.repository=stratio/cluster-operator"
+	release := kube.HelmRelease{
+		Name:      "cluster-operator",
+		Chart:     "/stratio/helm/cluster-operator",
+		Namespace: "kube-system",
+		Values: map[string]string{
+			"provider": keosCluster.Spec.InfraProvider,
+			"app.containers.controllerManager.image.tag":        commons.GetBOM().ClusterOperator.Image,
+			"app.containers.controllerManager.image.registry":   keosRegistry.url,
+			"app.containers.controllerManager.image.repository": "stratio/cluster-operator",
+		},
+		Wait: true,
+	}
 	if privateParams.Private {
-		c += " --set app.containers.kubeRbacProxy.image=" + keosRegistry.url + "/stratio/kube-rbac-proxy:v0.13.1"
-	}
-	if keosCluster.Spec.InfraProvider == "azure" {
-		c += " --set secrets.azure.clientIDBase64=" + strings.Split(p.capxEnvVars[1], "AZURE_CLIENT_ID_B64=")[1] +
-			" --set secrets.azure.clientSecretBase64=" + strings.Split(p.capxEnvVars[0], "AZURE_CLIENT_SECRET_B64=")[1] +
-			" --set secrets.azure.subscriptionIDBase64=" + strings.Split(p.capxEnvVars[2], "AZURE_SUBSCRIPTION_ID_B64=")[1] +
-			" --set secrets.azure.tenantIDBase64=" + strings.Split(p.capxEnvVars[3], "AZURE_TENANT_ID_B64=")[1]
-	} else if keosCluster.Spec.InfraProvider == "gcp" {
-		c += " --set secrets.common.credentialsBase64=" + strings.Split(p.capxEnvVars[0], "GCP_B64ENCODED_CREDENTIALS=")[1]
-	} else if keosCluster.Spec.InfraProvider == "aws" {
-		c += " --set secrets.common.credentialsBase64=" + strings.Split(p.capxEnvVars[3], "AWS_B64ENCODED_CREDENTIALS=")[1]
+		release.Values["app.containers.kubeRbacProxy.image"] = keosRegistry.url + "/stratio/kube-rbac-proxy:v0.13.1"
+	}
+	for key, value := range p.clusterOperatorValues {
+		release.Values[key] = value
 	}
 	if kubeconfigPath == "" {
-		c += " --set app.containers.controllerManager.imagePullSecrets.enabled=true" +
-			" --set app.containers.controllerManager.imagePullSecrets.name=regcred"
+		release.Values["app.containers.controllerManager.imagePullSecrets.enabled"] = "true"
+		release.Values["app.containers.controllerManager.imagePullSecrets.name"] = "regcred"
 	} else {
-		c += " --set app.replicas=2" + " --kubeconfig " + kubeconfigPath
+		release.Values["app.replicas"] = "2"
 	}
 
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = k.HelmInstall(release)
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy cluster-operator chart")
 	}
 
 	// Wait for cluster-operator deployment
-	c = "kubectl -n kube-system rollout status deploy/keoscluster-controller-manager --timeout=3m"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = kube.NewClient(n, "").RolloutStatus("kube-system", "deploy", "keoscluster-controller-manager", 3*time.Minute)
 	if err != nil {
 		return errors.Wrap(err, "failed to wait for cluster-operator deployment")
 	}
@@ -475,14 +463,13 @@ func (p *Provider) deployClusterOperator(n nodes.Node, privateParams PrivatePara
 }
 
 func installCalico(n nodes.Node, k string, privateParams PrivateParams, allowCommonEgressNetPolPath string) error {
-	var c string
-	var cmd exec.Cmd
 	var err error
 	keosCluster := privateParams.KeosCluster
 
 	calicoTemplate := "/kind/calico-helm-values.yaml"
 
 	calicoHelmParams := calicoHelmParams{
+		BOM:        commons.GetBOM(),
 		Spec:       keosCluster.Spec,
 		KeosRegUrl: privateParams.KeosRegUrl,
 		Private:    privateParams.Private,
@@ -497,46 +484,43 @@ func installCalico(n nodes.Node, k string, privateParams PrivateParams, allowCom
 		return errors.Wrap(err, "failed to generate calico helm values")
 	}
 
-	c = "echo '" + calicoHelmValues + "' > " + calicoTemplate
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = writeFile(n, calicoTemplate, calicoHelmValues)
 	if err != nil {
 		return errors.Wrap(err, "failed to create Calico Helm chart values file")
 	}
 
-	c = "helm install calico /stratio/helm/tigera-operator" +
-		" --kubeconfig " + k +
-		" --namespace tigera-operator" +
-		" --create-namespace" +
-		" --values " + calicoTemplate
-	_, err = commons.ExecuteCommand(n, c, 5)
+	workload := kube.NewClient(n, k)
+	err = workload.HelmInstall(kube.HelmRelease{
+		Name:            "calico",
+		Chart:           "/stratio/helm/tigera-operator",
+		Namespace:       "tigera-operator",
+		CreateNamespace: true,
+		ValuesFiles:     []string{calicoTemplate},
+	})
 	if err != nil {
 		return errors.Wrap(err, "failed to deploy Calico Helm Chart")
 	}
 
 	// Allow egress in tigera-operator namespace
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n tigera-operator apply -f " + allowCommonEgressNetPolPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.ApplyFile("tigera-operator", allowCommonEgressNetPolPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to apply tigera-operator egress NetworkPolicy")
 	}
 
 	// Wait for calico-system namespace to be created
-	c = "timeout 300s bash -c 'until kubectl --kubeconfig " + kubeconfigPath + " get ns calico-system; do sleep 2s ; done'"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.Wait("", "ns", "calico-system", "", 300*time.Second)
 	if err != nil {
 		return errors.Wrap(err, "failed to wait for calico-system namespace")
 	}
 
 	// Allow egress in calico-system namespace
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n calico-system apply -f " + allowCommonEgressNetPolPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.ApplyFile("calico-system", allowCommonEgressNetPolPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to apply calico-system egress NetworkPolicy")
 	}
 
 	// Create calico metrics services
-	cmd = n.Command("kubectl", "--kubeconfig", k, "apply", "-f", "-")
-	if err = cmd.SetStdin(strings.NewReader(calicoMetrics)).Run(); err != nil {
+	if err = workload.Apply("", calicoMetrics); err != nil {
 		return errors.Wrap(err, "failed to create calico metrics services")
 	}
 
@@ -544,16 +528,14 @@ func installCalico(n nodes.Node, k string, privateParams PrivateParams, allowCom
 }
 
 func customCoreDNS(n nodes.Node, k string, keosCluster commons.KeosCluster) error {
-	var c string
 	var err error
 
 	coreDNSPatchFile := "coredns"
-	coreDNSTemplate := "/kind/coredns-configmap.yaml"
 	coreDNSSuffix := ""
 
-	if keosCluster.Spec.InfraProvider == "azure" && keosCluster.Spec.ControlPlane.Managed {
-		coreDNSPatchFile = "coredns-custom"
-		coreDNSSuffix = "-aks"
+	if p, _ := commons.GetInfraProvider(keosCluster.Spec.InfraProvider); keosCluster.Spec.ControlPlane.Managed {
+		coreDNSPatchFile = p.ManagedCoreDNSConfigMap
+		coreDNSSuffix = "-managed"
 	}
 
 	coreDNSConfigmap, err := getManifest(keosCluster.Spec.InfraProvider, "coredns_configmap"+coreDNSSuffix+".tmpl", keosCluster.Spec)
@@ -561,29 +543,22 @@ func customCoreDNS(n nodes.Node, k string, keosCluster commons.KeosCluster) erro
 		return errors.Wrap(err, "failed to get CoreDNS file")
 	}
 
-	c = "echo '" + coreDNSConfigmap + "' > " + coreDNSTemplate
-	_, err = commons.ExecuteCommand(n, c, 5)
-	if err != nil {
-		return errors.Wrap(err, "failed to create CoreDNS configmap file")
-	}
+	workload := kube.NewClient(n, kubeconfigPath)
 
 	// Patch configmap
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n kube-system patch cm " + coreDNSPatchFile + " --patch-file " + coreDNSTemplate
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.Patch("kube-system", "cm", coreDNSPatchFile, kube.PatchStrategic, coreDNSConfigmap)
 	if err != nil {
 		return errors.Wrap(err, "failed to customize coreDNS patching ConfigMap")
 	}
 
 	// Rollout restart to catch the made changes
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n kube-system rollout restart deploy coredns"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.RolloutRestart("kube-system", "deploy", "coredns")
 	if err != nil {
 		return errors.Wrap(err, "failed to redeploy coreDNS")
 	}
 
 	// Wait until CoreDNS completely rollout
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n kube-system rollout status deploy coredns --timeout=3m"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.RolloutStatus("kube-system", "deploy", "coredns", 3*time.Minute)
 	if err != nil {
 		return errors.Wrap(err, "failed to wait for the customatization of CoreDNS configmap")
 	}
@@ -596,35 +571,23 @@ func (p *Provider) installCAPXWorker(n nodes.Node, keosCluster commons.KeosClust
 	var c string
 	var err error
 
-	capxPDBPath := "/kind/capi_pdb.yaml"
+	workload := kube.NewClient(n, kubeconfigPath)
+	capxNamespace := p.capxName + "-system"
+	capxDeployment := p.capxName + "-controller-manager"
 
-	if p.capxProvider == "azure" {
-		// Create capx namespace
-		c = "kubectl --kubeconfig " + kubeconfigPath + " create namespace " + p.capxName + "-system"
-		_, err = commons.ExecuteCommand(n, c, 5)
+	if p.capxIdentitySecret != nil {
+		err = p.createIdentitySecret(workload)
 		if err != nil {
-			return errors.Wrap(err, "failed to create CAPx namespace")
-		}
-
-		// Create capx secret
-		namespace := p.capxName + "-system"
-		clientSecret, _ := base64.StdEncoding.DecodeString(strings.Split(p.capxEnvVars[0], "AZURE_CLIENT_SECRET_B64=")[1])
-
-		c := fmt.Sprintf(
-			"kubectl --kubeconfig %s -n %s create secret generic cluster-identity-secret "+
-				"--from-literal=clientSecret='%s'",
-			kubeconfigPath, namespace, clientSecret)
-		_, err = commons.ExecuteCommand(n, c, 5)
-		if err != nil {
-			return errors.Wrap(err, "failed to create CAPx secret")
+			return err
 		}
 	}
 
 	// Install CAPX in worker cluster
+	capiVersion := commons.GetBOM().ClusterAPI.Version
 	c = "clusterctl --kubeconfig " + kubeconfigPath + " init --wait-providers" +
-		" --core " + CAPICoreProvider + ":" + CAPIVersion +
-		" --bootstrap " + CAPIBootstrapProvider + ":" + CAPIVersion +
-		" --control-plane " + CAPIControlPlaneProvider + ":" + CAPIVersion +
+		" --core " + CAPICoreProvider + ":" + capiVersion +
+		" --bootstrap " + CAPIBootstrapProvider + ":" + capiVersion +
+		" --control-plane " + CAPIControlPlaneProvider + ":" + capiVersion +
 		" --infrastructure " + p.capxProvider + ":" + p.capxVersion
 	_, err = commons.ExecuteCommand(n, c, 5, p.capxEnvVars)
 	if err != nil {
@@ -632,27 +595,23 @@ func (p *Provider) installCAPXWorker(n nodes.Node, keosCluster commons.KeosClust
 	}
 
 	// Manually assign PriorityClass to capx service
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + p.capxName + "-system patch deploy " + p.capxName + "-controller-manager -p '{\"spec\": {\"template\": {\"spec\": {\"priorityClassName\": \"system-node-critical\"}}}}' --type=merge"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.Patch(capxNamespace, "deploy", capxDeployment, kube.PatchMerge, priorityClassPatch)
 	if err != nil {
-		return errors.Wrap(err, "failed to assigned priorityClass to "+p.capxName+"-controller-manager")
+		return errors.Wrap(err, "failed to assigned priorityClass to "+capxDeployment)
 	}
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + p.capxName + "-system rollout status deploy " + p.capxName + "-controller-manager --timeout 60s"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.RolloutStatus(capxNamespace, "deploy", capxDeployment, 60*time.Second)
 	if err != nil {
-		return errors.Wrap(err, "failed to check rollout status for "+p.capxName+"-controller-manager")
+		return errors.Wrap(err, "failed to check rollout status for "+capxDeployment)
 	}
 
 	// Scale CAPX to 2 replicas
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + p.capxName + "-system scale --replicas 2 deploy " + p.capxName + "-controller-manager"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.Scale(capxNamespace, "deploy", capxDeployment, 2)
 	if err != nil {
 		return errors.Wrap(err, "failed to scale CAPX in workload cluster")
 	}
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + p.capxName + "-system rollout status deploy " + p.capxName + "-controller-manager --timeout 60s"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.RolloutStatus(capxNamespace, "deploy", capxDeployment, 60*time.Second)
 	if err != nil {
-		return errors.Wrap(err, "failed to check rollout status for "+p.capxName+"-controller-manager")
+		return errors.Wrap(err, "failed to check rollout status for "+capxDeployment)
 	}
 
 	// Define PodDisruptionBudget for capx services
@@ -661,21 +620,13 @@ func (p *Provider) installCAPXWorker(n nodes.Node, keosCluster commons.KeosClust
 		return errors.Wrap(err, "failed to get PodDisruptionBudget file")
 	}
 
-	c = "echo '" + capxPDB + "' > " + capxPDBPath
-	_, err = commons.ExecuteCommand(n, c, 5)
-	if err != nil {
-		return errors.Wrap(err, "failed to create PodDisruptionBudget file")
-	}
-
-	c = "kubectl --kubeconfig " + kubeconfigPath + " apply -f " + capxPDBPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.Apply("", capxPDB)
 	if err != nil {
 		return errors.Wrap(err, "failed to apply "+p.capxName+" PodDisruptionBudget")
 	}
 
 	// Allow egress in CAPX's Namespace
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + p.capxName + "-system apply -f " + allowAllEgressNetPolPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.ApplyFile(capxNamespace, allowAllEgressNetPolPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to apply CAPX's NetworkPolicy in workload cluster")
 	}
@@ -685,10 +636,11 @@ func (p *Provider) installCAPXWorker(n nodes.Node, keosCluster commons.KeosClust
 }
 
 func (p *Provider) configCAPIWorker(n nodes.Node, keosCluster commons.KeosCluster, kubeconfigPath string, allowCommonEgressNetPolPath string) error {
-	var c string
 	var err error
 	var capiKubeadmReplicas int
 
+	workload := kube.NewClient(n, kubeconfigPath)
+
 	capiDeployments := []struct {
 		name      string
 		namespace string
@@ -699,7 +651,6 @@ func (p *Provider) configCAPIWorker(n nodes.Node, keosCluster commons.KeosCluste
 	}
 
 	allowedNamePattern := regexp.MustCompile(`^capi-kubeadm-(control-plane|bootstrap)-controller-manager$`)
-	capiPDBPath := "/kind/capi_pdb.yaml"
 
 	// Determine the number of replicas for capi-kubeadm deployments
 	if p.capxManaged {
@@ -711,36 +662,31 @@ func (p *Provider) configCAPIWorker(n nodes.Node, keosCluster commons.KeosCluste
 	// Manually assign PriorityClass to capi services
 	for _, deployment := range capiDeployments {
 		if !p.capxManaged || (p.capxManaged && !allowedNamePattern.MatchString(deployment.name)) {
-			c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + deployment.namespace + " patch deploy " + deployment.name + " -p '{\"spec\": {\"template\": {\"spec\": {\"priorityClassName\": \"system-node-critical\"}}}}' --type=merge"
-			_, err = commons.ExecuteCommand(n, c, 5)
+			err = workload.Patch(deployment.namespace, "deploy", deployment.name, kube.PatchMerge, priorityClassPatch)
 			if err != nil {
 				return errors.Wrap(err, "failed to assigned priorityClass to "+deployment.name)
 			}
 		}
 	}
 
-	// Manually assign PriorityClass to nmi
-	if p.capxProvider == "azure" {
-		c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + p.capxName + "-system patch ds capz-nmi -p '{\"spec\": {\"template\": {\"spec\": {\"priorityClassName\": \"system-node-critical\"}}}}' --type=merge"
-		_, err = commons.ExecuteCommand(n, c, 5)
+	// Manually assign PriorityClass to the capx daemon sets
+	for _, daemonSet := range p.capxDaemonSets {
+		err = workload.Patch(p.capxName+"-system", "ds", daemonSet, kube.PatchMerge, priorityClassPatch)
 		if err != nil {
-			return errors.Wrap(err, "failed to assigned priorityClass to nmi")
+			return errors.Wrap(err, "failed to assigned priorityClass to "+daemonSet)
 		}
-		c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + p.capxName + "-system rollout status ds capz-nmi --timeout 60s"
-		_, err = commons.ExecuteCommand(n, c, 5)
+		err = workload.RolloutStatus(p.capxName+"-system", "ds", daemonSet, 60*time.Second)
 		if err != nil {
-			return errors.Wrap(err, "failed to check rollout status for nmi")
+			return errors.Wrap(err, "failed to check rollout status for "+daemonSet)
 		}
 	}
 
 	// Scale number of replicas to 2 for capi service
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n capi-system scale deploy capi-controller-manager --replicas 2"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.Scale("capi-system", "deploy", "capi-controller-manager", 2)
 	if err != nil {
 		return errors.Wrap(err, "failed to scale the CAPI Deployment")
 	}
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n capi-system rollout status deploy capi-controller-manager --timeout 60s"
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.RolloutStatus("capi-system", "deploy", "capi-controller-manager", 60*time.Second)
 	if err != nil {
 		return errors.Wrap(err, "failed to check rollout status for capi-controller-manager")
 	}
@@ -748,13 +694,11 @@ func (p *Provider) configCAPIWorker(n nodes.Node, keosCluster commons.KeosCluste
 	// Scale number of required replicas for capi kubeadm services
 	for _, deployment := range capiDeployments {
 		if deployment.name != "capi-controller-manager" {
-			c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + deployment.namespace + " scale --replicas " + strconv.Itoa(capiKubeadmReplicas) + " deploy " + deployment.name
-			_, err = commons.ExecuteCommand(n, c, 5)
+			err = workload.Scale(deployment.namespace, "deploy", deployment.name, capiKubeadmReplicas)
 			if err != nil {
 				return errors.Wrap(err, "failed to scale the "+deployment.name+" deployment")
 			}
-			c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + deployment.namespace + " rollout status deploy " + deployment.name + " --timeout 60s"
-			_, err = commons.ExecuteCommand(n, c, 5)
+			err = workload.RolloutStatus(deployment.namespace, "deploy", deployment.name, 60*time.Second)
 			if err != nil {
 				return errors.Wrap(err, "failed to check rollout status for "+deployment.name)
 			}
@@ -766,14 +710,7 @@ func (p *Provider) configCAPIWorker(n nodes.Node, keosCluster commons.KeosCluste
 	if err != nil {
 		return errors.Wrap(err, "failed to get PodDisruptionBudget file")
 	}
-	c = "echo '" + capiPDB + "' > " + capiPDBPath
-	_, err = commons.ExecuteCommand(n, c, 5)
-	if err != nil {
-		return errors.Wrap(err, "failed to create PodDisruptionBudget file")
-	}
-
-	c = "kubectl --kubeconfig " + kubeconfigPath + " apply -f " + capiPDBPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.Apply("", capiPDB)
 	if err != nil {
 		return errors.Wrap(err, "failed to apply "+p.capxName+" PodDisruptionBudget")
 	}
@@ -781,8 +718,7 @@ func (p *Provider) configCAPIWorker(n nodes.Node, keosCluster commons.KeosCluste
 	// Allow egress in CAPI's Namespaces
 	for _, deployment := range capiDeployments {
 		if !p.capxManaged || (p.capxManaged && !allowedNamePattern.MatchString(deployment.name)) {
-			c = "kubectl --kubeconfig " + kubeconfigPath + " -n " + deployment.namespace + " apply -f " + allowCommonEgressNetPolPath
-			_, err = commons.ExecuteCommand(n, c, 5)
+			err = workload.ApplyFile(deployment.namespace, allowCommonEgressNetPolPath)
 			if err != nil {
 				return errors.Wrap(err, "failed to apply CAPI's egress NetworkPolicy in namespace "+deployment.namespace)
 			}
@@ -790,8 +726,7 @@ func (p *Provider) configCAPIWorker(n nodes.Node, keosCluster commons.KeosCluste
 	}
 
 	// Allow egress in cert-manager Namespace
-	c = "kubectl --kubeconfig " + kubeconfigPath + " -n cert-manager apply -f " + allowCommonEgressNetPolPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = workload.ApplyFile("cert-manager", allowCommonEgressNetPolPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to apply cert-manager's NetworkPolicy")
 	}
@@ -799,37 +734,45 @@ func (p *Provider) configCAPIWorker(n nodes.Node, keosCluster commons.KeosCluste
 	return nil
 }
 
+// createIdentitySecret creates the CAPx namespace with the secret of the
+// cluster identity
+func (p *Provider) createIdentitySecret(k kube.Client) error {
+	namespace := p.capxName + "-system"
+
+	// Create capx namespace
+	err := k.CreateNamespace(namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to create CAPx namespace")
+	}
+
+	// Create capx secret
+	err = k.CreateSecret(namespace, kube.Secret{
+		Name: "cluster-identity-secret",
+		Data: p.capxIdentitySecret,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create CAPx secret")
+	}
+	return nil
+}
+
 // installCAPXLocal installs CAPX in the local cluster
 func (p *Provider) installCAPXLocal(n nodes.Node) error {
 	var c string
 	var err error
 
-	if p.capxProvider == "azure" {
-		// Create capx namespace
-		c = "kubectl create namespace " + p.capxName + "-system"
-		_, err = commons.ExecuteCommand(n, c, 5)
-		if err != nil {
-			return errors.Wrap(err, "failed to create CAPx namespace")
-		}
-
-		// Create capx secret
-		namespace := p.capxName + "-system"
-		clientSecret, _ := base64.StdEncoding.DecodeString(strings.Split(p.capxEnvVars[0], "AZURE_CLIENT_SECRET_B64=")[1])
-
-		c := fmt.Sprintf(
-			"kubectl -n %s create secret generic cluster-identity-secret "+
-				"--from-literal=clientSecret='%s' ",
-			namespace, clientSecret)
-		_, err = commons.ExecuteCommand(n, c, 5)
+	if p.capxIdentitySecret != nil {
+		err = p.createIdentitySecret(kube.NewClient(n, ""))
 		if err != nil {
-			return errors.Wrap(err, "failed to create CAPx secret")
+			return err
 		}
 	}
 
+	capiVersion := commons.GetBOM().ClusterAPI.Version
 	c = "clusterctl init --wait-providers" +
-		" --core " + CAPICoreProvider + ":" + CAPIVersion +
-		" --bootstrap " + CAPIBootstrapProvider + ":" + CAPIVersion +
-		" --control-plane " + CAPIControlPlaneProvider + ":" + CAPIVersion +
+		" --core " + CAPICoreProvider + ":" + capiVersion +
+		" --bootstrap " + CAPIBootstrapProvider + ":" + capiVersion +
+		" --control-plane " + CAPIControlPlaneProvider + ":" + capiVersion +
 		" --infrastructure " + p.capxProvider + ":" + p.capxVersion
 	_, err = commons.ExecuteCommand(n, c, 5, p.capxEnvVars)
 	if err != nil {
@@ -839,16 +782,44 @@ func (p *Provider) installCAPXLocal(n nodes.Node) error {
 	return nil
 }
 
+// trustCABundle makes the CAPx controller of the cluster of k trust the CA
+// bundle, mounting it among the system certificates, which Go reads all
+func (p *Provider) trustCABundle(k kube.Client) error {
+	capxNamespace := p.capxName + "-system"
+	capxDeployment := p.capxName + "-controller-manager"
+
+	configMap, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]string{"name": caBundleName},
+		"data":       map[string]string{"ca.crt": commons.GetCABundle()},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the CA bundle ConfigMap")
+	}
+	err = k.Apply(capxNamespace, string(configMap))
+	if err != nil {
+		return errors.Wrap(err, "failed to create the CA bundle ConfigMap")
+	}
+	patch := `{"spec":{"template":{"spec":{` +
+		`"volumes":[{"name":"` + caBundleName + `","configMap":{"name":"` + caBundleName + `"}}],` +
+		`"containers":[{"name":"manager","volumeMounts":[{"name":"` + caBundleName + `","mountPath":"/etc/ssl/certs/` + caBundleName + `.crt","subPath":"ca.crt","readOnly":true}]}]}}}}`
+	err = k.Patch(capxNamespace, "deploy", capxDeployment, kube.PatchStrategic, patch)
+	if err != nil {
+		return errors.Wrap(err, "failed to mount the CA bundle in "+capxDeployment)
+	}
+	return k.RolloutStatus(capxNamespace, "deploy", capxDeployment, 5*time.Minute)
+}
+
 func enableSelfHealing(n nodes.Node, keosCluster commons.KeosCluster, namespace string) error {
-	var c string
 	var err error
+	k := kube.NewClient(n, "")
 
 	if !keosCluster.Spec.ControlPlane.Managed {
 		machineRole := "-control-plane-node"
 		generateMHCManifest(n, keosCluster.Metadata.Name, namespace, machineHealthCheckControlPlaneNodePath, machineRole)
 
-		c = "kubectl -n " + namespace + " apply -f " + machineHealthCheckControlPlaneNodePath
-		_, err = commons.ExecuteCommand(n, c, 5)
+		err = k.ApplyFile(namespace, machineHealthCheckControlPlaneNodePath)
 		if err != nil {
 			return errors.Wrap(err, "failed to apply the MachineHealthCheck manifest")
 		}
@@ -857,8 +828,7 @@ func enableSelfHealing(n nodes.Node, keosCluster commons.KeosCluster, namespace
 	machineRole := "-worker-node"
 	generateMHCManifest(n, keosCluster.Metadata.Name, namespace, machineHealthCheckWorkerNodePath, machineRole)
 
-	c = "kubectl -n " + namespace + " apply -f " + machineHealthCheckWorkerNodePath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = k.ApplyFile(namespace, machineHealthCheckWorkerNodePath)
 	if err != nil {
 		return errors.Wrap(err, "failed to apply the MachineHealthCheck manifest")
 	}
@@ -867,7 +837,6 @@ func enableSelfHealing(n nodes.Node, keosCluster commons.KeosCluster, namespace
 }
 
 func generateMHCManifest(n nodes.Node, clusterID string, namespace string, manifestPath string, machineRole string) error {
-	var c string
 	var err error
 	var maxUnhealthy = "100%"
 
@@ -895,8 +864,7 @@ spec:
       status: 'False'
       timeout: 180s`
 
-	c = "echo \"" + machineHealthCheck + "\" > " + manifestPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = writeFile(n, manifestPath, machineHealthCheck)
 	if err != nil {
 		return errors.Wrap(err, "failed to write the MachineHealthCheck manifest")
 	}
@@ -920,9 +888,13 @@ func getManifest(parentPath string, name string, params interface{}) (string, er
 	return tpl.String(), nil
 }
 
+// writeFile writes content to the file path in the node
+func writeFile(n nodes.Node, path string, content string) error {
+	return n.Command("tee", path).SetStdin(strings.NewReader(content)).SetStdout(io.Discard).Run()
+}
+
 func patchDeploy(n nodes.Node, k string, ns string, deployName string, patch string) error {
-	c := "kubectl --kubeconfig " + k + " patch deploy -n " + ns + " " + deployName + " -p '" + patch + "'"
-	_, err := commons.ExecuteCommand(n, c, 5)
+	err := kube.NewClient(n, k).Patch(ns, "deploy", deployName, kube.PatchStrategic, patch)
 	if err != nil {
 		return err
 	}
@@ -930,9 +902,7 @@ func patchDeploy(n nodes.Node, k string, ns string, deployName string, patch str
 }
 
 func rolloutStatus(n nodes.Node, k string, ns string, deployName string) error {
-	c := "kubectl --kubeconfig " + k + " rollout status deploy -n " + ns + " " + deployName + " --timeout=5m"
-	_, err := commons.ExecuteCommand(n, c, 5)
-	return err
+	return kube.NewClient(n, k).RolloutStatus(ns, "deploy", deployName, 5*time.Minute)
 }
 
 func installCorednsPdb(n nodes.Node, k string) error {
@@ -944,16 +914,46 @@ func installCorednsPdb(n nodes.Node, k string) error {
 		return err
 	}
 
-	c := "echo \"" + corednsPDB + "\" > " + corednsPdbPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = writeFile(n, corednsPdbPath, corednsPDB)
 	if err != nil {
 		return errors.Wrap(err, "failed to create coredns PodDisruptionBudget file")
 	}
 
-	c = "kubectl --kubeconfig " + kubeconfigPath + " apply -f " + corednsPdbPath
-	_, err = commons.ExecuteCommand(n, c, 5)
+	err = kube.NewClient(n, k).ApplyFile("", corednsPdbPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to apply coredns PodDisruptionBudget")
 	}
 	return nil
 }
+
+// ensureCorednsPdb installs the coredns PodDisruptionBudget in the cluster of
+// k unless the PodDisruptionBudget name already exists
+func ensureCorednsPdb(n nodes.Node, k string, name string) error {
+	exists, err := kube.NewClient(n, k).Exists("kube-system", "pdb", name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get coredns PodDisruptionBudget")
+	}
+	if exists {
+		return nil
+	}
+	return installCorednsPdb(n, k)
+}
+
+// unsetDefaultStorageClass removes the default annotation from the default
+// storage classes of the cluster of k
+func unsetDefaultStorageClass(k kube.Client) error {
+	output, err := k.Get("", "sc", "", `jsonpath={.items[?(@.metadata.annotations.storageclass\.kubernetes\.io/is-default-class=="true")].metadata.name}`)
+	if err != nil {
+		return errors.Wrap(err, "failed to get default storage class")
+	}
+	if strings.HasPrefix(strings.TrimSpace(output), "No resources found") {
+		return nil
+	}
+	patch := `{"metadata":{"annotations":{"` + defaultScAnnotation + `":null}}}`
+	for _, name := range strings.Fields(output) {
+		if err = k.Patch("", "sc", name, kube.PatchMerge, patch); err != nil {
+			return errors.Wrap(err, "failed to remove annotation from default storage class")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestBuildersRegistered(t *testing.T) {
+	t.Parallel()
+	for _, name := range commons.InfraProviderNames() {
+		b, err := getBuilder(name)
+		assert.ExpectError(t, false, err)
+		if b == nil {
+			t.Errorf("the builder of infra provider %s is nil", name)
+		}
+	}
+	_, err := getBuilder("openstack")
+	assert.ExpectError(t, true, err)
+}
+
+func TestSetKEOSDescriptor(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name     string
+		Managed  bool
+		Expected func(d *KEOSDescriptor)
+	}{
+		{
+			Name:     "aws",
+			Managed:  true,
+			Expected: func(d *KEOSDescriptor) { d.AWS.Enabled, d.AWS.EKS = true, true },
+		},
+		{
+			Name:    "azure",
+			Managed: true,
+			Expected: func(d *KEOSDescriptor) {
+				d.Azure.Enabled, d.Azure.AKS, d.Azure.ResourceGroup = true, true, "test"
+			},
+		},
+		{
+			Name:     "gcp",
+			Expected: func(d *KEOSDescriptor) { d.GCP.Enabled = true },
+		},
+		{
+			Name:     "docker",
+			Expected: func(d *KEOSDescriptor) {},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			b, err := getBuilder(tc.Name)
+			assert.ExpectError(t, false, err)
+			a := newTestAction(tc.Name, tc.Managed)
+			var d, expected KEOSDescriptor
+			newInfra(b).setKEOSDescriptor(&d, a.keosCluster)
+			tc.Expected(&expected)
+			assert.DeepEqual(t, expected, d)
+		})
+	}
+}
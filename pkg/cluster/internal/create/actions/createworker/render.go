@@ -0,0 +1,232 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alessio/shellescape"
+
+	"sigs.k8s.io/kind/pkg/cluster/constants"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+	"sigs.k8s.io/kind/pkg/internal/redact"
+)
+
+const (
+	renderCommandsFile = "commands.sh"
+	renderStdinDir     = "stdin"
+)
+
+// renderNode is a node recording the commands run against it in a local
+// directory instead of running them, so the manifests and the helm and
+// clusterctl invocations of a creation can be reviewed without a cluster.
+// It behaves as an empty cluster: existence checks (kubectl get without an
+// output format) fail and any other command succeeds without output
+type renderNode struct {
+	dir   string
+	count int
+}
+
+var _ nodes.Node = &renderNode{}
+
+// newRenderNode returns a renderNode writing into dir, which is created if
+// it does not exist and must be empty otherwise
+func newRenderNode(dir string) (*renderNode, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to read the render directory")
+	}
+	if len(entries) > 0 {
+		return nil, errors.Errorf("the render directory %q is not empty", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create the render directory")
+	}
+	r := &renderNode{dir: dir}
+	return r, r.write(renderCommandsFile, []byte("#!/bin/sh\n"), false)
+}
+
+func (r *renderNode) String() string {
+	return "render"
+}
+
+func (r *renderNode) Role() (string, error) {
+	return constants.ControlPlaneNodeRoleValue, nil
+}
+
+func (r *renderNode) IP() (string, string, error) {
+	return "", "", nil
+}
+
+func (r *renderNode) SerialLogs(writer io.Writer) error {
+	return nil
+}
+
+func (r *renderNode) Command(name string, args ...string) exec.Cmd {
+	return &renderCmd{node: r, name: name, args: args}
+}
+
+func (r *renderNode) CommandContext(ctx context.Context, name string, args ...string) exec.Cmd {
+	return r.Command(name, args...)
+}
+
+// section starts the commands of the phase name
+func (r *renderNode) section(name string) error {
+	return r.write(renderCommandsFile, []byte("\n# phase: "+name+"\n"), true)
+}
+
+// record writes the command line of cmd, and its input if any
+func (r *renderNode) record(cmd *renderCmd) error {
+	r.count++
+	line := cmd.line()
+	if cmd.stdin != nil {
+		raw, err := io.ReadAll(cmd.stdin)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the input of "+cmd.name)
+		}
+		// the files written in the node keep their path, any other input is
+		// numbered after the command
+		file := filepath.Join(renderStdinDir, fmt.Sprintf("%03d-%s.yaml", r.count, cmd.name))
+		if target, ok := cmd.writtenFile(); ok {
+			file = strings.TrimPrefix(filepath.Clean(target), "/")
+		}
+		if err := r.write(file, raw, false); err != nil {
+			return err
+		}
+		line += " < " + file
+	}
+	return r.write(renderCommandsFile, []byte(line+"\n"), true)
+}
+
+// write writes (or appends) the redacted data to the file name of the render
+// directory
+func (r *renderNode) write(name string, data []byte, appendData bool) error {
+	path := filepath.Join(r.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create the directory of "+name)
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendData {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open "+name)
+	}
+	defer f.Close()
+	if _, err := f.Write(redact.Bytes(data)); err != nil {
+		return errors.Wrap(err, "failed to write "+name)
+	}
+	return nil
+}
+
+// renderCmd implements exec.Cmd for renderNode
+type renderCmd struct {
+	node  *renderNode
+	name  string
+	args  []string
+	env   []string
+	stdin io.Reader
+}
+
+var _ exec.Cmd = &renderCmd{}
+
+func (c *renderCmd) Run() error {
+	if err := c.node.record(c); err != nil {
+		return err
+	}
+	if c.existenceCheck() {
+		return &exec.RunError{
+			Command: append([]string{c.name}, c.args...),
+			Output:  []byte("not found (render only)"),
+			Inner:   errors.New("exit status 1"),
+		}
+	}
+	return nil
+}
+
+func (c *renderCmd) SetEnv(env ...string) exec.Cmd {
+	c.env = env
+	return c
+}
+
+func (c *renderCmd) SetStdin(r io.Reader) exec.Cmd {
+	c.stdin = r
+	return c
+}
+
+func (c *renderCmd) SetStdout(w io.Writer) exec.Cmd {
+	return c
+}
+
+func (c *renderCmd) SetStderr(w io.Writer) exec.Cmd {
+	return c
+}
+
+// line returns the command line, with the environment as variable
+// assignments. Shell scripts are written as they are
+func (c *renderCmd) line() string {
+	var line bytes.Buffer
+	for _, env := range c.env {
+		name, value, _ := strings.Cut(env, "=")
+		line.WriteString(name + "=" + shellescape.Quote(value) + " ")
+	}
+	if c.name == "sh" && len(c.args) == 2 && c.args[0] == "-c" {
+		line.WriteString(c.args[1])
+	} else {
+		line.WriteString(exec.PrettyCommand(c.name, c.args...))
+	}
+	return line.String()
+}
+
+// writtenFile returns the path of the node file written by the command, if
+// it is a `tee <path>`
+func (c *renderCmd) writtenFile() (string, bool) {
+	if c.name == "tee" && len(c.args) == 1 {
+		return c.args[0], true
+	}
+	return "", false
+}
+
+// existenceCheck returns true if the command is a kubectl get without an
+// output format, which is used to check whether an object exists
+func (c *renderCmd) existenceCheck() bool {
+	fields := append([]string{c.name}, c.args...)
+	if c.name == "sh" && len(c.args) == 2 {
+		fields = strings.Fields(c.args[1])
+	}
+	kubectl, get := false, false
+	for _, field := range fields {
+		switch {
+		case field == "kubectl":
+			kubectl = true
+		case field == "get" && kubectl:
+			get = true
+		case field == "-o" || strings.HasPrefix(field, "-o=") || strings.HasPrefix(field, "--output"):
+			return false
+		}
+	}
+	return get
+}
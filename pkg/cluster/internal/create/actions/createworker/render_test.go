@@ -0,0 +1,123 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestRenderNode(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "render")
+	n, err := newRenderNode(dir)
+	assert.ExpectError(t, false, err)
+
+	assert.ExpectError(t, false, n.section("storageclass"))
+	assert.ExpectError(t, false, writeFile(n, "/kind/manifests/keoscluster.yaml", "kind: KeosCluster\n"))
+	assert.ExpectError(t, false, kube.NewClient(n, kubeconfigPath).Apply("", "kind: StorageClass\n"))
+	_, err = commons.ExecuteCommand(n, "clusterctl init --infrastructure aws", 5, []string{"AWS_REGION=eu west"})
+	assert.ExpectError(t, false, err)
+	_, err = commons.ExecuteCommand(n, "kubectl get pdb coredns -n kube-system", 5)
+	assert.ExpectError(t, true, err)
+	_, err = commons.ExecuteCommand(n, "kubectl get sc -o name", 5)
+	assert.ExpectError(t, false, err)
+
+	expected := `#!/bin/sh
+
+# phase: storageclass
+tee /kind/manifests/keoscluster.yaml < kind/manifests/keoscluster.yaml
+kubectl --kubeconfig /kind/worker-cluster.kubeconfig apply -f - < stdin/002-kubectl.yaml
+AWS_REGION='eu west' clusterctl init --infrastructure aws
+kubectl get pdb coredns -n kube-system
+kubectl get sc -o name
+`
+	assertRendered(t, dir, renderCommandsFile, expected)
+	assertRendered(t, dir, "kind/manifests/keoscluster.yaml", "kind: KeosCluster\n")
+	assertRendered(t, dir, "stdin/002-kubectl.yaml", "kind: StorageClass\n")
+}
+
+func TestRenderNodeNotEmptyDir(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "previous"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := newRenderNode(dir)
+	assert.ExpectError(t, true, err)
+}
+
+func TestRenderPhases(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "render")
+	n, err := newRenderNode(dir)
+	assert.ExpectError(t, false, err)
+
+	a := newTestAction("aws", false)
+	a.phaseOptions.RenderDir = dir
+	builder, err := getBuilder("aws")
+	assert.ExpectError(t, false, err)
+	p := &phaseContext{
+		action:                a,
+		n:                     n,
+		kube:                  kube.NewClient(n, ""),
+		workload:              kube.NewClient(n, kubeconfigPath),
+		infra:                 newInfra(builder),
+		capiClustersNamespace: "cluster-test",
+	}
+	assert.ExpectError(t, false, enableWorkloadSelfHealing(p))
+	assert.ExpectError(t, false, postInstall(p))
+
+	for _, file := range []string{machineHealthCheckControlPlaneNodePath, machineHealthCheckWorkerNodePath, corednsPdbPath} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("expected %s to be rendered: %v", file, err)
+		}
+	}
+}
+
+func TestRenderDockerStorage(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "render")
+	n, err := newRenderNode(dir)
+	assert.ExpectError(t, false, err)
+
+	b := newDockerBuilder()
+	assert.ExpectError(t, false, b.installCSI(n, kubeconfigPath, PrivateParams{}))
+	assert.ExpectError(t, false, b.postInstallPhase(n, kubeconfigPath))
+
+	expected := `#!/bin/sh
+kubectl --kubeconfig /kind/worker-cluster.kubeconfig apply -f /kind/manifests/default-storage.yaml
+kubectl --kubeconfig /kind/worker-cluster.kubeconfig --namespace kube-system get pdb coredns --ignore-not-found -o name
+tee /kind/coredns_pdb.yaml < kind/coredns_pdb.yaml
+kubectl --kubeconfig /kind/worker-cluster.kubeconfig apply -f /kind/coredns_pdb.yaml
+`
+	assertRendered(t, dir, renderCommandsFile, expected)
+}
+
+func assertRendered(t *testing.T, dir string, name string, expected string) {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	assert.StringEqual(t, expected, string(raw))
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+const targetKubeconfigPath = "/kind/target.kubeconfig"
+
+var withTargetKubeconfig = condition{"target-kubeconfig", func(a *action) bool {
+	return a.targetKubeconfig != ""
+}}
+
+// restorePhases returns the phases of the management restoration, in order
+func restorePhases() []phase {
+	return []phase{
+		{"ca-bundle", "Installing the CA bundle 🔏", []condition{withCABundle}, installCABundle},
+		{"private-cni", "Installing Private CNI 🎖️", []condition{isPrivate}, installPrivateCNI},
+		{"delete-local-storage", "Deleting local storage plugin 🎖️", []condition{isPrivate}, deleteLocalStorage},
+		{"capx-local", "Installing CAPx 🎖️", nil, installCAPxLocal},
+		{"restore-objects", "Restoring cloud-provisioner Objects backup 🗄️", nil, restoreObjects},
+		{"kubeconfig", "Saving the workload cluster kubeconfig 📝", nil, restoreKubeconfig},
+		{"pivot", "Moving the management role to the target cluster 🗝️", []condition{withTargetKubeconfig}, pivotToTarget},
+	}
+}
+
+func restoreObjects(p *phaseContext) error {
+	objectsPath := filepath.Join(p.backupPath, filepath.Base(cloudProviderBackupPath))
+	if _, err := os.Stat(objectsPath); err != nil {
+		return errors.Wrap(err, "failed to find the cloud-provisioner Objects backup")
+	}
+
+	c := "mkdir -p " + filepath.Dir(cloudProviderBackupPath)
+	_, err := commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to create cloud-provisioner backup directory")
+	}
+
+	// the backup is streamed through the node so this works with any node provider
+	archive := bytes.Buffer{}
+	if err := tarDir(&archive, objectsPath, filepath.Base(cloudProviderBackupPath)); err != nil {
+		return errors.Wrap(err, "failed to archive "+objectsPath)
+	}
+	cmd := p.n.Command("tar", "-C", filepath.Dir(cloudProviderBackupPath), "-xf", "-")
+	if err := cmd.SetStdin(&archive).Run(); err != nil {
+		return errors.Wrap(err, "failed to copy "+objectsPath+" to the local cluster")
+	}
+
+	// Create namespace for CAPI clusters (it must exists)
+	if err := p.kube.CreateNamespace(p.capiClustersNamespace); err != nil {
+		return errors.Wrap(err, "failed to create cluster's Namespace")
+	}
+
+	c = "clusterctl move -n " + p.capiClustersNamespace + " --from-directory " + cloudProviderBackupPath
+	_, err = commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to restore cloud-provisioner Objects")
+	}
+	return nil
+}
+
+func restoreKubeconfig(p *phaseContext) error {
+	// Get the workload cluster kubeconfig
+	c := "clusterctl -n " + p.capiClustersNamespace + " get kubeconfig " + p.keosCluster.Metadata.Name + " | tee " + kubeconfigPath
+	kubeconfig, err := commons.ExecuteCommand(p.n, c, 5)
+	if err != nil || kubeconfig == "" {
+		return errors.Wrap(err, "failed to get workload cluster kubeconfig")
+	}
+
+	workKubeconfigBasePath := strings.Split(workKubeconfigPath, "/")[0]
+	if err = os.MkdirAll(workKubeconfigBasePath, os.ModePerm); err != nil {
+		return err
+	}
+	err = os.WriteFile(workKubeconfigPath, []byte(kubeconfig), 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the workload cluster kubeconfig")
+	}
+	return nil
+}
+
+func pivotToTarget(p *phaseContext) error {
+	kubeconfig, err := os.ReadFile(p.targetKubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the target kubeconfig")
+	}
+
+	cmd := p.n.Command("sh", "-c", "cat > "+targetKubeconfigPath)
+	if err = cmd.SetStdin(strings.NewReader(string(kubeconfig))).Run(); err != nil {
+		return errors.Wrap(err, "failed to write the target kubeconfig")
+	}
+
+	// Create namespace, if not exists, for CAPI clusters in target cluster
+	target := kube.NewClient(p.n, targetKubeconfigPath)
+	if err = target.CreateNamespace(p.capiClustersNamespace); err != nil {
+		return errors.Wrap(err, "failed to create cluster's Namespace in target cluster")
+	}
+
+	// Pivot management role to target cluster
+	c := "clusterctl move -n " + p.capiClustersNamespace + " --to-kubeconfig " + targetKubeconfigPath
+	_, err = commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to pivot management role to target cluster")
+	}
+	return nil
+}
+
+// tarDir writes the regular files and directories under dir to w as a tar
+// archive, with their names rooted at prefix.
+func tarDir(w io.Writer, dir, prefix string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
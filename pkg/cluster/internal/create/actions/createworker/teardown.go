@@ -0,0 +1,166 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package createworker
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+const (
+	localKubeconfigPath    = "/etc/kubernetes/admin.conf"
+	workloadDeletedTimeout = 60 * time.Minute
+)
+
+var (
+	withNewLocalCluster = condition{"new-local-cluster", func(a *action) bool {
+		return a.localProvisioned
+	}}
+	withDeleteIAM = condition{"delete-iam", func(a *action) bool {
+		return a.deleteIAM
+	}}
+)
+
+// deletePhases returns the phases of the workload cluster deletion, in order
+func deletePhases() []phase {
+	return []phase{
+		{"ca-bundle", "Installing the CA bundle 🔏", []condition{withNewLocalCluster, withCABundle}, installCABundle},
+		{"private-cni", "Installing Private CNI 🎖️", []condition{withNewLocalCluster, isPrivate}, installPrivateCNI},
+		{"delete-local-storage", "Deleting local storage plugin 🎖️", []condition{withNewLocalCluster, isPrivate}, deleteLocalStorage},
+		{"capx-local", "Installing CAPx 🎖️", []condition{withNewLocalCluster}, installCAPxLocal},
+		{"workload-kubeconfig", "Loading the workload cluster kubeconfig 📝", []condition{withNewLocalCluster}, loadKubeconfig},
+		{"move-management-back", "Moving the management role back to the local cluster 🗝️", nil, moveManagementBack},
+		{"delete-workload-cluster", "Deleting the workload cluster 💥", nil, deleteWorkloadCluster},
+		{"delete-iam", "[CAPA] Deleting IAM security 👮", []condition{withProviderIAM, withDeleteIAM}, deleteIAM},
+	}
+}
+
+func loadKubeconfig(p *phaseContext) error {
+	kubeconfig, err := os.ReadFile(workKubeconfigPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the workload cluster kubeconfig")
+	}
+
+	cmd := p.n.Command("sh", "-c", "cat > "+kubeconfigPath)
+	if err = cmd.SetStdin(strings.NewReader(string(kubeconfig))).Run(); err != nil {
+		return errors.Wrap(err, "failed to write the workload cluster kubeconfig")
+	}
+	return nil
+}
+
+func moveManagementBack(p *phaseContext) error {
+	// Nothing to move if the local cluster still manages the workload cluster (e.g. --keep-mgmt)
+	managed, err := p.kube.Exists(p.capiClustersNamespace, "cluster", p.keosCluster.Metadata.Name)
+	if err == nil && managed {
+		return nil
+	}
+
+	// Create namespace, if not exists, for CAPI clusters in local cluster
+	err = p.kube.CreateNamespace(p.capiClustersNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to create cluster's Namespace")
+	}
+
+	// Stop the cluster operator so it does not reconcile the objects being moved
+	err = p.workload.HelmUninstall("kube-system", "cluster-operator")
+	if err != nil {
+		return errors.Wrap(err, "failed to uninstall cluster-operator in workload cluster")
+	}
+
+	err = removeKeosObjects(p, p.workload)
+	if err != nil {
+		return err
+	}
+
+	// Pivot management role back to the local cluster
+	c := "clusterctl move -n " + p.capiClustersNamespace + " --kubeconfig " + kubeconfigPath + " --to-kubeconfig " + localKubeconfigPath
+	_, err = commons.ExecuteCommand(p.n, c, 5)
+	if err != nil {
+		return errors.Wrap(err, "failed to pivot management role to local cluster")
+	}
+	return nil
+}
+
+func deleteWorkloadCluster(p *phaseContext) error {
+	// The keoscluster must not recreate the cluster while it is being deleted
+	err := removeKeosObjects(p, p.kube)
+	if err != nil {
+		return err
+	}
+
+	err = p.kube.DeleteAsync(p.capiClustersNamespace, "cluster", p.keosCluster.Metadata.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the workload cluster")
+	}
+
+	// Wait for the cloud resources to be deleted
+	err = p.kube.Wait(p.capiClustersNamespace, "cluster", p.keosCluster.Metadata.Name, "delete", workloadDeletedTimeout)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for the workload cluster deletion")
+	}
+
+	// Delete the remaining objects of the cluster's Namespace
+	err = p.kube.Delete("", "ns", p.capiClustersNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete cluster's Namespace")
+	}
+	return nil
+}
+
+// removeKeosObjects deletes the keoscluster and clusterconfig objects of the
+// cluster's Namespace, in the cluster of k, after dropping their finalizers
+func removeKeosObjects(p *phaseContext, k kube.Client) error {
+	objects := [][2]string{{"keoscluster", p.keosCluster.Metadata.Name}}
+	if p.clusterConfig != nil {
+		objects = append(objects, [2]string{"clusterconfig", p.clusterConfig.Metadata.Name})
+	}
+
+	for _, object := range objects {
+		resource, name := object[0], object[1]
+		exists, err := k.Exists(p.capiClustersNamespace, resource, name)
+		if err != nil {
+			return errors.Wrap(err, "failed to get "+resource+" "+name)
+		}
+		if !exists {
+			continue
+		}
+
+		err = k.Patch(p.capiClustersNamespace, resource, name, kube.PatchMerge, `{"metadata":{"ownerReferences":null,"finalizers":null}}`)
+		if err != nil {
+			return errors.Wrap(err, "failed to remove "+resource+" "+name+" finalizers")
+		}
+
+		err = k.Delete(p.capiClustersNamespace, resource, name)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete "+resource+" "+name)
+		}
+	}
+	return nil
+}
+
+func deleteIAM(p *phaseContext) error {
+	err := deleteCloudFormationStack(p.n, p.provider.capxEnvVars)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the IAM security")
+	}
+	return nil
+}
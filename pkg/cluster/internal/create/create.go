@@ -59,6 +59,7 @@ type ClusterOptions struct {
 
 	// Stratio
 	VaultPassword      string
+	SecretsPath        string
 	DescriptorPath     string
 	MoveManagement     bool
 	AvoidCreation      bool
@@ -69,6 +70,23 @@ type ClusterOptions struct {
 
 	// Force local container delete before creating the cluster if it already exists
 	ForceDelete bool
+	// DeleteIAM removes the IAM CloudFormation stack when deleting the workload cluster
+	DeleteIAM bool
+	// Resume a previous creation in the existing local cluster from its checkpoint
+	Resume bool
+	// DryRun lists the createworker phases to be run without creating anything
+	DryRun bool
+	// SkipPhases lists the createworker phases not to be run
+	SkipPhases []string
+	// OnlyPhase runs a single createworker phase in the existing local cluster
+	OnlyPhase string
+	// RenderDir is the local directory the createworker phases are rendered
+	// into, without creating any container nor touching the cloud
+	RenderDir string
+	// BackupPath is the local directory holding the cloud-provisioner backup to restore
+	BackupPath string
+	// TargetKubeconfig is the cluster the restored management role is moved into
+	TargetKubeconfig string
 	// NodeImage overrides the nodes' images in Config if non-zero
 	NodeImage      string
 	Retain         bool
@@ -83,6 +101,16 @@ type ClusterOptions struct {
 
 // Cluster creates a cluster
 func Cluster(logger log.Logger, p providers.Provider, opts *ClusterOptions) error {
+	// Render the createworker phases, which needs no container runtime
+	if opts.RenderDir != "" {
+		status := cli.StatusForLogger(logger)
+		if err := newWorkerAction(opts).Execute(actions.NewActionContext(logger, status, p, opts.Config)); err != nil {
+			return err
+		}
+		logger.V(0).Infof("The manifests and commands of cluster %q have been rendered into %s\n", opts.KeosCluster.Metadata.Name, opts.RenderDir)
+		return nil
+	}
+
 	// validate provider first
 	if err := validateProvider(p); err != nil {
 		return err
@@ -93,6 +121,19 @@ func Cluster(logger log.Logger, p providers.Provider, opts *ClusterOptions) erro
 		return err
 	}
 
+	// setup a status object to show progress to the user
+	status := cli.StatusForLogger(logger)
+
+	// List the createworker phases without creating anything
+	if opts.DryRun {
+		return newWorkerAction(opts).Execute(actions.NewActionContext(logger, status, p, opts.Config))
+	}
+
+	// Resume the creation, or run a single phase, in the existing local cluster
+	if opts.Resume || opts.OnlyPhase != "" {
+		return resume(logger, p, status, opts)
+	}
+
 	// Check if the cluster name already exists
 	if err := alreadyExists(p, opts.Config.Name); err != nil {
 		if opts.ForceDelete {
@@ -114,8 +155,10 @@ func Cluster(logger log.Logger, p providers.Provider, opts *ClusterOptions) erro
 		return err
 	}
 
-	// setup a status object to show progress to the user
-	status := cli.StatusForLogger(logger)
+	// a checkpoint left by an earlier creation does not belong to this one
+	if err := createworker.RemoveCheckpoint(); err != nil {
+		return err
+	}
 
 	// we're going to start creating now, tell the user
 	logger.V(0).Infof("Creating temporary cluster %q ...\n", opts.Config.Name)
@@ -129,6 +172,32 @@ func Cluster(logger log.Logger, p providers.Provider, opts *ClusterOptions) erro
 		return err
 	}
 
+	// run all actions
+	actionsContext := actions.NewActionContext(logger, status, p, opts.Config)
+	for _, action := range kindActions(opts, newWorkerAction(opts)) {
+		if err := action.Execute(actionsContext); err != nil {
+			// the local cluster is kept to resume the creation from its checkpoint,
+			// which is saved under the KeosCluster name
+			if createworker.HasCheckpoint(opts.KeosCluster.Metadata.Name) {
+				logger.V(0).Infof("Keeping the local cluster %q to resume the creation with --resume\n", opts.Config.Name)
+			} else if !opts.Retain {
+				_ = delete.Cluster(logger, p, opts.Config.Name, opts.KubeconfigPath)
+			}
+			return err
+		}
+	}
+
+	// skip the rest if we're not setting up kubernetes
+	if opts.StopBeforeSettingUpKubernetes {
+		return nil
+	}
+
+	return finish(logger, p, actionsContext, opts)
+}
+
+// kindActions returns the actions setting up the local cluster, followed by
+// the given Stratio worker action
+func kindActions(opts *ClusterOptions, worker actions.Action) []actions.Action {
 	// TODO(bentheelder): make this controllable from the command line?
 	actionsToRun := []actions.Action{
 		loadbalancer.NewAction(), // setup external loadbalancer
@@ -153,26 +222,52 @@ func Cluster(logger log.Logger, p providers.Provider, opts *ClusterOptions) erro
 
 		// add Stratio step
 		actionsToRun = append(actionsToRun,
-			createworker.NewAction(opts.VaultPassword, opts.DescriptorPath, opts.MoveManagement, opts.AvoidCreation, opts.KeosCluster, opts.ClusterCredentials, opts.ClusterConfig), // create worker k8s cluster
+			worker, // create (or delete) worker k8s cluster
 		)
 	}
+	return actionsToRun
+}
 
-	// run all actions
+// newWorkerAction returns the Stratio createworker action for the options
+func newWorkerAction(opts *ClusterOptions) actions.Action {
+	phaseOptions := createworker.PhaseOptions{
+		Resume:    opts.Resume,
+		DryRun:    opts.DryRun,
+		Skip:      opts.SkipPhases,
+		Only:      opts.OnlyPhase,
+		RenderDir: opts.RenderDir,
+	}
+	return createworker.NewAction(opts.VaultPassword, opts.SecretsPath, opts.DescriptorPath, opts.MoveManagement, opts.AvoidCreation, phaseOptions, opts.KeosCluster, opts.ClusterCredentials, opts.ClusterConfig)
+}
+
+// resume continues a previous creation in the existing local cluster, running
+// only the createworker phases not recorded in its checkpoint (or just the
+// requested one)
+func resume(logger log.Logger, p providers.Provider, status *cli.Status, opts *ClusterOptions) error {
+	n, err := p.ListNodes(opts.Config.Name)
+	if err != nil {
+		return err
+	}
+	if len(n) == 0 {
+		return errors.Errorf("there is no local cluster with the name %q to resume", opts.Config.Name)
+	}
+
+	// the local cluster is always kept on failure so it can be resumed again
 	actionsContext := actions.NewActionContext(logger, status, p, opts.Config)
-	for _, action := range actionsToRun {
-		if err := action.Execute(actionsContext); err != nil {
-			if !opts.Retain {
-				_ = delete.Cluster(logger, p, opts.Config.Name, opts.KubeconfigPath)
-			}
-			return err
-		}
+	if err := newWorkerAction(opts).Execute(actionsContext); err != nil {
+		return err
 	}
 
-	// skip the rest if we're not setting up kubernetes
-	if opts.StopBeforeSettingUpKubernetes {
+	// a single phase leaves the local cluster as it was
+	if opts.OnlyPhase != "" {
 		return nil
 	}
 
+	return finish(logger, p, actionsContext, opts)
+}
+
+// finish exports the kubeconfig and cleans up the temporary cluster
+func finish(logger log.Logger, p providers.Provider, actionsContext *actions.ActionContext, opts *ClusterOptions) error {
 	// try exporting kubeconfig with backoff for locking failures
 	// TODO: factor out into a public errors API w/ backoff handling?
 	// for now this is easier than coming up with a good API
@@ -268,6 +363,17 @@ func fixupOptions(opts *ClusterOptions) error {
 		}
 	}
 
+	// the infra providers creating the workload cluster nodes as containers
+	// need the docker socket of the host in the local cluster
+	if p, ok := commons.GetInfraProvider(opts.KeosCluster.Spec.InfraProvider); ok && p.HostDockerSocket {
+		for i := range opts.Config.Nodes {
+			opts.Config.Nodes[i].ExtraMounts = append(opts.Config.Nodes[i].ExtraMounts, config.Mount{
+				HostPath:      commons.DockerSocketPath,
+				ContainerPath: commons.DockerSocketPath,
+			})
+		}
+	}
+
 	// default config fields (important for usage as a library, where the config
 	// may be constructed in memory rather than from disk)
 	config.SetDefaultsCluster(opts.Config)
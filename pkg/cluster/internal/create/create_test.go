@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package create
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/providers"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/apis/config"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// failingProvider provisions no nodes, writing the given local checkpoint
+// as the phases of the creation would, and fails to write the kubeadm
+// configuration, recording whether the local cluster was deleted
+type failingProvider struct {
+	providers.Provider
+	checkpoint string
+	deleted    bool
+}
+
+func (p *failingProvider) Info() (*providers.ProviderInfo, error) {
+	return &providers.ProviderInfo{}, nil
+}
+
+func (p *failingProvider) Provision(*cli.Status, *config.Cluster, string) error {
+	if p.checkpoint == "" {
+		return nil
+	}
+	return os.WriteFile("checkpoint.yaml", []byte(p.checkpoint), 0644)
+}
+
+func (p *failingProvider) ListNodes(string) ([]nodes.Node, error) {
+	return []nodes.Node{}, nil
+}
+
+func (p *failingProvider) DeleteNodes([]nodes.Node) error {
+	p.deleted = true
+	return nil
+}
+
+func (p *failingProvider) GetAPIServerInternalEndpoint(string) (string, error) {
+	return "", errors.New("no endpoint")
+}
+
+func TestClusterKeepsCheckpointedCluster(t *testing.T) {
+	cases := []struct {
+		Name       string
+		Stale      string
+		Checkpoint string
+		Deleted    bool
+	}{
+		{
+			Name:    "Without a checkpoint the local cluster is deleted",
+			Deleted: true,
+		},
+		{
+			Name:       "A checkpoint of the KeosCluster keeps the local cluster",
+			Checkpoint: "cluster: keos\nphases:\n    - capx-local\n",
+			Deleted:    false,
+		},
+		{
+			Name:       "A checkpoint of another cluster does not keep the local cluster",
+			Checkpoint: "cluster: other\nphases:\n    - capx-local\n",
+			Deleted:    true,
+		},
+		{
+			Name:       "A checkpoint without completed phases does not keep the local cluster",
+			Checkpoint: "cluster: keos\nphases: []\n",
+			Deleted:    true,
+		},
+		{
+			Name:    "A checkpoint left by an earlier creation does not keep the local cluster",
+			Stale:   "cluster: keos\nphases:\n    - capx-local\n",
+			Deleted: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			// the checkpoint is looked up in the working directory
+			dir := t.TempDir()
+			wd, err := os.Getwd()
+			assert.ExpectError(t, false, err)
+			assert.ExpectError(t, false, os.Chdir(dir))
+			t.Cleanup(func() { _ = os.Chdir(wd) })
+			if tc.Stale != "" {
+				assert.ExpectError(t, false, os.WriteFile("checkpoint.yaml", []byte(tc.Stale), 0644))
+			}
+
+			p := &failingProvider{checkpoint: tc.Checkpoint}
+			opts := &ClusterOptions{
+				NameOverride:   "kind",
+				KubeconfigPath: filepath.Join(dir, "kubeconfig"),
+			}
+			opts.KeosCluster.Metadata.Name = "keos"
+			err = Cluster(log.NoopLogger{}, p, opts)
+			assert.ExpectError(t, true, err)
+			assert.BoolEqual(t, tc.Deleted, p.deleted)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package create
+
+import (
+	"sigs.k8s.io/kind/pkg/cluster/internal/create/actions"
+	"sigs.k8s.io/kind/pkg/cluster/internal/create/actions/createworker"
+	"sigs.k8s.io/kind/pkg/cluster/internal/delete"
+	"sigs.k8s.io/kind/pkg/cluster/internal/providers"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// DeleteWorkloadCluster tears down the workload cluster described in opts
+// from the local cluster, which is created (and removed afterwards) if it
+// does not exist yet
+func DeleteWorkloadCluster(logger log.Logger, p providers.Provider, opts *ClusterOptions) error {
+	// validate provider first
+	if err := validateProvider(p); err != nil {
+		return err
+	}
+
+	// default / process options (namely config)
+	if err := fixupOptions(opts); err != nil {
+		return err
+	}
+
+	// setup a status object to show progress to the user
+	status := cli.StatusForLogger(logger)
+
+	// a retained local cluster (e.g. --keep-mgmt) is reused as it is
+	n, err := p.ListNodes(opts.Config.Name)
+	if err != nil {
+		return err
+	}
+	provisioned := len(n) == 0
+
+	phaseOptions := createworker.PhaseOptions{
+		DryRun: opts.DryRun,
+		Skip:   opts.SkipPhases,
+		Only:   opts.OnlyPhase,
+	}
+	worker := createworker.NewDeleteAction(opts.VaultPassword, opts.DescriptorPath, opts.DeleteIAM, provisioned, phaseOptions, opts.KeosCluster, opts.ClusterCredentials, opts.ClusterConfig)
+
+	// List the phases without creating anything
+	if opts.DryRun {
+		return worker.Execute(actions.NewActionContext(logger, status, p, opts.Config))
+	}
+
+	actionsToRun := []actions.Action{worker}
+	if provisioned {
+		if err := opts.Config.Validate(); err != nil {
+			return err
+		}
+
+		logger.V(0).Infof("Creating temporary cluster %q ...\n", opts.Config.Name)
+
+		// Create node containers implementing defined config Nodes
+		if err := p.Provision(status, opts.Config, opts.DockerRegUrl); err != nil {
+			if !opts.Retain {
+				_ = delete.Cluster(logger, p, opts.Config.Name, opts.KubeconfigPath)
+			}
+			return err
+		}
+		actionsToRun = kindActions(opts, worker)
+	}
+
+	// run all actions
+	actionsContext := actions.NewActionContext(logger, status, p, opts.Config)
+	for _, action := range actionsToRun {
+		if err := action.Execute(actionsContext); err != nil {
+			if provisioned && !opts.Retain {
+				_ = delete.Cluster(logger, p, opts.Config.Name, opts.KubeconfigPath)
+			}
+			return err
+		}
+	}
+
+	// only the local cluster created here is removed
+	if provisioned && !opts.Retain {
+		actionsContext.Status.Start("Cleaning up temporary cluster 🧹")
+		defer actionsContext.Status.End(false)
+		_ = delete.Cluster(logger, p, opts.Config.Name, opts.KubeconfigPath)
+		actionsContext.Status.End(true) // End Cleaning up local cluster
+	}
+
+	return nil
+}
+
+// RestoreCluster recreates the local management cluster from the backup in
+// opts.BackupPath, optionally moving the management role into the cluster
+// behind opts.TargetKubeconfig
+func RestoreCluster(logger log.Logger, p providers.Provider, opts *ClusterOptions) error {
+	// validate provider first
+	if err := validateProvider(p); err != nil {
+		return err
+	}
+
+	// default / process options (namely config)
+	if err := fixupOptions(opts); err != nil {
+		return err
+	}
+
+	// the restored local cluster is the management cluster unless it is pivoted
+	if opts.TargetKubeconfig == "" {
+		opts.Retain = true
+	}
+
+	// setup a status object to show progress to the user
+	status := cli.StatusForLogger(logger)
+
+	phaseOptions := createworker.PhaseOptions{
+		DryRun: opts.DryRun,
+		Skip:   opts.SkipPhases,
+	}
+	worker := createworker.NewRestoreAction(opts.BackupPath, opts.TargetKubeconfig, phaseOptions, opts.KeosCluster, opts.ClusterCredentials, opts.ClusterConfig)
+
+	// List the phases without creating anything
+	if opts.DryRun {
+		return worker.Execute(actions.NewActionContext(logger, status, p, opts.Config))
+	}
+
+	// Check if the cluster name already exists
+	if err := alreadyExists(p, opts.Config.Name); err != nil {
+		if opts.ForceDelete {
+			// Delete current cluster container
+			_ = delete.Cluster(nil, p, opts.Config.Name, "")
+		} else {
+			return errors.Errorf("A cluster with the name %q already exists \n"+
+				"Please use a different cluster name or delete the current container with --delete-previous flag", opts.Config.Name)
+		}
+	}
+
+	if err := opts.Config.Validate(); err != nil {
+		return err
+	}
+
+	logger.V(0).Infof("Creating temporary cluster %q ...\n", opts.Config.Name)
+
+	// Create node containers implementing defined config Nodes
+	if err := p.Provision(status, opts.Config, opts.DockerRegUrl); err != nil {
+		if !opts.Retain {
+			_ = delete.Cluster(logger, p, opts.Config.Name, opts.KubeconfigPath)
+		}
+		return err
+	}
+
+	// run all actions
+	actionsContext := actions.NewActionContext(logger, status, p, opts.Config)
+	for _, action := range kindActions(opts, worker) {
+		if err := action.Execute(actionsContext); err != nil {
+			if !opts.Retain {
+				_ = delete.Cluster(logger, p, opts.Config.Name, opts.KubeconfigPath)
+			}
+			return err
+		}
+	}
+
+	return finish(logger, p, actionsContext, opts)
+}
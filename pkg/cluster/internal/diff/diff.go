@@ -0,0 +1,235 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diff implements comparing the descriptor with the KeosCluster
+// running in the workload cluster
+package diff
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// DiffParams holds the descriptor to be compared and the cluster to compare
+// it with
+type DiffParams struct {
+	KeosCluster   commons.KeosCluster
+	ClusterConfig *commons.ClusterConfig
+	// Client manages the cluster holding the KeosCluster
+	Client kube.Client
+}
+
+// replicasOutput lists the name and replicas of each object, one per line
+const replicasOutput = `jsonpath={range .items[*]}{.metadata.name}{" "}{.spec.replicas}{"\n"}{end}`
+
+// Cluster returns the differences between the spec of the descriptor and the
+// one of the running KeosCluster, whose worker node quantities are the
+// replicas of their machine deployments or machine pools
+func Cluster(params *DiffParams) ([]commons.FieldChange, error) {
+	name := params.KeosCluster.Metadata.Name
+	namespace := commons.ClusterNamespace(name)
+	keosNamespace := params.KeosCluster.Metadata.Namespace
+	if keosNamespace == "" {
+		keosNamespace = namespace
+	}
+
+	manifest, err := params.Client.Get(keosNamespace, "keoscluster", name, "yaml")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the keoscluster")
+	}
+	var live commons.KeosCluster
+	if err := yaml.Unmarshal([]byte(manifest), &live); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the keoscluster")
+	}
+
+	resource := "machinedeployments"
+	if live.Spec.IsMachinePool() {
+		resource = "machinepools"
+	}
+	output, err := params.Client.Get(namespace, resource, "", replicasOutput)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the "+resource)
+	}
+	setWorkerReplicas(&live, parseReplicas(name, output))
+
+	descriptor, err := toGeneric(commons.KeosClusterObject(params.KeosCluster, params.ClusterConfig).Spec)
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := toGeneric(commons.KeosClusterObject(live, nil).Spec)
+	if err != nil {
+		return nil, err
+	}
+	changes := []commons.FieldChange{}
+	compare("spec", descriptor, cluster, &changes)
+	return changes, nil
+}
+
+// parseReplicas returns the replicas of each worker node group from the
+// machine deployments or machine pools of the cluster, which the
+// keoscluster-controller names <cluster>-<group>-md-<n> or <cluster>-<group>-mp-<n>
+func parseReplicas(cluster string, output string) map[string]int {
+	replicas := map[string]int{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], cluster+"-") {
+			continue
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		group := strings.TrimPrefix(fields[0], cluster+"-")
+		for _, suffix := range []string{"-md-", "-mp-"} {
+			if i := strings.LastIndex(group, suffix); i > 0 {
+				group = group[:i]
+				break
+			}
+		}
+		replicas[group] += count
+	}
+	return replicas
+}
+
+// setWorkerReplicas sets the quantity of the worker node groups to their
+// replicas, except for the autoscaled ones as their replicas are expected to
+// change. The groups without machine deployments or machine pools have no
+// replicas at all, autoscaled or not
+func setWorkerReplicas(keosCluster *commons.KeosCluster, replicas map[string]int) {
+	for i, wn := range keosCluster.Spec.WorkerNodes {
+		count, ok := replicas[wn.Name]
+		if ok && keosCluster.Spec.DeployAutoscaler && wn.NodeGroupMaxSize > 0 {
+			continue
+		}
+		keosCluster.Spec.WorkerNodes[i].Quantity = &count
+	}
+}
+
+// toGeneric returns the value as the maps, lists and scalars of its YAML
+func toGeneric(value interface{}) (interface{}, error) {
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the spec")
+	}
+	var generic interface{}
+	if err := yaml.Unmarshal(b, &generic); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the spec")
+	}
+	return generic, nil
+}
+
+// compare appends to changes the differences between the descriptor and the
+// cluster values at path
+func compare(path string, descriptor interface{}, cluster interface{}, changes *[]commons.FieldChange) {
+	descriptorMap, ok1 := descriptor.(map[string]interface{})
+	clusterMap, ok2 := cluster.(map[string]interface{})
+	if ok1 && ok2 {
+		for _, key := range unionKeys(descriptorMap, clusterMap) {
+			compare(path+"."+key, descriptorMap[key], clusterMap[key], changes)
+		}
+		return
+	}
+	descriptorList, ok1 := descriptor.([]interface{})
+	clusterList, ok2 := cluster.([]interface{})
+	if ok1 && ok2 {
+		compareLists(path, descriptorList, clusterList, changes)
+		return
+	}
+	if !reflect.DeepEqual(descriptor, cluster) {
+		*changes = append(*changes, commons.FieldChange{Path: path, Descriptor: descriptor, Cluster: cluster})
+	}
+}
+
+// compareLists compares the items of lists whose items all have a unique name
+// by name (e.g. spec.worker_nodes[workers]) and by index otherwise
+func compareLists(path string, descriptor []interface{}, cluster []interface{}, changes *[]commons.FieldChange) {
+	descriptorNames, ok1 := itemNames(descriptor)
+	clusterNames, ok2 := itemNames(cluster)
+	if !ok1 || !ok2 {
+		for i := 0; i < len(descriptor) || i < len(cluster); i++ {
+			var d, c interface{}
+			if i < len(descriptor) {
+				d = descriptor[i]
+			}
+			if i < len(cluster) {
+				c = cluster[i]
+			}
+			compare(path+"["+strconv.Itoa(i)+"]", d, c, changes)
+		}
+		return
+	}
+	for i, name := range descriptorNames {
+		var c interface{}
+		if j := indexOf(clusterNames, name); j >= 0 {
+			c = cluster[j]
+		}
+		compare(path+"["+name+"]", descriptor[i], c, changes)
+	}
+	for j, name := range clusterNames {
+		if indexOf(descriptorNames, name) < 0 {
+			compare(path+"["+name+"]", nil, cluster[j], changes)
+		}
+	}
+}
+
+// itemNames returns the names of the items, or false if any of them has no
+// name or it is repeated
+func itemNames(items []interface{}) ([]string, bool) {
+	names := []string{}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, ok := m["name"].(string)
+		if !ok || name == "" || indexOf(names, name) >= 0 {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func unionKeys(a map[string]interface{}, b map[string]interface{}) []string {
+	keys := []string{}
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
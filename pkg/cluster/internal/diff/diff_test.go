@@ -0,0 +1,155 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+const (
+	getKeosCluster        = "kubectl --namespace cluster-test get keoscluster test -o yaml"
+	getMachineDeployments = "kubectl --namespace cluster-test get machinedeployments -o " + replicasOutput
+)
+
+const descriptorSpec = `
+infra_provider: aws
+k8s_version: v1.26.8
+region: eu-west-1
+deploy_autoscaler: true
+credentials:
+  aws:
+    secret_key: s3cr3t
+docker_registries:
+  - url: registry.example.com
+    type: ecr
+    keos_registry: true
+helm_repository:
+  url: https://charts.example.com
+worker_nodes:
+  - name: workers
+    quantity: 3
+    size: m5.xlarge
+  - name: autoscaled
+    quantity: 3
+    min_size: 1
+    max_size: 6
+    size: m5.xlarge
+`
+
+func newKeosCluster(t *testing.T, spec string) commons.KeosCluster {
+	keosCluster := commons.KeosCluster{APIVersion: "installer.stratio.com/v1beta1", Kind: "KeosCluster"}
+	keosCluster.Metadata.Name = "test"
+	if err := yaml.Unmarshal([]byte(spec), &keosCluster.Spec); err != nil {
+		t.Fatalf("failed to parse spec: %v", err)
+	}
+	return keosCluster
+}
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name     string
+		LiveSpec string
+		Replicas string
+		Expected []string
+	}{
+		{
+			Name:     "no differences",
+			LiveSpec: descriptorSpec,
+			Replicas: "test-workers-md-0 1\ntest-workers-md-1 1\ntest-workers-md-2 1\ntest-autoscaled-md-0 5\n",
+			Expected: []string{},
+		},
+		{
+			Name:     "scaled workers",
+			LiveSpec: descriptorSpec,
+			Replicas: "test-workers-md-0 2\ntest-workers-md-1 2\ntest-workers-md-2 1\ntest-autoscaled-md-0 2\n",
+			Expected: []string{"spec.worker_nodes[workers].quantity: 3 -> 5"},
+		},
+		{
+			Name:     "missing machine deployments",
+			LiveSpec: descriptorSpec,
+			Replicas: "test-workers-md-0 1\ntest-workers-md-1 1\ntest-workers-md-2 1\n",
+			Expected: []string{"spec.worker_nodes[autoscaled].quantity: 3 -> 0"},
+		},
+		{
+			Name: "edited keoscluster",
+			LiveSpec: `
+infra_provider: aws
+k8s_version: v1.27.4
+region: eu-west-1
+deploy_autoscaler: true
+docker_registries:
+  - url: registry.example.com
+    type: ecr
+    keos_registry: true
+helm_repository:
+  url: https://charts.example.com
+worker_nodes:
+  - name: autoscaled
+    quantity: 3
+    min_size: 1
+    max_size: 6
+    size: m5.xlarge
+  - name: gpu
+    quantity: 1
+    size: p3.2xlarge
+`,
+			Replicas: "test-autoscaled-md-0 4\ntest-gpu-md-0 1\n",
+			Expected: []string{
+				"spec.k8s_version: v1.26.8 -> v1.27.4",
+				`spec.worker_nodes[workers]: {"name":"workers","quantity":3,"size":"m5.xlarge"} -> <unset>`,
+				`spec.worker_nodes[gpu]: <unset> -> {"name":"gpu","quantity":1,"size":"p3.2xlarge"}`,
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			live, err := yaml.Marshal(newKeosCluster(t, tc.LiveSpec))
+			if err != nil {
+				t.Fatalf("failed to marshal the live keoscluster: %v", err)
+			}
+			client := kube.NewFakeClient("")
+			client.Outputs[getKeosCluster] = string(live)
+			client.Outputs[getMachineDeployments] = tc.Replicas
+			changes, err := Cluster(&DiffParams{
+				KeosCluster: newKeosCluster(t, descriptorSpec),
+				Client:      client,
+			})
+			assert.ExpectError(t, false, err)
+			lines := []string{}
+			for _, change := range changes {
+				lines = append(lines, change.String())
+			}
+			assert.DeepEqual(t, tc.Expected, lines)
+			assert.DeepEqual(t, []string{getKeosCluster, getMachineDeployments}, client.Commands)
+		})
+	}
+}
+
+func TestParseReplicas(t *testing.T) {
+	t.Parallel()
+	replicas := parseReplicas("test", "test-workers-md-0 1\ntest-workers-md-1 2\ntest-pool-mp-0 3\nother-workers-md-0 4\ntest-invalid\n")
+	assert.DeepEqual(t, map[string]int{"workers": 3, "pool": 3}, replicas)
+}
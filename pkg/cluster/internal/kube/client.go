@@ -0,0 +1,580 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+	"sigs.k8s.io/kind/pkg/internal/redact"
+)
+
+// Client manages a cluster running kubectl and helm inside a node.
+// An empty namespace is not passed to the command, so the default one is used
+type Client interface {
+	// Apply applies the manifest
+	Apply(namespace string, manifest string) error
+	// ServerSideApply applies the manifest server-side as fieldManager, taking
+	// over the fields set by other managers
+	ServerSideApply(namespace string, manifest string, fieldManager string) error
+	// ApplyFile applies the manifest in the node file path
+	ApplyFile(namespace string, path string) error
+	// CreateFile creates the objects in the node file path
+	CreateFile(namespace string, path string) error
+	// CreateNamespace creates the namespace name, if it does not exist
+	CreateNamespace(name string) error
+	// CreateSecret creates the secret in namespace, or updates it if it
+	// already exists
+	CreateSecret(namespace string, secret Secret) error
+	// Get returns the object name of resource in the given output format, or
+	// all its objects if name is empty
+	Get(namespace string, resource string, name string, output string) (string, error)
+	// Exists returns true if the object name of resource exists
+	Exists(namespace string, resource string, name string) (bool, error)
+	// Patch patches the object name of resource
+	Patch(namespace string, resource string, name string, patchType PatchType, patch string) error
+	// Delete deletes the object name of resource, if it exists
+	Delete(namespace string, resource string, name string) error
+	// DeleteAsync deletes the object name of resource, if it exists, without
+	// waiting for its finalizers
+	DeleteAsync(namespace string, resource string, name string) error
+	// DeleteFile deletes the existing objects in the node file path
+	DeleteFile(namespace string, path string) error
+	// Scale sets the replicas of the object name of resource
+	Scale(namespace string, resource string, name string, replicas int) error
+	// Wait waits for condition (e.g. condition=Ready) on the object name of
+	// resource, or on all its objects if name is empty. An empty condition
+	// waits for the object name to exist
+	Wait(namespace string, resource string, name string, condition string, timeout time.Duration) error
+	// RolloutRestart restarts the pods of the object name of resource
+	RolloutRestart(namespace string, resource string, name string) error
+	// RolloutStatus waits for the rollout of the object name of resource
+	RolloutStatus(namespace string, resource string, name string, timeout time.Duration) error
+	// HelmInstall installs the release
+	HelmInstall(release HelmRelease) error
+	// HelmUpgrade upgrades the release, installing it if missing
+	HelmUpgrade(release HelmRelease) error
+	// HelmUninstall uninstalls the release name, succeeding if it is not
+	// installed
+	HelmUninstall(namespace string, name string) error
+	// HelmList returns the releases in namespace, or in all namespaces if
+	// empty, as JSON
+	HelmList(namespace string) (string, error)
+	// HelmRegistryLogin logs in the OCI registry of repo, the host in its URL
+	HelmRegistryLogin(repo HelmRepository) error
+	// HelmRepoAdd adds the chart repository repo
+	HelmRepoAdd(repo HelmRepository) error
+	// HelmPull pulls and untars chart into the node directory untarDir,
+	// trusting the CA file of repo
+	HelmPull(repo HelmRepository, chart string, version string, untarDir string) error
+}
+
+// PatchType is the kubectl patch --type
+type PatchType string
+
+const (
+	PatchMerge     PatchType = "merge"
+	PatchStrategic PatchType = "strategic"
+	PatchJSON      PatchType = "json"
+)
+
+// Secret is a secret to be created by the Client
+type Secret struct {
+	Name string
+	// DockerRegistry, if set, makes it a kubernetes.io/dockerconfigjson secret
+	DockerRegistry *DockerRegistryAuth
+	// Data are the literal entries of the secret
+	Data map[string]string
+	// Files maps entries of the secret to files in the node
+	Files map[string]string
+}
+
+// DockerRegistryAuth are the credentials of a docker registry
+type DockerRegistryAuth struct {
+	Server   string
+	Username string
+	Password string
+}
+
+// HelmRelease is a helm chart release
+type HelmRelease struct {
+	Name      string
+	Chart     string
+	Namespace string
+	Version   string
+	// CreateNamespace creates the namespace if missing
+	CreateNamespace bool
+	// ValuesFiles are files in the node with the values of the release
+	ValuesFiles []string
+	// Values are set one by one (--set), after ValuesFiles
+	Values map[string]string
+	// Wait waits for the resources of the release to be ready
+	Wait    bool
+	Timeout time.Duration
+}
+
+// HelmRepository is a helm chart repository or OCI registry
+type HelmRepository struct {
+	Name string
+	URL  string
+	// Username and Password are the credentials, if required. The password
+	// is passed through stdin
+	Username string
+	Password string
+	// CAFile is a node file with the CA bundle the repository is signed by
+	CAFile string
+}
+
+const (
+	defaultAttempts      = 3
+	defaultRetryInterval = 5 * time.Second
+)
+
+type client struct {
+	runner        runner
+	kubeconfig    string
+	attempts      int
+	retryInterval time.Duration
+}
+
+var _ Client = &client{}
+
+// NewClient returns a Client running in the node n against the cluster of
+// kubeconfig, a path in the node. An empty kubeconfig targets the cluster
+// the node belongs to
+func NewClient(n nodes.Node, kubeconfig string) Client {
+	return &client{
+		runner:        &nodeRunner{n: n},
+		kubeconfig:    kubeconfig,
+		attempts:      defaultAttempts,
+		retryInterval: defaultRetryInterval,
+	}
+}
+
+// NewLocalClient returns a Client running in the host against the cluster of
+// kubeconfig, a local path. kubectl and helm must be installed in the host
+func NewLocalClient(kubeconfig string) Client {
+	return &client{
+		runner:        &localRunner{},
+		kubeconfig:    kubeconfig,
+		attempts:      defaultAttempts,
+		retryInterval: defaultRetryInterval,
+	}
+}
+
+func (c *client) Apply(namespace string, manifest string) error {
+	_, err := c.kubectl(true, manifest, namespace, "apply", "-f", "-")
+	return err
+}
+
+func (c *client) ServerSideApply(namespace string, manifest string, fieldManager string) error {
+	_, err := c.kubectl(true, manifest, namespace, "apply", "--server-side", "--force-conflicts", "--field-manager="+fieldManager, "-f", "-")
+	return err
+}
+
+func (c *client) ApplyFile(namespace string, path string) error {
+	_, err := c.kubectl(true, "", namespace, "apply", "-f", path)
+	return err
+}
+
+func (c *client) CreateFile(namespace string, path string) error {
+	_, err := c.kubectl(false, "", namespace, "create", "-f", path)
+	return err
+}
+
+func (c *client) CreateNamespace(name string) error {
+	// applied so a resumed creation does not fail if it already exists
+	manifest, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]string{"name": name},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal namespace "+name)
+	}
+	_, err = c.kubectl(true, string(manifest), "", "apply", "-f", "-")
+	return err
+}
+
+func (c *client) CreateSecret(namespace string, secret Secret) error {
+	// the files can only be read in the node, so their manifest is rendered
+	// there and the data is added to it. The secret is sent through stdin to
+	// keep the credentials out of the arguments, and applied so a resumed
+	// creation does not fail if it already exists
+	manifest, err := secretManifest(secret)
+	if err != nil {
+		return err
+	}
+	if len(secret.Files) > 0 {
+		args := []string{"create", "secret", "generic", secret.Name}
+		for _, key := range sortedKeys(secret.Files) {
+			args = append(args, "--from-file="+key+"="+secret.Files[key])
+		}
+		files, err := c.kubectl(false, "", namespace, append(args, "--dry-run=client", "-o", "json")...)
+		if err != nil {
+			return err
+		}
+		if manifest, err = mergeSecretData(files, manifest); err != nil {
+			return err
+		}
+	}
+	_, err = c.kubectl(true, manifest, namespace, "apply", "-f", "-")
+	return err
+}
+
+func (c *client) Get(namespace string, resource string, name string, output string) (string, error) {
+	args := []string{"get", resource}
+	if name != "" {
+		args = append(args, name)
+	}
+	if output != "" {
+		args = append(args, "-o", output)
+	}
+	return c.kubectl(true, "", namespace, args...)
+}
+
+func (c *client) Exists(namespace string, resource string, name string) (bool, error) {
+	output, err := c.kubectl(true, "", namespace, "get", resource, name, "--ignore-not-found", "-o", "name")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(output) != "", nil
+}
+
+func (c *client) Patch(namespace string, resource string, name string, patchType PatchType, patch string) error {
+	_, err := c.kubectl(true, "", namespace, "patch", resource, name, "--type="+string(patchType), "-p", patch)
+	return err
+}
+
+func (c *client) Delete(namespace string, resource string, name string) error {
+	_, err := c.kubectl(true, "", namespace, "delete", resource, name, "--ignore-not-found")
+	return err
+}
+
+func (c *client) DeleteAsync(namespace string, resource string, name string) error {
+	_, err := c.kubectl(true, "", namespace, "delete", resource, name, "--ignore-not-found", "--wait=false")
+	return err
+}
+
+func (c *client) DeleteFile(namespace string, path string) error {
+	_, err := c.kubectl(true, "", namespace, "delete", "-f", path, "--ignore-not-found")
+	return err
+}
+
+func (c *client) Scale(namespace string, resource string, name string, replicas int) error {
+	_, err := c.kubectl(true, "", namespace, "scale", resource, name, "--replicas="+strconv.Itoa(replicas))
+	return err
+}
+
+func (c *client) Wait(namespace string, resource string, name string, condition string, timeout time.Duration) error {
+	if condition == "" {
+		return c.waitExists(namespace, resource, name, timeout)
+	}
+	args := []string{"wait", resource}
+	if name == "" {
+		args = append(args, "--all")
+	} else {
+		args = append(args, name)
+	}
+	args = append(args, "--for="+condition, "--timeout="+timeout.String())
+	_, err := c.kubectl(false, "", namespace, args...)
+	return err
+}
+
+// waitExists polls the object name of resource until it exists
+func (c *client) waitExists(namespace string, resource string, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := c.kubectl(false, "", namespace, "get", resource, name, "-o", "name")
+		if err == nil || ReasonForError(err) == ReasonNotInstalled || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(c.retryInterval)
+	}
+}
+
+func (c *client) RolloutRestart(namespace string, resource string, name string) error {
+	_, err := c.kubectl(false, "", namespace, "rollout", "restart", resource, name)
+	return err
+}
+
+func (c *client) RolloutStatus(namespace string, resource string, name string, timeout time.Duration) error {
+	_, err := c.kubectl(false, "", namespace, "rollout", "status", resource, name, "--timeout="+timeout.String())
+	return err
+}
+
+func (c *client) HelmInstall(release HelmRelease) error {
+	_, err := c.helm(false, "", append([]string{"install"}, helmArgs(release)...))
+	return err
+}
+
+func (c *client) HelmUpgrade(release HelmRelease) error {
+	_, err := c.helm(true, "", append([]string{"upgrade", "--install"}, helmArgs(release)...))
+	return err
+}
+
+func (c *client) HelmUninstall(namespace string, name string) error {
+	_, err := c.helm(false, "", []string{"uninstall", name, "--namespace", namespace})
+	// a rerun after a partial failure finds the release already uninstalled
+	if cmdErr, ok := err.(*CommandError); ok && strings.Contains(cmdErr.Output, "release: not found") {
+		return nil
+	}
+	return err
+}
+
+func (c *client) HelmList(namespace string) (string, error) {
+	args := []string{"list", "--output", "json"}
+	if namespace == "" {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "--namespace", namespace)
+	}
+	return c.helm(true, "", args)
+}
+
+func (c *client) HelmRegistryLogin(repo HelmRepository) error {
+	args := []string{"registry", "login", repo.URL}
+	if repo.Username != "" {
+		args = append(args, "--username", repo.Username, "--password-stdin")
+	}
+	if repo.CAFile != "" {
+		args = append(args, "--ca-file", repo.CAFile)
+	}
+	_, err := c.helm(true, repo.Password, args)
+	return err
+}
+
+func (c *client) HelmRepoAdd(repo HelmRepository) error {
+	args := []string{"repo", "add", repo.Name, repo.URL, "--force-update"}
+	if repo.Username != "" {
+		args = append(args, "--username", repo.Username, "--password-stdin")
+	}
+	if repo.CAFile != "" {
+		args = append(args, "--ca-file", repo.CAFile)
+	}
+	_, err := c.helm(true, repo.Password, args)
+	return err
+}
+
+func (c *client) HelmPull(repo HelmRepository, chart string, version string, untarDir string) error {
+	args := []string{"pull", chart, "--version", version, "--untar", "--untardir", untarDir}
+	if repo.CAFile != "" {
+		args = append(args, "--ca-file", repo.CAFile)
+	}
+	_, err := c.helm(true, "", args)
+	return err
+}
+
+// kubectl runs kubectl with args, retrying it on failure if idempotent
+func (c *client) kubectl(idempotent bool, stdin string, namespace string, args ...string) (string, error) {
+	cmdArgs := []string{}
+	if c.kubeconfig != "" {
+		cmdArgs = append(cmdArgs, "--kubeconfig", c.kubeconfig)
+	}
+	if namespace != "" {
+		cmdArgs = append(cmdArgs, "--namespace", namespace)
+	}
+	cmdArgs = append(cmdArgs, args...)
+	return c.run(idempotent, stdin, "kubectl", verb(args), cmdArgs)
+}
+
+// helm runs helm with args, retrying it on failure if idempotent
+func (c *client) helm(idempotent bool, stdin string, args []string) (string, error) {
+	cmdArgs := append([]string{}, args...)
+	if c.kubeconfig != "" {
+		cmdArgs = append(cmdArgs, "--kubeconfig", c.kubeconfig)
+	}
+	return c.run(idempotent, stdin, "helm", helmVerb(args), cmdArgs)
+}
+
+// run runs the command, retrying it while the node is unavailable or, if the
+// command is idempotent, until it succeeds or the attempts are exhausted
+func (c *client) run(idempotent bool, stdin string, name string, verb string, args []string) (string, error) {
+	var output, stderr string
+	var err error
+	for attempt := 1; attempt <= c.attempts; attempt++ {
+		output, stderr, err = c.runner.run(stdin, name, args)
+		if err == nil {
+			return output, nil
+		}
+		cmdErr := &CommandError{Name: name, Verb: verb, ExitCode: exitCode(err), Output: redact.String(output + stderr)}
+		err = cmdErr
+		switch cmdErr.Reason() {
+		case ReasonNotInstalled:
+			return "", err
+		case ReasonFailed:
+			if !idempotent {
+				return "", err
+			}
+		}
+		if attempt < c.attempts {
+			time.Sleep(c.retryInterval)
+		}
+	}
+	return "", err
+}
+
+// verb returns the kubectl subcommand in args, e.g. rollout status
+func verb(args []string) string {
+	if len(args) > 1 && (args[0] == "rollout" || args[0] == "create") && !strings.HasPrefix(args[1], "-") {
+		return args[0] + " " + args[1]
+	}
+	return args[0]
+}
+
+// helmVerb returns the helm subcommand in args, e.g. repo add
+func helmVerb(args []string) string {
+	if len(args) > 1 && (args[0] == "repo" || args[0] == "registry") {
+		return args[0] + " " + args[1]
+	}
+	return args[0]
+}
+
+func helmArgs(release HelmRelease) []string {
+	args := []string{release.Name, release.Chart}
+	if release.Namespace != "" {
+		args = append(args, "--namespace", release.Namespace)
+	}
+	if release.CreateNamespace {
+		args = append(args, "--create-namespace")
+	}
+	if release.Version != "" {
+		args = append(args, "--version", release.Version)
+	}
+	for _, f := range release.ValuesFiles {
+		args = append(args, "--values", f)
+	}
+	for _, key := range sortedKeys(release.Values) {
+		args = append(args, "--set", key+"="+release.Values[key])
+	}
+	if release.Wait {
+		args = append(args, "--wait")
+	}
+	if release.Timeout != 0 {
+		args = append(args, "--timeout", release.Timeout.String())
+	}
+	return args
+}
+
+// secretManifest returns the manifest of a secret without files
+func secretManifest(secret Secret) (string, error) {
+	manifest := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]string{"name": secret.Name},
+	}
+	stringData := map[string]string{}
+	for key, value := range secret.Data {
+		stringData[key] = value
+	}
+	if secret.DockerRegistry != nil {
+		r := secret.DockerRegistry
+		dockerConfig, err := json.Marshal(map[string]interface{}{
+			"auths": map[string]interface{}{
+				r.Server: map[string]string{
+					"username": r.Username,
+					"password": r.Password,
+					"auth":     base64.StdEncoding.EncodeToString([]byte(r.Username + ":" + r.Password)),
+				},
+			},
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "failed to marshal the docker config")
+		}
+		manifest["type"] = "kubernetes.io/dockerconfigjson"
+		stringData[".dockerconfigjson"] = string(dockerConfig)
+	}
+	manifest["stringData"] = stringData
+	raw, err := json.Marshal(manifest)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal secret "+secret.Name)
+	}
+	return string(raw), nil
+}
+
+// mergeSecretData adds the stringData and type of the secret manifest data
+// to the secret manifest files, returning the merged manifest
+func mergeSecretData(files string, data string) (string, error) {
+	merged := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(files), &merged); err != nil {
+		return "", errors.Wrap(err, "failed to decode the secret manifest")
+	}
+	d := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		return "", errors.Wrap(err, "failed to decode the secret manifest")
+	}
+	merged["stringData"] = d["stringData"]
+	if t, ok := d["type"]; ok {
+		merged["type"] = t
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the secret manifest")
+	}
+	return string(raw), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runner runs a command, returning its stdout and stderr apart so the
+// warnings of kubectl and helm are not parsed as their output
+type runner interface {
+	run(stdin string, name string, args []string) (string, string, error)
+}
+
+// nodeRunner runs the commands inside a node
+type nodeRunner struct {
+	n nodes.Node
+}
+
+func (r *nodeRunner) run(stdin string, name string, args []string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := r.n.Command(name, args...).SetStdout(&stdout).SetStderr(&stderr)
+	if stdin != "" {
+		cmd.SetStdin(strings.NewReader(stdin))
+	}
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// localRunner runs the commands in the host
+type localRunner struct{}
+
+func (r *localRunner) run(stdin string, name string, args []string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...).SetStdout(&stdout).SetStderr(&stderr)
+	if stdin != "" {
+		cmd.SetStdin(strings.NewReader(stdin))
+	}
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
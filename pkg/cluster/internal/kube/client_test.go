@@ -0,0 +1,319 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"testing"
+	"time"
+
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestClientCommands(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name       string
+		Kubeconfig string
+		Run        func(c Client) error
+		Expected   []string
+	}{
+		{
+			Name: "apply from stdin",
+			Run: func(c Client) error {
+				return c.Apply("kube-system", "kind: ConfigMap")
+			},
+			Expected: []string{"kubectl --namespace kube-system apply -f -"},
+		},
+		{
+			Name: "server-side apply",
+			Run: func(c Client) error {
+				return c.ServerSideApply("", "kind: KeosCluster", "cloud-provisioner")
+			},
+			Expected: []string{"kubectl apply --server-side --force-conflicts --field-manager=cloud-provisioner -f -"},
+		},
+		{
+			Name: "get all",
+			Run: func(c Client) error {
+				_, err := c.Get("cluster-test", "machinedeployments", "", "name")
+				return err
+			},
+			Expected: []string{"kubectl --namespace cluster-test get machinedeployments -o name"},
+		},
+		{
+			Name: "helm list",
+			Run: func(c Client) error {
+				_, err := c.HelmList("kube-system")
+				return err
+			},
+			Expected: []string{"helm list --output json --namespace kube-system"},
+		},
+		{
+			Name:       "workload cluster",
+			Kubeconfig: "/kind/worker-cluster.kubeconfig",
+			Run: func(c Client) error {
+				return c.ApplyFile("", "/kind/netpol.yaml")
+			},
+			Expected: []string{"kubectl --kubeconfig /kind/worker-cluster.kubeconfig apply -f /kind/netpol.yaml"},
+		},
+		{
+			Name: "patch arguments are not quoted",
+			Run: func(c Client) error {
+				return c.Patch("capa-system", "deploy", "capa-controller-manager", PatchMerge, `{"metadata":{"finalizers":null}}`)
+			},
+			Expected: []string{`kubectl --namespace capa-system patch deploy capa-controller-manager --type=merge -p {"metadata":{"finalizers":null}}`},
+		},
+		{
+			Name: "wait for all",
+			Run: func(c Client) error {
+				return c.Wait("cluster-test", "md", "", "condition=Ready", 15*time.Minute)
+			},
+			Expected: []string{"kubectl --namespace cluster-test wait md --all --for=condition=Ready --timeout=15m0s"},
+		},
+		{
+			Name: "wait for the object to exist",
+			Run: func(c Client) error {
+				return c.Wait("cluster-test", "cluster", "test", "", time.Minute)
+			},
+			Expected: []string{"kubectl --namespace cluster-test get cluster test -o name"},
+		},
+		{
+			Name: "exists and asynchronous delete",
+			Run: func(c Client) error {
+				if _, err := c.Exists("cluster-test", "cluster", "test"); err != nil {
+					return err
+				}
+				return c.DeleteAsync("cluster-test", "cluster", "test")
+			},
+			Expected: []string{
+				"kubectl --namespace cluster-test get cluster test --ignore-not-found -o name",
+				"kubectl --namespace cluster-test delete cluster test --ignore-not-found --wait=false",
+			},
+		},
+		{
+			Name: "rollout",
+			Run: func(c Client) error {
+				if err := c.RolloutRestart("kube-system", "deploy", "coredns"); err != nil {
+					return err
+				}
+				return c.RolloutStatus("kube-system", "deploy", "coredns", 3*time.Minute)
+			},
+			Expected: []string{
+				"kubectl --namespace kube-system rollout restart deploy coredns",
+				"kubectl --namespace kube-system rollout status deploy coredns --timeout=3m0s",
+			},
+		},
+		{
+			Name: "docker registry secret",
+			Run: func(c Client) error {
+				return c.CreateSecret("kube-system", Secret{Name: "regcred", DockerRegistry: &DockerRegistryAuth{Server: "registry.example.com", Username: "user", Password: "pass"}})
+			},
+			Expected: []string{"kubectl --namespace kube-system apply -f -"},
+		},
+		{
+			Name: "namespace",
+			Run: func(c Client) error {
+				return c.CreateNamespace("cluster-test")
+			},
+			Expected: []string{"kubectl apply -f -"},
+		},
+		{
+			Name:       "helm upgrade",
+			Kubeconfig: "/kind/worker-cluster.kubeconfig",
+			Run: func(c Client) error {
+				return c.HelmUpgrade(HelmRelease{
+					Name:            "calico",
+					Chart:           "/stratio/helm/tigera-operator",
+					Namespace:       "tigera-operator",
+					CreateNamespace: true,
+					ValuesFiles:     []string{"/kind/calico-helm-values.yaml"},
+					Values:          map[string]string{"b": "2", "a": "1"},
+					Wait:            true,
+				})
+			},
+			Expected: []string{"helm upgrade --install calico /stratio/helm/tigera-operator --namespace tigera-operator --create-namespace --values /kind/calico-helm-values.yaml --set a=1 --set b=2 --wait --kubeconfig /kind/worker-cluster.kubeconfig"},
+		},
+		{
+			Name: "helm registry login",
+			Run: func(c Client) error {
+				return c.HelmRegistryLogin(HelmRepository{URL: "registry.example.com", Username: "user", Password: "pass", CAFile: "/kind/ca.crt"})
+			},
+			Expected: []string{"helm registry login registry.example.com --username user --password-stdin --ca-file /kind/ca.crt"},
+		},
+		{
+			Name: "helm repo add and pull",
+			Run: func(c Client) error {
+				repo := HelmRepository{Name: "stratio-helm-repo", URL: "https://charts.example.com", Username: "user", Password: "pass"}
+				if err := c.HelmRepoAdd(repo); err != nil {
+					return err
+				}
+				return c.HelmPull(repo, "stratio-helm-repo/cluster-operator", "0.2.0", "/stratio/helm")
+			},
+			Expected: []string{
+				"helm repo add stratio-helm-repo https://charts.example.com --force-update --username user --password-stdin",
+				"helm pull stratio-helm-repo/cluster-operator --version 0.2.0 --untar --untardir /stratio/helm",
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			c := NewFakeClient(tc.Kubeconfig)
+			assert.ExpectError(t, false, tc.Run(c))
+			assert.DeepEqual(t, tc.Expected, c.Commands)
+		})
+	}
+}
+
+func TestDockerRegistrySecret(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClient("")
+	err := c.CreateSecret("", Secret{Name: "regcred", DockerRegistry: &DockerRegistryAuth{Server: "registry.example.com", Username: "user", Password: "pass"}})
+	assert.ExpectError(t, false, err)
+	expected := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"regcred"},"stringData":{".dockerconfigjson":"{\"auths\":{\"registry.example.com\":{\"auth\":\"dXNlcjpwYXNz\",\"password\":\"pass\",\"username\":\"user\"}}}"},"type":"kubernetes.io/dockerconfigjson"}`
+	assert.StringEqual(t, expected, c.Stdins["kubectl apply -f -"])
+}
+
+func TestSecretFromFiles(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClient("")
+	dryRun := "kubectl --namespace cluster-test create secret generic worker-kubeconfig --from-file=value=/kind/worker-cluster.kubeconfig --dry-run=client -o json"
+	c.Outputs[dryRun] = `{"apiVersion":"v1","data":{"value":"a3ViZWNvbmZpZw=="},"kind":"Secret","metadata":{"name":"worker-kubeconfig"}}`
+	err := c.CreateSecret("cluster-test", Secret{
+		Name:  "worker-kubeconfig",
+		Files: map[string]string{"value": "/kind/worker-cluster.kubeconfig"},
+		Data:  map[string]string{"token": "s3cr3t"},
+	})
+	assert.ExpectError(t, false, err)
+	assert.DeepEqual(t, []string{dryRun, "kubectl --namespace cluster-test apply -f -"}, c.Commands)
+	expected := `{"apiVersion":"v1","data":{"value":"a3ViZWNvbmZpZw=="},"kind":"Secret","metadata":{"name":"worker-kubeconfig"},"stringData":{"token":"s3cr3t"}}`
+	assert.StringEqual(t, expected, c.Stdins["kubectl --namespace cluster-test apply -f -"])
+}
+
+func TestHelmPasswordFromStdin(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClient("")
+	err := c.HelmRepoAdd(HelmRepository{Name: "stratio-helm-repo", URL: "https://charts.example.com", Username: "user", Password: "s3cr3t"})
+	assert.ExpectError(t, false, err)
+	assert.StringEqual(t, "s3cr3t", c.Stdins["helm repo add stratio-helm-repo https://charts.example.com --force-update --username user --password-stdin"])
+}
+
+func TestClientRetries(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name           string
+		Err            error
+		Run            func(c Client) error
+		ExpectedReason Reason
+		ExpectedRuns   int
+	}{
+		{
+			Name:           "idempotent commands are retried",
+			Err:            &CommandError{ExitCode: 1},
+			Run:            func(c Client) error { return c.ApplyFile("", "manifest.yaml") },
+			ExpectedReason: ReasonFailed,
+			ExpectedRuns:   3,
+		},
+		{
+			Name:           "other commands are not retried",
+			Err:            &CommandError{ExitCode: 1},
+			Run:            func(c Client) error { return c.CreateFile("", "manifest.yaml") },
+			ExpectedReason: ReasonFailed,
+			ExpectedRuns:   1,
+		},
+		{
+			Name:           "unavailable node is retried",
+			Err:            errors.New("container is not running"),
+			Run:            func(c Client) error { return c.CreateNamespace("test") },
+			ExpectedReason: ReasonUnavailable,
+			ExpectedRuns:   3,
+		},
+		{
+			Name:           "missing binary is not retried",
+			Err:            &CommandError{ExitCode: 127},
+			Run:            func(c Client) error { return c.ApplyFile("", "manifest.yaml") },
+			ExpectedReason: ReasonNotInstalled,
+			ExpectedRuns:   1,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			r := &failingRunner{err: tc.Err}
+			c := &client{runner: r, attempts: 3}
+			err := tc.Run(c)
+			assert.ExpectError(t, true, err)
+			assert.StringEqual(t, string(tc.ExpectedReason), string(ReasonForError(errors.Wrap(err, "wrapped"))))
+			if r.runs != tc.ExpectedRuns {
+				t.Errorf("expected %d runs but got %d", tc.ExpectedRuns, r.runs)
+			}
+		})
+	}
+}
+
+func TestStderrIsNotOutput(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClient("")
+	get := "kubectl --namespace cluster-test get cluster test -o json"
+	c.Outputs[get] = `{"kind":"Cluster"}`
+	c.Stderrs[get] = "Warning: v1beta1 Cluster is deprecated\n"
+	output, err := c.Get("cluster-test", "cluster", "test", "json")
+	assert.ExpectError(t, false, err)
+	assert.StringEqual(t, `{"kind":"Cluster"}`, output)
+
+	// the stderr is only reported within the error
+	c.Errors[get] = &CommandError{ExitCode: 1}
+	c.Outputs[get] = ""
+	c.Stderrs[get] = "Error from server (NotFound): clusters \"test\" not found\n"
+	_, err = c.Get("cluster-test", "cluster", "test", "json")
+	assert.ExpectError(t, true, err)
+	assert.StringEqual(t, `command "kubectl get" failed with exit code 1: Error from server (NotFound): clusters "test" not found`, err.Error())
+}
+
+func TestHelmUninstallMissingRelease(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClient("")
+	uninstall := "helm uninstall cluster-operator --namespace kube-system"
+	c.Errors[uninstall] = &CommandError{ExitCode: 1}
+	c.Stderrs[uninstall] = "Error: uninstall: Release not loaded: cluster-operator: release: not found\n"
+	assert.ExpectError(t, false, c.HelmUninstall("kube-system", "cluster-operator"))
+
+	c.Stderrs[uninstall] = "Error: Kubernetes cluster unreachable\n"
+	assert.ExpectError(t, true, c.HelmUninstall("kube-system", "cluster-operator"))
+}
+
+func TestCommandErrorHidesArguments(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClient("")
+	c.Errors["helm install cluster-operator /stratio/helm/cluster-operator --set secret=s3cr3t"] = &CommandError{ExitCode: 1}
+	c.Outputs["helm install cluster-operator /stratio/helm/cluster-operator --set secret=s3cr3t"] = "Error: timed out\n"
+	err := c.HelmInstall(HelmRelease{Name: "cluster-operator", Chart: "/stratio/helm/cluster-operator", Values: map[string]string{"secret": "s3cr3t"}})
+	assert.ExpectError(t, true, err)
+	assert.StringEqual(t, `command "helm install" failed with exit code 1: Error: timed out`, err.Error())
+}
+
+type failingRunner struct {
+	err  error
+	runs int
+}
+
+func (r *failingRunner) run(stdin string, name string, args []string) (string, string, error) {
+	r.runs++
+	return "", "", r.err
+}
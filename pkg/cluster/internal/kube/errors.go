@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	osexec "os/exec"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// Reason classifies a command failure from its exit code
+type Reason string
+
+const (
+	// ReasonUnavailable means the command could not be run in the node
+	ReasonUnavailable Reason = "Unavailable"
+	// ReasonNotInstalled means the command was not found or is not executable
+	ReasonNotInstalled Reason = "NotInstalled"
+	// ReasonFailed means the command ran and exited with a non zero code
+	ReasonFailed Reason = "Failed"
+)
+
+// CommandError is returned when a kubectl or helm command fails
+type CommandError struct {
+	// Name is the command, kubectl or helm
+	Name string
+	// Verb is the subcommand, e.g. apply or rollout status
+	Verb string
+	// ExitCode is the exit code of the command, or -1 if it could not be run
+	ExitCode int
+	// Output is the stdout of the command followed by its stderr
+	Output string
+}
+
+var _ error = &CommandError{}
+
+// Error does not include the command arguments as they may contain secrets
+func (e *CommandError) Error() string {
+	msg := "command \"" + e.Name + " " + e.Verb + "\" "
+	if e.ExitCode < 0 {
+		msg += "could not be run"
+	} else {
+		msg += "failed with exit code " + strconv.Itoa(e.ExitCode)
+	}
+	if output := strings.TrimSpace(e.Output); output != "" {
+		msg += ": " + output
+	}
+	return msg
+}
+
+// Reason returns the classification of the failure
+func (e *CommandError) Reason() Reason {
+	switch e.ExitCode {
+	case -1:
+		return ReasonUnavailable
+	case 126, 127:
+		return ReasonNotInstalled
+	}
+	return ReasonFailed
+}
+
+// ReasonForError returns the Reason of the CommandError in err, or
+// ReasonFailed if there is none
+func ReasonForError(err error) Reason {
+	for err != nil {
+		if cmdErr, ok := err.(*CommandError); ok {
+			return cmdErr.Reason()
+		}
+		causer, ok := err.(errors.Causer)
+		if !ok {
+			break
+		}
+		if cause := causer.Cause(); cause != err {
+			err = cause
+		} else {
+			break
+		}
+	}
+	return ReasonFailed
+}
+
+// exitCode returns the exit code of the command failing with err, or -1 if
+// the command could not be run
+func exitCode(err error) int {
+	if cmdErr, ok := err.(*CommandError); ok {
+		return cmdErr.ExitCode
+	}
+	if runErr := exec.RunErrorForError(err); runErr != nil {
+		if exitErr, ok := runErr.Inner.(*osexec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		// a missing local command is reported as the shell does
+		if execErr, ok := runErr.Inner.(*osexec.Error); ok && execErr.Err == osexec.ErrNotFound {
+			return 127
+		}
+	}
+	return -1
+}
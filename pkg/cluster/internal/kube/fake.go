@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"strings"
+)
+
+// FakeClient is a Client recording the commands instead of running them,
+// for unit testing the code using a Client
+type FakeClient struct {
+	client
+	// Commands are the recorded command lines, e.g. "kubectl apply -f -"
+	Commands []string
+	// Stdins are the recorded inputs of the commands, keyed by command line
+	Stdins map[string]string
+	// Outputs are the outputs to be returned, keyed by command line
+	Outputs map[string]string
+	// Stderrs are the stderr outputs to be returned, keyed by command line
+	Stderrs map[string]string
+	// Errors are the errors to be returned, keyed by command line. A
+	// CommandError sets the exit code of the command
+	Errors map[string]error
+}
+
+var _ Client = &FakeClient{}
+
+// NewFakeClient returns a FakeClient against the cluster of kubeconfig.
+// The failing commands are not retried
+func NewFakeClient(kubeconfig string) *FakeClient {
+	f := &FakeClient{
+		Stdins:  map[string]string{},
+		Outputs: map[string]string{},
+		Stderrs: map[string]string{},
+		Errors:  map[string]error{},
+	}
+	f.client = client{runner: f, kubeconfig: kubeconfig, attempts: 1}
+	return f
+}
+
+func (f *FakeClient) run(stdin string, name string, args []string) (string, string, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+	f.Commands = append(f.Commands, line)
+	if stdin != "" {
+		f.Stdins[line] = stdin
+	}
+	return f.Outputs[line], f.Stderrs[line], f.Errors[line]
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mirror implements copying the images required by the workload
+// cluster into the keos registry, for the private installations
+package mirror
+
+import (
+	"sigs.k8s.io/kind/pkg/cluster/internal/registry"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// MirrorParams holds the descriptor of the cluster whose images are copied
+type MirrorParams struct {
+	Logger             log.Logger
+	KeosCluster        commons.KeosCluster
+	ClusterCredentials commons.ClusterCredentials
+	// DryRun only checks which images are missing in the keos registry
+	DryRun bool
+	// Client copies the images, by default one with the credentials of the
+	// descriptor registries
+	Client *registry.Client
+}
+
+// Images copies the images required by the workload cluster into the keos
+// registry, reporting the result of each one. The copy of the rest of images
+// goes on if one fails
+func Images(params *MirrorParams) ([]commons.MirroredImage, error) {
+	spec := params.KeosCluster.Spec
+	keosRegistry, err := registry.KeosRegistry(spec)
+	if err != nil {
+		return nil, err
+	}
+	images, err := registry.RequiredImages(spec)
+	if err != nil {
+		return nil, err
+	}
+	client := params.Client
+	if client == nil {
+		credentials, err := registry.DescriptorCredentials(spec, params.ClusterCredentials)
+		if err != nil {
+			return nil, err
+		}
+		client = registry.NewClient(commons.HTTPClient(), credentials)
+	}
+
+	report := []commons.MirroredImage{}
+	for _, src := range images {
+		dst := src.InRegistry(keosRegistry.URL)
+		image := commons.MirroredImage{Source: src.String(), Destination: dst.String()}
+		if params.DryRun {
+			exists, err := client.Exists(dst)
+			image.Result = commons.MirrorMissing
+			if err != nil {
+				image.Result, image.Error = commons.MirrorFailed, err.Error()
+			} else if exists {
+				image.Result = commons.MirrorPresent
+			}
+		} else {
+			copied, err := client.Copy(src, dst)
+			image.Result = commons.MirrorPresent
+			if err != nil {
+				image.Result, image.Error = commons.MirrorFailed, err.Error()
+			} else if copied {
+				image.Result = commons.MirrorCopied
+			}
+		}
+		params.Logger.V(1).Infof("%s %s -> %s", image.Result, image.Source, image.Destination)
+		report = append(report, image)
+	}
+	return report, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/registry"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+func TestImagesDryRun(t *testing.T) {
+	t.Parallel()
+	keos := registry.NewFakeRegistry()
+	keos.PutManifest("keos/calico/node", "v3.26.1", "application/vnd.oci.image.manifest.v1+json", []byte("{}"))
+	server := httptest.NewServer(keos)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	keosCluster := commons.KeosCluster{}
+	keosCluster.Spec.InfraProvider = "docker"
+	keosCluster.Spec.DockerRegistries = []commons.DockerRegistry{
+		{URL: "registry.example.com", Type: "generic"},
+		{URL: host + "/keos", Type: "generic", KeosRegistry: true},
+	}
+	report, err := Images(&MirrorParams{
+		Logger:      log.NoopLogger{},
+		KeosCluster: keosCluster,
+		DryRun:      true,
+	})
+	assert.ExpectError(t, false, err)
+
+	required, err := registry.RequiredImages(keosCluster.Spec)
+	assert.ExpectError(t, false, err)
+	assert.DeepEqual(t, len(required), len(report))
+	results := map[string]string{}
+	for _, image := range report {
+		results[image.Destination] = image.Result
+	}
+	assert.StringEqual(t, commons.MirrorPresent, results[host+"/keos/calico/node:v3.26.1"])
+	assert.StringEqual(t, commons.MirrorMissing, results[host+"/keos/cluster-api/capd-manager:v1.5.3"])
+	for _, request := range keos.Requests {
+		if !strings.HasPrefix(request, "GET /v2/") && !strings.HasPrefix(request, "HEAD ") {
+			t.Errorf("unexpected request in a dry run: %s", request)
+		}
+	}
+}
+
+func TestImagesWithoutKeosRegistry(t *testing.T) {
+	t.Parallel()
+	keosCluster := commons.KeosCluster{}
+	keosCluster.Spec.InfraProvider = "docker"
+	_, err := Images(&MirrorParams{Logger: log.NoopLogger{}, KeosCluster: keosCluster, DryRun: true})
+	assert.ExpectError(t, true, err)
+}
@@ -20,9 +20,11 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"sigs.k8s.io/kind/pkg/commons"
 	"sigs.k8s.io/kind/pkg/errors"
 	"sigs.k8s.io/kind/pkg/exec"
 	"sigs.k8s.io/kind/pkg/log"
@@ -112,9 +114,40 @@ func ensureStratioImageFiles(logger log.Logger) (dir string, err error) {
 	return dir, nil
 }
 
+// stratioImageBuildArgs returns the build arguments of the Stratio Dockerfile
+// with the versions of the bill of materials
+func stratioImageBuildArgs(bom commons.BOM) []string {
+	versions := map[string]string{
+		"CLUSTERCTL":                 bom.ClusterAPI.Version,
+		"HELM":                       bom.Helm,
+		"CLOUD_PROVIDER_AWS_CHART":   bom.Charts["aws-cloud-controller-manager"],
+		"AWS_EBS_CSI_DRIVER_CHART":   bom.Charts["aws-ebs-csi-driver"],
+		"AZUREDISK_CSI_DRIVER_CHART": bom.Charts["azuredisk-csi-driver"],
+		"AZUREFILE_CSI_DRIVER_CHART": bom.Charts["azurefile-csi-driver"],
+		"CLOUD_PROVIDER_AZURE_CHART": bom.Charts["cloud-provider-azure"],
+		"CLUSTER_AUTOSCALER_CHART":   bom.Charts["cluster-autoscaler"],
+		"TIGERA_OPERATOR_CHART":      bom.Calico.Version,
+		"CERT_MANAGER_CHART_VERSION": bom.CertManager,
+		"CERT_MANAGER_CRDS_VERSION":  bom.CertManagerCRDs,
+	}
+	for _, name := range commons.InfraProviderNames() {
+		p, _ := commons.GetInfraProvider(name)
+		for _, arg := range p.BuildArgs {
+			versions[arg] = bom.InfraProviderVersion(name)
+		}
+	}
+	args := []string{}
+	for name, version := range versions {
+		args = append(args, "--build-arg="+name+"="+version)
+	}
+	sort.Strings(args)
+	return args
+}
+
 // buildStratioImage builds the stratio image
 func buildStratioImage(logger log.Logger, image string, path string) error {
-	cmd := exec.Command("docker", "build", "--tag="+image, path)
+	args := append([]string{"build", "--tag=" + image}, stratioImageBuildArgs(commons.GetBOM())...)
+	cmd := exec.Command("docker", append(args, path)...)
 	if err := cmd.Run(); err != nil {
 		return errors.Wrapf(err, "failed to build image %q", image)
 	}
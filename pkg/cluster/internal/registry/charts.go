@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// Chart is a helm chart version
+type Chart struct {
+	Name    string
+	Version string
+}
+
+func (c Chart) String() string {
+	return c.Name + " " + c.Version
+}
+
+// RequiredCharts returns the charts installed from the helm repository of
+// the descriptor, the rest are shipped in the Stratio image
+func RequiredCharts() []Chart {
+	return []Chart{
+		{Name: "cluster-operator", Version: commons.GetBOM().ClusterOperator.Chart},
+	}
+}
+
+// ChartsCheckable returns whether the charts of the helm repository at url
+// can be checked: the oci and http(s) repositories can, the ones served
+// through a helm plugin (e.g. s3) can't
+func ChartsCheckable(url string) bool {
+	for _, scheme := range []string{"oci://", "http://", "https://"} {
+		if strings.HasPrefix(url, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChartExists returns whether the helm repository at url has the chart: in
+// its index.yaml for the http(s) repositories or as a tag of its repository
+// for the oci ones
+func (c *Client) ChartExists(url string, chart Chart) (bool, error) {
+	if !ChartsCheckable(url) {
+		return false, errors.Errorf("the charts of the helm repository %s can't be checked", url)
+	}
+	if strings.HasPrefix(url, "oci://") {
+		name := strings.TrimSuffix(strings.TrimPrefix(url, "oci://"), "/") + "/" + chart.Name
+		return c.Exists(Reference{
+			Registry:   Host(name),
+			Repository: strings.SplitN(name, "/", 2)[1],
+			// helm replaces the + of the semver build metadata in the tags
+			Tag: strings.ReplaceAll(chart.Version, "+", "_"),
+		})
+	}
+
+	index, err := c.helmIndex(url)
+	if err != nil {
+		return false, err
+	}
+	for _, version := range index.Entries[chart.Name] {
+		if version.Version == chart.Version {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type helmIndex struct {
+	Entries map[string][]struct {
+		Version string `yaml:"version"`
+	} `yaml:"entries"`
+}
+
+func (c *Client) helmIndex(url string) (helmIndex, error) {
+	index := helmIndex{}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(url, "/")+"/index.yaml", nil)
+	if err != nil {
+		return index, err
+	}
+	if credentials, ok := c.credentials[req.URL.Host]; ok {
+		req.SetBasicAuth(credentials.User, credentials.Pass)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return index, errors.Wrapf(err, "failed to reach the helm repository %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return index, responseError(resp, "failed to get the index of the helm repository "+url)
+	}
+	if err := yaml.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return index, errors.Wrapf(err, "failed to parse the index of the helm repository %s", url)
+	}
+	return index, nil
+}
@@ -0,0 +1,423 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// manifestMediaTypes are the accepted manifests: the docker and OCI images and
+// their multi-platform indexes
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// Credentials are the user and password of a registry
+type Credentials struct {
+	User string
+	Pass string
+}
+
+// Client copies images between registries through their v2 API
+type Client struct {
+	httpClient *http.Client
+	// credentials are the credentials of the registries, by host
+	credentials map[string]Credentials
+
+	mu sync.Mutex
+	// authorizations are the Authorization headers by host and scope
+	authorizations map[string]string
+}
+
+// NewClient returns a client authenticating against the registries with
+// their credentials, by host, and anonymously against the rest
+func NewClient(httpClient *http.Client, credentials map[string]Credentials) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		httpClient:     httpClient,
+		credentials:    credentials,
+		authorizations: map[string]string{},
+	}
+}
+
+// manifest holds the fields of the image manifests and indexes needed to copy
+// them
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    *descriptor  `json:"config,omitempty"`
+	Layers    []descriptor `json:"layers,omitempty"`
+	Manifests []descriptor `json:"manifests,omitempty"`
+}
+
+type descriptor struct {
+	MediaType string   `json:"mediaType"`
+	Digest    string   `json:"digest"`
+	Size      int64    `json:"size"`
+	URLs      []string `json:"urls,omitempty"`
+}
+
+// Exists returns whether the manifest of ref is in its registry
+func (c *Client) Exists(ref Reference) (bool, error) {
+	digest, err := c.headManifest(ref, ref.manifestReference())
+	return digest != "", err
+}
+
+// Copy copies the image src, with every platform of its index, into dst. It
+// returns false if dst was already the same image
+func (c *Client) Copy(src Reference, dst Reference) (bool, error) {
+	raw, mediaType, digest, err := c.getManifest(src, src.manifestReference())
+	if err != nil {
+		return false, err
+	}
+	current, err := c.headManifest(dst, dst.manifestReference())
+	if err != nil {
+		return false, err
+	}
+	if current != "" && current == digest {
+		return false, nil
+	}
+	return true, c.copyManifest(src, dst, dst.manifestReference(), raw, mediaType)
+}
+
+// copyManifest copies the blobs or the child manifests of the manifest raw of
+// src and then puts it in dst as reference
+func (c *Client) copyManifest(src Reference, dst Reference, reference string, raw []byte, mediaType string) error {
+	m := manifest{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return errors.Wrapf(err, "failed to parse the manifest of %s", src)
+	}
+	for _, child := range m.Manifests {
+		childRaw, childMediaType, _, err := c.getManifest(src, child.Digest)
+		if err != nil {
+			return err
+		}
+		if err := c.copyManifest(src, dst, child.Digest, childRaw, childMediaType); err != nil {
+			return err
+		}
+	}
+	blobs := m.Layers
+	if m.Config != nil {
+		blobs = append([]descriptor{*m.Config}, blobs...)
+	}
+	for _, blob := range blobs {
+		// the non distributable layers (e.g. windows base layers) are
+		// downloaded from their URLs instead of the registry
+		if len(blob.URLs) > 0 || strings.Contains(blob.MediaType, "foreign") {
+			continue
+		}
+		if err := c.copyBlob(src, dst, blob); err != nil {
+			return err
+		}
+	}
+	return c.putManifest(dst, reference, raw, mediaType)
+}
+
+func (c *Client) getManifest(ref Reference, reference string) ([]byte, string, string, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint(ref.Registry, ref.Repository, "manifests", reference), nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	resp, err := c.do(req, ref, "pull")
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", "", responseError(resp, "failed to get the manifest of "+ref.String())
+	}
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", errors.Wrapf(err, "failed to read the manifest of %s", ref)
+	}
+	return raw, resp.Header.Get("Content-Type"), resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// headManifest returns the digest of the manifest reference of the
+// repository of ref, empty if it is not found
+func (c *Client) headManifest(ref Reference, reference string) (string, error) {
+	req, err := http.NewRequest(http.MethodHead, endpoint(ref.Registry, ref.Repository, "manifests", reference), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	resp, err := c.do(req, ref, "pull")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		digest := resp.Header.Get("Docker-Content-Digest")
+		if digest == "" {
+			// some registries do not report it, take reference as found
+			digest = reference
+		}
+		return digest, nil
+	case http.StatusNotFound:
+		return "", nil
+	}
+	return "", responseError(resp, "failed to check the manifest of "+ref.String())
+}
+
+func (c *Client) putManifest(ref Reference, reference string, raw []byte, mediaType string) error {
+	req, err := http.NewRequest(http.MethodPut, endpoint(ref.Registry, ref.Repository, "manifests", reference), strings.NewReader(string(raw)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	resp, err := c.do(req, ref, "pull,push")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return responseError(resp, "failed to put the manifest of "+ref.String())
+	}
+	return nil
+}
+
+// copyBlob streams the blob from src into dst, unless dst already has it
+func (c *Client) copyBlob(src Reference, dst Reference, blob descriptor) error {
+	req, err := http.NewRequest(http.MethodHead, endpoint(dst.Registry, dst.Repository, "blobs", blob.Digest), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req, dst, "pull,push")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	req, err = http.NewRequest(http.MethodGet, endpoint(src.Registry, src.Repository, "blobs", blob.Digest), nil)
+	if err != nil {
+		return err
+	}
+	blobResp, err := c.do(req, src, "pull")
+	if err != nil {
+		return err
+	}
+	defer blobResp.Body.Close()
+	if blobResp.StatusCode != http.StatusOK {
+		return responseError(blobResp, "failed to get the blob "+blob.Digest+" of "+src.String())
+	}
+
+	req, err = http.NewRequest(http.MethodPost, endpoint(dst.Registry, dst.Repository, "blobs", "uploads/"), nil)
+	if err != nil {
+		return err
+	}
+	resp, err = c.do(req, dst, "pull,push")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return responseError(resp, "failed to start the upload of the blob "+blob.Digest+" to "+dst.String())
+	}
+	location, err := req.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return errors.Wrapf(err, "invalid upload location of the blob %s", blob.Digest)
+	}
+	query := location.Query()
+	query.Set("digest", blob.Digest)
+	location.RawQuery = query.Encode()
+
+	req, err = http.NewRequest(http.MethodPut, location.String(), blobResp.Body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = blobResp.ContentLength
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = c.do(req, dst, "pull,push")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return responseError(resp, "failed to upload the blob "+blob.Digest+" to "+dst.String())
+	}
+	return nil
+}
+
+// do sends req to the registry of ref, authorized to perform actions on its
+// repository. The request is retried once with a new authorization if the
+// current one expired and its body can be sent again
+func (c *Client) do(req *http.Request, ref Reference, actions string) (*http.Response, error) {
+	scope := "repository:" + ref.Repository + ":" + actions
+	for retry := 0; ; retry++ {
+		authorization, err := c.authorize(ref.Registry, scope)
+		if err != nil {
+			return nil, err
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to reach the registry %s", ref.Registry)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || authorization == "" || retry > 0 || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		resp.Body.Close()
+		c.mu.Lock()
+		delete(c.authorizations, ref.Registry+" "+scope)
+		c.mu.Unlock()
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authorize returns the Authorization header for scope in the registry,
+// answering the challenge of its /v2/ endpoint: none, Basic with the
+// credentials or a Bearer token of its token service
+func (c *Client) authorize(registry string, scope string) (string, error) {
+	key := registry + " " + scope
+	c.mu.Lock()
+	authorization, ok := c.authorizations[key]
+	c.mu.Unlock()
+	if ok {
+		return authorization, nil
+	}
+
+	resp, err := c.httpClient.Get(baseURL(registry) + "/v2/")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to reach the registry %s", registry)
+	}
+	resp.Body.Close()
+	credentials, withCredentials := c.credentials[registry]
+	challenge := resp.Header.Get("WWW-Authenticate")
+	switch {
+	case resp.StatusCode != http.StatusUnauthorized:
+		authorization = ""
+	case strings.HasPrefix(strings.ToLower(challenge), "basic"):
+		if !withCredentials {
+			return "", errors.Errorf("the registry %s requires credentials", registry)
+		}
+		authorization = "Basic " + basicAuth(credentials)
+	case strings.HasPrefix(strings.ToLower(challenge), "bearer"):
+		params := map[string]string{}
+		for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+			params[match[1]] = match[2]
+		}
+		token, err := c.token(params["realm"], params["service"], scope, credentials, withCredentials)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to authenticate against the registry %s", registry)
+		}
+		authorization = "Bearer " + token
+	default:
+		return "", errors.Errorf("unsupported authentication challenge of the registry %s: %q", registry, challenge)
+	}
+
+	c.mu.Lock()
+	c.authorizations[key] = authorization
+	c.mu.Unlock()
+	return authorization, nil
+}
+
+// token requests a token for scope to the token service at realm
+func (c *Client) token(realm string, service string, scope string, credentials Credentials, withCredentials bool) (string, error) {
+	tokenURL, err := url.Parse(realm)
+	if err != nil || realm == "" {
+		return "", errors.Errorf("invalid token realm %q", realm)
+	}
+	query := tokenURL.Query()
+	if service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+	req, err := http.NewRequest(http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if withCredentials {
+		req.SetBasicAuth(credentials.User, credentials.Pass)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp, "failed to get a token")
+	}
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", errors.Wrap(err, "failed to parse the token")
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+func basicAuth(credentials Credentials) string {
+	return base64.StdEncoding.EncodeToString([]byte(credentials.User + ":" + credentials.Pass))
+}
+
+// baseURL returns the URL of the registry, served over plain http only if it
+// is local, as docker does
+func baseURL(registry string) string {
+	if registry == dockerHub {
+		registry = dockerHubAPI
+	}
+	host := registry
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	if host == "localhost" || host == "127.0.0.1" || host == "[::1]" {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
+
+func endpoint(registry string, repository string, kind string, reference string) string {
+	return baseURL(registry) + "/v2/" + repository + "/" + kind + "/" + reference
+}
+
+// responseError returns an error with the status and the body of resp, where
+// the registries explain the failure
+func responseError(resp *http.Response, message string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Errorf("%s: %s %s", message, resp.Status, strings.TrimSpace(string(body)))
+}
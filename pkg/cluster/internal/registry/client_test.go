@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+const (
+	indexMediaType    = "application/vnd.oci.image.index.v1+json"
+	manifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// pushImage stores in the fake registry an index of two platforms sharing a
+// layer, tagged as tag in repository
+func pushImage(f *FakeRegistry, repository string, tag string) string {
+	layer := f.PutBlob(repository, []byte("shared layer"))
+	platforms := []string{}
+	for _, arch := range []string{"amd64", "arm64"} {
+		config := f.PutBlob(repository, []byte(`{"architecture":"`+arch+`"}`))
+		m := `{"schemaVersion":2,"mediaType":"` + manifestMediaType + `",` +
+			`"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"` + config + `","size":1},` +
+			`"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"` + layer + `","size":1},` +
+			`{"mediaType":"application/vnd.docker.image.rootfs.foreign.diff.tar.gzip","digest":"sha256:0000","size":1,"urls":["https://example.com/layer"]}]}`
+		digest := f.PutManifest(repository, "", manifestMediaType, []byte(m))
+		platforms = append(platforms, `{"mediaType":"`+manifestMediaType+`","digest":"`+digest+`","size":1}`)
+	}
+	index := `{"schemaVersion":2,"mediaType":"` + indexMediaType + `","manifests":[` + strings.Join(platforms, ",") + `]}`
+	return f.PutManifest(repository, tag, indexMediaType, []byte(index))
+}
+
+func TestClientCopy(t *testing.T) {
+	t.Parallel()
+	source := NewFakeRegistry()
+	digest := pushImage(source, "calico/node", "v3.26.1")
+	sourceServer := httptest.NewServer(source)
+	defer sourceServer.Close()
+
+	target := NewFakeRegistry()
+	target.Credentials = Credentials{User: "keos", Pass: "secret"}
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+	targetHost := strings.TrimPrefix(targetServer.URL, "http://")
+
+	client := NewClient(nil, map[string]Credentials{targetHost: {User: "keos", Pass: "secret"}})
+	src, err := ParseReference(strings.TrimPrefix(sourceServer.URL, "http://") + "/calico/node:v3.26.1")
+	assert.ExpectError(t, false, err)
+	dst := src.InRegistry(targetHost + "/keos")
+
+	exists, err := client.Exists(dst)
+	assert.ExpectError(t, false, err)
+	assert.BoolEqual(t, false, exists)
+
+	copied, err := client.Copy(src, dst)
+	assert.ExpectError(t, false, err)
+	assert.BoolEqual(t, true, copied)
+	assert.StringEqual(t, digest, Digest(target.Manifests["keos/calico/node:v3.26.1"].Content))
+	assert.StringEqual(t, indexMediaType, target.Manifests["keos/calico/node:v3.26.1"].MediaType)
+	// two configs and the shared layer, but not the foreign one
+	assert.DeepEqual(t, 3, len(target.Blobs))
+	for key := range source.Blobs {
+		if _, ok := target.Blobs["keos/"+key]; !ok {
+			t.Errorf("blob %s was not copied", key)
+		}
+	}
+
+	exists, err = client.Exists(dst)
+	assert.ExpectError(t, false, err)
+	assert.BoolEqual(t, true, exists)
+
+	// copying it again does not upload anything
+	uploads := len(target.Requests)
+	copied, err = client.Copy(src, dst)
+	assert.ExpectError(t, false, err)
+	assert.BoolEqual(t, false, copied)
+	for _, request := range target.Requests[uploads:] {
+		if strings.HasPrefix(request, "PUT ") || strings.HasPrefix(request, "POST ") {
+			t.Errorf("unexpected request copying an image already present: %s", request)
+		}
+	}
+}
+
+func TestClientCopyErrors(t *testing.T) {
+	t.Parallel()
+	source := NewFakeRegistry()
+	pushImage(source, "calico/node", "v3.26.1")
+	sourceServer := httptest.NewServer(source)
+	defer sourceServer.Close()
+	target := NewFakeRegistry()
+	target.Credentials = Credentials{User: "keos", Pass: "secret"}
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+	targetHost := strings.TrimPrefix(targetServer.URL, "http://")
+
+	src, err := ParseReference(strings.TrimPrefix(sourceServer.URL, "http://") + "/calico/node:v3.26.0")
+	assert.ExpectError(t, false, err)
+	_, err = NewClient(nil, nil).Copy(src, src.InRegistry(targetHost))
+	assert.ExpectError(t, true, err)
+
+	src.Tag = "v3.26.1"
+	_, err = NewClient(nil, nil).Copy(src, src.InRegistry(targetHost))
+	assert.ExpectError(t, true, err)
+	assert.BoolEqual(t, true, strings.Contains(err.Error(), "requires credentials"))
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// Host returns the host of a registry url (host[/path])
+func Host(url string) string {
+	return strings.SplitN(url, "/", 2)[0]
+}
+
+// KeosRegistry returns the docker registry of spec holding the keos images
+func KeosRegistry(spec commons.KeosSpec) (commons.DockerRegistry, error) {
+	for _, dockerRegistry := range spec.DockerRegistries {
+		if dockerRegistry.KeosRegistry {
+			return dockerRegistry, nil
+		}
+	}
+	return commons.DockerRegistry{}, errors.New("there isn't any docker_registry defined as keos_registry")
+}
+
+// DescriptorCredentials returns the credentials of the docker registries of
+// spec needing them, by host
+func DescriptorCredentials(spec commons.KeosSpec, clusterCredentials commons.ClusterCredentials) (map[string]Credentials, error) {
+	credentials := map[string]Credentials{}
+	for _, dockerRegistry := range spec.DockerRegistries {
+		user, pass, err := commons.DockerRegistryAuth(dockerRegistry, clusterCredentials)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the credentials of the registry %s", dockerRegistry.URL)
+		}
+		if user != "" || pass != "" {
+			credentials[Host(dockerRegistry.URL)] = Credentials{User: user, Pass: pass}
+		}
+	}
+	return credentials, nil
+}
+
+// HelmRepositoryHost returns the host of the helm repository url, either
+// http(s):// or oci://
+func HelmRepositoryHost(url string) string {
+	for _, scheme := range []string{"oci://", "https://", "http://"} {
+		url = strings.TrimPrefix(url, scheme)
+	}
+	return Host(url)
+}
+
+// HelmRepositoryCredentials returns the credentials of the helm repository
+// of spec, if any: the ones of the secrets file for the generic repositories
+// and the ones obtained from the provider credentials for the cloud ones
+func HelmRepositoryCredentials(spec commons.KeosSpec, clusterCredentials commons.ClusterCredentials) (Credentials, bool, error) {
+	helmRepository := spec.HelmRepository
+	if helmRepository.Type != "" && helmRepository.Type != "generic" {
+		url := strings.TrimPrefix(helmRepository.URL, "oci://")
+		user, pass, err := commons.GetRegistryCredentials(helmRepository.Type, clusterCredentials.ProviderCredentials, url)
+		if err != nil {
+			return Credentials{}, false, errors.Wrapf(err, "failed to get the credentials of the helm repository %s", helmRepository.URL)
+		}
+		return Credentials{User: user, Pass: pass}, true, nil
+	}
+	if len(clusterCredentials.HelmRepositoryCredentials) == 0 {
+		return Credentials{}, false, nil
+	}
+	return Credentials{
+		User: clusterCredentials.HelmRepositoryCredentials["User"],
+		Pass: clusterCredentials.HelmRepositoryCredentials["Pass"],
+	}, true, nil
+}
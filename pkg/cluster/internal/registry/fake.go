@@ -0,0 +1,190 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FakeRegistry is an in-memory registry serving the subset of the v2 API
+// used by the Client, for unit testing it as a stand-in of registry:2 with
+// httptest.NewServer
+type FakeRegistry struct {
+	mu sync.Mutex
+	// Blobs are the stored blobs, keyed by repository and digest
+	Blobs map[string][]byte
+	// Manifests are the stored manifests, keyed by repository and tag or
+	// digest
+	Manifests map[string]FakeManifest
+	// Credentials are the required credentials, anonymous access if empty
+	Credentials Credentials
+	// Requests are the recorded requests, e.g. "PUT /v2/calico/node/manifests/v3.26.1"
+	Requests []string
+
+	uploads int
+}
+
+// FakeManifest is a manifest stored in a FakeRegistry
+type FakeManifest struct {
+	MediaType string
+	Content   []byte
+}
+
+// NewFakeRegistry returns an empty FakeRegistry
+func NewFakeRegistry() *FakeRegistry {
+	return &FakeRegistry{
+		Blobs:     map[string][]byte{},
+		Manifests: map[string]FakeManifest{},
+	}
+}
+
+// Digest returns the sha256 digest of content
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// PutBlob stores a blob in repository and returns its digest
+func (f *FakeRegistry) PutBlob(repository string, content []byte) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	digest := Digest(content)
+	f.Blobs[repository+"@"+digest] = content
+	return digest
+}
+
+// PutManifest stores a manifest in repository as tag and as its digest, and
+// returns the digest
+func (f *FakeRegistry) PutManifest(repository string, tag string, mediaType string, content []byte) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	digest := Digest(content)
+	f.Manifests[repository+"@"+digest] = FakeManifest{MediaType: mediaType, Content: content}
+	if tag != "" {
+		f.Manifests[repository+":"+tag] = FakeManifest{MediaType: mediaType, Content: content}
+	}
+	return digest
+}
+
+func (f *FakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Requests = append(f.Requests, r.Method+" "+r.URL.Path)
+
+	if f.Credentials != (Credentials{}) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != f.Credentials.User || pass != f.Credentials.Pass {
+			w.Header().Set("WWW-Authenticate", `Basic realm="fake"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	if r.URL.Path == "/v2/" {
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	for _, kind := range []string{"/manifests/", "/blobs/uploads/", "/blobs/"} {
+		i := strings.LastIndex(path, kind)
+		if i < 0 {
+			continue
+		}
+		repository, reference := path[:i], path[i+len(kind):]
+		switch kind {
+		case "/manifests/":
+			f.serveManifest(w, r, repository, reference)
+		case "/blobs/uploads/":
+			f.serveUpload(w, r, repository, reference)
+		case "/blobs/":
+			f.serveBlob(w, r, repository, reference)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func manifestKey(repository string, reference string) string {
+	if strings.Contains(reference, ":") {
+		return repository + "@" + reference
+	}
+	return repository + ":" + reference
+}
+
+func (f *FakeRegistry) serveManifest(w http.ResponseWriter, r *http.Request, repository string, reference string) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		m, ok := f.Manifests[manifestKey(repository, reference)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", m.MediaType)
+		w.Header().Set("Docker-Content-Digest", Digest(m.Content))
+		w.Header().Set("Content-Length", strconv.Itoa(len(m.Content)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(m.Content)
+		}
+	case http.MethodPut:
+		content, _ := io.ReadAll(r.Body)
+		m := FakeManifest{MediaType: r.Header.Get("Content-Type"), Content: content}
+		f.Manifests[repository+"@"+Digest(content)] = m
+		f.Manifests[manifestKey(repository, reference)] = m
+		w.Header().Set("Docker-Content-Digest", Digest(content))
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *FakeRegistry) serveBlob(w http.ResponseWriter, r *http.Request, repository string, digest string) {
+	content, ok := f.Blobs[repository+"@"+digest]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(content)
+	}
+}
+
+func (f *FakeRegistry) serveUpload(w http.ResponseWriter, r *http.Request, repository string, upload string) {
+	switch {
+	case r.Method == http.MethodPost && upload == "":
+		f.uploads++
+		w.Header().Set("Location", "/v2/"+repository+"/blobs/uploads/"+strconv.Itoa(f.uploads)+"?state=fake")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && upload != "":
+		content, _ := io.ReadAll(r.Body)
+		digest := r.URL.Query().Get("digest")
+		if Digest(content) != digest || r.URL.Query().Get("state") != "fake" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.Blobs[repository+"@"+digest] = content
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"embed"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// imageLists are the lists of the images a private installation needs, by
+// infra provider (commons for all of them), also used by bin/registry-push.sh.
+// They are listed with the versions of the embedded bill of materials
+//
+//go:embed images/*/imagenes-*.txt
+var imageLists embed.FS
+
+// imageList is a list of images common to every infra provider and the
+// clusters needing it
+type imageList struct {
+	name   string
+	needed func(spec commons.KeosSpec) bool
+}
+
+func always(commons.KeosSpec) bool { return true }
+
+// withCalico returns true if the cluster needs Calico: it is the CNI of the
+// unmanaged clusters and the network policy engine of the managed ones
+// without their own CNI
+func withCalico(spec commons.KeosSpec) bool {
+	p, _ := commons.GetInfraProvider(spec.InfraProvider)
+	return !spec.ControlPlane.Managed || !p.ManagedCNI
+}
+
+var commonImageLists = []imageList{
+	{"kind", always},
+	{"capi", always},
+	{"cert-manager", always},
+	{"commons", always},
+	{"calico", withCalico},
+	{"tigera", withCalico},
+	// the machine pools are scaled by the managed autoscaler
+	{"cluster-autoscaler", func(spec commons.KeosSpec) bool {
+		return spec.DeployAutoscaler && !spec.IsMachinePool()
+	}},
+}
+
+// requiredImageLists returns the paths of the image lists needed by the
+// cluster of spec: the common ones and the ones of its infra provider
+func requiredImageLists(spec commons.KeosSpec) []string {
+	paths := []string{}
+	for _, list := range commonImageLists {
+		if list.needed(spec) {
+			paths = append(paths, "images/commons/imagenes-"+list.name+".txt")
+		}
+	}
+	p, ok := commons.GetInfraProvider(spec.InfraProvider)
+	if !ok {
+		return paths
+	}
+	lists := p.Images
+	if !spec.ControlPlane.Managed {
+		lists = append(append([]string{}, lists...), p.UnmanagedImages...)
+	}
+	for _, name := range lists {
+		paths = append(paths, "images/"+p.Name+"/imagenes-"+name+".txt")
+	}
+	return paths
+}
+
+func clusterAPIVersion(b commons.BOM) string { return b.ClusterAPI.Version }
+
+func certManagerVersion(b commons.BOM) string { return b.CertManager }
+
+func calicoVersion(b commons.BOM) string { return b.Calico.Version }
+
+// bomImages are the images whose tag is a version of the bill of materials,
+// by registry/repository, besides the controllers of the infra providers
+var bomImages = map[string]func(commons.BOM) string{
+	"registry.k8s.io/cluster-api/cluster-api-controller":           clusterAPIVersion,
+	"registry.k8s.io/cluster-api/kubeadm-bootstrap-controller":     clusterAPIVersion,
+	"registry.k8s.io/cluster-api/kubeadm-control-plane-controller": clusterAPIVersion,
+	"quay.io/jetstack/cert-manager-controller":                     certManagerVersion,
+	"quay.io/jetstack/cert-manager-cainjector":                     certManagerVersion,
+	"quay.io/jetstack/cert-manager-webhook":                        certManagerVersion,
+	"quay.io/jetstack/cert-manager-acmesolver":                     certManagerVersion,
+	"quay.io/jetstack/cert-manager-ctl":                            certManagerVersion,
+	"docker.io/calico/kube-controllers":                            calicoVersion,
+	"docker.io/calico/typha":                                       calicoVersion,
+	"docker.io/calico/node":                                        calicoVersion,
+	"docker.io/calico/csi":                                         calicoVersion,
+	"docker.io/calico/node-driver-registrar":                       calicoVersion,
+	"docker.io/calico/ctl":                                         calicoVersion,
+	"docker.io/calico/cni":                                         calicoVersion,
+	"docker.io/calico/pod2daemon-flexvol":                          calicoVersion,
+	"quay.io/tigera/operator":                                      func(b commons.BOM) string { return b.Calico.Operator },
+	"qa.int.stratio.com/stratio/cluster-operator":                  func(b commons.BOM) string { return b.ClusterOperator.Image },
+}
+
+// bomVersion returns the version of image (registry/repository) in the bill
+// of materials, if it is one of its images
+func bomVersion(image string) (func(commons.BOM) string, bool) {
+	if version, ok := bomImages[image]; ok {
+		return version, true
+	}
+	for _, name := range commons.InfraProviderNames() {
+		if p, _ := commons.GetInfraProvider(name); p.ControllerImage == image {
+			return func(b commons.BOM) string { return b.InfraProviderVersion(name) }, true
+		}
+	}
+	return nil, false
+}
+
+// bomReference returns ref with the tag of bom if it is an image of the bill
+// of materials listed with the tag of the embedded one. The rest of tags of
+// the lists (e.g. the images of other versions) are kept
+func bomReference(ref Reference, bom, defaults commons.BOM) Reference {
+	version, ok := bomVersion(ref.Registry + "/" + ref.Repository)
+	if !ok || ref.Tag != version(defaults) || ref.Tag == version(bom) {
+		return ref
+	}
+	ref.Tag = version(bom)
+	// the digest pins the image of the listed tag
+	ref.Digest = ""
+	return ref
+}
+
+// RequiredImages returns the images the workload cluster of spec needs in
+// its infra provider, control plane and autoscaler, with the versions of the
+// bill of materials, sorted and without duplicates
+func RequiredImages(spec commons.KeosSpec) ([]Reference, error) {
+	return requiredImages(spec, commons.GetBOM())
+}
+
+func requiredImages(spec commons.KeosSpec, bom commons.BOM) ([]Reference, error) {
+	defaults := commons.DefaultBOM()
+	seen := map[string]bool{}
+	refs := []Reference{}
+	for _, path := range requiredImageLists(spec) {
+		raw, err := imageLists.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the image list %s", path)
+		}
+		for _, line := range strings.Split(string(raw), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			ref, err := ParseReference(line)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid image list %s", path)
+			}
+			ref = bomReference(ref, bom, defaults)
+			if seen[ref.String()] {
+				continue
+			}
+			seen[ref.String()] = true
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].String() < refs[j].String()
+	})
+	return refs, nil
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestRequiredImages(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name       string
+		Spec       commons.KeosSpec
+		Managed    bool
+		Present    []string
+		NotPresent []string
+	}{
+		{
+			Name: "aws unmanaged",
+			Spec: commons.KeosSpec{InfraProvider: "aws"},
+			Present: []string{
+				"registry.k8s.io/cluster-api/cluster-api-controller:v1.5.3",
+				"registry.k8s.io/cluster-api-aws/cluster-api-aws-controller:v2.2.1",
+				"registry.k8s.io/provider-aws/cloud-controller-manager:v1.27.1",
+				"public.ecr.aws/ebs-csi-driver/aws-ebs-csi-driver:v1.20.0",
+				"docker.io/calico/node:v3.26.1",
+				"docker.io/kindest/node:v1.27.0",
+			},
+			NotPresent: []string{
+				"registry.k8s.io/autoscaling/cluster-autoscaler:v1.27.2",
+				"registry.k8s.io/cluster-api-gcp/cluster-api-gcp-controller:v1.4.0@sha256:742ebf999137f4ab83c83b408793e2c97a1311adebe3b0c0e7e7317a122f26df",
+			},
+		},
+		{
+			Name:    "eks with autoscaler",
+			Spec:    commons.KeosSpec{InfraProvider: "aws", DeployAutoscaler: true},
+			Managed: true,
+			Present: []string{
+				"registry.k8s.io/cluster-api-aws/cluster-api-aws-controller:v2.2.1",
+				"registry.k8s.io/autoscaling/cluster-autoscaler:v1.27.2",
+				"quay.io/tigera/operator:v1.30.5",
+			},
+			NotPresent: []string{
+				"registry.k8s.io/provider-aws/cloud-controller-manager:v1.27.1",
+				"public.ecr.aws/ebs-csi-driver/aws-ebs-csi-driver:v1.20.0",
+			},
+		},
+		{
+			Name:    "aks",
+			Spec:    commons.KeosSpec{InfraProvider: "azure", DeployAutoscaler: true},
+			Managed: true,
+			Present: []string{
+				"registry.k8s.io/cluster-api-azure/cluster-api-azure-controller:v1.11.4",
+				"qa.int.stratio.com/stratio/cluster-operator:0.2.0-SNAPSHOT",
+			},
+			NotPresent: []string{
+				"docker.io/calico/node:v3.26.1",
+				"registry.k8s.io/autoscaling/cluster-autoscaler:v1.27.2",
+				"mcr.microsoft.com/oss/kubernetes-csi/azuredisk-csi:v1.28.3",
+			},
+		},
+		{
+			Name: "gcp unmanaged",
+			Spec: commons.KeosSpec{InfraProvider: "gcp"},
+			Present: []string{
+				"registry.k8s.io/cluster-api-gcp/cluster-api-gcp-controller:v1.4.0@sha256:742ebf999137f4ab83c83b408793e2c97a1311adebe3b0c0e7e7317a122f26df",
+				"registry.k8s.io/cloud-provider-gcp/gcp-compute-persistent-disk-csi-driver:v1.10.1",
+			},
+			NotPresent: []string{
+				"registry.k8s.io/cluster-api-aws/cluster-api-aws-controller:v2.2.1",
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			tc.Spec.ControlPlane.Managed = tc.Managed
+			refs, err := RequiredImages(tc.Spec)
+			assert.ExpectError(t, false, err)
+			images := map[string]int{}
+			for _, ref := range refs {
+				images[ref.String()]++
+			}
+			for image, count := range images {
+				if count > 1 {
+					t.Errorf("%s is required %d times", image, count)
+				}
+			}
+			for _, image := range tc.Present {
+				assert.BoolEqual(t, true, images[image] == 1)
+			}
+			for _, image := range tc.NotPresent {
+				assert.BoolEqual(t, false, images[image] > 0)
+			}
+		})
+	}
+}
+
+func TestRequiredImagesOverriddenBOM(t *testing.T) {
+	t.Parallel()
+	bom := commons.DefaultBOM()
+	bom.ClusterAPI.Version = "v1.6.0"
+	bom.ClusterAPI.Providers["gcp"] = "v1.5.0"
+	bom.CertManager = "v1.13.1"
+	bom.Calico.Version = "v3.27.0"
+	bom.ClusterOperator.Image = "0.3.0"
+	refs, err := requiredImages(commons.KeosSpec{InfraProvider: "gcp"}, bom)
+	assert.ExpectError(t, false, err)
+	images := map[string]bool{}
+	for _, ref := range refs {
+		images[ref.String()] = true
+	}
+	for _, image := range []string{
+		"registry.k8s.io/cluster-api/cluster-api-controller:v1.6.0",
+		"registry.k8s.io/cluster-api-gcp/cluster-api-gcp-controller:v1.5.0",
+		"quay.io/jetstack/cert-manager-controller:v1.13.1",
+		"docker.io/calico/node:v3.27.0",
+		"qa.int.stratio.com/stratio/cluster-operator:0.3.0",
+		// not in the bill of materials
+		"registry.k8s.io/cloud-provider-gcp/gcp-compute-persistent-disk-csi-driver:v1.10.1",
+	} {
+		assert.BoolEqual(t, true, images[image])
+	}
+	for _, image := range []string{
+		"registry.k8s.io/cluster-api/cluster-api-controller:v1.5.3",
+		"registry.k8s.io/cluster-api-gcp/cluster-api-gcp-controller:v1.4.0@sha256:742ebf999137f4ab83c83b408793e2c97a1311adebe3b0c0e7e7317a122f26df",
+		"quay.io/jetstack/cert-manager-controller:v1.12.3",
+		"docker.io/calico/node:v3.26.1",
+		"qa.int.stratio.com/stratio/cluster-operator:0.2.0-SNAPSHOT",
+	} {
+		assert.BoolEqual(t, false, images[image])
+	}
+}
+
+func TestEveryImageListIsRequired(t *testing.T) {
+	t.Parallel()
+	required := map[string]bool{}
+	for _, list := range commonImageLists {
+		required["images/commons/imagenes-"+list.name+".txt"] = true
+	}
+	for _, name := range commons.InfraProviderNames() {
+		p, _ := commons.GetInfraProvider(name)
+		for _, list := range append(append([]string{}, p.Images...), p.UnmanagedImages...) {
+			required["images/"+name+"/imagenes-"+list+".txt"] = true
+		}
+	}
+	paths, err := fs.Glob(imageLists, "images/*/imagenes-*.txt")
+	assert.ExpectError(t, false, err)
+	for _, path := range paths {
+		if !required[path] {
+			t.Errorf("the image list %s is not required by any infra provider", path)
+		}
+		delete(required, path)
+	}
+	for path := range required {
+		t.Errorf("the required image list %s does not exist", path)
+	}
+}
+
+func TestImageListsFollowBOM(t *testing.T) {
+	t.Parallel()
+	defaults := commons.DefaultBOM()
+	listed := map[string]bool{}
+	paths, err := fs.Glob(imageLists, "images/*/imagenes-*.txt")
+	assert.ExpectError(t, false, err)
+	for _, path := range paths {
+		raw, err := imageLists.ReadFile(path)
+		assert.ExpectError(t, false, err)
+		for _, line := range strings.Split(string(raw), "\n") {
+			if ref, err := ParseReference(line); err == nil && strings.TrimSpace(line) != "" {
+				listed[ref.Registry+"/"+ref.Repository+":"+ref.Tag] = true
+			}
+		}
+	}
+	images := []string{}
+	for name := range bomImages {
+		images = append(images, name)
+	}
+	for _, name := range commons.InfraProviderNames() {
+		p, _ := commons.GetInfraProvider(name)
+		images = append(images, p.ControllerImage)
+	}
+	for _, image := range images {
+		version, ok := bomVersion(image)
+		assert.BoolEqual(t, true, ok)
+		if ok && !listed[image+":"+version(defaults)] {
+			t.Errorf("%s is not listed with the version %s of the bill of materials", image, version(defaults))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// dockerHub is the registry of the images without one, and dockerHubAPI the
+// host serving its v2 API
+const (
+	dockerHub    = "docker.io"
+	dockerHubAPI = "registry-1.docker.io"
+)
+
+// Reference is an image reference, e.g. registry.k8s.io/cluster-api/cluster-api-controller:v1.5.3
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference, defaulting its registry to
+// docker.io and its tag to latest
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{}
+	name := strings.TrimSpace(image)
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, errors.Errorf("invalid image %q: the digest must be algorithm:hex", image)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if name == "" {
+		return Reference{}, errors.Errorf("invalid image %q: the name is empty", image)
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry, ref.Repository = parts[0], parts[1]
+	} else {
+		ref.Registry, ref.Repository = dockerHub, name
+	}
+	if ref.Registry == dockerHub && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// String returns the reference as registry/repository[:tag][@digest]
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// manifestReference returns the digest of the reference if it is pinned, or
+// its tag otherwise
+func (r Reference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// InRegistry returns the reference of the image once copied into the registry
+// at url (host[/path]): the repository keeps its path after the url, as the
+// private installations expect (e.g. <url>/cluster-api/cluster-api-controller).
+// The tag is kept and the digest is only kept for the images without tag
+func (r Reference) InRegistry(url string) Reference {
+	parts := strings.SplitN(strings.TrimSuffix(url, "/"), "/", 2)
+	mirrored := Reference{Registry: parts[0], Repository: r.Repository, Tag: r.Tag}
+	if len(parts) == 2 {
+		mirrored.Repository = parts[1] + "/" + r.Repository
+	}
+	if r.Tag == "" {
+		mirrored.Digest = r.Digest
+	}
+	return mirrored
+}
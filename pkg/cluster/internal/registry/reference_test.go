@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Image       string
+		Expected    Reference
+		ExpectError bool
+	}{
+		{
+			Image:    "registry.k8s.io/cluster-api/cluster-api-controller:v1.5.3",
+			Expected: Reference{Registry: "registry.k8s.io", Repository: "cluster-api/cluster-api-controller", Tag: "v1.5.3"},
+		},
+		{
+			Image:    "docker.io/calico/node:v3.26.1",
+			Expected: Reference{Registry: "docker.io", Repository: "calico/node", Tag: "v3.26.1"},
+		},
+		{
+			Image:    "calico/node",
+			Expected: Reference{Registry: "docker.io", Repository: "calico/node", Tag: "latest"},
+		},
+		{
+			Image:    "busybox",
+			Expected: Reference{Registry: "docker.io", Repository: "library/busybox", Tag: "latest"},
+		},
+		{
+			Image:    "localhost:5000/keos/busybox:1.36",
+			Expected: Reference{Registry: "localhost:5000", Repository: "keos/busybox", Tag: "1.36"},
+		},
+		{
+			Image:    "registry.k8s.io/cluster-api-gcp/cluster-api-gcp-controller:v1.4.0@sha256:742e",
+			Expected: Reference{Registry: "registry.k8s.io", Repository: "cluster-api-gcp/cluster-api-gcp-controller", Tag: "v1.4.0", Digest: "sha256:742e"},
+		},
+		{
+			Image:       "registry.k8s.io/pause@742e",
+			ExpectError: true,
+		},
+		{
+			Image:       ":v1",
+			ExpectError: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Image, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseReference(tc.Image)
+			assert.ExpectError(t, tc.ExpectError, err)
+			if err == nil {
+				assert.DeepEqual(t, tc.Expected, ref)
+			}
+		})
+	}
+}
+
+func TestReferenceInRegistry(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name     string
+		Image    string
+		URL      string
+		Expected string
+	}{
+		{
+			Name:     "the repository is kept under the url path",
+			Image:    "registry.k8s.io/cluster-api/cluster-api-controller:v1.5.3",
+			URL:      "eosregistry.azurecr.io/keos",
+			Expected: "eosregistry.azurecr.io/keos/cluster-api/cluster-api-controller:v1.5.3",
+		},
+		{
+			Name:     "url without path",
+			Image:    "docker.io/calico/node:v3.26.1",
+			URL:      "registry.example.com/",
+			Expected: "registry.example.com/calico/node:v3.26.1",
+		},
+		{
+			Name:     "the digest is dropped if there is a tag",
+			Image:    "registry.k8s.io/cluster-api-gcp/cluster-api-gcp-controller:v1.4.0@sha256:742e",
+			URL:      "registry.example.com/keos",
+			Expected: "registry.example.com/keos/cluster-api-gcp/cluster-api-gcp-controller:v1.4.0",
+		},
+		{
+			Name:     "the digest is kept if there is no tag",
+			Image:    "registry.k8s.io/pause@sha256:742e",
+			URL:      "registry.example.com",
+			Expected: "registry.example.com/pause@sha256:742e",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseReference(tc.Image)
+			assert.ExpectError(t, false, err)
+			assert.StringEqual(t, tc.Expected, ref.InRegistry(tc.URL).String())
+		})
+	}
+}
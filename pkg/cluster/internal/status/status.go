@@ -0,0 +1,203 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package status implements reporting the state of a running workload
+// cluster
+package status
+
+import (
+	"encoding/json"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// StatusParams holds the descriptor of the cluster to be reported
+type StatusParams struct {
+	KeosCluster commons.KeosCluster
+	// Client manages the cluster holding the KeosCluster
+	Client kube.Client
+}
+
+// clusterOperatorRelease is the helm release of the cluster-operator
+const clusterOperatorRelease = "cluster-operator"
+
+type capiCluster struct {
+	Spec struct {
+		ControlPlaneRef struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"controlPlaneRef"`
+	} `json:"spec"`
+	Status struct {
+		Phase      string                     `json:"phase"`
+		Conditions []commons.ClusterCondition `json:"conditions"`
+	} `json:"status"`
+}
+
+type controlPlane struct {
+	Status struct {
+		Ready         bool `json:"ready"`
+		Replicas      int  `json:"replicas"`
+		ReadyReplicas int  `json:"readyReplicas"`
+	} `json:"status"`
+}
+
+type workerGroupList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Replicas int `json:"replicas"`
+		} `json:"spec"`
+		Status struct {
+			ReadyReplicas   int `json:"readyReplicas"`
+			UpdatedReplicas int `json:"updatedReplicas"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type helmRelease struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Chart      string `json:"chart"`
+	AppVersion string `json:"app_version"`
+	Status     string `json:"status"`
+}
+
+// Cluster returns the state of the workload cluster of the descriptor
+func Cluster(params *StatusParams) (*commons.WorkloadClusterStatus, error) {
+	name := params.KeosCluster.Metadata.Name
+	namespace := commons.ClusterNamespace(name)
+	keosNamespace := params.KeosCluster.Metadata.Namespace
+	if keosNamespace == "" {
+		keosNamespace = namespace
+	}
+	status := &commons.WorkloadClusterStatus{
+		Name:       name,
+		Conditions: []commons.ClusterCondition{},
+		Workers:    []commons.WorkerGroupStatus{},
+		Addons:     []commons.AddonStatus{},
+	}
+
+	var cluster capiCluster
+	if err := getJSON(params.Client, namespace, "clusters.cluster.x-k8s.io", name, &cluster); err != nil {
+		return nil, err
+	}
+	status.Phase = cluster.Status.Phase
+	status.Conditions = append(status.Conditions, cluster.Status.Conditions...)
+
+	ref := cluster.Spec.ControlPlaneRef
+	if ref.Kind != "" {
+		var cp controlPlane
+		if err := getJSON(params.Client, namespace, strings.ToLower(ref.Kind), ref.Name, &cp); err != nil {
+			return nil, err
+		}
+		status.ControlPlane = commons.ControlPlaneStatus{
+			Kind:          ref.Kind,
+			Name:          ref.Name,
+			Ready:         cp.Status.Ready,
+			Replicas:      cp.Status.Replicas,
+			ReadyReplicas: cp.Status.ReadyReplicas,
+		}
+	}
+
+	kind, resource := "MachineDeployment", "machinedeployments"
+	if params.KeosCluster.Spec.IsMachinePool() {
+		kind, resource = "MachinePool", "machinepools"
+	}
+	var workers workerGroupList
+	if err := getJSON(params.Client, namespace, resource, "", &workers); err != nil {
+		return nil, err
+	}
+	for _, item := range workers.Items {
+		worker := commons.WorkerGroupStatus{
+			Kind:    kind,
+			Name:    item.Metadata.Name,
+			Desired: item.Spec.Replicas,
+			Ready:   item.Status.ReadyReplicas,
+		}
+		if kind == "MachineDeployment" {
+			updated := item.Status.UpdatedReplicas
+			worker.Updated = &updated
+		}
+		status.Workers = append(status.Workers, worker)
+	}
+
+	keosStatus, err := params.Client.Get(keosNamespace, "keoscluster", name, "jsonpath={.status}")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the keoscluster")
+	}
+	if strings.TrimSpace(keosStatus) != "" {
+		if err := json.Unmarshal([]byte(keosStatus), &status.KeosCluster); err != nil {
+			return nil, errors.Wrap(err, "failed to parse the keoscluster status")
+		}
+	}
+
+	output, err := params.Client.HelmList("")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the helm releases")
+	}
+	releases := []helmRelease{}
+	if err := json.Unmarshal([]byte(output), &releases); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the helm releases")
+	}
+	for _, release := range releases {
+		chart, version := splitChart(release.Chart)
+		if release.Name == clusterOperatorRelease {
+			status.ClusterOperatorVersion = version
+		}
+		status.Addons = append(status.Addons, commons.AddonStatus{
+			Name:       release.Name,
+			Namespace:  release.Namespace,
+			Chart:      chart,
+			Version:    version,
+			AppVersion: release.AppVersion,
+			Status:     release.Status,
+		})
+	}
+	return status, nil
+}
+
+// getJSON gets the object name of resource, or all its objects if name is
+// empty, into object
+func getJSON(client kube.Client, namespace string, resource string, name string, object interface{}) error {
+	output, err := client.Get(namespace, resource, name, "json")
+	if err != nil {
+		return errors.Wrap(err, "failed to get the "+resource)
+	}
+	if err := json.Unmarshal([]byte(output), object); err != nil {
+		return errors.Wrap(err, "failed to parse the "+resource)
+	}
+	return nil
+}
+
+// splitChart splits the <name>-<version> chart of a helm release, the version
+// starting at the first dash followed by a digit or by a v and a digit
+func splitChart(chart string) (string, string) {
+	isDigit := func(i int) bool {
+		return i < len(chart) && chart[i] >= '0' && chart[i] <= '9'
+	}
+	for i := 0; i < len(chart); i++ {
+		if chart[i] == '-' && (isDigit(i+1) || (i+1 < len(chart) && chart[i+1] == 'v' && isDigit(i+2))) {
+			return chart[:i], chart[i+1:]
+		}
+	}
+	return chart, ""
+}
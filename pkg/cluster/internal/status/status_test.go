@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+const (
+	getCluster         = "kubectl --namespace cluster-test get clusters.cluster.x-k8s.io test -o json"
+	getControlPlane    = "kubectl --namespace cluster-test get kubeadmcontrolplane test-control-plane -o json"
+	getWorkers         = "kubectl --namespace cluster-test get machinedeployments -o json"
+	getKeosStatus      = "kubectl --namespace cluster-test get keoscluster test -o jsonpath={.status}"
+	helmList           = "helm list --output json --all-namespaces"
+	clusterOutput      = `{"spec":{"controlPlaneRef":{"kind":"KubeadmControlPlane","name":"test-control-plane"}},"status":{"phase":"Provisioned","conditions":[{"type":"Ready","status":"True"},{"type":"InfrastructureReady","status":"False","reason":"LoadBalancerFailed","message":"timed out"}]}}`
+	controlPlaneOutput = `{"status":{"ready":true,"replicas":3,"readyReplicas":2}}`
+	workersOutput      = `{"items":[{"metadata":{"name":"test-workers-md-0"},"spec":{"replicas":2},"status":{"readyReplicas":2,"updatedReplicas":1}}]}`
+	helmListOutput     = `[{"name":"cluster-operator","namespace":"kube-system","chart":"cluster-operator-0.2.0-SNAPSHOT","app_version":"0.2.0","status":"deployed"},{"name":"calico","namespace":"tigera-operator","chart":"tigera-operator-v3.26.1","status":"deployed"}]`
+)
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+	keosCluster := commons.KeosCluster{}
+	keosCluster.Metadata.Name = "test"
+	keosCluster.Spec.InfraProvider = "gcp"
+
+	client := kube.NewFakeClient("")
+	client.Outputs[getCluster] = clusterOutput
+	client.Outputs[getControlPlane] = controlPlaneOutput
+	client.Outputs[getWorkers] = workersOutput
+	client.Outputs[getKeosStatus] = `{"ready":true}`
+	client.Outputs[helmList] = helmListOutput
+
+	status, err := Cluster(&StatusParams{KeosCluster: keosCluster, Client: client})
+	assert.ExpectError(t, false, err)
+	assert.DeepEqual(t, []string{getCluster, getControlPlane, getWorkers, getKeosStatus, helmList}, client.Commands)
+
+	updated := 1
+	expected := &commons.WorkloadClusterStatus{
+		Name:  "test",
+		Phase: "Provisioned",
+		Conditions: []commons.ClusterCondition{
+			{Type: "Ready", Status: "True"},
+			{Type: "InfrastructureReady", Status: "False", Reason: "LoadBalancerFailed", Message: "timed out"},
+		},
+		ControlPlane: commons.ControlPlaneStatus{Kind: "KubeadmControlPlane", Name: "test-control-plane", Ready: true, Replicas: 3, ReadyReplicas: 2},
+		Workers: []commons.WorkerGroupStatus{
+			{Kind: "MachineDeployment", Name: "test-workers-md-0", Desired: 2, Ready: 2, Updated: &updated},
+		},
+		KeosCluster:            map[string]interface{}{"ready": true},
+		ClusterOperatorVersion: "0.2.0-SNAPSHOT",
+		Addons: []commons.AddonStatus{
+			{Name: "cluster-operator", Namespace: "kube-system", Chart: "cluster-operator", Version: "0.2.0-SNAPSHOT", AppVersion: "0.2.0", Status: "deployed"},
+			{Name: "calico", Namespace: "tigera-operator", Chart: "tigera-operator", Version: "v3.26.1", Status: "deployed"},
+		},
+	}
+	assert.DeepEqual(t, expected, status)
+}
+
+func TestClusterFailure(t *testing.T) {
+	t.Parallel()
+	keosCluster := commons.KeosCluster{}
+	keosCluster.Metadata.Name = "test"
+
+	client := kube.NewFakeClient("")
+	client.Errors[getCluster] = &kube.CommandError{Name: "kubectl", Verb: "get", ExitCode: 1}
+	_, err := Cluster(&StatusParams{KeosCluster: keosCluster, Client: client})
+	assert.ExpectError(t, true, err)
+	assert.DeepEqual(t, []string{getCluster}, client.Commands)
+}
+
+func TestSplitChart(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Chart   string
+		Name    string
+		Version string
+	}{
+		{Chart: "cluster-autoscaler-9.29.1", Name: "cluster-autoscaler", Version: "9.29.1"},
+		{Chart: "cluster-operator-0.2.0-SNAPSHOT", Name: "cluster-operator", Version: "0.2.0-SNAPSHOT"},
+		{Chart: "tigera-operator-v3.26.1", Name: "tigera-operator", Version: "v3.26.1"},
+		{Chart: "local-chart", Name: "local-chart", Version: ""},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Chart, func(t *testing.T) {
+			t.Parallel()
+			name, version := splitChart(tc.Chart)
+			assert.StringEqual(t, tc.Name, name)
+			assert.StringEqual(t, tc.Version, version)
+		})
+	}
+}
@@ -18,7 +18,6 @@ package validate
 
 import (
 	"context"
-	"fmt"
 	"net"
 	"reflect"
 	"regexp"
@@ -43,165 +42,157 @@ var AWSVolumes = []string{"io1", "io2", "gp2", "gp3", "sc1", "st1", "standard",
 var isAWSNodeImage = regexp.MustCompile(`^ami-\w+$`).MatchString
 var AWSNodeImageFormat = "ami-[IMAGE_ID]"
 
-func validateAWS(spec commons.KeosSpec, providerSecrets map[string]string) error {
-	var err error
-	var ctx = context.TODO()
+func init() {
+	registerInfraValidator("aws", infraValidator{
+		newInventory: newAWSInventory,
+		validate: func(keosCluster commons.KeosCluster, inv Inventory, errs *errorList) {
+			validateAWS(keosCluster.Spec, inv, errs)
+		},
+	})
+}
 
-	cfg, err := commons.AWSGetConfig(ctx, providerSecrets, spec.Region)
-	if err != nil {
-		return err
-	}
+// validateAWS validates the AWS specific settings of the descriptor, the
+// checks that query AWS are skipped if inv is nil (offline validation)
+func validateAWS(spec commons.KeosSpec, inv Inventory, errs *errorList) {
+	var azs []string
 
-	regions, err := getAWSRegions(cfg)
-	if err != nil {
-		return err
-	}
-	if !commons.Contains(regions, spec.Region) {
-		return errors.New("spec.region: " + spec.Region + " region does not exist")
-	}
+	if inv == nil {
+		errs.skip(specPath.child("region"), "region")
+	} else {
+		regions, err := inv.Regions()
+		if err != nil {
+			errs.fail(err, "failed to list the AWS regions")
+			return
+		}
+		if !commons.Contains(regions, spec.Region) {
+			errs.add(specPath.child("region"), spec.Region+" region does not exist", "")
+			return
+		}
 
-	azs, err := getAWSAzs(ctx, cfg, spec.Region)
-	if err != nil {
-		return err
+		azs, err = inv.AZs(spec.Region)
+		if err != nil {
+			errs.fail(err, "failed to list the AWS availability zones")
+			return
+		}
 	}
 
 	if (spec.StorageClass != commons.StorageClass{}) {
-		if err = validateAWSStorageClass(spec.StorageClass, spec.WorkerNodes); err != nil {
-			return errors.Wrap(err, "spec.storageclass: Invalid value")
-		}
+		validateAWSStorageClass(spec.StorageClass, specPath.child("storageclass"), errs)
 	}
 
 	if !reflect.ValueOf(spec.Networks).IsZero() {
-		if err = validateAWSNetwork(ctx, cfg, spec); err != nil {
-			return errors.Wrap(err, "spec.networks: Invalid value")
-		}
+		validateAWSNetwork(spec, inv, specPath.child("networks"), errs)
 	}
 
 	for i, dr := range spec.DockerRegistries {
 		if dr.Type != "ecr" && dr.Type != "generic" {
-			return errors.New("spec.docker_registries[" + strconv.Itoa(i) + "]: Invalid value: \"type\": only 'ecr' or 'generic' are supported in aws clusters")
+			errs.add(specPath.child("docker_registries").index(i).child("type"), "only 'ecr' or 'generic' are supported in aws clusters", "")
 		}
 	}
 
-	for _, tag := range spec.ControlPlane.Tags {
+	for i, tag := range spec.ControlPlane.Tags {
 		for k, v := range tag {
-			label := k + "=" + v
-			fmt.Println(label)
-			if err = validateAWSLabel(label); err != nil {
-				return errors.Wrap(err, "spec.control_plane.tags: Invalid value")
-			}
+			validateAWSLabel(k+"="+v, specPath.child("control_plane").child("tags").index(i).child(k), errs)
 		}
 	}
 
 	if !spec.ControlPlane.Managed {
+		path := specPath.child("control_plane")
 		if spec.ControlPlane.NodeImage != "" {
 			if !isAWSNodeImage(spec.ControlPlane.NodeImage) {
-				return errors.New("spec.control_plane: Invalid value: \"node_image\": must have the format " + AWSNodeImageFormat)
-			}
-		}
-		if err := validateAWSInstanceType(cfg, spec.ControlPlane.Size); err != nil {
-			return errors.New("spec.control_plane.size: " + spec.ControlPlane.Size + " does not exists in AWS instance types")
-		}
-		if err := validateVolumeType(spec.ControlPlane.RootVolume.Type, AWSVolumes); err != nil {
-			return errors.Wrap(err, "spec.control_plane.root_volume: Invalid value: \"type\"")
-		}
-
-		for i, ev := range spec.ControlPlane.ExtraVolumes {
-			if ev.DeviceName == "" {
-				return errors.New("spec.control_plane.extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"device_name\": is required")
-			}
-			if err := validateVolumeType(ev.Type, AWSVolumes); err != nil {
-				return errors.Wrap(err, "spec.control_plane.extra_volumes["+strconv.Itoa(i)+"]: Invalid value: \"type\"")
-			}
-			for j, ev2 := range spec.ControlPlane.ExtraVolumes {
-				if i != j {
-					if ev.DeviceName == ev2.DeviceName {
-						return errors.New("spec.control_plane.extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"device_name\": is duplicated")
-					}
-				}
+				errs.add(path.child("node_image"), "must have the format "+AWSNodeImageFormat, "")
 			}
 		}
+		validateInstanceType(inv, spec.Region, nil, spec.ControlPlane.Size, path.child("size"), errs)
+		validateVolumeType(spec.ControlPlane.RootVolume.Type, AWSVolumes, path.child("root_volume").child("type"), errs)
+		validateAWSExtraVolumes(spec.ControlPlane.ExtraVolumes, path.child("extra_volumes"), errs)
 	}
 
-	for _, wn := range spec.WorkerNodes {
+	for i, wn := range spec.WorkerNodes {
+		path := specPath.child("worker_nodes").index(i)
 		if wn.NodeImage != "" {
 			if !isAWSNodeImage(wn.NodeImage) {
-				return errors.New("spec.worker_nodes." + wn.Name + ": \"node_image\": must have the format " + AWSNodeImageFormat)
+				errs.add(path.child("node_image"), "must have the format "+AWSNodeImageFormat, "")
 			}
 		}
 		if wn.AZ != "" {
-			if len(azs) > 0 {
+			if inv == nil {
+				errs.skip(path.child("az"), "availability zone")
+			} else if len(azs) > 0 {
 				if !commons.Contains(azs, wn.AZ) {
-					return errors.New(wn.AZ + " does not exist in this region, azs: " + fmt.Sprint(azs))
+					errs.add(path.child("az"), wn.AZ+" does not exist in this region", "azs: "+strings.Join(azs, ", "))
 				}
 			}
 		}
 		if wn.Size != "" {
-			if err := validateAWSInstanceType(cfg, wn.Size); err != nil {
-				return errors.New("spec.worker_nodes." + wn.Name + ".size: " + wn.Size + " does not exists in AWS instance types")
-			}
+			validateInstanceType(inv, spec.Region, nil, wn.Size, path.child("size"), errs)
 		}
-		if err := validateVolumeType(wn.RootVolume.Type, AWSVolumes); err != nil {
-			return errors.Wrap(err, "spec.worker_nodes."+wn.Name+".root_volume: Invalid value: \"type\"")
+		validateVolumeType(wn.RootVolume.Type, AWSVolumes, path.child("root_volume").child("type"), errs)
+		validateAWSExtraVolumes(wn.ExtraVolumes, path.child("extra_volumes"), errs)
+	}
+}
+
+func validateAWSExtraVolumes(extraVolumes []commons.ExtraVolume, path fieldPath, errs *errorList) {
+	for i, ev := range extraVolumes {
+		if ev.DeviceName == "" {
+			errs.add(path.index(i).child("device_name"), "is required", "")
 		}
-		for i, ev := range wn.ExtraVolumes {
-			if ev.DeviceName == "" {
-				return errors.New("spec.worker_nodes." + wn.Name + ".extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"device_name\": is required")
-			}
-			if err := validateVolumeType(ev.Type, AWSVolumes); err != nil {
-				return errors.Wrap(err, "spec.worker_nodes."+wn.Name+".extra_volumes["+strconv.Itoa(i)+"]: Invalid value: \"type\"")
-			}
-			for j, ev2 := range spec.ControlPlane.ExtraVolumes {
-				if i != j {
-					if ev.DeviceName == ev2.DeviceName {
-						return errors.New("spec.worker_nodes." + wn.Name + ".extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"device_name\": is duplicated")
-					}
-				}
+		validateVolumeType(ev.Type, AWSVolumes, path.index(i).child("type"), errs)
+		for _, ev2 := range extraVolumes[:i] {
+			if ev.DeviceName != "" && ev.DeviceName == ev2.DeviceName {
+				errs.add(path.index(i).child("device_name"), "is duplicated", "")
 			}
 		}
 	}
-
-	return nil
 }
 
-func validateAWSNetwork(ctx context.Context, cfg aws.Config, spec commons.KeosSpec) error {
-	var err error
+func validateAWSNetwork(spec commons.KeosSpec, inv Inventory, path fieldPath, errs *errorList) {
 	if spec.Networks.PodsCidrBlock != "" {
 		if spec.ControlPlane.Managed {
-			if err = validateAWSPodsNetwork(spec.Networks.PodsCidrBlock); err != nil {
-				return err
-			}
+			validateAWSPodsNetwork(spec.Networks.PodsCidrBlock, path.child("pods_cidr"), errs)
 		}
 	} else {
 		if len(spec.Networks.PodsSubnets) > 0 {
-			return errors.New("\"pods_cidr\": is required when \"pods_subnets\" is set")
+			errs.add(path.child("pods_cidr"), "is required when \"pods_subnets\" is set", "")
 		}
 	}
 	if spec.Networks.VPCID != "" {
 		if spec.Networks.VPCCIDRBlock != "" {
-			return errors.New("\"vpc_id\" and \"vpc_cidr\" are mutually exclusive")
+			errs.add(path.child("vpc_cidr"), "\"vpc_id\" and \"vpc_cidr\" are mutually exclusive", "")
 		}
-		vpcs, _ := getAWSVPCs(cfg)
-		if len(vpcs) > 0 && !commons.Contains(vpcs, spec.Networks.VPCID) {
-			return errors.New("\"vpc_id\": " + spec.Networks.VPCID + " does not exist")
+		if inv == nil {
+			errs.skip(path.child("vpc_id"), "VPC")
+		} else if vpcs, err := inv.VPCs(spec.Region, ""); err != nil {
+			errs.warn(path.child("vpc_id"), "could not be checked", err.Error())
+		} else if !commons.Contains(vpcs, spec.Networks.VPCID) {
+			errs.add(path.child("vpc_id"), spec.Networks.VPCID+" does not exist", "")
 		}
 		if len(spec.Networks.Subnets) == 0 {
-			return errors.New("\"subnets\": are required when \"vpc_id\" is set")
+			errs.add(path.child("subnets"), "are required when \"vpc_id\" is set", "")
 		} else {
-			for _, s := range spec.Networks.Subnets {
+			missingSubnetID := false
+			for i, s := range spec.Networks.Subnets {
 				if s.SubnetId == "" {
-					return errors.New("\"subnet_id\": is required")
+					errs.add(path.child("subnets").index(i).child("subnet_id"), "is required", "")
+					missingSubnetID = true
 				}
 			}
-			if err = validateAWSAZs(ctx, cfg, spec); err != nil {
-				return err
+			if missingSubnetID {
+				return
+			}
+			if inv == nil {
+				errs.skip(path.child("subnets"), "subnets")
+				return
+			}
+			validateAWSAZs(spec, inv, errs)
+			subnets, err := inv.Subnets(spec.Region, "", spec.Networks.VPCID)
+			if err != nil {
+				errs.warn(path.child("subnets"), "could not be checked", err.Error())
+				return
 			}
-			subnets, _ := getAWSSubnets(spec.Networks.VPCID, cfg)
-			if len(subnets) > 0 {
-				for _, subnet := range spec.Networks.Subnets {
-					if !commons.Contains(subnets, subnet.SubnetId) {
-						return errors.New("\"subnets\": " + subnet.SubnetId + " does not belong to vpc with id: " + spec.Networks.VPCID)
-					}
+			for i, subnet := range spec.Networks.Subnets {
+				if !commons.Contains(subnets, subnet.SubnetId) {
+					errs.add(path.child("subnets").index(i).child("subnet_id"), subnet.SubnetId+" does not belong to vpc with id: "+spec.Networks.VPCID, "")
 				}
 			}
 		}
@@ -210,24 +201,21 @@ func validateAWSNetwork(ctx context.Context, cfg aws.Config, spec commons.KeosSp
 			const cidrSizeMin = 256
 			_, ipv4Net, err := net.ParseCIDR(spec.Networks.VPCCIDRBlock)
 			if err != nil {
-				return errors.New("\"vpc_cidr\": CIDR block must be a valid IPv4 CIDR block")
-			}
-			cidrSize := cidr.AddressCount(ipv4Net)
-			if cidrSize < cidrSizeMin {
-				return errors.New("\"vpc_cidr\": CIDR block size must be at least /24 netmask")
+				errs.add(path.child("vpc_cidr"), "CIDR block must be a valid IPv4 CIDR block", "")
+			} else if cidr.AddressCount(ipv4Net) < cidrSizeMin {
+				errs.add(path.child("vpc_cidr"), "CIDR block size must be at least /24 netmask", "")
 			}
 			if len(spec.Networks.Subnets) > 0 {
-				return errors.New("\"subnets\": are not supported when \"vpc_cidr\" is set")
+				errs.add(path.child("subnets"), "are not supported when \"vpc_cidr\" is set", "")
 			}
 		}
 		if len(spec.Networks.PodsSubnets) > 0 {
-			return errors.New("\"vpc_id\": is required when \"pods_subnets\" is set")
+			errs.add(path.child("vpc_id"), "is required when \"pods_subnets\" is set", "")
 		}
 	}
-	return nil
 }
 
-func validateAWSPodsNetwork(podsNetwork string) error {
+func validateAWSPodsNetwork(podsNetwork string, path fieldPath, errs *errorList) {
 	// Minimum cidr range: 100.64.0.0/10
 	validRange1 := net.IPNet{
 		IP:   net.ParseIP("100.64.0.0"),
@@ -241,19 +229,19 @@ func validateAWSPodsNetwork(podsNetwork string) error {
 
 	_, ipv4Net, err := net.ParseCIDR(podsNetwork)
 	if err != nil {
-		return errors.New("\"pods_cidr\": CIDR block must be a valid IPv4 CIDR block")
+		errs.add(path, "CIDR block must be a valid IPv4 CIDR block", "")
+		return
 	}
 
 	cidrSize := cidr.AddressCount(ipv4Net)
 	if cidrSize > cidrSizeMax || cidrSize < cidrSizeMin {
-		return errors.New("\"pods_cidr\": CIDR block sizes must be between a /16 and /28 netmask")
+		errs.add(path, "CIDR block sizes must be between a /16 and /28 netmask", "")
 	}
 
 	start, end := cidr.AddressRange(ipv4Net)
 	if (!validRange1.Contains(start) || !validRange1.Contains(end)) && (!validRange2.Contains(start) || !validRange2.Contains(end)) {
-		return errors.New("\"pods_cidr\": CIDR block must be between " + validRange1.String() + " and " + validRange2.String())
+		errs.add(path, "CIDR block must be between "+validRange1.String()+" and "+validRange2.String(), "")
 	}
-	return nil
 }
 
 func getAWSRegions(config aws.Config) ([]string, error) {
@@ -317,8 +305,7 @@ func getAWSSubnets(vpcId string, config aws.Config) ([]string, error) {
 	return subnets, nil
 }
 
-func validateAWSStorageClass(sc commons.StorageClass, wn commons.WorkerNodes) error {
-	var err error
+func validateAWSStorageClass(sc commons.StorageClass, path fieldPath, errs *errorList) {
 	var isKeyValid = regexp.MustCompile(`^arn:aws:kms:[a-zA-Z0-9-]+:\d{12}:key/[\w-]+$`).MatchString
 	var AWSFSTypes = []string{"xfs", "ext3", "ext4", "ext2"}
 	var AWSSCFields = []string{"Type", "FsType", "Labels", "AllowAutoIOPSPerGBIncrease", "BlockExpress", "BlockSize", "Iops", "IopsPerGB", "Encrypted", "KmsKeyId", "Throughput"}
@@ -326,43 +313,44 @@ func validateAWSStorageClass(sc commons.StorageClass, wn commons.WorkerNodes) er
 	var typesSupportedForIOPS = []string{"io1", "io2", "gp3"}
 	var iopsValue string
 	var iopsKey string
+	parameters := path.child("parameters")
 
 	// Validate fields
 	fields := getFieldNames(sc.Parameters)
 	for _, f := range fields {
 		if !commons.Contains(AWSSCFields, f) {
-			return errors.New("\"parameters\": unsupported " + f + ", supported fields: " + fmt.Sprint(strings.Join(AWSSCYamlFields, ", ")))
+			errs.add(parameters, "unsupported "+f, "supported fields: "+strings.Join(AWSSCYamlFields, ", "))
 		}
 	}
 	// Validate class
 	if sc.Class != "" && sc.Parameters != (commons.SCParameters{}) {
-		return errors.New("\"class\": cannot be set when \"parameters\" is set")
+		errs.add(path.child("class"), "cannot be set when \"parameters\" is set", "")
 	}
 	// Validate type
 	if sc.Parameters.Type != "" && !commons.Contains(AWSVolumes, sc.Parameters.Type) {
-		return errors.New("\"type\": unsupported " + sc.Parameters.Type + ", supported types: " + fmt.Sprint(strings.Join(AWSVolumes, ", ")))
+		errs.add(parameters.child("type"), "unsupported "+sc.Parameters.Type, "supported types: "+strings.Join(AWSVolumes, ", "))
 	}
 	// Validate encryptionKey format
 	if sc.EncryptionKey != "" {
 		if sc.Parameters != (commons.SCParameters{}) {
-			return errors.New("\"encryptionKey\": cannot be set when \"parameters\" is set")
+			errs.add(path.child("encryptionKey"), "cannot be set when \"parameters\" is set", "")
 		}
 		if !isKeyValid(sc.EncryptionKey) {
-			return errors.New("\"encryptionKey\": it must have the format arn:aws:kms:[REGION]:[ACCOUNT_ID]:key/[KEY_ID]")
+			errs.add(path.child("encryptionKey"), "invalid format", "it must have the format arn:aws:kms:[REGION]:[ACCOUNT_ID]:key/[KEY_ID]")
 		}
 	}
 	// Validate diskEncryptionSetID format
 	if sc.Parameters.KmsKeyId != "" {
 		if !isKeyValid(sc.Parameters.KmsKeyId) {
-			return errors.New("\"kmsKeyId\": it must have the format arn:aws:kms:[REGION]:[ACCOUNT_ID]:key/[KEY_ID]")
+			errs.add(parameters.child("kmsKeyId"), "invalid format", "it must have the format arn:aws:kms:[REGION]:[ACCOUNT_ID]:key/[KEY_ID]")
 		}
 		if sc.Parameters.Encrypted != "true" {
-			return errors.New("\"kmsKeyId\": cannot be set when \"parameters.encrypted\" is not set to true")
+			errs.add(parameters.child("kmsKeyId"), "cannot be set when \"parameters.encrypted\" is not set to true", "")
 		}
 	}
 	// Validate fsType
 	if sc.Parameters.FsType != "" && !commons.Contains(AWSFSTypes, sc.Parameters.FsType) {
-		return errors.New("\fsType\": unsupported " + sc.Parameters.FsType + ", supported types: " + fmt.Sprint(strings.Join(AWSFSTypes, ", ")))
+		errs.add(parameters.child("fsType"), "unsupported "+sc.Parameters.FsType, "supported types: "+strings.Join(AWSFSTypes, ", "))
 	}
 	// Validate iops
 	if sc.Parameters.Iops != "" {
@@ -374,91 +362,60 @@ func validateAWSStorageClass(sc commons.StorageClass, wn commons.WorkerNodes) er
 		iopsKey = "iopsPerGB"
 	}
 	if iopsValue != "" && sc.Parameters.Type != "" && !slices.Contains(typesSupportedForIOPS, sc.Parameters.Type) {
-		return errors.New(iopsKey + " only can be specified for " + fmt.Sprint(strings.Join(typesSupportedForIOPS, ", ")) + " types")
+		errs.add(parameters.child(iopsKey), "only can be specified for "+strings.Join(typesSupportedForIOPS, ", ")+" types", "")
 	}
 	if iopsValue != "" {
 		iops, err := strconv.Atoi(iopsValue)
 		if err != nil {
-			return errors.New("invalid " + iopsKey + " parameter. It must be a number in string format")
+			errs.add(parameters.child(iopsKey), "invalid value", "it must be a number in string format")
+			return
 		}
 		if (sc.Class == "standard" && sc.Parameters.Type == "") || sc.Parameters.Type == "gp3" {
 			if iops < 3000 || iops > 16000 {
-				return errors.New("invalid " + iopsKey + " parameter. It must be greater than 3000 and lower than 16000 for gp3 type")
+				errs.add(parameters.child(iopsKey), "invalid value", "it must be greater than 3000 and lower than 16000 for gp3 type")
 			}
 		}
 		if (sc.Class == "premium" && sc.Parameters.Type == "") || sc.Parameters.Type == "io1" || sc.Parameters.Type == "io2" {
 			if iops < 16000 || iops > 64000 {
-				return errors.New("invalid " + iopsKey + " parameter. It must be greater than 16000 and lower than 64000 for io1 and io2 types")
+				errs.add(parameters.child(iopsKey), "invalid value", "it must be greater than 16000 and lower than 64000 for io1 and io2 types")
 			}
 		}
 	}
 	// Validate labels
 	if sc.Parameters.Labels != "" {
-		if err = validateAWSLabel(sc.Parameters.Labels); err != nil {
-			return errors.Wrap(err, "invalid labels")
-		}
+		validateAWSLabel(sc.Parameters.Labels, parameters.child("labels"), errs)
 	}
-	return nil
 }
 
-func validateAWSInstanceType(cfg aws.Config, instanceType string) error {
-
-	client := ec2.NewFromConfig(cfg)
-
-	// Call DescribeInstanceTypes API to get details about the instance type
-	diti := &ec2.DescribeInstanceTypesInput{
-		InstanceTypes: []types.InstanceType{types.InstanceType(instanceType)},
-	}
-
-	_, err := client.DescribeInstanceTypes(context.TODO(), diti)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateAWSLabel(l string) error {
+func validateAWSLabel(l string, path fieldPath, errs *errorList) {
 	var isLabel = regexp.MustCompile(`^([\w\.\/-]+=[\w\.\/-]+)(\s?,\s?[\w\.\/-]+=[\w\.\/-]+)*$`).MatchString
 	if !isLabel(l) {
-		return errors.New("incorrect format. Must have the format 'key1=value1,key2=value2'")
+		errs.add(path, "incorrect format", "must have the format 'key1=value1,key2=value2'")
 	}
-	return nil
 }
 
-func validateAWSAZs(ctx context.Context, cfg aws.Config, spec commons.KeosSpec) error {
-	var err error
-	var azs []string
-
-	svc := ec2.NewFromConfig(cfg)
-	if spec.Networks.VPCID != "" {
-		if len(spec.Networks.Subnets) > 0 {
-			azs, err = commons.AWSGetPrivateAZs(ctx, svc, spec.Networks.Subnets)
-			if err != nil {
-				return err
-			}
-			if len(azs) < 3 {
-				return errors.New("insufficient Availability Zones in region " + spec.Region + ". Please add at least 3 private subnets in different Availability Zones")
-			}
-		}
-	} else {
-		azs, err = commons.AWSGetAZs(ctx, svc)
-		if err != nil {
-			return err
-		}
-		if len(azs) < 3 {
-			return errors.New("insufficient Availability Zones in region " + spec.Region + ". Must have at least 3")
-		}
+// validateAWSAZs checks that the private subnets of the descriptor are
+// spread across 3 availability zones, where the unbalanced worker nodes are
+func validateAWSAZs(spec commons.KeosSpec, inv Inventory, errs *errorList) {
+	subnetIDs := []string{}
+	for _, s := range spec.Networks.Subnets {
+		subnetIDs = append(subnetIDs, s.SubnetId)
+	}
+	azs, err := inv.PrivateAZs(spec.Region, subnetIDs)
+	if err != nil {
+		errs.fail(err, "failed to get the availability zones of the subnets")
+		return
 	}
-	for _, node := range spec.WorkerNodes {
+	if len(azs) < 3 {
+		errs.add(specPath.child("networks").child("subnets"), "insufficient Availability Zones in region "+spec.Region, "please add at least 3 private subnets in different Availability Zones")
+	}
+	for i, node := range spec.WorkerNodes {
 		if node.ZoneDistribution == "unbalanced" && node.AZ != "" {
 			if !slices.Contains(azs, node.AZ) {
-				return errors.New("worker node " + node.Name + " whose AZ is defined in " + node.AZ + " must match with the AZs associated to the defined subnets in descriptor")
+				errs.add(specPath.child("worker_nodes").index(i).child("az"), node.AZ+" must match with the AZs associated to the defined subnets in descriptor", "")
 			}
 		}
 	}
-
-	return nil
 }
 
 func getAWSAzs(ctx context.Context, cfg aws.Config, region string) ([]string, error) {
@@ -475,3 +432,58 @@ func getAWSAzs(ctx context.Context, cfg aws.Config, region string) ([]string, er
 	}
 	return azs, nil
 }
+
+// awsInventory lists the AWS resources with the EC2 API
+type awsInventory struct {
+	cfg aws.Config
+}
+
+func newAWSInventory(providerSecrets map[string]string, region string) (Inventory, error) {
+	cfg, err := commons.AWSGetConfig(context.TODO(), providerSecrets, region)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the AWS config")
+	}
+	return &awsInventory{cfg: cfg}, nil
+}
+
+func (i *awsInventory) Regions() ([]string, error) {
+	return getAWSRegions(i.cfg)
+}
+
+func (i *awsInventory) AZs(region string) ([]string, error) {
+	return getAWSAzs(context.TODO(), i.cfg, region)
+}
+
+func (i *awsInventory) PrivateAZs(region string, subnetIDs []string) ([]string, error) {
+	subnets := []commons.Subnets{}
+	for _, id := range subnetIDs {
+		subnets = append(subnets, commons.Subnets{SubnetId: id})
+	}
+	return commons.AWSGetPrivateAZs(context.TODO(), ec2.NewFromConfig(i.cfg), subnets)
+}
+
+func (i *awsInventory) VPCs(region string, resourceGroup string) ([]string, error) {
+	return getAWSVPCs(i.cfg)
+}
+
+func (i *awsInventory) Subnets(region string, resourceGroup string, vpcID string) ([]string, error) {
+	return getAWSSubnets(vpcID, i.cfg)
+}
+
+func (i *awsInventory) InstanceTypeExists(region string, zones []string, instanceType string) (bool, error) {
+	client := ec2.NewFromConfig(i.cfg)
+
+	// Call DescribeInstanceTypes API to get details about the instance type
+	diti := &ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []types.InstanceType{types.InstanceType(instanceType)},
+	}
+
+	// unknown instance types are rejected by the API
+	_, err := client.DescribeInstanceTypes(context.TODO(), diti)
+	return err == nil, nil
+}
+
+// KubernetesVersions is not used as the EKS versions are not checked
+func (i *awsInventory) KubernetesVersions(region string) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestValidateAWS(t *testing.T) {
+	t.Parallel()
+	inventory := fakeInventory{
+		regions:       []string{"eu-west-1"},
+		azs:           []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"},
+		privateAZs:    []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"},
+		vpcs:          []string{"vpc-1"},
+		subnets:       []string{"subnet-1", "subnet-2", "subnet-3"},
+		instanceTypes: []string{"m5.xlarge"},
+	}
+	cases := []struct {
+		Name      string
+		Spec      string
+		Inventory func(inv *fakeInventory)
+		Expected  []string
+	}{
+		{
+			Name: "valid",
+			Spec: `
+region: eu-west-1
+control_plane:
+  managed: true
+networks:
+  vpc_id: vpc-1
+  subnets:
+    - subnet_id: subnet-1
+    - subnet_id: subnet-2
+    - subnet_id: subnet-3
+worker_nodes:
+  - name: worker
+    az: eu-west-1a
+    size: m5.xlarge
+`,
+			Expected: []string{},
+		},
+		{
+			Name: "unknown region",
+			Spec: `
+region: eu-north-9
+worker_nodes:
+  - name: worker
+    size: unknown
+`,
+			Expected: []string{"error spec.region"},
+		},
+		{
+			Name: "unknown availability zone and instance type",
+			Spec: `
+region: eu-west-1
+control_plane:
+  managed: true
+worker_nodes:
+  - name: worker
+    az: eu-west-1z
+    size: m5.huge
+`,
+			Expected: []string{
+				"error spec.worker_nodes[0].az",
+				"error spec.worker_nodes[0].size",
+			},
+		},
+		{
+			Name: "unknown VPC and subnets",
+			Spec: `
+region: eu-west-1
+control_plane:
+  managed: true
+networks:
+  vpc_id: vpc-2
+  subnets:
+    - subnet_id: subnet-1
+    - subnet_id: subnet-4
+`,
+			Expected: []string{
+				"error spec.networks.vpc_id",
+				"error spec.networks.subnets[1].subnet_id",
+			},
+		},
+		{
+			Name: "insufficient private availability zones",
+			Spec: `
+region: eu-west-1
+control_plane:
+  managed: true
+networks:
+  vpc_id: vpc-1
+  subnets:
+    - subnet_id: subnet-1
+worker_nodes:
+  - name: worker
+    az: eu-west-1c
+    zone_distribution: unbalanced
+    size: m5.xlarge
+`,
+			Inventory: func(inv *fakeInventory) {
+				inv.privateAZs = []string{"eu-west-1a"}
+			},
+			Expected: []string{
+				"error spec.networks.subnets",
+				"error spec.worker_nodes[0].az",
+			},
+		},
+		{
+			Name: "network lookup failures are warnings",
+			Spec: `
+region: eu-west-1
+control_plane:
+  managed: true
+networks:
+  vpc_id: vpc-1
+  subnets:
+    - subnet_id: subnet-1
+    - subnet_id: subnet-2
+    - subnet_id: subnet-3
+`,
+			Inventory: func(inv *fakeInventory) {
+				inv.err = errors.New("access denied")
+			},
+			Expected: []string{
+				"warning spec.networks.vpc_id",
+				"warning spec.networks.subnets",
+			},
+		},
+		{
+			Name: "unknown control plane instance type",
+			Spec: `
+region: eu-west-1
+control_plane:
+  managed: false
+  size: m5.huge
+`,
+			Expected: []string{"error spec.control_plane.size"},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			inv := inventory
+			if tc.Inventory != nil {
+				tc.Inventory(&inv)
+			}
+			errs := &errorList{}
+			validateAWS(parseSpec(t, tc.Spec), &inv, errs)
+			assert.DeepEqual(t, tc.Expected, findings(errs))
+		})
+	}
+}
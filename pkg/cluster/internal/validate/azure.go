@@ -19,7 +19,6 @@ package validate
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net"
 	"reflect"
 	"regexp"
@@ -50,192 +49,184 @@ var isAzureIdentity = regexp.MustCompile(`(?i)^\/subscriptions\/[\w-]+\/resource
 var AzureIdentityFormat = "/subscriptions/[SUBSCRIPTION_ID]/resourceGroups/[RESOURCE_GROUP]/providers/Microsoft.ManagedIdentity/userAssignedIdentities/[IDENTITY_NAME]"
 var isPremium = regexp.MustCompile(`^(Premium|Ultra).*$`).MatchString
 
-func validateAzure(spec commons.KeosSpec, providerSecrets map[string]string, clusterName string) error {
-	var err error
+func init() {
+	registerInfraValidator("azure", infraValidator{
+		newInventory: func(providerSecrets map[string]string, region string) (Inventory, error) {
+			return newAzureInventory(providerSecrets)
+		},
+		validate: func(keosCluster commons.KeosCluster, inv Inventory, errs *errorList) {
+			validateAzure(keosCluster.Spec, inv, keosCluster.Metadata.Name, errs)
+		},
+	})
+}
 
-	creds, err := validateAzureCredentials(providerSecrets)
-	if err != nil {
-		return err
-	}
+// validateAzure validates the Azure specific settings of the descriptor, the
+// checks that query Azure are skipped if inv is nil (offline validation)
+func validateAzure(spec commons.KeosSpec, inv Inventory, clusterName string, errs *errorList) {
+	var azs []string
 
-	regions, err := getAzureRegions(creds, providerSecrets["SubscriptionID"])
-	if err != nil {
-		return err
-	}
-	if !commons.Contains(regions, spec.Region) {
-		return errors.New("spec.region: " + spec.Region + " region does not exist")
-	}
+	if inv == nil {
+		errs.skip(specPath.child("region"), "region")
+	} else {
+		regions, err := inv.Regions()
+		if err != nil {
+			errs.fail(err, "failed to list the Azure regions")
+			return
+		}
+		if !commons.Contains(regions, spec.Region) {
+			errs.add(specPath.child("region"), spec.Region+" region does not exist", "")
+			return
+		}
 
-	azs, err := getAzureAzs(creds, providerSecrets["SubscriptionID"], spec.Region)
-	if err != nil {
-		return err
+		azs, err = inv.AZs(spec.Region)
+		if err != nil {
+			errs.fail(err, "failed to list the Azure availability zones")
+			return
+		}
 	}
 
-	for _, wn := range spec.WorkerNodes {
+	for i, wn := range spec.WorkerNodes {
+		path := specPath.child("worker_nodes").index(i)
 		if wn.AZ != "" {
-			if len(azs) > 0 {
+			if inv == nil {
+				errs.skip(path.child("az"), "availability zone")
+			} else if len(azs) > 0 {
 				if !commons.Contains(azs, wn.AZ) {
-					return errors.New(wn.AZ + " does not exist in this region, azs: " + fmt.Sprint(azs))
+					errs.add(path.child("az"), wn.AZ+" does not exist in this region", "azs: "+strings.Join(azs, ", "))
 				}
 			}
 		}
 		if wn.Size != "" {
-			if err := validateAzureInstanceType(creds, wn.Size, providerSecrets["SubscriptionID"], spec.Region); err != nil {
-				return errors.New("spec.worker_nodes." + wn.Name + ".size: " + wn.Size + " does not exist as a Azure instance types in region " + spec.Region)
-			}
+			validateInstanceType(inv, spec.Region, nil, wn.Size, path.child("size"), errs)
 		}
 	}
 
 	if (spec.StorageClass != commons.StorageClass{}) {
-		if err = validateAzureStorageClass(spec.StorageClass, spec.WorkerNodes); err != nil {
-			return errors.Wrap(err, "spec.storageclass: Invalid value")
-		}
+		validateAzureStorageClass(spec.StorageClass, spec.WorkerNodes, specPath.child("storageclass"), errs)
 	}
 	if !reflect.ValueOf(spec.Networks).IsZero() {
-		if err = validateAzureNetwork(spec.Networks, spec, creds, providerSecrets["SubscriptionID"], clusterName); err != nil {
-			return errors.Wrap(err, "spec.networks: Invalid value")
-		}
+		validateAzureNetwork(spec.Networks, spec, inv, clusterName, specPath.child("networks"), errs)
 	}
 	if !isAzureIdentity(spec.Security.ControlPlaneIdentity) {
-		return errors.New("spec.security: Invalid value: \"control_plane_identity\": is required and have the format " + AzureIdentityFormat)
+		errs.add(specPath.child("security").child("control_plane_identity"), "is required", "must have the format "+AzureIdentityFormat)
 	}
 	if spec.Security.NodesIdentity != "" {
 		if !isAzureIdentity(spec.Security.NodesIdentity) {
-			return errors.New("spec.security: Invalid value: \"nodes_identity\": it must have the format " + AzureIdentityFormat)
+			errs.add(specPath.child("security").child("nodes_identity"), "invalid format", "must have the format "+AzureIdentityFormat)
 		}
 	}
 
-	firstRegistryType := spec.DockerRegistries[0].Type
 	for i, dr := range spec.DockerRegistries {
-		if firstRegistryType != dr.Type {
-			return errors.New("spec.docker_registries[" + strconv.Itoa(i) + "]: Invalid value: \"type\": inconsistent registry types, the types among the defined registries must be the same")
+		path := specPath.child("docker_registries").index(i).child("type")
+		if spec.DockerRegistries[0].Type != dr.Type {
+			errs.add(path, "inconsistent registry types", "the types among the defined registries must be the same")
 		}
 		if dr.Type != "acr" && spec.ControlPlane.Managed {
-			return errors.New("spec.docker_registries[" + strconv.Itoa(i) + "]: Invalid value: \"type\": only acr is supported in azure managed clusters")
-		}
-		if dr.Type != "acr" && dr.Type != "generic" {
-			return errors.New("spec.docker_registries[" + strconv.Itoa(i) + "]: Invalid value: \"type\": only acr and generic are supported in azure unmanaged clusters")
+			errs.add(path, "only acr is supported in azure managed clusters", "")
+		} else if dr.Type != "acr" && dr.Type != "generic" {
+			errs.add(path, "only acr and generic are supported in azure unmanaged clusters", "")
 		}
 	}
 
 	if spec.ControlPlane.Managed {
-		if err = validateAKSVersion(spec, creds, providerSecrets["SubscriptionID"]); err != nil {
-			return err
-		}
-		if err = validateAKSNodes(spec.WorkerNodes); err != nil {
-			return err
-		}
+		validateAKSVersion(spec, inv, errs)
+		validateAKSNodes(spec.WorkerNodes, errs)
 	}
 
 	if !spec.ControlPlane.Managed {
+		path := specPath.child("control_plane")
 		if spec.ControlPlane.NodeImage != "" {
 			if !isAzureNodeImage(spec.ControlPlane.NodeImage) {
-				return errors.New("spec.control_plane: Invalid value: \"node_image\": must have the format " + AzureNodeImageFormat)
+				errs.add(path.child("node_image"), "must have the format "+AzureNodeImageFormat, "")
 			}
 		}
-		if err := validateAzureInstanceType(creds, spec.ControlPlane.Size, providerSecrets["SubscriptionID"], spec.Region); err != nil {
-			return errors.New("spec.control_plane.size: " + spec.ControlPlane.Size + " does not exist as a GCP instance types in region " + spec.Region)
-		}
-		if err := validateVolumeType(spec.ControlPlane.RootVolume.Type, AzureVolumes); err != nil {
-			return errors.Wrap(err, "spec.control_plane.root_volume: Invalid value: \"type\"")
-		}
-		for i, ev := range spec.ControlPlane.ExtraVolumes {
-			if ev.Name == "" {
-				return errors.New("spec.control_plane.extra_volumes[" + strconv.Itoa(i) + "]: Required value: \"name\"")
-			}
-			if err := validateVolumeType(ev.Type, AzureVolumes); err != nil {
-				return errors.Wrap(err, "spec.control_plane.extra_volumes["+strconv.Itoa(i)+"]: Invalid value: \"type\"")
-			}
-			for _, ev2 := range spec.ControlPlane.ExtraVolumes[i+1:] {
-				if ev.Name == ev2.Name {
-					return errors.New("spec.control_plane.extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"name\": is duplicated")
-				}
-			}
-		}
-		for _, wn := range spec.WorkerNodes {
+		validateInstanceType(inv, spec.Region, nil, spec.ControlPlane.Size, path.child("size"), errs)
+		validateVolumeType(spec.ControlPlane.RootVolume.Type, AzureVolumes, path.child("root_volume").child("type"), errs)
+		validateAzureExtraVolumes(spec.ControlPlane.ExtraVolumes, true, path.child("extra_volumes"), errs)
+		for i, wn := range spec.WorkerNodes {
+			path := specPath.child("worker_nodes").index(i)
 			if wn.NodeImage != "" {
 				if !isAzureNodeImage(wn.NodeImage) {
-					return errors.New("spec.worker_nodes." + wn.Name + ": \"node_image\": must have the format " + AzureNodeImageFormat)
+					errs.add(path.child("node_image"), "must have the format "+AzureNodeImageFormat, "")
 				}
 			}
-			if err := validateVolumeType(wn.RootVolume.Type, AzureVolumes); err != nil {
-				return errors.Wrap(err, "spec.worker_nodes."+wn.Name+".root_volume: Invalid value: \"type\"")
-			}
+			validateVolumeType(wn.RootVolume.Type, AzureVolumes, path.child("root_volume").child("type"), errs)
 
 			premiumStorage := hasAzurePremiumStorage(wn.Size)
 			if isPremium(wn.RootVolume.Type) && !premiumStorage {
-				return errors.New("spec.worker_nodes." + wn.Name + ".root_volume: Invalid value: \"type\": size doesn't support premium storage")
-			}
-			for i, ev := range wn.ExtraVolumes {
-				if ev.Name == "" {
-					return errors.New("spec.worker_nodes." + wn.Name + ".extra_volumes[" + strconv.Itoa(i) + "]: Required value: \"name\"")
-				}
-				if err := validateVolumeType(ev.Type, AzureVolumes); err != nil {
-					return errors.Wrap(err, "spec.worker_nodes."+wn.Name+".extra_volumes["+strconv.Itoa(i)+"]: Invalid value: \"type\"")
-				}
-				if isPremium(ev.Type) && !premiumStorage {
-					return errors.New("spec.worker_nodes." + wn.Name + ".extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"type\": size doesn't support premium storage")
-				}
-				for _, ev2 := range wn.ExtraVolumes[i+1:] {
-					if ev.Name == ev2.Name {
-						return errors.New("spec.worker_nodes." + wn.Name + ".extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"name\": is duplicated")
-					}
-				}
+				errs.add(path.child("root_volume").child("type"), "size doesn't support premium storage", "")
 			}
+			validateAzureExtraVolumes(wn.ExtraVolumes, premiumStorage, path.child("extra_volumes"), errs)
 		}
 	}
+}
 
-	return nil
+func validateAzureExtraVolumes(extraVolumes []commons.ExtraVolume, premiumStorage bool, path fieldPath, errs *errorList) {
+	for i, ev := range extraVolumes {
+		if ev.Name == "" {
+			errs.add(path.index(i).child("name"), "is required", "")
+		}
+		validateVolumeType(ev.Type, AzureVolumes, path.index(i).child("type"), errs)
+		if isPremium(ev.Type) && !premiumStorage {
+			errs.add(path.index(i).child("type"), "size doesn't support premium storage", "")
+		}
+		for _, ev2 := range extraVolumes[:i] {
+			if ev.Name != "" && ev.Name == ev2.Name {
+				errs.add(path.index(i).child("name"), "is duplicated", "")
+			}
+		}
+	}
 }
 
 func validateAzureCredentials(secrets map[string]string) (*azidentity.ClientSecretCredential, error) {
-	creds, err := azidentity.NewClientSecretCredential(secrets["TenantID"], secrets["ClientID"], secrets["ClientSecret"], nil)
+	creds, err := commons.AzureGetConfig(secrets)
 	if err != nil {
 		return &azidentity.ClientSecretCredential{}, err
 	}
 	return creds, nil
 }
 
-func validateAzureStorageClass(sc commons.StorageClass, wn commons.WorkerNodes) error {
-	var err error
+func validateAzureStorageClass(sc commons.StorageClass, wn commons.WorkerNodes, path fieldPath, errs *errorList) {
 	var isKeyValid = regexp.MustCompile(`(?i)^\/subscriptions\/[\w-]+\/resourceGroups\/[\w\.-]+\/providers\/Microsoft\.Compute\/diskEncryptionSets\/[\w\.-]+$`).MatchString
 	var AzureFSTypes = []string{"xfs", "ext3", "ext4", "ext2", "btrfs"}
 	var AzureSCFields = []string{"FsType", "Kind", "CachingMode", "DiskAccessID", "DiskEncryptionSetID", "DiskEncryptionType", "EnableBursting", "EnablePerformancePlus", "NetworkAccessPolicy", "Provisioner", "PublicNetworkAccess", "ResourceGroup", "SkuName", "SubscriptionID", "Tags"}
 	var AzureSCYamlFields = []string{"fsType", "kind", "cachingMode", "diskAccessID", "diskEncryptionSetID", "diskEncryptionType", "enableBursting", "enablePerformancePlus", "networkAccessPolicy", "provisioner", "publicNetworkAccess", "resourceGroup", "skuName", "subscriptionID", "tags"}
+	var diskEncryptionSetFormat = "/subscriptions/[SUBSCRIPTION_ID]/resourceGroups/[RESOURCE_GROUP]/providers/Microsoft.ManagedIdentity/diskEncryptionSets/[DISK_ENCRYPION_SETS_NAME]"
+	parameters := path.child("parameters")
 
 	// Validate fields
 	fields := getFieldNames(sc.Parameters)
 	for _, f := range fields {
 		if !commons.Contains(AzureSCFields, f) {
-			return errors.New("\"parameters\": unsupported " + f + ", supported fields: " + fmt.Sprint(strings.Join(AzureSCYamlFields, ", ")))
+			errs.add(parameters, "unsupported "+f, "supported fields: "+strings.Join(AzureSCYamlFields, ", "))
 		}
 	}
 	// Validate class
 	if sc.Class != "" && sc.Parameters != (commons.SCParameters{}) {
-		return errors.New("\"class\": cannot be set when \"parameters\" is set")
+		errs.add(path.child("class"), "cannot be set when \"parameters\" is set", "")
 	}
 	// Validate type
 	if sc.Parameters.SkuName != "" && !commons.Contains(AzureVolumes, sc.Parameters.SkuName) {
-		return errors.New("\"type\": unsupported " + sc.Parameters.Type + ", supported types: " + fmt.Sprint(strings.Join(AzureVolumes, ", ")))
+		errs.add(parameters.child("skuName"), "unsupported "+sc.Parameters.SkuName, "supported types: "+strings.Join(AzureVolumes, ", "))
 	}
 	// Validate encryptionKey format
 	if sc.EncryptionKey != "" {
 		if sc.Parameters != (commons.SCParameters{}) {
-			return errors.New("\"encryptionKey\": cannot be set when \"parameters\" is set")
+			errs.add(path.child("encryptionKey"), "cannot be set when \"parameters\" is set", "")
 		}
 		if !isKeyValid(sc.EncryptionKey) {
-			return errors.New("\"encryptionKey\": it must have the format /subscriptions/[SUBSCRIPTION_ID]/resourceGroups/[RESOURCE_GROUP]/providers/Microsoft.ManagedIdentity/diskEncryptionSets/[DISK_ENCRYPION_SETS_NAME]")
+			errs.add(path.child("encryptionKey"), "invalid format", "it must have the format "+diskEncryptionSetFormat)
 		}
 	}
 	// Validate diskEncryptionSetID format
 	if sc.Parameters.DiskEncryptionSetID != "" {
 		if !isKeyValid(sc.Parameters.DiskEncryptionSetID) {
-			return errors.New("incorrect diskEncryptionSetID format. It must have the format /subscriptions/[SUBSCRIPTION_ID]/resourceGroups/[RESOURCE_GROUP]/providers/Microsoft.ManagedIdentity/diskEncryptionSets/[DISK_ENCRYPION_SETS_NAME]")
+			errs.add(parameters.child("diskEncryptionSetID"), "invalid format", "it must have the format "+diskEncryptionSetFormat)
 		}
 	}
 	// Validate fsType
 	if sc.Parameters.FsType != "" && !commons.Contains(AzureFSTypes, sc.Parameters.FsType) {
-		return errors.New("unsupported fsType: " + sc.Parameters.FsType + ". Supported types: " + fmt.Sprint(strings.Join(AzureFSTypes, ", ")))
+		errs.add(parameters.child("fsType"), "unsupported "+sc.Parameters.FsType, "supported types: "+strings.Join(AzureFSTypes, ", "))
 	}
 	// Validate size support premium storage
 	if sc.Class == "premium" || isPremium(sc.Parameters.SkuName) {
@@ -247,59 +238,60 @@ func validateAzureStorageClass(sc commons.StorageClass, wn commons.WorkerNodes)
 			}
 		}
 		if !hasPremium {
-			return errors.New("premium storage is not supported in any workers nodes")
+			errs.add(path, "premium storage is not supported in any workers nodes", "")
 		}
 	}
 	// Validate cachingMode
 	if sc.Parameters.CachingMode == "ReadOnly" && sc.Parameters.SkuName == "PremiumV2_LRS" {
-		return errors.New("with skuName PremiumV2_LRS, CachingMode only can be none")
+		errs.add(parameters.child("cachingMode"), "with skuName PremiumV2_LRS, CachingMode only can be none", "")
 	}
 	// Validate tags
 	if sc.Parameters.Tags != "" {
-		if err = validateAzureTag(sc.Parameters.Tags); err != nil {
-			return errors.Wrap(err, "invalid tags")
-		}
+		validateAzureTag(sc.Parameters.Tags, parameters.child("tags"), errs)
 	}
-	return nil
 }
 
-func validateAzureTag(t string) error {
+func validateAzureTag(t string, path fieldPath, errs *errorList) {
 	// The following characters are not supported in Azure: <>%&\?/.
 	var isTag = regexp.MustCompile(`^([\w-]+=[\w-]+)(\s?,\s?[\w-]+=[\w-]+)*$`).MatchString
 	if !isTag(t) {
-		return errors.New("incorrect format. Must have the format 'key1=value1,key2=value2'")
+		errs.add(path, "incorrect format", "must have the format 'key1=value1,key2=value2'")
 	}
-	return nil
 }
 
-func validateAzureNetwork(network commons.Networks, spec commons.KeosSpec, creds *azidentity.ClientSecretCredential, subscription string, clusterName string) error {
+func validateAzureNetwork(network commons.Networks, spec commons.KeosSpec, inv Inventory, clusterName string, path fieldPath, errs *errorList) {
 	rg := clusterName
 	if network.VPCID != "" {
 		if spec.Networks.ResourceGroup != "" {
 			rg = spec.Networks.ResourceGroup
 		}
-		vpcs, err := getAzureVpcs(creds, subscription, spec.Region, rg)
-		if err != nil {
-			return err
-		}
-		if len(vpcs) > 0 && !commons.Contains(vpcs, network.VPCID) {
-			return errors.New("\"vpc_id\": " + network.VPCID + " does not exist in this resourceGroup")
+		if inv == nil {
+			errs.skip(path.child("vpc_id"), "VPC")
+		} else {
+			vpcs, err := inv.VPCs(spec.Region, rg)
+			if err != nil {
+				errs.fail(err, "failed to list the Azure virtual networks")
+				return
+			}
+			if len(vpcs) > 0 && !commons.Contains(vpcs, network.VPCID) {
+				errs.add(path.child("vpc_id"), network.VPCID+" does not exist in this resourceGroup", "")
+			}
 		}
 		if len(network.Subnets) == 0 {
-			return errors.New("\"subnets\": are required when \"vpc_id\" is set")
+			errs.add(path.child("subnets"), "are required when \"vpc_id\" is set", "")
 		}
 		if spec.ControlPlane.Managed && network.VPCCIDRBlock == "" {
-			return errors.New("\"vpc_cidr\": is required when \"vpc_id\" is set")
+			errs.add(path.child("vpc_cidr"), "is required when \"vpc_id\" is set", "")
 		}
 	} else {
 		if len(network.Subnets) > 0 {
-			return errors.New("\"vpc_id\": is required when \"subnets\" is set")
+			errs.add(path.child("vpc_id"), "is required when \"subnets\" is set", "")
 		}
 		if network.VPCCIDRBlock != "" {
 			if spec.ControlPlane.Managed {
-				return errors.New("\"vpc_id\": is required when \"vpc_cidr\" is set")
+				errs.add(path.child("vpc_id"), "is required when \"vpc_cidr\" is set", "")
 			} else {
-				return errors.New("\"vpc_cidr\": is only supported in azure managed clusters")
+				errs.add(path.child("vpc_cidr"), "is only supported in azure managed clusters", "")
 			}
 		}
 	}
@@ -307,103 +299,72 @@ func validateAzureNetwork(network commons.Networks, spec commons.KeosSpec, creds
 		const cidrSizeMin = 256
 		_, ipv4Net, err := net.ParseCIDR(network.VPCCIDRBlock)
 		if err != nil {
-			return errors.New("\"pods_cidr\": CIDR block must be a valid IPv4 CIDR block")
-		}
-		cidrSize := cidr.AddressCount(ipv4Net)
-		if cidrSize < cidrSizeMin {
-			return errors.New("\"vpc_cidr\": CIDR block size must be at least /24 netmask")
-		}
-	}
-	if len(network.Subnets) > 0 {
-		subnets, err := getAzureSubnets(creds, subscription, rg, network.VPCID)
-		if err != nil {
-			return err
+			errs.add(path.child("vpc_cidr"), "CIDR block must be a valid IPv4 CIDR block", "")
+		} else if cidr.AddressCount(ipv4Net) < cidrSizeMin {
+			errs.add(path.child("vpc_cidr"), "CIDR block size must be at least /24 netmask", "")
+		}
+	}
+	if len(network.Subnets) > 0 && network.VPCID != "" {
+		var subnets []string
+		if inv == nil {
+			errs.skip(path.child("subnets"), "subnets")
+		} else {
+			var err error
+			subnets, err = inv.Subnets(spec.Region, rg, network.VPCID)
+			if err != nil {
+				errs.fail(err, "failed to list the Azure subnets")
+				return
+			}
 		}
-		for _, s := range network.Subnets {
+		for i, s := range network.Subnets {
+			path := path.child("subnets").index(i)
 			if s.SubnetId == "" {
-				return errors.New("\"subnet_id\": is required")
-			}
-			if len(subnets) > 0 && !commons.Contains(subnets, s.SubnetId) {
-				return errors.New("\"subnet_id\": " + s.SubnetId + " does not belong to VPC: " + network.VPCID + " and resourceGroup: " + rg)
+				errs.add(path.child("subnet_id"), "is required", "")
+			} else if len(subnets) > 0 && !commons.Contains(subnets, s.SubnetId) {
+				errs.add(path.child("subnet_id"), s.SubnetId+" does not belong to VPC: "+network.VPCID+" and resourceGroup: "+rg, "")
 			}
 			if spec.ControlPlane.Managed {
 				if s.CidrBlock == "" {
-					return errors.New("\"cidr\": is required")
+					errs.add(path.child("cidr"), "is required", "")
 				}
 				if s.Role != "" {
-					return errors.New("\"role\": is only supported in azure unmanaged clusters")
+					errs.add(path.child("role"), "is only supported in azure unmanaged clusters", "")
 				}
 			} else {
 				if s.Role == "" {
-					return errors.New("\"role\": is required")
+					errs.add(path.child("role"), "is required", "")
 				}
 				if s.CidrBlock != "" {
-					return errors.New("\"cidr\": is only supported in azure managed clusters")
+					errs.add(path.child("cidr"), "is only supported in azure managed clusters", "")
 				}
 			}
 		}
 	}
-	return nil
-}
-
-func validateAzureInstanceType(creds *azidentity.ClientSecretCredential, instanceType string, subscription string, region string) error {
-	ctx := context.Background()
-	clientFactory, err := armcompute.NewClientFactory(subscription, creds, nil)
-	if err != nil {
-		return err
-	}
-
-	pager := clientFactory.NewResourceSKUsClient().NewListPager(&armcompute.ResourceSKUsClientListOptions{
-		Filter:                   to.Ptr("location eq '" + region + "'"),
-		IncludeExtendedLocations: nil,
-	})
-
-	for pager.More() {
-		page, err := pager.NextPage(ctx)
-		if err != nil {
-			return err
-		}
-		for _, sku := range page.Value {
-			if *sku.Name == instanceType && *sku.ResourceType == "virtualMachines" {
-				return nil
-			}
-		}
-	}
-
-	return errors.New("nonexistent instance type: " + instanceType + " in region " + region)
 }
 
-func validateAKSVersion(spec commons.KeosSpec, creds *azidentity.ClientSecretCredential, subscription string) error {
-	var availableVersions []string
-	ctx := context.Background()
-	clientFactory, err := armcontainerservice.NewClientFactory(subscription, creds, nil)
-	if err != nil {
-		return err
+// validateAKSVersion checks that the k8s_version is offered by AKS, the
+// check is skipped if inv is nil (offline validation)
+func validateAKSVersion(spec commons.KeosSpec, inv Inventory, errs *errorList) {
+	if inv == nil {
+		errs.skip(specPath.child("k8s_version"), "AKS version")
+		return
 	}
-	res, err := clientFactory.NewManagedClustersClient().ListKubernetesVersions(ctx, spec.Region, nil)
+	availableVersions, err := inv.KubernetesVersions(spec.Region)
 	if err != nil {
-		return err
-	}
-	for _, v := range res.KubernetesVersionListResult.Values {
-		for _, p := range v.PatchVersions {
-			for _, u := range p.Upgrades {
-				if !commons.Contains(availableVersions, *u) {
-					availableVersions = append(availableVersions, *u)
-				}
-			}
-		}
+		errs.fail(err, "failed to list the AKS versions")
+		return
 	}
 	if !slices.Contains(availableVersions, strings.ReplaceAll(spec.K8SVersion, "v", "")) {
 		a, _ := json.Marshal(availableVersions)
-		return errors.New("spec: Invalid value: \"k8s_version\": AKS only supports Kubernetes versions: " + string(a))
+		errs.add(specPath.child("k8s_version"), "unsupported AKS version "+spec.K8SVersion, "AKS only supports Kubernetes versions: "+string(a))
 	}
-	return nil
 }
 
-func validateAKSNodes(wn commons.WorkerNodes) error {
+func validateAKSNodes(wn commons.WorkerNodes, errs *errorList) {
 	var isLetter = regexp.MustCompile(`^[a-z0-9]+$`).MatchString
 	var numberOfSystemPool = 0
-	for _, n := range wn {
+	for i, n := range wn {
+		path := specPath.child("worker_nodes").index(i)
 		isBalanced := n.ZoneDistribution == "balanced" || (n.ZoneDistribution == "" && n.AZ == "")
 		isSystemPool := len(n.Taints) == 0 && !n.Spot && (n.NodeGroupMinSize == nil || *n.NodeGroupMinSize != 0)
 		if isSystemPool {
@@ -415,20 +376,19 @@ func validateAKSNodes(wn commons.WorkerNodes) error {
 				minSizeThreshold = 1
 			}
 			if *n.NodeGroupMinSize < minSizeThreshold {
-				return errors.New("spec.worker_nodes." + n.Name + ": as a system node group must have min_size equal or greater than " + strconv.Itoa(minSizeThreshold))
+				errs.add(path.child("min_size"), "as a system node group must be equal or greater than "+strconv.Itoa(minSizeThreshold), "")
 			}
 		}
 		if !isLetter(n.Name) || len(n.Name) >= AKSMaxNodeNameLength {
-			return errors.New("spec.worker_nodes." + n.Name + ": Invalid value \"name\": in AKS must be " + strconv.Itoa(AKSMaxNodeNameLength) + " characters or less & contain only lowercase alphanumeric characters")
+			errs.add(path.child("name"), n.Name+" is invalid", "in AKS must be "+strconv.Itoa(AKSMaxNodeNameLength)+" characters or less & contain only lowercase alphanumeric characters")
 		}
 		if n.RootVolume.Type != "" && !commons.Contains(AzureAKSVolumes, n.RootVolume.Type) {
-			return errors.New("spec.worker_nodes." + n.Name + ".root_volume: Invalid value \"type\": " + n.RootVolume.Type + " unsupported, supported types: " + fmt.Sprint(strings.Join(AzureAKSVolumes, ", ")))
+			errs.add(path.child("root_volume").child("type"), "unsupported type "+n.RootVolume.Type, "supported types: "+strings.Join(AzureAKSVolumes, ", "))
 		}
 	}
 	if numberOfSystemPool == 0 {
-		return errors.New("spec.worker_nodes: At least one system node group must exist")
+		errs.add(specPath.child("worker_nodes"), "at least one system node group must exist", "")
 	}
-	return nil
 }
 
 func hasAzurePremiumStorage(s string) bool {
@@ -439,7 +399,7 @@ func getAzureAzs(creds *azidentity.ClientSecretCredential, subscription string,
 	azs := []string{}
 
 	ctx := context.Background()
-	clientFactory, err := armsubscriptions.NewClientFactory(creds, nil)
+	clientFactory, err := armsubscriptions.NewClientFactory(creds, commons.AzureClientOptions())
 	if err != nil {
 		return []string{}, err
 	}
@@ -466,7 +426,7 @@ func getAzureRegions(creds *azidentity.ClientSecretCredential, subscription stri
 	regions := []string{}
 
 	ctx := context.Background()
-	clientFactory, err := armsubscriptions.NewClientFactory(creds, nil)
+	clientFactory, err := armsubscriptions.NewClientFactory(creds, commons.AzureClientOptions())
 	if err != nil {
 		return []string{}, err
 	}
@@ -489,7 +449,7 @@ func getAzureRegions(creds *azidentity.ClientSecretCredential, subscription stri
 func getAzureVpcs(creds *azidentity.ClientSecretCredential, subscription string, region string, resourceGroup string) ([]string, error) {
 	ctx := context.Background()
 	vpcs := []string{}
-	clientFactory, err := armnetwork.NewClientFactory(subscription, creds, nil)
+	clientFactory, err := armnetwork.NewClientFactory(subscription, creds, commons.AzureClientOptions())
 	if err != nil {
 		return []string{}, err
 	}
@@ -511,7 +471,7 @@ func getAzureVpcs(creds *azidentity.ClientSecretCredential, subscription string,
 func getAzureSubnets(creds *azidentity.ClientSecretCredential, subscription string, resourceGroup string, vpcId string) ([]string, error) {
 	ctx := context.Background()
 	subnets := []string{}
-	clientFactory, err := armnetwork.NewClientFactory(subscription, creds, nil)
+	clientFactory, err := armnetwork.NewClientFactory(subscription, creds, commons.AzureClientOptions())
 	if err != nil {
 		return []string{}, err
 	}
@@ -527,3 +487,87 @@ func getAzureSubnets(creds *azidentity.ClientSecretCredential, subscription stri
 	}
 	return subnets, nil
 }
+
+// azureInventory lists the Azure resources with the Resource Manager API
+type azureInventory struct {
+	creds        *azidentity.ClientSecretCredential
+	subscription string
+}
+
+func newAzureInventory(providerSecrets map[string]string) (Inventory, error) {
+	creds, err := validateAzureCredentials(providerSecrets)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the Azure credentials")
+	}
+	return &azureInventory{creds: creds, subscription: providerSecrets["SubscriptionID"]}, nil
+}
+
+func (i *azureInventory) Regions() ([]string, error) {
+	return getAzureRegions(i.creds, i.subscription)
+}
+
+func (i *azureInventory) AZs(region string) ([]string, error) {
+	return getAzureAzs(i.creds, i.subscription, region)
+}
+
+// PrivateAZs is not used as the subnets' zones are not checked in Azure
+func (i *azureInventory) PrivateAZs(region string, subnetIDs []string) ([]string, error) {
+	return nil, nil
+}
+
+func (i *azureInventory) VPCs(region string, resourceGroup string) ([]string, error) {
+	return getAzureVpcs(i.creds, i.subscription, region, resourceGroup)
+}
+
+func (i *azureInventory) Subnets(region string, resourceGroup string, vpcID string) ([]string, error) {
+	return getAzureSubnets(i.creds, i.subscription, resourceGroup, vpcID)
+}
+
+func (i *azureInventory) InstanceTypeExists(region string, zones []string, instanceType string) (bool, error) {
+	ctx := context.Background()
+	clientFactory, err := armcompute.NewClientFactory(i.subscription, i.creds, commons.AzureClientOptions())
+	if err != nil {
+		return false, err
+	}
+
+	pager := clientFactory.NewResourceSKUsClient().NewListPager(&armcompute.ResourceSKUsClientListOptions{
+		Filter:                   to.Ptr("location eq '" + region + "'"),
+		IncludeExtendedLocations: nil,
+	})
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, sku := range page.Value {
+			if *sku.Name == instanceType && *sku.ResourceType == "virtualMachines" {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (i *azureInventory) KubernetesVersions(region string) ([]string, error) {
+	var availableVersions []string
+	ctx := context.Background()
+	clientFactory, err := armcontainerservice.NewClientFactory(i.subscription, i.creds, commons.AzureClientOptions())
+	if err != nil {
+		return nil, err
+	}
+	res, err := clientFactory.NewManagedClustersClient().ListKubernetesVersions(ctx, region, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range res.KubernetesVersionListResult.Values {
+		for _, p := range v.PatchVersions {
+			for _, u := range p.Upgrades {
+				if !commons.Contains(availableVersions, *u) {
+					availableVersions = append(availableVersions, *u)
+				}
+			}
+		}
+	}
+	return availableVersions, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestValidateAzure(t *testing.T) {
+	t.Parallel()
+	inventory := fakeInventory{
+		regions:       []string{"westeurope"},
+		azs:           []string{"1", "2", "3"},
+		vpcs:          []string{"vnet-1"},
+		subnets:       []string{"subnet-1"},
+		instanceTypes: []string{"Standard_D8s_v3"},
+		k8sVersions:   []string{"1.26.6", "1.27.3"},
+	}
+	cases := []struct {
+		Name     string
+		Spec     string
+		Expected []string
+	}{
+		{
+			Name: "valid",
+			Spec: `
+region: westeurope
+k8s_version: v1.26.6
+control_plane:
+  managed: true
+security:
+  control_plane_identity: /subscriptions/1234/resourcegroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/cp
+networks:
+  vpc_id: vnet-1
+  vpc_cidr: 10.0.0.0/16
+  subnets:
+    - subnet_id: subnet-1
+      cidr: 10.0.1.0/24
+worker_nodes:
+  - name: worker
+    az: "1"
+    size: Standard_D8s_v3
+`,
+			Expected: []string{},
+		},
+		{
+			Name: "unknown region",
+			Spec: `
+region: northeurope
+`,
+			Expected: []string{"error spec.region"},
+		},
+		{
+			Name: "unknown AKS version, zone and instance type",
+			Spec: `
+region: westeurope
+k8s_version: v1.25.1
+control_plane:
+  managed: true
+security:
+  control_plane_identity: /subscriptions/1234/resourcegroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/cp
+worker_nodes:
+  - name: worker
+    az: "4"
+    size: Standard_Huge
+`,
+			Expected: []string{
+				"error spec.worker_nodes[0].az",
+				"error spec.worker_nodes[0].size",
+				"error spec.k8s_version",
+			},
+		},
+		{
+			Name: "unknown VNet and subnet",
+			Spec: `
+region: westeurope
+k8s_version: v1.26.6
+control_plane:
+  managed: true
+security:
+  control_plane_identity: /subscriptions/1234/resourcegroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/cp
+networks:
+  vpc_id: vnet-2
+  vpc_cidr: 10.0.0.0/16
+  subnets:
+    - subnet_id: subnet-2
+      cidr: 10.0.1.0/24
+worker_nodes:
+  - name: worker
+    size: Standard_D8s_v3
+`,
+			Expected: []string{
+				"error spec.networks.vpc_id",
+				"error spec.networks.subnets[0].subnet_id",
+			},
+		},
+		{
+			Name: "unknown control plane instance type",
+			Spec: `
+region: westeurope
+control_plane:
+  managed: false
+  size: Standard_Huge
+security:
+  control_plane_identity: /subscriptions/1234/resourcegroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/cp
+`,
+			Expected: []string{"error spec.control_plane.size"},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			inv := inventory
+			errs := &errorList{}
+			validateAzure(parseSpec(t, tc.Spec), &inv, "cluster", errs)
+			assert.DeepEqual(t, tc.Expected, findings(errs))
+		})
+	}
+}
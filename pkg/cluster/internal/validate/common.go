@@ -17,14 +17,12 @@ limitations under the License.
 package validate
 
 import (
-	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"golang.org/x/exp/slices"
 	"sigs.k8s.io/kind/pkg/commons"
-	"sigs.k8s.io/kind/pkg/errors"
 )
 
 const (
@@ -32,106 +30,101 @@ const (
 	MinWorkerNodeNameLength = 3
 )
 
-var k8sVersionSupported = []string{"1.24", "1.25", "1.26", "1.27", "1.28"}
-
-func validateCommon(spec commons.KeosSpec) error {
-	var err error
-	if err = validateK8SVersion(spec.K8SVersion); err != nil {
-		return err
-	}
-	if err = validateWorkers(spec.WorkerNodes); err != nil {
-		return err
-	}
-	if err = validateVolumes(spec); err != nil {
-		return err
-	}
-	return nil
+func validateCommon(spec commons.KeosSpec, errs *errorList) {
+	validateK8SVersion(spec.K8SVersion, errs)
+	validateWorkers(spec.WorkerNodes, errs)
+	validateVolumes(spec, errs)
 }
 
-func validateK8SVersion(v string) error {
+func validateK8SVersion(v string, errs *errorList) {
+	path := specPath.child("k8s_version")
 	var isVersion = regexp.MustCompile(`^v\d.\d{2}.\d{1,2}(-gke.\d{3,4})?$`).MatchString
 	if !isVersion(v) {
-		return errors.New("spec: Invalid value: \"k8s_version\": regex used for validation is '^v\\d.\\d{2}.\\d{1,2}(-gke.\\d{3,4})?$'")
+		errs.add(path, "invalid format", "regex used for validation is '^v\\d.\\d{2}.\\d{1,2}(-gke.\\d{3,4})?$'")
+		return
 	}
 	K8sVersionMM := strings.Split(v, ".")
 	k8sVersion := strings.Join(K8sVersionMM[:2], ".")
+	k8sVersionSupported := commons.GetBOM().Kubernetes.Supported
 	if !slices.Contains(k8sVersionSupported, strings.ReplaceAll(k8sVersion, "v", "")) {
-		return errors.New("spec: Invalid value: \"k8s_version\": kubernetes versions supported: " + fmt.Sprint(strings.Join(k8sVersionSupported, ", ")))
+		errs.add(path, "unsupported kubernetes version "+v, "kubernetes versions supported: "+strings.Join(k8sVersionSupported, ", "))
 	}
-	return nil
 }
 
-func validateWorkers(wn commons.WorkerNodes) error {
-	if err := validateWorkersName(wn); err != nil {
-		return err
-	}
-	if err := validateWorkersQuantity(wn); err != nil {
-		return err
+func validateCABundle(path string, errs *errorList) {
+	if path == "" {
+		return
 	}
-	if err := validateWorkersTaints(wn); err != nil {
-		return err
+	if _, err := commons.ReadCABundle(path); err != nil {
+		errs.add(specPath.child("ca_bundle"), err.Error(), "set the path of a PEM file with the CAs to be trusted")
 	}
-	if err := validateWorkersType(wn); err != nil {
-		return err
-	}
-	return nil
 }
 
-func validateWorkersName(workerNodes commons.WorkerNodes) error {
+func validateWorkers(wn commons.WorkerNodes, errs *errorList) {
+	validateWorkersName(wn, errs)
+	validateWorkersQuantity(wn, errs)
+	validateWorkersTaints(wn, errs)
+	validateWorkersType(wn, errs)
+}
+
+func validateWorkersName(workerNodes commons.WorkerNodes, errs *errorList) {
 	regex := regexp.MustCompile(`^[-a-z]([-a-z0-9]*[a-z0-9])+$`)
 	for i, worker := range workerNodes {
+		path := specPath.child("worker_nodes").index(i).child("name")
 		// Validate worker name
 		if !regex.MatchString(worker.Name) {
-			return errors.New(worker.Name + " is invalid: " +
-				"must consist of lower case alphanumeric characters, '-' or '.', start with an alphabetic character and end with an alphanumeric character, " +
-				"regex used for validation is '" + regex.String() + "'")
+			errs.add(path, worker.Name+" is invalid: "+
+				"must consist of lower case alphanumeric characters, '-' or '.', start with an alphabetic character and end with an alphanumeric character",
+				"regex used for validation is '"+regex.String()+"'")
 		}
 		// Validate worker name length
 		if len([]rune(worker.Name)) > MaxWorkerNodeNameLength || len([]rune(worker.Name)) < MinWorkerNodeNameLength {
-			return errors.New(worker.Name + " is invalid: must be no more than " +
-				strconv.Itoa(MaxWorkerNodeNameLength) + " & no less than " +
-				strconv.Itoa(MinWorkerNodeNameLength) + " characters long",
-			)
+			errs.add(path, worker.Name+" is invalid: must be no more than "+
+				strconv.Itoa(MaxWorkerNodeNameLength)+" & no less than "+
+				strconv.Itoa(MinWorkerNodeNameLength)+" characters long", "")
 		}
 		// Validate worker name uniqueness
-		for j, worker2 := range workerNodes {
-			if i != j && worker.Name == worker2.Name {
-				return errors.New("WorkerNodes name " + worker.Name + " is duplicated")
+		for _, worker2 := range workerNodes[:i] {
+			if worker.Name == worker2.Name {
+				errs.add(path, worker.Name+" is duplicated", "worker node names must be unique")
+				break
 			}
 		}
 	}
-	return nil
 }
 
-func validateWorkersQuantity(workerNodes commons.WorkerNodes) error {
+func validateWorkersQuantity(workerNodes commons.WorkerNodes, errs *errorList) {
 	var InitialBalancedWorkerNode int
 	var InitialUnBalancedWorkerNode int
 	numberOfNodes := len(workerNodes)
 
-	for _, wn := range workerNodes {
+	for i, wn := range workerNodes {
+		path := specPath.child("worker_nodes").index(i)
 		var isBalanced = wn.ZoneDistribution == "balanced" || (wn.ZoneDistribution == "" && wn.AZ == "")
 
 		// Cluster Autoscaler doesn't scale a managed node group lower than minSize or higher than maxSize.
 		if wn.NodeGroupMaxSize < *wn.Quantity && wn.NodeGroupMaxSize != 0 {
-			return errors.New("max_size in WorkerNodes " + wn.Name + " must be equal or greater than quantity")
+			errs.add(path.child("max_size"), "must be equal or greater than quantity", "")
 		}
 		if wn.NodeGroupMinSize != nil && (*wn.Quantity < *wn.NodeGroupMinSize) {
-			return errors.New("quantity in WorkerNodes " + wn.Name + " must be equal or greater than min_size")
+			errs.add(path.child("quantity"), "must be equal or greater than min_size", "")
 		}
 		if wn.AZ != "" && wn.ZoneDistribution != "" {
-			return errors.New("az and zone_distribution cannot be used at the same time for " + wn.Name)
+			errs.add(path.child("az"), "az and zone_distribution cannot be used at the same time", "remove one of them")
 		}
 		if isBalanced && *wn.Quantity%3 != 0 {
-			return errors.New("quantity in WorkerNode: " + wn.Name + " must be zero or multiple of 3")
+			errs.add(path.child("quantity"), "must be zero or multiple of 3", "balanced worker nodes are spread across 3 zones, set zone_distribution: unbalanced otherwise")
 		}
 
 		// Validate when only one WorkerNode is defined
 		if numberOfNodes == 1 {
 			switch {
-			case isBalanced && (*wn.Quantity == 0 || *wn.Quantity%3 != 0):
-				return errors.New("in case of defining one WorkerNode, quantity must be multiple of 3 for " + wn.Name)
+			case isBalanced && *wn.Quantity == 0:
+				errs.add(path.child("quantity"), "in case of defining one WorkerNode, quantity must be multiple of 3", "")
+			case isBalanced && *wn.Quantity%3 != 0:
+				// already reported
 			case !isBalanced && *wn.Quantity < 1:
-				return errors.New("in case of defining one WorkerNode, quantity must be greater than 0 for " + wn.Name)
+				errs.add(path.child("quantity"), "in case of defining one WorkerNode, quantity must be greater than 0", "")
 			default:
 				if isBalanced {
 					InitialBalancedWorkerNode++
@@ -148,26 +141,23 @@ func validateWorkersQuantity(workerNodes commons.WorkerNodes) error {
 		}
 	}
 
-	if InitialBalancedWorkerNode == 0 && InitialUnBalancedWorkerNode == 0 {
-		return errors.New("at least one WorkerNode must have quantity equal or greater than 1 for unbalanced and greater than 0 and multiple of 3 for balanced")
+	if numberOfNodes != 1 && InitialBalancedWorkerNode == 0 && InitialUnBalancedWorkerNode == 0 {
+		errs.add(specPath.child("worker_nodes"), "at least one WorkerNode must have quantity equal or greater than 1 for unbalanced and greater than 0 and multiple of 3 for balanced", "")
 	}
-
-	return nil
 }
 
-func validateWorkersTaints(wns commons.WorkerNodes) error {
+func validateWorkersTaints(wns commons.WorkerNodes, errs *errorList) {
 	regex := regexp.MustCompile(`^(\w+|.*)=(\w+|.*):(NoSchedule|PreferNoSchedule|NoExecute)$`)
-	for _, wn := range wns {
-		for i, taint := range wn.Taints {
+	for i, wn := range wns {
+		for j, taint := range wn.Taints {
 			if !regex.MatchString(taint) {
-				return errors.New("Incorrect taint format in taint[" + strconv.Itoa(i) + "] of wn: " + wn.Name + "")
+				errs.add(specPath.child("worker_nodes").index(i).child("taints").index(j), "incorrect taint format", "must have the format key=value:NoSchedule|PreferNoSchedule|NoExecute")
 			}
 		}
 	}
-	return nil
 }
 
-func validateWorkersType(wns commons.WorkerNodes) error {
+func validateWorkersType(wns commons.WorkerNodes, errs *errorList) {
 	hasNodeSystem := false
 	for _, wn := range wns {
 		if len(wn.Taints) == 0 && !wn.Spot {
@@ -175,42 +165,34 @@ func validateWorkersType(wns commons.WorkerNodes) error {
 		}
 	}
 	if !hasNodeSystem {
-		return errors.New("at least one worker node must be non spot and without taints")
+		errs.add(specPath.child("worker_nodes"), "at least one worker node must be non spot and without taints", "")
 	}
-	return nil
 }
 
-func validateVolumes(spec commons.KeosSpec) error {
+func validateVolumes(spec commons.KeosSpec, errs *errorList) {
 	if !spec.ControlPlane.Managed {
-		for i, ev := range spec.ControlPlane.ExtraVolumes {
-			for _, ev2 := range spec.ControlPlane.ExtraVolumes[i+1:] {
-				if ev.Label == ev2.Label {
-					return errors.New("spec.control_plane.extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"label\": is duplicated")
-				}
-				if ev.MountPath == ev2.MountPath {
-					return errors.New("spec.control_plane.extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"mount_path\": is duplicated")
-				}
-			}
-		}
+		validateExtraVolumesUniqueness(spec.ControlPlane.ExtraVolumes, specPath.child("control_plane").child("extra_volumes"), errs)
 	}
-	for _, wn := range spec.WorkerNodes {
-		for i, ev := range wn.ExtraVolumes {
-			for _, ev2 := range wn.ExtraVolumes[i+1:] {
-				if ev.Label == ev2.Label {
-					return errors.New("spec.worker_nodes." + wn.Name + ".extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"label\": is duplicated")
-				}
-				if ev.MountPath == ev2.MountPath {
-					return errors.New("spec.worker_nodes." + wn.Name + ".extra_volumes[" + strconv.Itoa(i) + "]: Invalid value: \"mount_path\": is duplicated")
-				}
+	for i, wn := range spec.WorkerNodes {
+		validateExtraVolumesUniqueness(wn.ExtraVolumes, specPath.child("worker_nodes").index(i).child("extra_volumes"), errs)
+	}
+}
+
+func validateExtraVolumesUniqueness(extraVolumes []commons.ExtraVolume, path fieldPath, errs *errorList) {
+	for i, ev := range extraVolumes {
+		for _, ev2 := range extraVolumes[:i] {
+			if ev.Label == ev2.Label {
+				errs.add(path.index(i).child("label"), "is duplicated", "")
+			}
+			if ev.MountPath == ev2.MountPath {
+				errs.add(path.index(i).child("mount_path"), "is duplicated", "")
 			}
 		}
 	}
-	return nil
 }
 
-func validateVolumeType(t string, supportedTypes []string) error {
+func validateVolumeType(t string, supportedTypes []string, path fieldPath, errs *errorList) {
 	if t != "" && !commons.Contains(supportedTypes, t) {
-		return errors.New(t + ", supported types: " + fmt.Sprint(strings.Join(supportedTypes, ", ")))
+		errs.add(path, "unsupported type "+t, "supported types: "+strings.Join(supportedTypes, ", "))
 	}
-	return nil
 }
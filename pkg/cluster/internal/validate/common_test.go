@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestValidateCABundle(t *testing.T) {
+	t.Parallel()
+	errs := &errorList{}
+	validateCABundle("", errs)
+	validateCABundle("testdata/nonexistent.pem", errs)
+	paths := []string{}
+	for _, fieldErr := range commons.FieldErrors(errs.aggregate()) {
+		paths = append(paths, fieldErr.Path)
+	}
+	assert.DeepEqual(t, []string{"spec.ca_bundle"}, paths)
+
+	// a valid bundle is accepted
+	errs = &errorList{}
+	validateCABundle("../../../commons/testdata/ca.pem", errs)
+	assert.DeepEqual(t, 0, len(errs.errs))
+}
+
+func TestValidateCommon(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name     string
+		Spec     string
+		Expected []string
+	}{
+		{
+			Name: "valid",
+			Spec: `
+k8s_version: v1.26.8
+worker_nodes:
+  - name: worker
+    quantity: 3
+    size: m5.xlarge
+`,
+			Expected: []string{},
+		},
+		{
+			Name: "every problem is reported",
+			Spec: `
+k8s_version: 1.26
+worker_nodes:
+  - name: worker
+    quantity: 3
+    size: m5.xlarge
+    taints: ["key=value:NoSchedule"]
+  - name: worker
+    quantity: 3
+    size: m5.xlarge
+    spot: true
+  - name: spread
+    quantity: 2
+    size: m5.xlarge
+    taints: ["invalid"]
+`,
+			Expected: []string{
+				"spec.k8s_version",
+				"spec.worker_nodes[1].name",
+				"spec.worker_nodes[2].quantity",
+				"spec.worker_nodes[2].taints[0]",
+				"spec.worker_nodes",
+			},
+		},
+		{
+			Name: "duplicated extra volumes",
+			Spec: `
+k8s_version: v1.26.8
+worker_nodes:
+  - name: worker
+    quantity: 3
+    size: m5.xlarge
+    extra_volumes:
+      - label: data
+        mount_path: /data
+      - label: data
+        mount_path: /other
+`,
+			Expected: []string{"spec.worker_nodes[0].extra_volumes[1].label"},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			spec := commons.KeosSpec{}
+			if err := yaml.Unmarshal([]byte(tc.Spec), &spec); err != nil {
+				t.Fatalf("failed to parse spec: %v", err)
+			}
+			errs := &errorList{}
+			validateCommon(spec, errs)
+			paths := []string{}
+			for _, fieldErr := range commons.FieldErrors(errs.aggregate()) {
+				assert.StringEqual(t, string(commons.SeverityError), string(fieldErr.Severity))
+				paths = append(paths, fieldErr.Path)
+			}
+			assert.DeepEqual(t, tc.Expected, paths)
+		})
+	}
+}
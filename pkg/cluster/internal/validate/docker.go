@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"sigs.k8s.io/kind/pkg/commons"
+)
+
+func init() {
+	registerInfraValidator("docker", infraValidator{
+		validate: func(keosCluster commons.KeosCluster, inv Inventory, errs *errorList) {
+			validateDocker(keosCluster.Spec, errs)
+		},
+	})
+}
+
+// validateDocker validates the settings of a workload cluster created with
+// Cluster API Provider Docker, whose nodes are containers of the local host
+func validateDocker(spec commons.KeosSpec, errs *errorList) {
+	if spec.ControlPlane.Managed {
+		errs.add(specPath.child("control_plane").child("managed"), "there is no managed control plane in docker", "set it to false")
+	}
+	for i, dockerRegistry := range spec.DockerRegistries {
+		if dockerRegistry.Type != "generic" {
+			errs.add(specPath.child("docker_registries").index(i).child("type"), dockerRegistry.Type+" registries are not supported in docker", "use a generic registry")
+		}
+	}
+	for i, wn := range spec.WorkerNodes {
+		if wn.Spot {
+			errs.add(specPath.child("worker_nodes").index(i).child("spot"), "there are no spot instances in docker", "")
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestValidateDocker(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name     string
+		Spec     string
+		Expected []string
+	}{
+		{
+			Name: "valid",
+			Spec: `
+region: local
+control_plane:
+  managed: false
+docker_registries:
+  - url: registry.example.com
+    type: generic
+worker_nodes:
+  - name: worker
+    quantity: 2
+    size: docker
+`,
+			Expected: []string{},
+		},
+		{
+			Name: "cloud settings",
+			Spec: `
+region: local
+control_plane:
+  managed: true
+docker_registries:
+  - url: registry.example.com
+    type: ecr
+worker_nodes:
+  - name: worker
+    quantity: 2
+    size: docker
+    spot: true
+`,
+			Expected: []string{
+				"error spec.control_plane.managed",
+				"error spec.docker_registries[0].type",
+				"error spec.worker_nodes[0].spot",
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			errs := &errorList{}
+			validateDocker(parseSpec(t, tc.Spec), errs)
+			assert.DeepEqual(t, tc.Expected, findings(errs))
+		})
+	}
+}
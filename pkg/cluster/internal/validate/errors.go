@@ -0,0 +1,97 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"strconv"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// fieldPath is the JSON path of a descriptor field
+type fieldPath string
+
+const specPath fieldPath = "spec"
+
+func (p fieldPath) child(name string) fieldPath {
+	if p == "" {
+		return fieldPath(name)
+	}
+	return p + "." + fieldPath(name)
+}
+
+func (p fieldPath) index(i int) fieldPath {
+	return p + "[" + fieldPath(strconv.Itoa(i)) + "]"
+}
+
+// errorList collects every problem found validating the descriptor
+type errorList struct {
+	errs []error
+}
+
+// add records an error in the field at path
+func (l *errorList) add(path fieldPath, message string, hint string) {
+	l.errs = append(l.errs, &commons.FieldError{Path: string(path), Severity: commons.SeverityError, Message: message, Hint: hint})
+}
+
+// warn records a warning in the field at path
+func (l *errorList) warn(path fieldPath, message string, hint string) {
+	l.errs = append(l.errs, &commons.FieldError{Path: string(path), Severity: commons.SeverityWarning, Message: message, Hint: hint})
+}
+
+// skip records that the check of the field at path was skipped because it
+// needs to query the cloud provider or read the secrets
+func (l *errorList) skip(path fieldPath, check string) {
+	l.errs = append(l.errs, &commons.FieldError{Path: string(path), Severity: commons.SeveritySkipped, Message: check + " check skipped", Hint: "offline validation"})
+}
+
+// skipUnsupported records that the check of the field at path was skipped
+// because it can't be run, hint telling why
+func (l *errorList) skipUnsupported(path fieldPath, check string, hint string) {
+	l.errs = append(l.errs, &commons.FieldError{Path: string(path), Severity: commons.SeveritySkipped, Message: check + " check skipped", Hint: hint})
+}
+
+// fail records an error not caused by a single field (e.g. a cloud API call failure)
+func (l *errorList) fail(err error, message string) {
+	l.errs = append(l.errs, &commons.FieldError{Severity: commons.SeverityError, Message: errors.Wrap(err, message).Error()})
+}
+
+// notices returns the recorded warnings and skipped checks
+func (l *errorList) notices() []*commons.FieldError {
+	notices := []*commons.FieldError{}
+	for _, err := range l.errs {
+		if fieldErr := err.(*commons.FieldError); fieldErr.Severity != commons.SeverityError {
+			notices = append(notices, fieldErr)
+		}
+	}
+	return notices
+}
+
+// aggregate returns the recorded errors, if any
+func (l *errorList) aggregate() error {
+	errs := []error{}
+	for _, err := range l.errs {
+		if err.(*commons.FieldError).Severity == commons.SeverityError {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.NewAggregate(errs)
+}
@@ -19,7 +19,6 @@ package validate
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/url"
 	"reflect"
 	"regexp"
@@ -29,262 +28,226 @@ import (
 	b64 "encoding/base64"
 
 	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/option"
 	"sigs.k8s.io/kind/pkg/commons"
-	"sigs.k8s.io/kind/pkg/errors"
 )
 
 var GCPVolumes = []string{"pd-balanced", "pd-ssd", "pd-standard", "pd-extreme"}
 var isGCPNodeImage = regexp.MustCompile(`^projects/[\w-]+/global/images/[\w-]+$`).MatchString
 var GCPNodeImageFormat = "projects/[PROJECT_ID]/global/images/[IMAGE_NAME]"
 
-func validateGCP(spec commons.KeosSpec, providerSecrets map[string]string) error {
-	var err error
-	var isGKEVersion = regexp.MustCompile(`^v\d.\d{2}.\d{1,2}-gke.\d{3,4}$`).MatchString
+func init() {
+	registerInfraValidator("gcp", infraValidator{
+		newInventory: func(providerSecrets map[string]string, region string) (Inventory, error) {
+			return newGCPInventory(providerSecrets), nil
+		},
+		validate: func(keosCluster commons.KeosCluster, inv Inventory, errs *errorList) {
+			validateGCP(keosCluster.Spec, inv, errs)
+		},
+	})
+}
 
-	credentialsJson := getGCPCreds(providerSecrets)
+// validateGCP validates the GCP specific settings of the descriptor, the
+// checks that query GCP are skipped if inv is nil (offline validation)
+func validateGCP(spec commons.KeosSpec, inv Inventory, errs *errorList) {
+	var isGKEVersion = regexp.MustCompile(`^v\d.\d{2}.\d{1,2}-gke.\d{3,4}$`).MatchString
+	var azs []string
 
-	regions, err := getGCPRegions(credentialsJson)
-	if err != nil {
-		return err
-	}
-	if !commons.Contains(regions, spec.Region) {
-		return errors.New("spec.region: " + spec.Region + " region does not exist")
-	}
+	if inv == nil {
+		errs.skip(specPath.child("region"), "region")
+	} else {
+		regions, err := inv.Regions()
+		if err != nil {
+			errs.fail(err, "failed to list the GCP regions")
+			return
+		}
+		if !commons.Contains(regions, spec.Region) {
+			errs.add(specPath.child("region"), spec.Region+" region does not exist", "")
+			return
+		}
 
-	azs, err := getGoogleAZs(credentialsJson, spec.Region)
-	if err != nil {
-		return err
+		azs, err = inv.AZs(spec.Region)
+		if err != nil {
+			errs.fail(err, "failed to list the GCP zones")
+			return
+		}
 	}
 	if (spec.StorageClass != commons.StorageClass{}) {
-		if err = validateGCPStorageClass(spec); err != nil {
-			return errors.Wrap(err, "spec.storageclass: Invalid value")
-		}
+		validateGCPStorageClass(spec, specPath.child("storageclass"), errs)
 	}
 
 	if !reflect.ValueOf(spec.Networks).IsZero() {
-		if err = validateGCPNetwork(spec.Networks, credentialsJson, spec.Region); err != nil {
-			return errors.Wrap(err, "spec.networks: Invalid value")
-		}
+		validateGCPNetwork(spec.Networks, inv, spec.Region, specPath.child("networks"), errs)
 	}
 
 	for i, dr := range spec.DockerRegistries {
+		path := specPath.child("docker_registries").index(i).child("type")
 		if dr.Type != "gar" && dr.Type != "gcr" && spec.ControlPlane.Managed {
-			return errors.New("spec.docker_registries[" + strconv.Itoa(i) + "]: Invalid value: \"type\": only 'gar' and 'gcr' are supported in gcp managed clusters")
-		}
-		if dr.Type != "gar" && dr.Type != "gcr" && dr.Type != "generic" {
-			return errors.New("spec.docker_registries[" + strconv.Itoa(i) + "]: Invalid value: \"type\": only 'gar', 'gcr' and 'generic' are supported in gcp unmanaged clusters")
+			errs.add(path, "only 'gar' and 'gcr' are supported in gcp managed clusters", "")
+		} else if dr.Type != "gar" && dr.Type != "gcr" && dr.Type != "generic" {
+			errs.add(path, "only 'gar', 'gcr' and 'generic' are supported in gcp unmanaged clusters", "")
 		}
 	}
 
 	if spec.ControlPlane.Managed {
 		if !isGKEVersion(spec.K8SVersion) {
-			return errors.New("spec: Invalid value: \"k8s_version\": must have the format 'v1.27.3-gke-1400'")
+			errs.add(specPath.child("k8s_version"), "invalid format", "must have the format 'v1.27.3-gke-1400'")
 		}
 	} else {
+		path := specPath.child("control_plane")
 		if spec.ControlPlane.NodeImage == "" || !isGCPNodeImage(spec.ControlPlane.NodeImage) {
-			return errors.New("spec.control_plane: Invalid value: \"node_image\": is required and have the format " + GCPNodeImageFormat)
-		}
-		if err := validateGCPInstanceType(spec.ControlPlane.Size, credentialsJson, spec.Region, azs, ""); err != nil {
-			return errors.New("spec.control_plane.size: " + spec.ControlPlane.Size + " does not exist as a GCP instance types in region " + spec.Region)
-		}
-		if err := validateVolumeType(spec.ControlPlane.RootVolume.Type, GCPVolumes); err != nil {
-			return errors.Wrap(err, "spec.control_plane.root_volume: Invalid value: \"type\"")
+			errs.add(path.child("node_image"), "is required", "must have the format "+GCPNodeImageFormat)
 		}
+		validateInstanceType(inv, spec.Region, azs, spec.ControlPlane.Size, path.child("size"), errs)
+		validateVolumeType(spec.ControlPlane.RootVolume.Type, GCPVolumes, path.child("root_volume").child("type"), errs)
 		for i, ev := range spec.ControlPlane.ExtraVolumes {
-			if err := validateVolumeType(ev.Type, GCPVolumes); err != nil {
-				return errors.Wrap(err, "spec.control_plane.extra_volumes["+strconv.Itoa(i)+"]: Invalid value: \"type\"")
-			}
+			validateVolumeType(ev.Type, GCPVolumes, path.child("extra_volumes").index(i).child("type"), errs)
 		}
-		for _, wn := range spec.WorkerNodes {
+		for i, wn := range spec.WorkerNodes {
+			path := specPath.child("worker_nodes").index(i)
 			if wn.NodeImage == "" || !isGCPNodeImage(wn.NodeImage) {
-				return errors.New("spec.worker_nodes." + wn.Name + ": \"node_image\": is required and have the format " + GCPNodeImageFormat)
+				errs.add(path.child("node_image"), "is required", "must have the format "+GCPNodeImageFormat)
 			}
-			if err := validateVolumeType(wn.RootVolume.Type, GCPVolumes); err != nil {
-				return errors.Wrap(err, "spec.worker_nodes."+wn.Name+".root_volume: Invalid value: \"type\"")
-			}
-			for i, ev := range wn.ExtraVolumes {
-				if err := validateVolumeType(ev.Type, GCPVolumes); err != nil {
-					return errors.Wrap(err, "spec.worker_nodes."+wn.Name+".extra_volumes["+strconv.Itoa(i)+"]: Invalid value: \"type\"")
-				}
+			validateVolumeType(wn.RootVolume.Type, GCPVolumes, path.child("root_volume").child("type"), errs)
+			for j, ev := range wn.ExtraVolumes {
+				validateVolumeType(ev.Type, GCPVolumes, path.child("extra_volumes").index(j).child("type"), errs)
 			}
 		}
 	}
 
-	for _, wn := range spec.WorkerNodes {
+	for i, wn := range spec.WorkerNodes {
+		path := specPath.child("worker_nodes").index(i)
 		if wn.AZ != "" {
-			if len(azs) > 0 {
+			if inv == nil {
+				errs.skip(path.child("az"), "availability zone")
+			} else if len(azs) > 0 {
 				if !commons.Contains(azs, wn.AZ) {
-					return errors.New(wn.AZ + " does not exist in this region, azs: " + fmt.Sprint(azs))
+					errs.add(path.child("az"), wn.AZ+" does not exist in this region", "azs: "+strings.Join(azs, ", "))
 				}
 			}
 		}
 		if wn.Size != "" {
-			if err := validateGCPInstanceType(wn.Size, credentialsJson, spec.Region, azs, wn.AZ); err != nil {
-				return errors.New("spec.worker_nodes." + wn.Name + ".size: " + wn.Size + " does not exist as a GCP instance types in region " + spec.Region)
-			}
-		}
-	}
-
-	return nil
-}
-
-func validateGCPInstanceType(instanceType string, credentialsJson string, region string, azs []string, azWorker string) error {
-	var iterateAZs []string
-	var ctx = context.Background()
-
-	gcpCreds := map[string]string{}
-	err := json.Unmarshal([]byte(credentialsJson), &gcpCreds)
-	if err != nil {
-		return err
-	}
-
-	cfg := option.WithCredentialsJSON([]byte(credentialsJson))
-	computeService, err := compute.NewService(ctx, cfg)
-	if err != nil {
-		return err
-	}
-
-	if azWorker != "" {
-		iterateAZs = append(iterateAZs, azWorker)
-	} else {
-		iterateAZs = azs
-	}
-
-	instanceTypeListCall := computeService.MachineTypes.AggregatedList(string(gcpCreds["project_id"])).Filter("(zone eq " + region + ".*) (name eq " + instanceType + ")")
-	instanceTypes, err := instanceTypeListCall.Do()
-	if err != nil {
-		return err
-	}
-
-	for _, iterateAZ := range iterateAZs {
-		// Check if instance type exists
-		for zonesAZ, machineTypesScopedList := range instanceTypes.Items {
-			az := strings.Split(zonesAZ, "/")[1]
-			if iterateAZ == az && len(machineTypesScopedList.MachineTypes) == 0 {
-				return errors.New("nonexistent instance type: " + instanceType + " in region " + region)
+			zones := azs
+			if wn.AZ != "" {
+				zones = []string{wn.AZ}
 			}
+			validateInstanceType(inv, spec.Region, zones, wn.Size, path.child("size"), errs)
 		}
 	}
-
-	return nil
-
 }
 
-func validateGCPStorageClass(spec commons.KeosSpec) error {
-	var err error
+func validateGCPStorageClass(spec commons.KeosSpec, path fieldPath, errs *errorList) {
 	var isKeyValid = regexp.MustCompile(`^projects/[a-zA-Z0-9-]+/locations/[a-zA-Z0-9-]+/keyRings/[a-zA-Z0-9-]+/cryptoKeys/[a-zA-Z0-9-]+$`).MatchString
 	var sc = spec.StorageClass
 	var GCPFSTypes = []string{"xfs", "ext3", "ext4", "ext2"}
 	var GCPSCFields = []string{"Type", "FsType", "Labels", "DiskEncryptionKmsKey", "ProvisionedIopsOnCreate", "ProvisionedThroughputOnCreate", "ReplicationType"}
 	var GCPYamlFields = []string{"type", "fsType", "labels", "disk-encryption-kms-key", "provisioned-iops-on-create", "provisioned-throughput-on-create", "replication-type"}
+	parameters := path.child("parameters")
 
 	// Validate fields
 	fields := getFieldNames(sc.Parameters)
 	for _, f := range fields {
 		if !commons.Contains(GCPSCFields, f) {
-			return errors.New("\"parameters\": unsupported " + f + ", supported fields: " + fmt.Sprint(strings.Join(GCPYamlFields, ", ")))
+			errs.add(parameters, "unsupported "+f, "supported fields: "+strings.Join(GCPYamlFields, ", "))
 		}
 	}
 	// Validate class
 	if sc.Class != "" && sc.Parameters != (commons.SCParameters{}) {
-		return errors.New("\"class\": cannot be set when \"parameters\" is set")
+		errs.add(path.child("class"), "cannot be set when \"parameters\" is set", "")
 	}
 	// Validate type
 	if sc.Parameters.Type != "" && !commons.Contains(GCPVolumes, sc.Parameters.Type) {
-		return errors.New("\"type\": unsupported " + sc.Parameters.Type + ", supported types: " + fmt.Sprint(strings.Join(GCPVolumes, ", ")))
+		errs.add(parameters.child("type"), "unsupported "+sc.Parameters.Type, "supported types: "+strings.Join(GCPVolumes, ", "))
 	}
 	// Validate encryptionKey format
 	if sc.EncryptionKey != "" {
 		if sc.Parameters != (commons.SCParameters{}) {
-			return errors.New("\"encryptionKey\": cannot be set when \"parameters\" is set")
+			errs.add(path.child("encryptionKey"), "cannot be set when \"parameters\" is set", "")
 		}
 		if !isKeyValid(sc.EncryptionKey) {
-			return errors.New("\"encryptionKey\": it must have the format projects/[PROJECT_ID]/locations/[REGION]/keyRings/[RING_NAME]/cryptoKeys/[KEY_NAME]")
+			errs.add(path.child("encryptionKey"), "invalid format", "it must have the format projects/[PROJECT_ID]/locations/[REGION]/keyRings/[RING_NAME]/cryptoKeys/[KEY_NAME]")
 		}
 	}
 	// Validate disk-encryption-kms-key format
 	if sc.Parameters.DiskEncryptionKmsKey != "" {
 		if !isKeyValid(sc.Parameters.DiskEncryptionKmsKey) {
-			return errors.New("\"disk-encryption-kms-key\": it must have the format projects/[PROJECT_ID]/locations/[REGION]/keyRings/[RING_NAME]/cryptoKeys/[KEY_NAME]")
+			errs.add(parameters.child("disk-encryption-kms-key"), "invalid format", "it must have the format projects/[PROJECT_ID]/locations/[REGION]/keyRings/[RING_NAME]/cryptoKeys/[KEY_NAME]")
 		}
 	}
 	// Validate fsType
 	if sc.Parameters.FsType != "" && !commons.Contains(GCPFSTypes, sc.Parameters.FsType) {
-		return errors.New("\fsType\": unsupported " + sc.Parameters.FsType + ", supported types: " + fmt.Sprint(strings.Join(GCPFSTypes, ", ")))
+		errs.add(parameters.child("fsType"), "unsupported "+sc.Parameters.FsType, "supported types: "+strings.Join(GCPFSTypes, ", "))
 	}
 
 	if spec.ControlPlane.Managed {
 		version, _ := strconv.ParseFloat(regexp.MustCompile(".[0-9]+$").Split(strings.ReplaceAll(spec.K8SVersion, "v", ""), -1)[0], 64)
 		if sc.Parameters.Type == "pd-extreme" && version < 1.26 {
-			return errors.New("\"pd-extreme\": is only supported in GKE 1.26 or later")
+			errs.add(parameters.child("type"), "\"pd-extreme\" is only supported in GKE 1.26 or later", "")
 		}
 	}
 	// Validate provisioned-iops-on-create
 	if sc.Parameters.ProvisionedIopsOnCreate != "" {
 		if sc.Parameters.Type != "pd-extreme" {
-			return errors.New("\"provisioned-iops-on-create\": is only supported for pd-extreme type")
+			errs.add(parameters.child("provisioned-iops-on-create"), "is only supported for pd-extreme type", "")
 		}
-		if _, err = strconv.Atoi(sc.Parameters.ProvisionedIopsOnCreate); err != nil {
-			return errors.New("\"provisioned-iops-on-create\": must be an integer")
+		if _, err := strconv.Atoi(sc.Parameters.ProvisionedIopsOnCreate); err != nil {
+			errs.add(parameters.child("provisioned-iops-on-create"), "must be an integer", "")
 		}
 	}
 	// Validate replication-type
 	if sc.Parameters.ReplicationType != "" && !regexp.MustCompile(`^(none|regional-pd)$`).MatchString(sc.Parameters.ReplicationType) {
-		return errors.New("\"replication-type\": supported values are 'none' or 'regional-pd'")
+		errs.add(parameters.child("replication-type"), "unsupported "+sc.Parameters.ReplicationType, "supported values are 'none' or 'regional-pd'")
 	}
 	// Validate labels
 	if sc.Parameters.Labels != "" {
-		if err = validateGCPLabel(sc.Parameters.Labels); err != nil {
-			return errors.Wrap(err, "invalid labels")
-		}
+		validateGCPLabel(sc.Parameters.Labels, parameters.child("labels"), errs)
 	}
-	return nil
 }
 
-func validateGCPLabel(l string) error {
+func validateGCPLabel(l string, path fieldPath, errs *errorList) {
 	// Keys must start with a lowercase character and contain only hyphens (-), underscores (_), lowercase characters, and numbers.
 	var isLabel = regexp.MustCompile(`^([a-z][a-z\d_-]*=[a-z\d_-]+)(\s?,\s?[a-z][a-z\d_-]*=[a-z\d_-]+)*$`).MatchString
 	if !isLabel(l) {
-		return errors.New("incorrect format. Must have the format 'key1=value1,key2=value2'")
+		errs.add(path, "incorrect format", "must have the format 'key1=value1,key2=value2'")
 	}
-	return nil
 }
 
-func validateGCPNetwork(network commons.Networks, credentialsJson string, region string) error {
+func validateGCPNetwork(network commons.Networks, inv Inventory, region string, path fieldPath, errs *errorList) {
 	if network.VPCID != "" {
-		vpcs, _ := getGoogleVPCs(credentialsJson)
-		if len(vpcs) > 0 && !commons.Contains(vpcs, network.VPCID) {
-			return errors.New("\"vpc_id\": " + network.VPCID + " does not exist")
-		}
-		if len(network.Subnets) != 1 {
-			return errors.New("\"subnet\": when \"vpc_id\" is set, one subnet must be specified")
-		}
-		if network.Subnets[0].SubnetId == "" {
-			return errors.New("\"subnet_id\": required")
+		if inv == nil {
+			errs.skip(path.child("vpc_id"), "VPC")
+		} else if vpcs, err := inv.VPCs(region, ""); err != nil {
+			errs.warn(path.child("vpc_id"), "could not be checked", err.Error())
+		} else if !commons.Contains(vpcs, network.VPCID) {
+			errs.add(path.child("vpc_id"), network.VPCID+" does not exist", "")
 		}
-		subnets, _ := getGoogleSubnets(credentialsJson, region, network.VPCID)
-		if !commons.Contains(subnets, network.Subnets[0].SubnetId) {
-			return errors.New("\"subnets\": " + network.Subnets[0].SubnetId + " does not belong to vpc with id: " + network.VPCID)
+		if len(network.Subnets) == 0 {
+			errs.add(path.child("subnets"), "when \"vpc_id\" is set, one subnet must be specified", "")
+		} else if network.Subnets[0].SubnetId != "" && inv == nil {
+			errs.skip(path.child("subnets"), "subnets")
+		} else if network.Subnets[0].SubnetId != "" {
+			subnets, err := inv.Subnets(region, "", network.VPCID)
+			if err != nil {
+				errs.warn(path.child("subnets"), "could not be checked", err.Error())
+			} else if !commons.Contains(subnets, network.Subnets[0].SubnetId) {
+				errs.add(path.child("subnets").index(0).child("subnet_id"), network.Subnets[0].SubnetId+" does not belong to vpc with id: "+network.VPCID, "")
+			}
 		}
 	} else {
 		if len(network.Subnets) > 0 {
-			return errors.New("\"vpc_id\": is required when \"subnets\" is set")
+			errs.add(path.child("vpc_id"), "is required when \"subnets\" is set", "")
 		}
 	}
 	if len(network.Subnets) > 0 {
 		if len(network.Subnets) > 1 {
-			return errors.New("\"subnet\": only one subnet is supported")
+			errs.add(path.child("subnets"), "only one subnet is supported", "")
 		}
 		if network.Subnets[0].SubnetId == "" {
-			return errors.New("\"subnet_id\": required")
+			errs.add(path.child("subnets").index(0).child("subnet_id"), "is required", "")
 		}
 	}
 	if network.VPCCIDRBlock != "" {
-		return errors.New("\"vpc_cidr\": is not supported")
+		errs.add(path.child("vpc_cidr"), "is not supported", "")
 	}
-
-	return nil
 }
 
 func getGCPRegions(credentialsJson string) ([]string, error) {
@@ -297,9 +260,11 @@ func getGCPRegions(credentialsJson string) ([]string, error) {
 		return []string{}, err
 	}
 
-	cfg := option.WithCredentialsJSON([]byte(credentialsJson))
+	cfg, err := commons.GCPClientOption(ctx, []byte(credentialsJson))
+	if err != nil {
+		return []string{}, err
+	}
 	computeService, err := compute.NewService(ctx, cfg)
-
 	if err != nil {
 		return []string{}, err
 	}
@@ -329,9 +294,11 @@ func getGoogleVPCs(credentialsJson string) ([]string, error) {
 		return []string{}, err
 	}
 
-	cfg := option.WithCredentialsJSON([]byte(credentialsJson))
+	cfg, err := commons.GCPClientOption(ctx, []byte(credentialsJson))
+	if err != nil {
+		return []string{}, err
+	}
 	computeService, err := compute.NewService(ctx, cfg)
-
 	if err != nil {
 		return []string{}, err
 	}
@@ -359,9 +326,11 @@ func getGoogleSubnets(credentialsJson string, region string, vpcId string) ([]st
 		return []string{}, err
 	}
 
-	cfg := option.WithCredentialsJSON([]byte(credentialsJson))
+	cfg, err := commons.GCPClientOption(ctx, []byte(credentialsJson))
+	if err != nil {
+		return []string{}, err
+	}
 	computeService, err := compute.NewService(ctx, cfg)
-
 	if err != nil {
 		return []string{}, err
 	}
@@ -393,9 +362,11 @@ func getGoogleAZs(credentialsJson string, region string) ([]string, error) {
 		return []string{}, err
 	}
 
-	cfg := option.WithCredentialsJSON([]byte(credentialsJson))
+	cfg, err := commons.GCPClientOption(ctx, []byte(credentialsJson))
+	if err != nil {
+		return []string{}, err
+	}
 	computeService, err := compute.NewService(ctx, cfg)
-
 	if err != nil {
 		return []string{}, err
 	}
@@ -430,3 +401,74 @@ func getGCPCreds(providerSecrets map[string]string) string {
 	credentialsJson, _ := b64.StdEncoding.DecodeString(credentials)
 	return string(credentialsJson)
 }
+
+// gcpInventory lists the GCP resources with the Compute Engine API
+type gcpInventory struct {
+	credentialsJson string
+}
+
+func newGCPInventory(providerSecrets map[string]string) Inventory {
+	return &gcpInventory{credentialsJson: getGCPCreds(providerSecrets)}
+}
+
+func (i *gcpInventory) Regions() ([]string, error) {
+	return getGCPRegions(i.credentialsJson)
+}
+
+func (i *gcpInventory) AZs(region string) ([]string, error) {
+	return getGoogleAZs(i.credentialsJson, region)
+}
+
+// PrivateAZs is not used as the subnets' zones are not checked in GCP
+func (i *gcpInventory) PrivateAZs(region string, subnetIDs []string) ([]string, error) {
+	return nil, nil
+}
+
+func (i *gcpInventory) VPCs(region string, resourceGroup string) ([]string, error) {
+	return getGoogleVPCs(i.credentialsJson)
+}
+
+func (i *gcpInventory) Subnets(region string, resourceGroup string, vpcID string) ([]string, error) {
+	return getGoogleSubnets(i.credentialsJson, region, vpcID)
+}
+
+func (i *gcpInventory) InstanceTypeExists(region string, zones []string, instanceType string) (bool, error) {
+	var ctx = context.Background()
+
+	gcpCreds := map[string]string{}
+	err := json.Unmarshal([]byte(i.credentialsJson), &gcpCreds)
+	if err != nil {
+		return false, err
+	}
+
+	cfg, err := commons.GCPClientOption(ctx, []byte(i.credentialsJson))
+	if err != nil {
+		return false, err
+	}
+	computeService, err := compute.NewService(ctx, cfg)
+	if err != nil {
+		return false, err
+	}
+
+	instanceTypeListCall := computeService.MachineTypes.AggregatedList(string(gcpCreds["project_id"])).Filter("(zone eq " + region + ".*) (name eq " + instanceType + ")")
+	instanceTypes, err := instanceTypeListCall.Do()
+	if err != nil {
+		return false, err
+	}
+
+	for _, zone := range zones {
+		// Check if instance type exists
+		for zonesAZ, machineTypesScopedList := range instanceTypes.Items {
+			az := strings.Split(zonesAZ, "/")[1]
+			if zone == az && len(machineTypesScopedList.MachineTypes) == 0 {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// KubernetesVersions is not used as the GKE versions are only checked by format
+func (i *gcpInventory) KubernetesVersions(region string) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestValidateGCP(t *testing.T) {
+	t.Parallel()
+	inventory := fakeInventory{
+		regions:       []string{"europe-west4"},
+		azs:           []string{"europe-west4-a", "europe-west4-b", "europe-west4-c"},
+		vpcs:          []string{"vpc-1"},
+		subnets:       []string{"subnet-1"},
+		instanceTypes: []string{"n2-standard-4"},
+	}
+	cases := []struct {
+		Name     string
+		Spec     string
+		Expected []string
+	}{
+		{
+			Name: "valid",
+			Spec: `
+region: europe-west4
+k8s_version: v1.27.3-gke.1400
+control_plane:
+  managed: true
+networks:
+  vpc_id: vpc-1
+  subnets:
+    - subnet_id: subnet-1
+worker_nodes:
+  - name: worker
+    az: europe-west4-a
+    size: n2-standard-4
+`,
+			Expected: []string{},
+		},
+		{
+			Name: "unknown region",
+			Spec: `
+region: europe-west9
+`,
+			Expected: []string{"error spec.region"},
+		},
+		{
+			Name: "unknown zone and instance type",
+			Spec: `
+region: europe-west4
+k8s_version: v1.27.3-gke.1400
+control_plane:
+  managed: true
+worker_nodes:
+  - name: worker
+    az: europe-west4-z
+    size: n2-huge
+`,
+			Expected: []string{
+				"error spec.worker_nodes[0].az",
+				"error spec.worker_nodes[0].size",
+			},
+		},
+		{
+			Name: "unknown VPC and subnet",
+			Spec: `
+region: europe-west4
+k8s_version: v1.27.3-gke.1400
+control_plane:
+  managed: true
+networks:
+  vpc_id: vpc-2
+  subnets:
+    - subnet_id: subnet-2
+`,
+			Expected: []string{
+				"error spec.networks.vpc_id",
+				"error spec.networks.subnets[0].subnet_id",
+			},
+		},
+		{
+			Name: "unknown control plane instance type",
+			Spec: `
+region: europe-west4
+control_plane:
+  managed: false
+  node_image: projects/my-project/global/images/my-image
+  size: n2-huge
+`,
+			Expected: []string{"error spec.control_plane.size"},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			inv := inventory
+			errs := &errorList{}
+			validateGCP(parseSpec(t, tc.Spec), &inv, errs)
+			assert.DeepEqual(t, tc.Expected, findings(errs))
+		})
+	}
+}
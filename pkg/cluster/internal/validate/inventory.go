@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// Inventory lists the cloud provider resources the descriptor refers to
+type Inventory interface {
+	// Regions returns the names of the regions
+	Regions() ([]string, error)
+	// AZs returns the availability zones of region
+	AZs(region string) ([]string, error)
+	// PrivateAZs returns the availability zones of the private subnets among subnetIDs
+	PrivateAZs(region string, subnetIDs []string) ([]string, error)
+	// VPCs returns the VPCs of region (in resourceGroup, for Azure)
+	VPCs(region string, resourceGroup string) ([]string, error)
+	// Subnets returns the subnets of vpcID (in resourceGroup, for Azure)
+	Subnets(region string, resourceGroup string, vpcID string) ([]string, error)
+	// InstanceTypeExists returns whether instanceType is offered in region,
+	// in every one of zones if any
+	InstanceTypeExists(region string, zones []string, instanceType string) (bool, error)
+	// KubernetesVersions returns the Kubernetes versions of the managed
+	// control plane in region
+	KubernetesVersions(region string) ([]string, error)
+}
+
+// infraValidator validates the descriptor of an infra provider
+type infraValidator struct {
+	// newInventory returns the Inventory of the provider using its
+	// credentials, it is nil if the provider has nothing to query
+	newInventory func(providerSecrets map[string]string, region string) (Inventory, error)
+	// validate validates the provider settings, inv is nil in the offline
+	// validation
+	validate func(keosCluster commons.KeosCluster, inv Inventory, errs *errorList)
+}
+
+// infraValidators are the validations of the infra providers, registered
+// from their files along with their commons.InfraProvider
+var infraValidators = map[string]infraValidator{}
+
+// registerInfraValidator makes the validations of the infra provider name
+// available
+func registerInfraValidator(name string, v infraValidator) {
+	if _, ok := infraValidators[name]; ok {
+		panic("validator " + name + " is already registered")
+	}
+	infraValidators[name] = v
+}
+
+// newInventory returns the Inventory of infraProvider using its credentials
+func newInventory(infraProvider string, providerSecrets map[string]string, region string) (Inventory, error) {
+	v, ok := infraValidators[infraProvider]
+	if !ok {
+		return nil, errors.New("unknown infra provider " + infraProvider)
+	}
+	if v.newInventory == nil {
+		return nil, errors.New("there is no inventory of the infra provider " + infraProvider)
+	}
+	return v.newInventory(providerSecrets, region)
+}
+
+// validateInstanceType checks that instanceType exists in region, the check
+// is skipped if inv is nil (offline validation)
+func validateInstanceType(inv Inventory, region string, zones []string, instanceType string, path fieldPath, errs *errorList) {
+	if inv == nil {
+		errs.skip(path, "instance type")
+		return
+	}
+	exists, err := inv.InstanceTypeExists(region, zones, instanceType)
+	if err != nil {
+		errs.fail(err, "failed to check the instance type "+instanceType)
+		return
+	}
+	if !exists {
+		errs.add(path, instanceType+" does not exist as an instance type in region "+region, "")
+	}
+}
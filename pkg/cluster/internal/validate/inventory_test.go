@@ -0,0 +1,97 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/commons"
+)
+
+// fakeInventory is an in-memory Inventory of a single region
+type fakeInventory struct {
+	regions       []string
+	azs           []string
+	privateAZs    []string
+	vpcs          []string
+	subnets       []string
+	instanceTypes []string
+	k8sVersions   []string
+	// err is returned when listing the VPCs and subnets
+	err error
+}
+
+var _ Inventory = &fakeInventory{}
+
+func (i *fakeInventory) Regions() ([]string, error) {
+	return i.regions, nil
+}
+
+func (i *fakeInventory) AZs(region string) ([]string, error) {
+	return i.azs, nil
+}
+
+func (i *fakeInventory) PrivateAZs(region string, subnetIDs []string) ([]string, error) {
+	return i.privateAZs, nil
+}
+
+func (i *fakeInventory) VPCs(region string, resourceGroup string) ([]string, error) {
+	return i.vpcs, i.err
+}
+
+func (i *fakeInventory) Subnets(region string, resourceGroup string, vpcID string) ([]string, error) {
+	return i.subnets, i.err
+}
+
+func (i *fakeInventory) InstanceTypeExists(region string, zones []string, instanceType string) (bool, error) {
+	return commons.Contains(i.instanceTypes, instanceType), nil
+}
+
+func (i *fakeInventory) KubernetesVersions(region string) ([]string, error) {
+	return i.k8sVersions, nil
+}
+
+// parseSpec parses the KeosCluster spec in yaml
+func parseSpec(t *testing.T, raw string) commons.KeosSpec {
+	t.Helper()
+	spec := commons.KeosSpec{}
+	if err := yaml.Unmarshal([]byte(raw), &spec); err != nil {
+		t.Fatalf("failed to parse spec: %v", err)
+	}
+	return spec
+}
+
+// findings returns the recorded findings as "severity path"
+func findings(errs *errorList) []string {
+	out := []string{}
+	for _, err := range errs.errs {
+		fieldErr := err.(*commons.FieldError)
+		out = append(out, string(fieldErr.Severity)+" "+fieldErr.Path)
+	}
+	return out
+}
+
+func TestInfraValidatorsRegistered(t *testing.T) {
+	t.Parallel()
+	for _, name := range commons.InfraProviderNames() {
+		if _, ok := infraValidators[name]; !ok {
+			t.Errorf("there is no validator registered for infra provider %s", name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"sigs.k8s.io/kind/pkg/cluster/internal/registry"
+	"sigs.k8s.io/kind/pkg/commons"
+)
+
+// validatePrivateRegistry checks that the keos registry holds every image
+// and the helm repository every chart a private installation pulls, listing
+// the missing ones
+func validatePrivateRegistry(params ValidateParams, creds commons.ClusterCredentials, errs *errorList) {
+	spec := params.KeosCluster.Spec
+	client := params.Registry
+	if client == nil {
+		credentials, err := registry.DescriptorCredentials(spec, creds)
+		if err != nil {
+			errs.fail(err, "failed to check the private registry")
+			return
+		}
+		helmCredentials, ok, err := registry.HelmRepositoryCredentials(spec, creds)
+		if err != nil {
+			errs.fail(err, "failed to check the private registry")
+			return
+		}
+		if ok {
+			credentials[registry.HelmRepositoryHost(spec.HelmRepository.URL)] = helmCredentials
+		}
+		client = registry.NewClient(commons.HTTPClient(), credentials)
+	}
+
+	for i, dockerRegistry := range spec.DockerRegistries {
+		if !dockerRegistry.KeosRegistry {
+			continue
+		}
+		images, err := registry.RequiredImages(spec)
+		if err != nil {
+			errs.fail(err, "failed to list the required images")
+			return
+		}
+		path := specPath.child("docker_registries").index(i).child("url")
+		for _, image := range images {
+			mirrored := image.InRegistry(dockerRegistry.URL)
+			exists, err := client.Exists(mirrored)
+			if err != nil {
+				// the rest of images would fail the same way
+				errs.fail(err, "failed to check the image "+mirrored.String()+" in the keos registry")
+				break
+			}
+			if !exists {
+				errs.add(path, "the image "+mirrored.String()+" is missing", "copy it with cloud-provisioner mirror images")
+			}
+		}
+	}
+
+	path := specPath.child("helm_repository").child("url")
+	if !registry.ChartsCheckable(spec.HelmRepository.URL) {
+		errs.skipUnsupported(path, "charts", "only the charts of the oci and http(s) helm repositories are checked")
+		return
+	}
+	for _, chart := range registry.RequiredCharts() {
+		exists, err := client.ChartExists(spec.HelmRepository.URL, chart)
+		if err != nil {
+			errs.fail(err, "failed to check the chart "+chart.String()+" in the helm repository")
+			return
+		}
+		if !exists {
+			errs.add(path, "the chart "+chart.String()+" is missing", "")
+		}
+	}
+}
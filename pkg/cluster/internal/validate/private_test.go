@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/cluster/internal/registry"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestValidatePrivateRegistry(t *testing.T) {
+	t.Parallel()
+	keos := registry.NewFakeRegistry()
+	keosServer := httptest.NewServer(keos)
+	defer keosServer.Close()
+	keosURL := strings.TrimPrefix(keosServer.URL, "http://") + "/keos"
+
+	helmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("apiVersion: v1\nentries:\n  cluster-operator:\n    - version: 0.1.0\n"))
+	}))
+	defer helmServer.Close()
+
+	spec := parseSpec(t, `
+infra_provider: docker
+control_plane:
+  managed: false
+docker_registries:
+  - url: registry.example.com
+    type: generic
+  - url: `+keosURL+`
+    type: generic
+    keos_registry: true
+helm_repository:
+  url: `+helmServer.URL+`
+`)
+	images, err := registry.RequiredImages(spec)
+	assert.ExpectError(t, false, err)
+	missing := ""
+	for _, image := range images {
+		mirrored := image.InRegistry(keosURL)
+		if strings.Contains(mirrored.Repository, "capd-manager") {
+			missing = mirrored.String()
+			continue
+		}
+		keos.PutManifest(mirrored.Repository, mirrored.Tag, "application/vnd.oci.image.manifest.v1+json", []byte("{}"))
+	}
+
+	errs := &errorList{}
+	validatePrivateRegistry(ValidateParams{KeosCluster: commons.KeosCluster{Spec: spec}}, commons.ClusterCredentials{}, errs)
+	assert.DeepEqual(t, []string{
+		"error spec.docker_registries[1].url",
+		"error spec.helm_repository.url",
+	}, findings(errs))
+	messages := []string{}
+	for _, err := range errs.errs {
+		messages = append(messages, err.(*commons.FieldError).Message)
+	}
+	assert.DeepEqual(t, []string{
+		"the image " + missing + " is missing",
+		"the chart cluster-operator " + commons.GetBOM().ClusterOperator.Chart + " is missing",
+	}, messages)
+}
+
+func TestValidatePrivateRegistryUnreachable(t *testing.T) {
+	t.Parallel()
+	keos := registry.NewFakeRegistry()
+	keos.Credentials = registry.Credentials{User: "keos", Pass: "secret"}
+	keosServer := httptest.NewServer(keos)
+	defer keosServer.Close()
+
+	spec := parseSpec(t, `
+infra_provider: docker
+docker_registries:
+  - url: `+strings.TrimPrefix(keosServer.URL, "http://")+`
+    type: generic
+    keos_registry: true
+helm_repository:
+  url: http://127.0.0.1:1
+`)
+	errs := &errorList{}
+	validatePrivateRegistry(ValidateParams{KeosCluster: commons.KeosCluster{Spec: spec}}, commons.ClusterCredentials{}, errs)
+	// a single failure instead of one per image, and one for the charts
+	assert.DeepEqual(t, []string{"error ", "error "}, findings(errs))
+}
+
+func TestValidatePrivateRegistryS3HelmRepository(t *testing.T) {
+	t.Parallel()
+	keos := registry.NewFakeRegistry()
+	keosServer := httptest.NewServer(keos)
+	defer keosServer.Close()
+	keosURL := strings.TrimPrefix(keosServer.URL, "http://") + "/keos"
+
+	spec := parseSpec(t, `
+infra_provider: docker
+docker_registries:
+  - url: `+keosURL+`
+    type: generic
+    keos_registry: true
+helm_repository:
+  url: s3://stratio-charts/keos
+`)
+	images, err := registry.RequiredImages(spec)
+	assert.ExpectError(t, false, err)
+	for _, image := range images {
+		mirrored := image.InRegistry(keosURL)
+		keos.PutManifest(mirrored.Repository, mirrored.Tag, "application/vnd.oci.image.manifest.v1+json", []byte("{}"))
+	}
+
+	errs := &errorList{}
+	validatePrivateRegistry(ValidateParams{KeosCluster: commons.KeosCluster{Spec: spec}}, commons.ClusterCredentials{}, errs)
+	// the s3 helm repositories are read through a helm plugin
+	assert.DeepEqual(t, []string{"skipped spec.helm_repository.url"}, findings(errs))
+	assert.ExpectError(t, false, errs.aggregate())
+}
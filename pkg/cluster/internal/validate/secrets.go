@@ -18,7 +18,6 @@ package validate
 
 import (
 	"fmt"
-	"os"
 	"reflect"
 	"regexp"
 	"strings"
@@ -26,101 +25,116 @@ import (
 	"github.com/fatih/structs"
 	"github.com/oleiade/reflections"
 	"sigs.k8s.io/kind/pkg/commons"
-	"sigs.k8s.io/kind/pkg/errors"
 )
 
-func validateCredentials(params ValidateParams) (commons.ClusterCredentials, error) {
+func validateCredentials(params ValidateParams, errs *errorList) commons.ClusterCredentials {
 	var secrets commons.Secrets
 	var creds commons.ClusterCredentials
 
-	// Get secrets file if exists
-	_, err := os.Stat(params.SecretsPath)
-	if err == nil {
-		secretsFile, err := commons.GetSecretsFile(params.SecretsPath, params.VaultPassword)
+	// Get secrets if they exist
+	provider := params.Secrets
+	if provider == nil {
+		provider = commons.NewAnsibleVaultSecrets(params.SecretsPath, params.VaultPassword)
+	}
+	if provider.Exists() && params.Offline {
+		errs.skip(fieldPath("secrets"), provider.Source())
+	} else if provider.Exists() {
+		secretsFile, err := provider.Read()
 		if err != nil {
-			return commons.ClusterCredentials{}, err
+			errs.fail(err, "failed to read the secrets from the "+provider.Source())
+			return creds
 		}
 		secrets = secretsFile.Secrets
 	}
 
-	creds.ProviderCredentials, err = validateProviderCredentials(secrets, params)
-	if err != nil {
-		return commons.ClusterCredentials{}, err
-	}
+	creds.ProviderCredentials = validateProviderCredentials(secrets, params, errs)
+	creds.KeosRegistryCredentials, creds.DockerRegistriesCredentials = validateRegistryCredentials(secrets, params.KeosCluster.Spec, params.Offline, errs)
+	creds.HelmRepositoryCredentials = validateHelmCredentials(secrets, params.KeosCluster.Spec, params.Offline, errs)
+	creds.GithubToken = validateGithubToken(secrets, params.KeosCluster.Spec, errs)
 
-	creds.KeosRegistryCredentials, creds.DockerRegistriesCredentials, err = validateRegistryCredentials(secrets, params.KeosCluster.Spec)
-	if err != nil {
-		return commons.ClusterCredentials{}, err
-	}
-
-	creds.HelmRepositoryCredentials, err = validateHelmCredentials(secrets, params.KeosCluster.Spec)
-	if err != nil {
-		return commons.ClusterCredentials{}, err
-	}
+	return creds
+}
 
-	creds.GithubToken, err = validateGithubToken(secrets, params.KeosCluster.Spec)
-	if err != nil {
-		return commons.ClusterCredentials{}, err
+// credentialsPath returns the path of a credential, which is read from the
+// secrets file if it is set there or from the descriptor otherwise
+func credentialsPath(fromSecrets bool, name string) fieldPath {
+	if fromSecrets {
+		return fieldPath("secrets").child(name)
 	}
-
-	return creds, nil
+	return specPath.child("credentials").child(name)
 }
 
-func validateProviderCredentials(secrets interface{}, params ValidateParams) (map[string]string, error) {
+func validateProviderCredentials(secrets interface{}, params ValidateParams, errs *errorList) map[string]string {
 	infraProvider := params.KeosCluster.Spec.InfraProvider
+	if p, ok := commons.GetInfraProvider(infraProvider); ok && p.WithoutCredentials {
+		return map[string]string{}
+	}
+	path := credentialsPath(true, infraProvider).child("credentials")
 	credentialsProvider, err := reflections.GetField(secrets, strings.ToUpper(infraProvider))
 	if err != nil || reflect.DeepEqual(credentialsProvider, reflect.Zero(reflect.TypeOf(credentialsProvider)).Interface()) {
+		path = credentialsPath(false, infraProvider)
 		credentialsProvider, err = reflections.GetField(params.KeosCluster.Spec.Credentials, strings.ToUpper(infraProvider))
 		if err != nil || reflect.DeepEqual(credentialsProvider, reflect.Zero(reflect.TypeOf(credentialsProvider)).Interface()) {
-			return nil, errors.New("there is not " + infraProvider + " credentials in descriptor or secrets file")
+			if params.Offline {
+				errs.skip(path, "credentials")
+				return nil
+			}
+			errs.add(path, "there is not "+infraProvider+" credentials in descriptor or secrets file", "set them in the secrets file or in spec.credentials")
+			return nil
 		}
 	} else {
 		credentialsProvider, _ = reflections.GetField(credentialsProvider, "Credentials")
 
 	}
-	err = validateStruct(credentialsProvider)
-	if err != nil {
-		return nil, err
+	if !validateStruct(credentialsProvider, path, errs) {
+		return nil
 	}
 	resultCredsMap := structs.Map(credentialsProvider)
 	resultCreds := convertToMapStringString(resultCredsMap)
-	return resultCreds, nil
+	return resultCreds
 }
 
-func validateRegistryCredentials(secrets commons.Secrets, spec commons.KeosSpec) (map[string]string, []map[string]interface{}, error) {
+func validateRegistryCredentials(secrets commons.Secrets, spec commons.KeosSpec, offline bool, errs *errorList) (map[string]string, []map[string]interface{}) {
 	var dockerRegistries []commons.DockerRegistryCredentials
 	var resultKeosRegistry map[string]string
 	var resultDockerRegistries = []map[string]interface{}{}
 
-	if len(secrets.DockerRegistries) > 0 {
+	fromSecrets := len(secrets.DockerRegistries) > 0
+	if fromSecrets {
 		dockerRegistries = secrets.DockerRegistries
 	} else {
 		dockerRegistries = spec.Credentials.DockerRegistries
 	}
+	credentialsPath := credentialsPath(fromSecrets, "docker_registries")
+
+	// Check if there are more than one credential for the same registry
+	for l, dockerRegistryCredential := range dockerRegistries {
+		for _, dockerRegistryCredential2 := range dockerRegistries[:l] {
+			if dockerRegistryCredential.URL == dockerRegistryCredential2.URL {
+				errs.add(credentialsPath.index(l).child("url"), "there is more than one credential for the registry: "+dockerRegistryCredential.URL, "")
+				break
+			}
+		}
+	}
 
 	keosCount := 0
 	for i, dockerRegistry := range spec.DockerRegistries {
+		path := specPath.child("docker_registries").index(i)
 		// Check if there are more than one docker_registry with the same URL
-		for j, dockerRegistry2 := range spec.DockerRegistries {
-			if i != j && dockerRegistry.URL == dockerRegistry2.URL {
-				return nil, nil, errors.New("there is more than one docker_registry with the same URL: " + dockerRegistry.URL)
+		for _, dockerRegistry2 := range spec.DockerRegistries[:i] {
+			if dockerRegistry.URL == dockerRegistry2.URL {
+				errs.add(path.child("url"), "there is more than one docker_registry with the same URL: "+dockerRegistry.URL, "")
+				break
 			}
 		}
 		if dockerRegistry.AuthRequired {
 			existCredentials := false
 			for l, dockerRegistryCredential := range dockerRegistries {
-				// Check if there are more than one credential for the same registry
-				for k, dockerRegistryCredential2 := range dockerRegistries {
-					if l != k && dockerRegistryCredential.URL == dockerRegistryCredential2.URL {
-						return nil, nil, errors.New("there is more than one credential for the registry: " + dockerRegistry.URL)
-					}
-				}
 				// Check if there are valid credentials for the registry
-				if dockerRegistryCredential.URL == dockerRegistry.URL {
+				if dockerRegistryCredential.URL == dockerRegistry.URL && !existCredentials {
 					existCredentials = true
-					err := validateStruct(dockerRegistryCredential)
-					if err != nil {
-						return nil, nil, errors.Wrap(err, "there aren't valid credentials for the registry: "+dockerRegistry.URL)
+					if !validateStruct(dockerRegistryCredential, credentialsPath.index(l), errs) {
+						continue
 					}
 					registryMap := structs.Map(dockerRegistryCredential)
 					resultDockerRegistries = append(resultDockerRegistries, commons.ConvertMapKeysToSnakeCase(registryMap))
@@ -130,70 +144,78 @@ func validateRegistryCredentials(secrets commons.Secrets, spec commons.KeosSpec)
 				}
 			}
 
-			if !existCredentials {
-				return nil, nil, errors.New("there aren't valid credentials for the registry: " + dockerRegistry.URL)
+			if !existCredentials && offline {
+				errs.skip(path.child("auth_required"), "credentials")
+			} else if !existCredentials {
+				errs.add(path.child("auth_required"), "there aren't valid credentials for the registry: "+dockerRegistry.URL, "add them to "+string(credentialsPath))
 			}
 		}
 		if dockerRegistry.KeosRegistry {
 			// Check if there are more than one docker_registry defined as keos_registry
 			keosCount++
 			if keosCount > 1 {
-				return nil, nil, errors.New("there are more than one docker_registry defined as keos_registry")
+				errs.add(path.child("keos_registry"), "there are more than one docker_registry defined as keos_registry", "")
 			}
 		}
 	}
 	if keosCount == 0 {
-		return nil, nil, errors.New("there isn't any docker_registry defined as keos_registry")
+		errs.add(specPath.child("docker_registries"), "there isn't any docker_registry defined as keos_registry", "")
 	}
-	return resultKeosRegistry, resultDockerRegistries, nil
+	return resultKeosRegistry, resultDockerRegistries
 }
 
-func validateHelmCredentials(secrets commons.Secrets, spec commons.KeosSpec) (map[string]string, error) {
+func validateHelmCredentials(secrets commons.Secrets, spec commons.KeosSpec, offline bool, errs *errorList) map[string]string {
 	var helmRepository commons.HelmRepositoryCredentials
 	var resultHelmRepository map[string]string
 
-	if secrets.HelmRepository.URL != "" && secrets.HelmRepository.User != "" && secrets.HelmRepository.Pass != "" {
+	fromSecrets := secrets.HelmRepository.URL != "" && secrets.HelmRepository.User != "" && secrets.HelmRepository.Pass != ""
+	if fromSecrets {
 		helmRepository = secrets.HelmRepository
 	} else {
 		helmRepository = spec.Credentials.HelmRepository
 	}
 
 	if spec.HelmRepository.AuthRequired {
+		path := specPath.child("helm_repository")
 		if spec.HelmRepository.Type != "generic" {
-			return nil, errors.New("Helm repository type: " + spec.HelmRepository.Type + " cannot be auth_required: " + fmt.Sprint(spec.HelmRepository.AuthRequired))
+			errs.add(path.child("auth_required"), "Helm repository type: "+spec.HelmRepository.Type+" cannot be auth_required: "+fmt.Sprint(spec.HelmRepository.AuthRequired), "")
+			return nil
 		}
-		existCredentials := false
-		if helmRepository.URL == spec.HelmRepository.URL {
-			existCredentials = true
-			err := validateStruct(helmRepository)
-			if err != nil {
-				return nil, errors.Wrap(err, "there aren't valid credentials for the repository: "+helmRepository.URL)
-			}
-			registryMap := structs.Map(helmRepository)
-			resultHelmRepository = convertToMapStringString(registryMap)
+		if helmRepository.URL != spec.HelmRepository.URL && offline {
+			errs.skip(path.child("auth_required"), "credentials")
+			return nil
 		}
-		if !existCredentials {
-			return nil, errors.New("there aren't valid credentials for the repository: " + helmRepository.URL)
+		if helmRepository.URL != spec.HelmRepository.URL {
+			errs.add(path.child("auth_required"), "there aren't valid credentials for the repository: "+spec.HelmRepository.URL, "add them to "+string(credentialsPath(fromSecrets, "helm_repository")))
+			return nil
 		}
+		if !validateStruct(helmRepository, credentialsPath(fromSecrets, "helm_repository"), errs) {
+			return nil
+		}
+		registryMap := structs.Map(helmRepository)
+		resultHelmRepository = convertToMapStringString(registryMap)
 	}
-	return resultHelmRepository, nil
+	return resultHelmRepository
 }
 
-func validateGithubToken(secrets commons.Secrets, spec commons.KeosSpec) (string, error) {
+func validateGithubToken(secrets commons.Secrets, spec commons.KeosSpec, errs *errorList) string {
 	var githubToken string
+	var path fieldPath
 	var isGithubToken = regexp.MustCompile(`^(github_pat_|ghp_)\w+$`).MatchString
 
 	if secrets.GithubToken != "" {
 		githubToken = secrets.GithubToken
+		path = credentialsPath(true, "github_token")
 	} else if spec.Credentials.GithubToken != "" {
 		githubToken = spec.Credentials.GithubToken
+		path = credentialsPath(false, "github_token")
 	} else {
-		return "", nil
+		return ""
 	}
 
-	if isGithubToken(githubToken) {
-		return githubToken, nil
-	} else {
-		return "", errors.New("github_token is not valid")
+	if !isGithubToken(githubToken) {
+		errs.add(path, "is not valid", "it must start with github_pat_ or ghp_")
+		return ""
 	}
+	return githubToken
 }
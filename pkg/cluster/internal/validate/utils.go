@@ -17,37 +17,29 @@ limitations under the License.
 package validate
 
 import (
-	"fmt"
 	"reflect"
-
-	"sigs.k8s.io/kind/pkg/errors"
+	"strings"
 )
 
-func validateStruct(s interface{}) (err error) {
-	// first make sure that the input is a struct
-	// having any other type, especially a pointer to a struct,
-	// might result in panic
+// validateStruct records every unset field of the struct s as required,
+// returning whether all of them are set
+func validateStruct(s interface{}, path fieldPath, errs *errorList) bool {
 	structType := reflect.TypeOf(s)
-	if structType.Kind() != reflect.Struct {
-		return errors.New("input param should be a struct")
-	}
-
-	// now go one by one through the fields and validate their value
 	structVal := reflect.ValueOf(s)
-	fieldNum := structVal.NumField()
-
-	for i := 0; i < fieldNum; i++ {
+	valid := true
+	for i := 0; i < structType.NumField(); i++ {
 		field := structVal.Field(i)
-		fieldName := structType.Field(i).Name
-
 		isSet := field.IsValid() && !field.IsZero()
-
 		if !isSet {
-			err = errors.New(fmt.Sprintf("%v%s in not set; ", err, fieldName))
+			name := strings.Split(structType.Field(i).Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = structType.Field(i).Name
+			}
+			errs.add(path.child(name), "is required", "")
+			valid = false
 		}
 	}
-
-	return err
+	return valid
 }
 
 func convertToMapStringString(m map[string]interface{}) map[string]string {
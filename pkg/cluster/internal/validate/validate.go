@@ -17,37 +17,84 @@ limitations under the License.
 package validate
 
 import (
+	"sigs.k8s.io/kind/pkg/cluster/internal/registry"
 	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/redact"
+	"sigs.k8s.io/kind/pkg/log"
 )
 
 type ValidateParams struct {
-	KeosCluster   commons.KeosCluster
+	KeosCluster commons.KeosCluster
+	// ClusterConfig enables, with private_registry, the check that the keos
+	// registry and the helm repository hold every image and chart
+	ClusterConfig *commons.ClusterConfig
 	SecretsPath   string
 	VaultPassword string
+	Logger        log.Logger
+	// Secrets reads the secrets, it is the ansible-vault encrypted file at
+	// SecretsPath if not set
+	Secrets commons.SecretsProvider
+	// Offline skips the checks querying the cloud provider and reading the
+	// secrets
+	Offline bool
+	// Inventory lists the cloud provider resources, it is built from the
+	// provider credentials if not set
+	Inventory Inventory
+	// Registry checks the private registry images and charts, it is built
+	// from the descriptor credentials if not set
+	Registry *registry.Client
+	// Report receives the warnings and skipped checks, which are logged
+	// if not set
+	Report func(*commons.FieldError)
 }
 
+// Cluster validates the descriptor and its credentials, reporting every
+// problem found at once as commons.FieldErrors
 func Cluster(params *ValidateParams) (commons.ClusterCredentials, error) {
-	var err error
-	var creds commons.ClusterCredentials
+	errs := &errorList{}
+	spec := params.KeosCluster.Spec
 
-	creds, err = validateCredentials(*params)
-	if err != nil {
-		return commons.ClusterCredentials{}, err
+	validateCABundle(spec.CABundle, errs)
+
+	creds := validateCredentials(*params, errs)
+	redact.Add(creds.SecretValues()...)
+
+	validateCommon(spec, errs)
+
+	// the cloud provider can only be queried with valid credentials
+	v, ok := infraValidators[spec.InfraProvider]
+	queried := ok && v.newInventory != nil
+	inventory := params.Inventory
+	if params.Offline || !queried {
+		inventory = nil
+	} else if inventory == nil && creds.ProviderCredentials != nil {
+		var err error
+		inventory, err = newInventory(spec.InfraProvider, creds.ProviderCredentials, spec.Region)
+		if err != nil {
+			errs.fail(err, "failed to connect to the cloud provider")
+		}
+	}
+	if ok && (inventory != nil || params.Offline || !queried) {
+		v.validate(params.KeosCluster, inventory, errs)
 	}
 
-	if err := validateCommon(params.KeosCluster.Spec); err != nil {
-		return commons.ClusterCredentials{}, err
+	// the private registry is only checked once the credentials are valid
+	if params.ClusterConfig != nil && params.ClusterConfig.Spec.Private {
+		if params.Offline {
+			errs.skip(specPath.child("docker_registries"), "private registry")
+		} else if errs.aggregate() == nil {
+			validatePrivateRegistry(*params, creds, errs)
+		}
 	}
 
-	switch params.KeosCluster.Spec.InfraProvider {
-	case "aws":
-		err = validateAWS(params.KeosCluster.Spec, creds.ProviderCredentials)
-	case "gcp":
-		err = validateGCP(params.KeosCluster.Spec, creds.ProviderCredentials)
-	case "azure":
-		err = validateAzure(params.KeosCluster.Spec, creds.ProviderCredentials, params.KeosCluster.Metadata.Name)
+	for _, notice := range errs.notices() {
+		if params.Report != nil {
+			params.Report(notice)
+		} else if params.Logger != nil {
+			params.Logger.Warn(string(notice.Severity) + ": " + notice.Error())
+		}
 	}
-	if err != nil {
+	if err := errs.aggregate(); err != nil {
 		return commons.ClusterCredentials{}, err
 	}
 
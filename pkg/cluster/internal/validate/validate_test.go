@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestClusterOffline(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name          string
+		Spec          string
+		Private       bool
+		ExpectError   bool
+		ExpectedPaths []string
+	}{
+		{
+			Name: "cloud checks are skipped",
+			Spec: `
+infra_provider: aws
+k8s_version: v1.26.8
+region: eu-west-1
+control_plane:
+  managed: true
+docker_registries:
+  - url: registry.example.com
+    type: ecr
+    keos_registry: true
+helm_repository:
+  url: https://charts.example.com
+worker_nodes:
+  - name: worker
+    quantity: 3
+    size: m5.xlarge
+`,
+			ExpectedPaths: []string{
+				"spec.credentials.aws",
+				"spec.region",
+				"spec.worker_nodes[0].size",
+			},
+		},
+		{
+			Name: "docker needs no credentials nor inventory",
+			Spec: `
+infra_provider: docker
+k8s_version: v1.26.8
+region: local
+control_plane:
+  managed: false
+  size: docker
+docker_registries:
+  - url: registry.example.com
+    type: generic
+    keos_registry: true
+helm_repository:
+  url: https://charts.example.com
+worker_nodes:
+  - name: worker
+    quantity: 2
+    size: docker
+    zone_distribution: unbalanced
+`,
+			ExpectedPaths: []string{},
+		},
+		{
+			Name: "private registry check is skipped",
+			Spec: `
+infra_provider: docker
+k8s_version: v1.26.8
+region: local
+control_plane:
+  managed: false
+  size: docker
+docker_registries:
+  - url: registry.example.com
+    type: generic
+    keos_registry: true
+helm_repository:
+  url: https://charts.example.com
+worker_nodes:
+  - name: worker
+    quantity: 2
+    size: docker
+    zone_distribution: unbalanced
+`,
+			Private: true,
+			ExpectedPaths: []string{
+				"spec.docker_registries",
+			},
+		},
+		{
+			Name: "structural checks are run",
+			Spec: `
+infra_provider: aws
+k8s_version: v1.26.8
+region: eu-west-1
+control_plane:
+  managed: true
+docker_registries:
+  - url: registry.example.com
+    type: acr
+    keos_registry: true
+helm_repository:
+  url: https://charts.example.com
+worker_nodes:
+  - name: worker
+    quantity: 3
+    size: m5.xlarge
+    node_image: invalid
+`,
+			ExpectError: true,
+			ExpectedPaths: []string{
+				"spec.credentials.aws",
+				"spec.region",
+				"spec.worker_nodes[0].size",
+				"spec.docker_registries[0].type",
+				"spec.worker_nodes[0].node_image",
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			keosCluster := commons.KeosCluster{}
+			if err := yaml.Unmarshal([]byte(tc.Spec), &keosCluster.Spec); err != nil {
+				t.Fatalf("failed to parse spec: %v", err)
+			}
+			paths := []string{}
+			params := &ValidateParams{
+				KeosCluster:   keosCluster,
+				ClusterConfig: &commons.ClusterConfig{Spec: commons.ClusterConfigSpec{Private: tc.Private}},
+				SecretsPath:   "testdata/nonexistent.yml",
+				Offline:       true,
+				Report: func(finding *commons.FieldError) {
+					assert.StringEqual(t, string(commons.SeveritySkipped), string(finding.Severity))
+					paths = append(paths, finding.Path)
+				},
+			}
+			_, err := Cluster(params)
+			assert.ExpectError(t, tc.ExpectError, err)
+			for _, finding := range commons.FieldErrors(err) {
+				paths = append(paths, finding.Path)
+			}
+			assert.DeepEqual(t, tc.ExpectedPaths, paths)
+		})
+	}
+}
@@ -29,14 +29,20 @@ import (
 	"sigs.k8s.io/kind/pkg/cluster/nodes"
 	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
 	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/cli"
 	"sigs.k8s.io/kind/pkg/log"
 
+	internalapply "sigs.k8s.io/kind/pkg/cluster/internal/apply"
 	internalcreate "sigs.k8s.io/kind/pkg/cluster/internal/create"
 	internaldelete "sigs.k8s.io/kind/pkg/cluster/internal/delete"
+	internaldiff "sigs.k8s.io/kind/pkg/cluster/internal/diff"
+	"sigs.k8s.io/kind/pkg/cluster/internal/kube"
 	"sigs.k8s.io/kind/pkg/cluster/internal/kubeconfig"
+	internalmirror "sigs.k8s.io/kind/pkg/cluster/internal/mirror"
 	internalproviders "sigs.k8s.io/kind/pkg/cluster/internal/providers"
 	"sigs.k8s.io/kind/pkg/cluster/internal/providers/docker"
 	"sigs.k8s.io/kind/pkg/cluster/internal/providers/podman"
+	internalstatus "sigs.k8s.io/kind/pkg/cluster/internal/status"
 	internalvalidate "sigs.k8s.io/kind/pkg/cluster/internal/validate"
 )
 
@@ -191,6 +197,47 @@ func (p *Provider) Create(name string, vaultPassword string, descriptorPath stri
 	return internalcreate.Cluster(p.logger, p.provider, opts)
 }
 
+// DeleteWorkload tears down the workload cluster created by Create, managing it
+// from the local cluster (which is provisioned temporarily if it does not exist)
+func (p *Provider) DeleteWorkload(name string, vaultPassword string, descriptorPath string, deleteIAM bool, dockerRegUrl string, clusterConfig *commons.ClusterConfig, keosCluster commons.KeosCluster, clusterCredentials commons.ClusterCredentials, options ...CreateOption) error {
+	opts := &internalcreate.ClusterOptions{
+		NameOverride:       name,
+		VaultPassword:      vaultPassword,
+		DescriptorPath:     descriptorPath,
+		DeleteIAM:          deleteIAM,
+		KeosCluster:        keosCluster,
+		ClusterCredentials: clusterCredentials,
+		ClusterConfig:      clusterConfig,
+		DockerRegUrl:       dockerRegUrl,
+	}
+	for _, o := range options {
+		if err := o.apply(opts); err != nil {
+			return err
+		}
+	}
+	return internalcreate.DeleteWorkloadCluster(p.logger, p.provider, opts)
+}
+
+// Restore recreates the local management cluster from the cloud-provisioner
+// backup in backupPath, moving it into targetKubeconfig if not empty
+func (p *Provider) Restore(name string, backupPath string, targetKubeconfig string, dockerRegUrl string, clusterConfig *commons.ClusterConfig, keosCluster commons.KeosCluster, clusterCredentials commons.ClusterCredentials, options ...CreateOption) error {
+	opts := &internalcreate.ClusterOptions{
+		NameOverride:       name,
+		BackupPath:         backupPath,
+		TargetKubeconfig:   targetKubeconfig,
+		KeosCluster:        keosCluster,
+		ClusterCredentials: clusterCredentials,
+		ClusterConfig:      clusterConfig,
+		DockerRegUrl:       dockerRegUrl,
+	}
+	for _, o := range options {
+		if err := o.apply(opts); err != nil {
+			return err
+		}
+	}
+	return internalcreate.RestoreCluster(p.logger, p.provider, opts)
+}
+
 // Delete tears down a kubernetes-in-docker cluster
 func (p *Provider) Delete(name, explicitKubeconfigPath string) error {
 	return internaldelete.Cluster(p.logger, p.provider, defaultName(name), explicitKubeconfigPath)
@@ -255,11 +302,74 @@ func (p *Provider) CollectLogs(name, dir string) error {
 	return p.provider.CollectLogs(dir, n)
 }
 
-func (p *Provider) Validate(keosCluster commons.KeosCluster, secretsPath string, vaultPassword string) (commons.ClusterCredentials, error) {
+// Validate checks the descriptor and its credentials, returning every problem
+// found (see commons.FieldErrors) and the credentials to use otherwise
+func (p *Provider) Validate(keosCluster commons.KeosCluster, secretsPath string, vaultPassword string, options ...ValidateOption) (commons.ClusterCredentials, error) {
 	params := &internalvalidate.ValidateParams{
 		KeosCluster:   keosCluster,
 		SecretsPath:   secretsPath,
 		VaultPassword: vaultPassword,
+		Logger:        p.logger,
+	}
+	for _, o := range options {
+		if err := o.apply(params); err != nil {
+			return commons.ClusterCredentials{}, err
+		}
 	}
 	return internalvalidate.Cluster(params)
 }
+
+// ValidateDescriptor validates the descriptor loaded by a command against its
+// secrets, see Validate
+func (p *Provider) ValidateDescriptor(d *cli.Descriptor, options ...ValidateOption) (commons.ClusterCredentials, error) {
+	options = append([]ValidateOption{ValidateWithSecrets(d.Secrets)}, options...)
+	return p.Validate(*d.KeosCluster, d.SecretsPath, d.VaultPassword, options...)
+}
+
+// Apply server-side applies the descriptor to the running workload cluster
+// of kubeconfigPath, a local kubeconfig, and waits for it to be reconciled
+func (p *Provider) Apply(keosCluster commons.KeosCluster, clusterConfig *commons.ClusterConfig, kubeconfigPath string, options ...ApplyOption) error {
+	params := &internalapply.ApplyParams{
+		KeosCluster:   keosCluster,
+		ClusterConfig: clusterConfig,
+		Logger:        p.logger,
+		Client:        kube.NewLocalClient(kubeconfigPath),
+	}
+	for _, o := range options {
+		if err := o.apply(params); err != nil {
+			return err
+		}
+	}
+	return internalapply.Cluster(params)
+}
+
+// Diff returns the differences between the descriptor and the KeosCluster
+// running in the workload cluster of kubeconfigPath, a local kubeconfig
+func (p *Provider) Diff(keosCluster commons.KeosCluster, clusterConfig *commons.ClusterConfig, kubeconfigPath string) ([]commons.FieldChange, error) {
+	return internaldiff.Cluster(&internaldiff.DiffParams{
+		KeosCluster:   keosCluster,
+		ClusterConfig: clusterConfig,
+		Client:        kube.NewLocalClient(kubeconfigPath),
+	})
+}
+
+// WorkloadClusterStatus returns the state of the workload cluster of the
+// descriptor, using kubeconfigPath, a local kubeconfig
+func (p *Provider) WorkloadClusterStatus(keosCluster commons.KeosCluster, kubeconfigPath string) (*commons.WorkloadClusterStatus, error) {
+	return internalstatus.Cluster(&internalstatus.StatusParams{
+		KeosCluster: keosCluster,
+		Client:      kube.NewLocalClient(kubeconfigPath),
+	})
+}
+
+// MirrorImages copies the images required by the workload cluster of the
+// descriptor into its keos registry, reporting the result of each one. With
+// dryRun, the images are only checked in the keos registry
+func (p *Provider) MirrorImages(keosCluster commons.KeosCluster, clusterCredentials commons.ClusterCredentials, dryRun bool) ([]commons.MirroredImage, error) {
+	return internalmirror.Images(&internalmirror.MirrorParams{
+		Logger:             p.logger,
+		KeosCluster:        keosCluster,
+		ClusterCredentials: clusterCredentials,
+		DryRun:             dryRun,
+	})
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	internalvalidate "sigs.k8s.io/kind/pkg/cluster/internal/validate"
+	"sigs.k8s.io/kind/pkg/commons"
+)
+
+// ValidateOption is a Provider.Validate option
+type ValidateOption interface {
+	apply(*internalvalidate.ValidateParams) error
+}
+
+type validateOptionAdapter func(*internalvalidate.ValidateParams) error
+
+func (c validateOptionAdapter) apply(o *internalvalidate.ValidateParams) error {
+	return c(o)
+}
+
+// ValidateWithOffline skips the checks that query the cloud provider or read
+// the secrets file, reporting them as skipped
+func ValidateWithOffline(offline bool) ValidateOption {
+	return validateOptionAdapter(func(o *internalvalidate.ValidateParams) error {
+		o.Offline = offline
+		return nil
+	})
+}
+
+// ValidateWithReport configures the function receiving the warnings and
+// skipped checks, instead of logging them
+func ValidateWithReport(report func(*commons.FieldError)) ValidateOption {
+	return validateOptionAdapter(func(o *internalvalidate.ValidateParams) error {
+		o.Report = report
+		return nil
+	})
+}
+
+// ValidateWithSecrets configures the provider the secrets are read from,
+// instead of the ansible-vault encrypted secrets file
+func ValidateWithSecrets(secrets commons.SecretsProvider) ValidateOption {
+	return validateOptionAdapter(func(o *internalvalidate.ValidateParams) error {
+		o.Secrets = secrets
+		return nil
+	})
+}
+
+// ValidateWithClusterConfig configures the ClusterConfig of the descriptor,
+// whose private_registry enables checking that the keos registry and the
+// helm repository hold every image and chart of the installation
+func ValidateWithClusterConfig(clusterConfig *commons.ClusterConfig) ValidateOption {
+	return validateOptionAdapter(func(o *internalvalidate.ValidateParams) error {
+		o.ClusterConfig = clusterConfig
+		return nil
+	})
+}
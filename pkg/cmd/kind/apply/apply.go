@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apply implements the `apply` command
+package apply
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/log"
+
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/internal/runtime"
+)
+
+type flagpole struct {
+	Vault          cli.VaultPassword
+	Secrets        string
+	DescriptorPath string
+	Kubeconfig     string
+	Wait           time.Duration
+}
+
+const kubeconfigDefaultPath = "./.kube/config"
+
+// NewCommand returns a new cobra.Command for applying the descriptor changes
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "apply",
+		Short: "Applies the descriptor changes to the workload cluster",
+		Long:  "Validates the descriptor and server-side applies its KeosCluster and ClusterConfig to the running workload cluster, waiting for the keoscluster-controller to reconcile them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, flags)
+		},
+	}
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt secrets")
+	cmd.Flags().StringVar(
+		&flags.Secrets,
+		"secrets",
+		cli.SecretsDefaultPath,
+		"source of the secrets, one of: <path> or ansible-vault:<path>, sops:<path>, env:[<prefix>], vault:<mount>/<path>",
+	)
+	cmd.Flags().StringVarP(
+		&flags.DescriptorPath,
+		"descriptor",
+		"d",
+		cli.DescriptorDefaultPath,
+		"allows you to indicate the name of the descriptor located in current or other directory",
+	)
+	cmd.Flags().StringVar(
+		&flags.Kubeconfig,
+		"kubeconfig",
+		kubeconfigDefaultPath,
+		"kubeconfig of the cluster managing the workload cluster, by default the one saved during its creation",
+	)
+	cmd.Flags().DurationVar(
+		&flags.Wait,
+		"wait",
+		5*time.Minute,
+		"time to wait for the changes to be reconciled, 0 to not wait",
+	)
+	return cmd
+}
+
+func runE(logger log.Logger, flags *flagpole) error {
+	d, err := cli.LoadDescriptor(cli.DescriptorOptions{
+		DescriptorPath: flags.DescriptorPath,
+		Secrets:        flags.Secrets,
+		Vault:          &flags.Vault,
+	})
+	if err != nil {
+		return err
+	}
+	keosCluster := d.KeosCluster
+
+	provider := cluster.NewProvider(
+		cluster.ProviderWithLogger(logger),
+		runtime.GetDefault(logger),
+	)
+
+	if _, err = provider.ValidateDescriptor(d); err != nil {
+		for _, fieldErr := range commons.FieldErrors(err) {
+			logger.Error(fieldErr.Error())
+		}
+		return errors.New("failed to validate cluster")
+	}
+
+	if err = provider.Apply(
+		*keosCluster,
+		d.ClusterConfig,
+		flags.Kubeconfig,
+		cluster.ApplyWithWait(flags.Wait),
+	); err != nil {
+		return errors.Wrapf(err, "failed to apply the descriptor of cluster %q", keosCluster.Metadata.Name)
+	}
+
+	logger.V(0).Infof("The descriptor of cluster %q has been applied\n", keosCluster.Metadata.Name)
+	return nil
+}
@@ -18,15 +18,11 @@ limitations under the License.
 package cluster
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
-	"os"
-
-	"syscall"
 	"time"
 
-	term "golang.org/x/term"
-
 	"github.com/spf13/cobra"
 
 	"sigs.k8s.io/kind/pkg/cluster"
@@ -47,17 +43,23 @@ type flagpole struct {
 	Retain         bool
 	Wait           time.Duration
 	Kubeconfig     string
-	VaultPassword  string
+	Vault          cli.VaultPassword
 	DescriptorPath string
 	MoveManagement bool
 	AvoidCreation  bool
 	ForceDelete    bool
 	ValidateOnly   bool
+	Output         string
+	Offline        bool
+	Secrets        string
+	Resume         bool
+	DryRun         bool
+	SkipPhases     []string
+	OnlyPhase      string
+	RenderOnly     string
+	BOM            string
 }
 
-const clusterDefaultPath = "./cluster.yaml"
-const secretsDefaultPath = "./secrets.yml"
-
 // NewCommand returns a new cobra.Command for cluster creation
 func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
 	flags := &flagpole{}
@@ -108,13 +110,7 @@ func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
 		"",
 		"sets kubeconfig path instead of $KUBECONFIG or $HOME/.kube/config",
 	)
-	cmd.Flags().StringVarP(
-		&flags.VaultPassword,
-		"vault-password",
-		"p",
-		"",
-		"sets vault password to encrypt secrets",
-	)
+	flags.Vault.AddFlags(cmd.Flags(), "to encrypt secrets")
 	cmd.Flags().StringVarP(
 		&flags.DescriptorPath,
 		"descriptor",
@@ -146,6 +142,61 @@ func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
 		false,
 		"by setting this flag the descriptor will be validated and the cluster won't be created",
 	)
+	cmd.Flags().StringVarP(
+		&flags.Output,
+		"output",
+		"o",
+		"",
+		"output format of the --validate-only findings, one of: text, json",
+	)
+	cmd.Flags().BoolVar(
+		&flags.Offline,
+		"offline",
+		false,
+		"by setting this flag --validate-only won't query the cloud provider nor read the secrets file, reporting those checks as skipped",
+	)
+	cmd.Flags().StringVar(
+		&flags.Secrets,
+		"secrets",
+		cli.SecretsDefaultPath,
+		"source of the secrets, one of: <path> or ansible-vault:<path>, sops:<path>, env:[<prefix>], vault:<mount>/<path>",
+	)
+	cmd.Flags().BoolVar(
+		&flags.Resume,
+		"resume",
+		false,
+		"by setting this flag a failed creation will be resumed in the local cluster from its last checkpoint (the local cluster is kept on failure once a phase has completed)",
+	)
+	cmd.Flags().BoolVar(
+		&flags.DryRun,
+		"dry-run",
+		false,
+		"by setting this flag the phases to be run will be listed and the cluster won't be created",
+	)
+	cmd.Flags().StringSliceVar(
+		&flags.SkipPhases,
+		"skip-phase",
+		nil,
+		"phase(s) of the workload cluster creation that won't be run",
+	)
+	cmd.Flags().StringVar(
+		&flags.OnlyPhase,
+		"only-phase",
+		"",
+		"runs only this phase of the workload cluster creation against the existing local cluster",
+	)
+	cmd.Flags().StringVar(
+		&flags.RenderOnly,
+		"render-only",
+		"",
+		"writes the manifests and the commands of the workload cluster creation into this directory without creating any container nor querying the cloud provider",
+	)
+	cmd.Flags().StringVar(
+		&flags.BOM,
+		"bom",
+		"",
+		"overrides the versions of the bill of materials with the ones set in this file",
+	)
 
 	return cmd
 }
@@ -156,43 +207,61 @@ func runE(logger log.Logger, streams cmd.IOStreams, flags *flagpole) error {
 	if err != nil {
 		return err
 	}
-
-	if flags.DescriptorPath == "" {
-		flags.DescriptorPath = clusterDefaultPath
-	}
-
-	if flags.VaultPassword == "" {
-		flags.VaultPassword, err = setPassword(secretsDefaultPath)
-		if err != nil {
-			return err
-		}
-	}
-
-	keosCluster, clusterConfig, err := commons.GetClusterDescriptor(flags.DescriptorPath)
+	// Rendering validates the descriptor offline, so the secrets are not read
+	offline := flags.Offline || flags.RenderOnly != ""
+
+	d, err := cli.LoadDescriptor(cli.DescriptorOptions{
+		DescriptorPath:       flags.DescriptorPath,
+		Secrets:              flags.Secrets,
+		BOM:                  flags.BOM,
+		Vault:                &flags.Vault,
+		ConfirmVaultPassword: true,
+		Offline:              offline,
+	})
 	if err != nil {
-		return errors.Wrap(err, "failed to parse cluster descriptor")
+		return err
 	}
+	keosCluster, clusterConfig := d.KeosCluster, d.ClusterConfig
 
 	provider := cluster.NewProvider(
 		cluster.ProviderWithLogger(logger),
 		runtime.GetDefault(logger),
 	)
 
-	clusterCredentials, err := provider.Validate(
-		*keosCluster,
-		secretsDefaultPath,
-		flags.VaultPassword,
-	)
-	if err != nil {
-		return errors.Wrap(err, "failed to validate cluster")
+	validateOptions := []cluster.ValidateOption{
+		cluster.ValidateWithOffline(offline),
+		cluster.ValidateWithClusterConfig(clusterConfig),
+	}
+	findings := []*commons.FieldError{}
+	if flags.ValidateOnly {
+		validateOptions = append(validateOptions, cluster.ValidateWithReport(func(finding *commons.FieldError) {
+			findings = append(findings, finding)
+		}))
+	}
+	clusterCredentials, err := provider.ValidateDescriptor(d, validateOptions...)
+	if flags.ValidateOnly {
+		findings = append(commons.FieldErrors(err), findings...)
+		if err := printFindings(streams.Out, flags.Output, findings); err != nil {
+			return err
+		}
+		if err != nil {
+			return errors.New("cluster descriptor is invalid")
+		}
+	} else if err != nil {
+		for _, fieldErr := range commons.FieldErrors(err) {
+			logger.Error(fieldErr.Error())
+		}
+		return errors.New("failed to validate cluster")
 	}
 
 	dockerRegUrl := ""
-	if clusterConfig != nil && clusterConfig.Spec.Private {
-		configFile, err := getConfigFile(keosCluster, clusterCredentials)
+	if clusterConfig != nil && clusterConfig.Spec.Private && !offline {
+		configFile, cleanup, err := GetConfigFile(keosCluster, clusterCredentials)
 		if err != nil {
 			return errors.Wrap(err, "Error getting private kubeadm config")
 		}
+		// the registry CAs are only mounted when the kind cluster is created
+		defer cleanup()
 		flags.Config = configFile
 		for _, dockerReg := range keosCluster.Spec.DockerRegistries {
 			if dockerReg.KeosRegistry {
@@ -202,7 +271,9 @@ func runE(logger log.Logger, streams cmd.IOStreams, flags *flagpole) error {
 	}
 
 	if flags.ValidateOnly {
-		fmt.Println("Cluster descriptor is valid")
+		if flags.Output != "json" {
+			fmt.Fprintln(streams.Out, "Cluster descriptor is valid")
+		}
 		return nil
 	}
 
@@ -215,8 +286,8 @@ func runE(logger log.Logger, streams cmd.IOStreams, flags *flagpole) error {
 	// create the cluster
 	if err = provider.Create(
 		flags.Name,
-		flags.VaultPassword,
-		flags.DescriptorPath,
+		d.VaultPassword,
+		d.Path,
 		flags.MoveManagement,
 		flags.AvoidCreation,
 		dockerRegUrl,
@@ -229,6 +300,12 @@ func runE(logger log.Logger, streams cmd.IOStreams, flags *flagpole) error {
 		cluster.CreateWithMove(flags.MoveManagement),
 		cluster.CreateWithAvoidCreation(flags.AvoidCreation),
 		cluster.CreateWithForceDelete(flags.ForceDelete),
+		cluster.CreateWithSecretsPath(d.SecretsPath),
+		cluster.CreateWithResume(flags.Resume),
+		cluster.CreateWithDryRun(flags.DryRun),
+		cluster.CreateWithSkipPhases(flags.SkipPhases),
+		cluster.CreateWithOnlyPhase(flags.OnlyPhase),
+		cluster.CreateWithRenderDir(flags.RenderOnly),
 		cluster.CreateWithWaitForReady(flags.Wait),
 		cluster.CreateWithKubeconfigPath(flags.Kubeconfig),
 		cluster.CreateWithDisplayUsage(true),
@@ -255,33 +332,22 @@ func configOption(rawConfigFlag string, stdin io.Reader) (cluster.CreateOption,
 	return cluster.CreateWithRawConfig(raw), nil
 }
 
-func setPassword(secretsDefaultPath string) (string, error) {
-	firstPassword, err := requestPassword("Vault Password: ")
-	if err != nil {
-		return "", err
-	}
-
-	if _, err := os.Stat(secretsDefaultPath); os.IsNotExist(err) {
-		secondPassword, err := requestPassword("Rewrite Vault Password:")
+// printFindings writes the validation findings to w in the output format
+func printFindings(w io.Writer, output string, findings []*commons.FieldError) error {
+	if output == "json" {
+		raw, err := json.MarshalIndent(findings, "", "  ")
 		if err != nil {
-			return "", err
-		}
-		if firstPassword != secondPassword {
-			return "", errors.New("The passwords do not match.")
+			return errors.Wrap(err, "failed to marshal validation findings")
 		}
+		_, err = fmt.Fprintln(w, string(raw))
+		return err
 	}
-
-	return firstPassword, nil
-}
-
-func requestPassword(request string) (string, error) {
-	fmt.Print(request)
-	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-	if err != nil {
-		return "", err
+	for _, finding := range findings {
+		if _, err := fmt.Fprintln(w, string(finding.Severity)+": "+finding.Error()); err != nil {
+			return err
+		}
 	}
-	fmt.Print("\n")
-	return string(bytePassword), nil
+	return nil
 }
 
 func validateFlags(flags *flagpole) error {
@@ -295,8 +361,23 @@ func validateFlags(flags *flagpole) error {
 	if flags.MoveManagement {
 		count++
 	}
+	if flags.Output != "" && flags.Output != "text" && flags.Output != "json" {
+		return errors.New("Flag --output must be one of: text, json")
+	}
+	if (flags.Output != "" || flags.Offline) && !flags.ValidateOnly {
+		return errors.New("Flags --output and --offline can only be used with --validate-only")
+	}
 	if count > 1 {
 		return errors.New("Flags --retain, --avoid-creation, and --keep-mgmt are mutually exclusive")
 	}
+	if flags.Resume && (flags.AvoidCreation || flags.ForceDelete) {
+		return errors.New("Flag --resume can't be used with --avoid-creation or --delete-previous")
+	}
+	if flags.OnlyPhase != "" && (flags.Resume || len(flags.SkipPhases) > 0 || flags.ForceDelete) {
+		return errors.New("Flag --only-phase can't be used with --resume, --skip-phase or --delete-previous")
+	}
+	if flags.RenderOnly != "" && (flags.ValidateOnly || flags.DryRun || flags.Resume || flags.ForceDelete || flags.Retain) {
+		return errors.New("Flag --render-only can't be used with --validate-only, --dry-run, --resume, --delete-previous or --retain")
+	}
 	return nil
 }
@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestRenderConfig(t *testing.T) {
+	t.Parallel()
+	spec := commons.KeosSpec{
+		DockerRegistries: []commons.DockerRegistry{
+			{URL: "keos.example.com/keos", Type: "generic", KeosRegistry: true, AuthRequired: true},
+			{URL: "keos.example.com/other", Type: "generic"},
+			{URL: "other.example.com:5000", Type: "generic"},
+		},
+	}
+	clusterCredentials := commons.ClusterCredentials{
+		DockerRegistriesCredentials: []map[string]interface{}{
+			{"url": "keos.example.com/keos", "user": "user", "pass": "pass"},
+		},
+	}
+	config, err := renderConfig(spec, clusterCredentials)
+	assert.ExpectError(t, false, err)
+
+	var parsed struct {
+		ContainerdConfigPatches []string `yaml:"containerdConfigPatches"`
+	}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("failed to parse the config: %v\n%s", err, config)
+	}
+	patch := parsed.ContainerdConfigPatches[0]
+	for _, expected := range []string{
+		`mirrors."keos.example.com"]`,
+		`mirrors."other.example.com:5000"]`,
+		// base64 of user:pass
+		`auth = "dXNlcjpwYXNz"`,
+	} {
+		assert.BoolEqual(t, true, strings.Contains(patch, expected))
+	}
+	assert.BoolEqual(t, true, strings.Count(patch, `mirrors."keos.example.com"]`) == 1)
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workloadcluster implements the `delete workload-cluster` command
+package workloadcluster
+
+import (
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cmd"
+	createcluster "sigs.k8s.io/kind/pkg/cmd/kind/create/cluster"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/log"
+
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/internal/runtime"
+)
+
+type flagpole struct {
+	Name           string
+	Kubeconfig     string
+	Vault          cli.VaultPassword
+	Secrets        string
+	DescriptorPath string
+	DeleteIAM      bool
+	Retain         bool
+	DryRun         bool
+	SkipPhases     []string
+	BOM            string
+}
+
+// NewCommand returns a new cobra.Command for workload cluster deletion
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "workload-cluster",
+		Short: "Deletes a workload cluster",
+		Long:  "Deletes the cloud workload cluster described in the descriptor, managing it from a temporary local cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cli.OverrideDefaultName(cmd.Flags())
+			return runE(logger, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.Name,
+		"name",
+		"n",
+		"",
+		"local cluster name, overrides KIND_CLUSTER_NAME, config (default kind)",
+	)
+	cmd.Flags().StringVar(
+		&flags.Kubeconfig,
+		"kubeconfig",
+		"",
+		"sets kubeconfig path instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt secrets")
+	cmd.Flags().StringVar(
+		&flags.Secrets,
+		"secrets",
+		cli.SecretsDefaultPath,
+		"source of the secrets, one of: <path> or ansible-vault:<path>, sops:<path>, env:[<prefix>], vault:<mount>/<path>",
+	)
+	cmd.Flags().StringVarP(
+		&flags.DescriptorPath,
+		"descriptor",
+		"d",
+		"",
+		"allows you to indicate the name of the descriptor located in current or other directory. Default: cluster.yaml",
+	)
+	cmd.Flags().BoolVar(
+		&flags.DeleteIAM,
+		"delete-iam",
+		false,
+		"by setting this flag the IAM CloudFormation stack will be deleted too (AWS only)",
+	)
+	cmd.Flags().BoolVar(
+		&flags.Retain,
+		"retain",
+		false,
+		"retain the temporary local cluster after the deletion",
+	)
+	cmd.Flags().BoolVar(
+		&flags.DryRun,
+		"dry-run",
+		false,
+		"by setting this flag the phases to be run will be listed and the cluster won't be deleted",
+	)
+	cmd.Flags().StringSliceVar(
+		&flags.SkipPhases,
+		"skip-phase",
+		nil,
+		"phase(s) of the workload cluster deletion that won't be run",
+	)
+	cmd.Flags().StringVar(
+		&flags.BOM,
+		"bom",
+		"",
+		"overrides the versions of the bill of materials with the ones set in this file",
+	)
+	return cmd
+}
+
+func runE(logger log.Logger, flags *flagpole) error {
+	d, err := cli.LoadDescriptor(cli.DescriptorOptions{
+		DescriptorPath: flags.DescriptorPath,
+		Secrets:        flags.Secrets,
+		BOM:            flags.BOM,
+		Vault:          &flags.Vault,
+	})
+	if err != nil {
+		return err
+	}
+	keosCluster, clusterConfig := d.KeosCluster, d.ClusterConfig
+
+	provider := cluster.NewProvider(
+		cluster.ProviderWithLogger(logger),
+		runtime.GetDefault(logger),
+	)
+
+	clusterCredentials, err := provider.ValidateDescriptor(d)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate cluster")
+	}
+
+	options := []cluster.CreateOption{
+		cluster.CreateWithRetain(flags.Retain),
+		cluster.CreateWithKubeconfigPath(flags.Kubeconfig),
+		cluster.CreateWithDryRun(flags.DryRun),
+		cluster.CreateWithSkipPhases(flags.SkipPhases),
+	}
+
+	dockerRegUrl := ""
+	if clusterConfig != nil && clusterConfig.Spec.Private {
+		configFile, cleanup, err := createcluster.GetConfigFile(keosCluster, clusterCredentials)
+		if err != nil {
+			return errors.Wrap(err, "Error getting private kubeadm config")
+		}
+		// the registry CAs are only mounted when the kind cluster is created
+		defer cleanup()
+		options = append(options, cluster.CreateWithConfigFile(configFile))
+		for _, dockerReg := range keosCluster.Spec.DockerRegistries {
+			if dockerReg.KeosRegistry {
+				dockerRegUrl = dockerReg.URL
+			}
+		}
+	}
+
+	logger.V(0).Infof("Deleting workload cluster %q ...\n", keosCluster.Metadata.Name)
+	if err = provider.DeleteWorkload(
+		flags.Name,
+		d.VaultPassword,
+		d.Path,
+		flags.DeleteIAM,
+		dockerRegUrl,
+		clusterConfig,
+		*keosCluster,
+		clusterCredentials,
+		options...,
+	); err != nil {
+		return errors.Wrapf(err, "failed to delete workload cluster %q", keosCluster.Metadata.Name)
+	}
+
+	return nil
+}
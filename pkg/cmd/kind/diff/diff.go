@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diff implements the `diff` command
+package diff
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/log"
+
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/internal/runtime"
+)
+
+type flagpole struct {
+	DescriptorPath string
+	Kubeconfig     string
+	ExitCode       bool
+}
+
+const kubeconfigDefaultPath = "./.kube/config"
+
+// NewCommand returns a new cobra.Command for comparing the descriptor with the
+// running cluster
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "diff",
+		Short: "Shows the differences between the descriptor and the workload cluster",
+		Long:  "Compares the descriptor with the KeosCluster running in the workload cluster, whose worker node quantities are taken from its machine deployments or machine pools, and prints the differing fields as \"path: descriptor -> cluster\"",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, streams, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.DescriptorPath,
+		"descriptor",
+		"d",
+		cli.DescriptorDefaultPath,
+		"allows you to indicate the name of the descriptor located in current or other directory",
+	)
+	cmd.Flags().StringVar(
+		&flags.Kubeconfig,
+		"kubeconfig",
+		kubeconfigDefaultPath,
+		"kubeconfig of the workload cluster, by default the one saved during its creation",
+	)
+	cmd.Flags().BoolVar(
+		&flags.ExitCode,
+		"exit-code",
+		false,
+		"exit with status 1 if there are differences",
+	)
+	return cmd
+}
+
+func runE(logger log.Logger, streams cmd.IOStreams, flags *flagpole) error {
+	keosCluster, clusterConfig, err := commons.GetClusterDescriptor(flags.DescriptorPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse cluster descriptor")
+	}
+
+	provider := cluster.NewProvider(
+		cluster.ProviderWithLogger(logger),
+		runtime.GetDefault(logger),
+	)
+	changes, err := provider.Diff(*keosCluster, clusterConfig, flags.Kubeconfig)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compare the descriptor with cluster %q", keosCluster.Metadata.Name)
+	}
+
+	if len(changes) == 0 {
+		logger.V(0).Infof("The cluster %q matches the descriptor", keosCluster.Metadata.Name)
+		return nil
+	}
+	for _, change := range changes {
+		fmt.Fprintln(streams.Out, change.String())
+	}
+	if flags.ExitCode {
+		return errors.Errorf("the cluster %q differs from the descriptor", keosCluster.Metadata.Name)
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"sigs.k8s.io/kind/pkg/cmd/kind/get/clusters"
 	"sigs.k8s.io/kind/pkg/cmd/kind/get/kubeconfig"
 	"sigs.k8s.io/kind/pkg/cmd/kind/get/nodes"
+	"sigs.k8s.io/kind/pkg/cmd/kind/get/workloadcluster"
 	"sigs.k8s.io/kind/pkg/log"
 )
 
@@ -35,8 +36,8 @@ func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
 		Args: cobra.NoArgs,
 		// TODO(bentheelder): more detailed usage
 		Use:   "get",
-		Short: "Gets one of [clusters, nodes, kubeconfig]",
-		Long:  "Gets one of [clusters, nodes, kubeconfig]",
+		Short: "Gets one of [clusters, nodes, kubeconfig, workload-cluster]",
+		Long:  "Gets one of [clusters, nodes, kubeconfig, workload-cluster]",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			err := cmd.Help()
 			if err != nil {
@@ -49,5 +50,6 @@ func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
 	cmd.AddCommand(clusters.NewCommand(logger, streams))
 	cmd.AddCommand(nodes.NewCommand(logger, streams))
 	cmd.AddCommand(kubeconfig.NewCommand(logger, streams))
+	cmd.AddCommand(workloadcluster.NewCommand(logger, streams))
 	return cmd
 }
@@ -0,0 +1,175 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workloadcluster implements the `get workload-cluster` command
+package workloadcluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/log"
+
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/internal/runtime"
+)
+
+type flagpole struct {
+	DescriptorPath string
+	Kubeconfig     string
+	Output         string
+}
+
+const kubeconfigDefaultPath = "./.kube/config"
+
+// NewCommand returns a new cobra.Command for getting the workload cluster status
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "workload-cluster",
+		Short: "Shows the status of the workload cluster",
+		Long:  "Shows the Cluster API conditions, the control plane and worker replicas, the KeosCluster status and the helm releases of the workload cluster described in the descriptor",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, streams, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.DescriptorPath,
+		"descriptor",
+		"d",
+		cli.DescriptorDefaultPath,
+		"allows you to indicate the name of the descriptor located in current or other directory",
+	)
+	cmd.Flags().StringVar(
+		&flags.Kubeconfig,
+		"kubeconfig",
+		kubeconfigDefaultPath,
+		"kubeconfig of the workload cluster, by default the one saved during its creation",
+	)
+	cmd.Flags().StringVarP(
+		&flags.Output,
+		"output",
+		"o",
+		"table",
+		"output format, one of: table, json, yaml",
+	)
+	return cmd
+}
+
+func runE(logger log.Logger, streams cmd.IOStreams, flags *flagpole) error {
+	if flags.Output != "table" && flags.Output != "json" && flags.Output != "yaml" {
+		return errors.New("Flag --output must be one of: table, json, yaml")
+	}
+
+	keosCluster, _, err := commons.GetClusterDescriptor(flags.DescriptorPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse cluster descriptor")
+	}
+
+	provider := cluster.NewProvider(
+		cluster.ProviderWithLogger(logger),
+		runtime.GetDefault(logger),
+	)
+	status, err := provider.WorkloadClusterStatus(*keosCluster, flags.Kubeconfig)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the status of workload cluster %q", keosCluster.Metadata.Name)
+	}
+	return printStatus(streams.Out, flags.Output, status)
+}
+
+func printStatus(w io.Writer, output string, status *commons.WorkloadClusterStatus) error {
+	switch output {
+	case "json":
+		raw, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the workload cluster status")
+		}
+		_, err = fmt.Fprintln(w, string(raw))
+		return err
+	case "yaml":
+		raw, err := yaml.Marshal(status)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the workload cluster status")
+		}
+		_, err = w.Write(raw)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "CLUSTER\tPHASE\tCLUSTER OPERATOR\n")
+	fmt.Fprintf(tw, "%s\t%s\t%s\n", status.Name, orNone(status.Phase), orNone(status.ClusterOperatorVersion))
+
+	fmt.Fprintf(tw, "\nCONDITION\tSTATUS\tREASON\tMESSAGE\n")
+	for _, condition := range status.Conditions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+
+	fmt.Fprintf(tw, "\nCONTROL PLANE\tREADY\n")
+	cp := status.ControlPlane
+	ready := strconv.FormatBool(cp.Ready)
+	if cp.Replicas > 0 {
+		ready = strconv.Itoa(cp.ReadyReplicas) + "/" + strconv.Itoa(cp.Replicas)
+	}
+	fmt.Fprintf(tw, "%s/%s\t%s\n", cp.Kind, cp.Name, ready)
+
+	fmt.Fprintf(tw, "\nWORKERS\tDESIRED\tREADY\tUPDATED\n")
+	for _, worker := range status.Workers {
+		updated := "-"
+		if worker.Updated != nil {
+			updated = strconv.Itoa(*worker.Updated)
+		}
+		fmt.Fprintf(tw, "%s/%s\t%d\t%d\t%s\n", worker.Kind, worker.Name, worker.Desired, worker.Ready, updated)
+	}
+
+	fmt.Fprintf(tw, "\nKEOSCLUSTER STATUS\tVALUE\n")
+	keys := []string{}
+	for key := range status.KeosCluster {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := json.Marshal(status.KeosCluster[key])
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the keoscluster status")
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", key, value)
+	}
+
+	fmt.Fprintf(tw, "\nADDON\tNAMESPACE\tCHART\tVERSION\tAPP VERSION\tSTATUS\n")
+	for _, addon := range status.Addons {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", addon.Name, addon.Namespace, addon.Chart, addon.Version, orNone(addon.AppVersion), addon.Status)
+	}
+	return tw.Flush()
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
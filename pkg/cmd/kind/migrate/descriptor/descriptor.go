@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package descriptor implements the `migrate descriptor` command
+package descriptor
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+type flagpole struct {
+	DescriptorPath string
+	Output         string
+}
+
+// NewCommand returns a new cobra.Command for descriptor migration
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "descriptor",
+		Short: "Migrates the cluster descriptor to the latest apiVersion",
+		Long:  "Rewrites the cluster descriptor with the latest apiVersion, keeping its comments",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.DescriptorPath,
+		"descriptor",
+		"d",
+		cli.DescriptorDefaultPath,
+		"allows you to indicate the name of the descriptor located in current or other directory",
+	)
+	cmd.Flags().StringVarP(
+		&flags.Output,
+		"output",
+		"o",
+		"",
+		"file to write the migrated descriptor to, the descriptor is rewritten in place by default (keeping a .bak copy)",
+	)
+	return cmd
+}
+
+func runE(logger log.Logger, flags *flagpole) error {
+	raw, err := os.ReadFile(flags.DescriptorPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read cluster descriptor")
+	}
+
+	migrated, changed, err := commons.MigrateDescriptor(raw)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		logger.V(0).Infof("%s is already at the latest version\n", flags.DescriptorPath)
+		return nil
+	}
+
+	output := flags.Output
+	if output == "" {
+		output = flags.DescriptorPath
+		if err := os.WriteFile(flags.DescriptorPath+".bak", raw, 0600); err != nil {
+			return errors.Wrap(err, "failed to back up cluster descriptor")
+		}
+	}
+	if err := os.WriteFile(output, migrated, 0600); err != nil {
+		return errors.Wrap(err, "failed to write migrated cluster descriptor")
+	}
+
+	logger.V(0).Infof("%s migrated to %s\n", flags.DescriptorPath, commons.SupportedAPIVersions[len(commons.SupportedAPIVersions)-1])
+	return nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package images implements the `mirror images` command
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/log"
+
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/internal/runtime"
+)
+
+type flagpole struct {
+	DescriptorPath string
+	Vault          cli.VaultPassword
+	Secrets        string
+	DryRun         bool
+	Output         string
+	BOM            string
+}
+
+// NewCommand returns a new cobra.Command for mirroring the images
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "images",
+		Short: "Copies the images required by the workload cluster into the keos registry",
+		Long:  "Copies the images required by the workload cluster of the descriptor (depending on its provider, its managed control plane and its autoscaler) from their source registries into its keos_registry, using the credentials of the secrets file, and reports the result of each one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, streams, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.DescriptorPath,
+		"descriptor",
+		"d",
+		cli.DescriptorDefaultPath,
+		"allows you to indicate the name of the descriptor located in current or other directory",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt secrets")
+	cmd.Flags().StringVar(
+		&flags.Secrets,
+		"secrets",
+		cli.SecretsDefaultPath,
+		"source of the secrets, one of: <path> or ansible-vault:<path>, sops:<path>, env:[<prefix>], vault:<mount>/<path>",
+	)
+	cmd.Flags().BoolVar(
+		&flags.DryRun,
+		"dry-run",
+		false,
+		"only reports which images are missing in the keos registry, without copying them",
+	)
+	cmd.Flags().StringVarP(
+		&flags.Output,
+		"output",
+		"o",
+		"table",
+		"output format, one of: table, json, yaml",
+	)
+	cmd.Flags().StringVar(
+		&flags.BOM,
+		"bom",
+		"",
+		"overrides the versions of the bill of materials with the ones set in this file",
+	)
+	return cmd
+}
+
+func runE(logger log.Logger, streams cmd.IOStreams, flags *flagpole) error {
+	if flags.Output != "table" && flags.Output != "json" && flags.Output != "yaml" {
+		return errors.New("Flag --output must be one of: table, json, yaml")
+	}
+	d, err := cli.LoadDescriptor(cli.DescriptorOptions{
+		DescriptorPath: flags.DescriptorPath,
+		Secrets:        flags.Secrets,
+		BOM:            flags.BOM,
+		Vault:          &flags.Vault,
+	})
+	if err != nil {
+		return err
+	}
+	keosCluster := d.KeosCluster
+
+	provider := cluster.NewProvider(
+		cluster.ProviderWithLogger(logger),
+		runtime.GetDefault(logger),
+	)
+	clusterCredentials, err := provider.ValidateDescriptor(d)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate cluster")
+	}
+
+	report, err := provider.MirrorImages(*keosCluster, clusterCredentials, flags.DryRun)
+	if err != nil {
+		return errors.Wrapf(err, "failed to mirror the images of cluster %q", keosCluster.Metadata.Name)
+	}
+	if err := printReport(streams.Out, flags.Output, report); err != nil {
+		return err
+	}
+	failed, missing := 0, 0
+	for _, image := range report {
+		switch image.Result {
+		case commons.MirrorFailed:
+			failed++
+		case commons.MirrorMissing:
+			missing++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to mirror %d of %d images", failed, len(report))
+	}
+	if missing > 0 {
+		return errors.Errorf("%d of %d images are missing in the keos registry", missing, len(report))
+	}
+	return nil
+}
+
+func printReport(w io.Writer, output string, report []commons.MirroredImage) error {
+	switch output {
+	case "json":
+		raw, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the mirror report")
+		}
+		_, err = fmt.Fprintln(w, string(raw))
+		return err
+	case "yaml":
+		raw, err := yaml.Marshal(report)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the mirror report")
+		}
+		_, err = w.Write(raw)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "SOURCE\tDESTINATION\tRESULT\tERROR\n")
+	for _, image := range report {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", image.Source, image.Destination, image.Result, image.Error)
+	}
+	return tw.Flush()
+}
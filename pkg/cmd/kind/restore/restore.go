@@ -0,0 +1,189 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package restore implements the `restore` command
+package restore
+
+import (
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cmd"
+	createcluster "sigs.k8s.io/kind/pkg/cmd/kind/create/cluster"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/log"
+
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/internal/runtime"
+)
+
+type flagpole struct {
+	Name             string
+	Kubeconfig       string
+	Vault            cli.VaultPassword
+	Secrets          string
+	DescriptorPath   string
+	BackupDir        string
+	TargetKubeconfig string
+	Retain           bool
+	DryRun           bool
+	ForceDelete      bool
+	BOM              string
+}
+
+const backupDefaultPath = "./backup"
+
+// NewCommand returns a new cobra.Command for management cluster restoration
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "restore",
+		Short: "Restores the management cluster from a local backup",
+		Long:  "Restores the management cluster of the workload cluster described in the descriptor from the local backup directory, optionally moving it into a target cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cli.OverrideDefaultName(cmd.Flags())
+			return runE(logger, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.Name,
+		"name",
+		"n",
+		"",
+		"local cluster name, overrides KIND_CLUSTER_NAME, config (default kind)",
+	)
+	cmd.Flags().StringVar(
+		&flags.Kubeconfig,
+		"kubeconfig",
+		"",
+		"sets kubeconfig path instead of $KUBECONFIG or $HOME/.kube/config",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt secrets")
+	cmd.Flags().StringVar(
+		&flags.Secrets,
+		"secrets",
+		cli.SecretsDefaultPath,
+		"source of the secrets, one of: <path> or ansible-vault:<path>, sops:<path>, env:[<prefix>], vault:<mount>/<path>",
+	)
+	cmd.Flags().StringVarP(
+		&flags.DescriptorPath,
+		"descriptor",
+		"d",
+		"",
+		"allows you to indicate the name of the descriptor located in current or other directory. Default: cluster.yaml",
+	)
+	cmd.Flags().StringVar(
+		&flags.BackupDir,
+		"backup-dir",
+		backupDefaultPath,
+		"directory holding the backup saved during the cluster creation",
+	)
+	cmd.Flags().StringVar(
+		&flags.TargetKubeconfig,
+		"to-kubeconfig",
+		"",
+		"kubeconfig of the cluster the management role will be moved into (by default the local cluster is kept as management cluster)",
+	)
+	cmd.Flags().BoolVar(
+		&flags.Retain,
+		"retain",
+		false,
+		"retain the local cluster after moving the management role with --to-kubeconfig",
+	)
+	cmd.Flags().BoolVar(
+		&flags.DryRun,
+		"dry-run",
+		false,
+		"by setting this flag the phases to be run will be listed and nothing will be restored",
+	)
+	cmd.Flags().BoolVar(
+		&flags.ForceDelete,
+		"delete-previous",
+		false,
+		"by setting this flag the local cluster will be deleted and recreated if it already exists",
+	)
+	cmd.Flags().StringVar(
+		&flags.BOM,
+		"bom",
+		"",
+		"overrides the versions of the bill of materials with the ones set in this file",
+	)
+	return cmd
+}
+
+func runE(logger log.Logger, flags *flagpole) error {
+	d, err := cli.LoadDescriptor(cli.DescriptorOptions{
+		DescriptorPath: flags.DescriptorPath,
+		Secrets:        flags.Secrets,
+		BOM:            flags.BOM,
+		Vault:          &flags.Vault,
+	})
+	if err != nil {
+		return err
+	}
+	keosCluster, clusterConfig := d.KeosCluster, d.ClusterConfig
+
+	provider := cluster.NewProvider(
+		cluster.ProviderWithLogger(logger),
+		runtime.GetDefault(logger),
+	)
+
+	clusterCredentials, err := provider.ValidateDescriptor(d)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate cluster")
+	}
+
+	options := []cluster.CreateOption{
+		cluster.CreateWithRetain(flags.Retain),
+		cluster.CreateWithKubeconfigPath(flags.Kubeconfig),
+		cluster.CreateWithDryRun(flags.DryRun),
+		cluster.CreateWithForceDelete(flags.ForceDelete),
+		cluster.CreateWithDisplaySalutation(true),
+	}
+
+	dockerRegUrl := ""
+	if clusterConfig != nil && clusterConfig.Spec.Private {
+		configFile, cleanup, err := createcluster.GetConfigFile(keosCluster, clusterCredentials)
+		if err != nil {
+			return errors.Wrap(err, "Error getting private kubeadm config")
+		}
+		// the registry CAs are only mounted when the kind cluster is created
+		defer cleanup()
+		options = append(options, cluster.CreateWithConfigFile(configFile))
+		for _, dockerReg := range keosCluster.Spec.DockerRegistries {
+			if dockerReg.KeosRegistry {
+				dockerRegUrl = dockerReg.URL
+			}
+		}
+	}
+
+	logger.V(0).Infof("Restoring management of cluster %q from %s ...\n", keosCluster.Metadata.Name, flags.BackupDir)
+	if err = provider.Restore(
+		flags.Name,
+		flags.BackupDir,
+		flags.TargetKubeconfig,
+		dockerRegUrl,
+		clusterConfig,
+		*keosCluster,
+		clusterCredentials,
+		options...,
+	); err != nil {
+		return errors.Wrapf(err, "failed to restore management of cluster %q", keosCluster.Metadata.Name)
+	}
+
+	return nil
+}
@@ -24,13 +24,20 @@ import (
 	"github.com/spf13/cobra"
 
 	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/cmd/kind/apply"
 	"sigs.k8s.io/kind/pkg/cmd/kind/build"
 	"sigs.k8s.io/kind/pkg/cmd/kind/completion"
 	"sigs.k8s.io/kind/pkg/cmd/kind/create"
 	"sigs.k8s.io/kind/pkg/cmd/kind/delete"
+	"sigs.k8s.io/kind/pkg/cmd/kind/diff"
 	"sigs.k8s.io/kind/pkg/cmd/kind/export"
 	"sigs.k8s.io/kind/pkg/cmd/kind/get"
 	"sigs.k8s.io/kind/pkg/cmd/kind/load"
+	"sigs.k8s.io/kind/pkg/cmd/kind/migrate"
+	"sigs.k8s.io/kind/pkg/cmd/kind/mirror"
+	"sigs.k8s.io/kind/pkg/cmd/kind/restore"
+	"sigs.k8s.io/kind/pkg/cmd/kind/schema"
+	"sigs.k8s.io/kind/pkg/cmd/kind/secrets"
 	"sigs.k8s.io/kind/pkg/cmd/kind/version"
 	"sigs.k8s.io/kind/pkg/log"
 )
@@ -79,14 +86,21 @@ func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
 		"silence all stderr output",
 	)
 	// add all top level subcommands
+	cmd.AddCommand(apply.NewCommand(logger, streams))
 	cmd.AddCommand(build.NewCommand(logger, streams))
 	cmd.AddCommand(completion.NewCommand(logger, streams))
 	cmd.AddCommand(create.NewCommand(logger, streams))
 	cmd.AddCommand(delete.NewCommand(logger, streams))
+	cmd.AddCommand(diff.NewCommand(logger, streams))
 	cmd.AddCommand(export.NewCommand(logger, streams))
 	cmd.AddCommand(get.NewCommand(logger, streams))
 	cmd.AddCommand(version.NewCommand(logger, streams))
 	cmd.AddCommand(load.NewCommand(logger, streams))
+	cmd.AddCommand(restore.NewCommand(logger, streams))
+	cmd.AddCommand(migrate.NewCommand(logger, streams))
+	cmd.AddCommand(mirror.NewCommand(logger, streams))
+	cmd.AddCommand(schema.NewCommand(logger, streams))
+	cmd.AddCommand(secrets.NewCommand(logger, streams))
 	return cmd
 }
 
@@ -0,0 +1,52 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package descriptor implements the `schema descriptor` command
+package descriptor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// NewCommand returns a new cobra.Command for the descriptor JSON Schema
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "descriptor",
+		Short: "Prints the JSON Schema of the cluster descriptor (cluster.yaml)",
+		Long:  "Prints the JSON Schema of the cluster descriptor (cluster.yaml)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(streams)
+		},
+	}
+	return cmd
+}
+
+func runE(streams cmd.IOStreams) error {
+	schema, err := json.MarshalIndent(commons.DescriptorSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(streams.Out, string(schema))
+	return nil
+}
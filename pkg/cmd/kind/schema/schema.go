@@ -0,0 +1,49 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schema implements the `schema` command
+package schema
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	schemadescriptor "sigs.k8s.io/kind/pkg/cmd/kind/schema/descriptor"
+	schemasecrets "sigs.k8s.io/kind/pkg/cmd/kind/schema/secrets"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// NewCommand returns a new cobra.Command for JSON Schema generation
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "schema",
+		Short: "Prints the JSON Schema of one of [descriptor, secrets]",
+		Long:  "Prints the JSON Schema of one of [descriptor, secrets], to validate them from editors or CI",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cmd.Help()
+			if err != nil {
+				return err
+			}
+			return errors.New("Subcommand is required")
+		},
+	}
+	cmd.AddCommand(schemadescriptor.NewCommand(logger, streams))
+	cmd.AddCommand(schemasecrets.NewCommand(logger, streams))
+	return cmd
+}
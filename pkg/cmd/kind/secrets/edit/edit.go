@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package edit implements the `secrets edit` command
+package edit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+type flagpole struct {
+	SecretsPath string
+	Vault       cli.VaultPassword
+}
+
+// NewCommand returns a new cobra.Command for editing the secrets file
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "edit",
+		Short: "Edits the secrets file",
+		Long: "Opens the decrypted secrets file in $EDITOR (vi by default) and encrypts it again once saved, " +
+			"creating the file if it does not exist. The editor is reopened while the secrets are invalid",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, streams, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.SecretsPath,
+		"file",
+		"f",
+		cli.SecretsDefaultPath,
+		"path of the secrets file",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt and encrypt secrets")
+	return cmd
+}
+
+func runE(logger log.Logger, streams cmd.IOStreams, flags *flagpole) error {
+	var err error
+	// the password is confirmed when the secrets file is created
+	_, statErr := os.Stat(flags.SecretsPath)
+	vaultPassword, err := flags.Vault.Get(os.IsNotExist(statErr))
+	if err != nil {
+		return err
+	}
+
+	original := []byte("secrets:\n")
+	if _, err := os.Stat(flags.SecretsPath); err == nil {
+		original, err = commons.ReadSecretsFile(flags.SecretsPath, vaultPassword)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the decrypted secrets are only readable by the user and removed afterwards
+	dir, err := os.MkdirTemp("", "secrets-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the temporary directory")
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets.yml")
+
+	edited := original
+	for {
+		if err := os.WriteFile(path, edited, 0600); err != nil {
+			return errors.Wrap(err, "failed to write the decrypted secrets")
+		}
+		if err := runEditor(streams, path); err != nil {
+			return err
+		}
+		previous := edited
+		edited, err = os.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the edited secrets")
+		}
+		if bytes.Equal(edited, previous) || bytes.Equal(edited, original) {
+			logger.V(0).Info("Edit cancelled, no changes made")
+			return nil
+		}
+		if _, err := commons.ParseSecretsFile(edited); err != nil {
+			logger.Errorf("%v, reopening the editor (save it unchanged to cancel)", err)
+			continue
+		}
+		break
+	}
+
+	if err := commons.WriteSecretsFile(flags.SecretsPath, edited, vaultPassword); err != nil {
+		return err
+	}
+	logger.V(0).Infof("%s updated\n", flags.SecretsPath)
+	return nil
+}
+
+// runEditor opens path in $EDITOR, which may include arguments (e.g. "code --wait")
+func runEditor(streams cmd.IOStreams, path string) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	args := append(editor[1:], path)
+	err := exec.Command(editor[0], args...).
+		SetStdin(streams.In).
+		SetStdout(streams.Out).
+		SetStderr(streams.ErrOut).
+		Run()
+	if err != nil {
+		return errors.Wrap(err, "failed to run the editor "+editor[0])
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rekey implements the `secrets rekey` command
+package rekey
+
+import (
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+type flagpole struct {
+	SecretsPath string
+	Vault       cli.VaultPassword
+	NewVault    cli.VaultPassword
+}
+
+// NewCommand returns a new cobra.Command for rotating the vault password
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "rekey",
+		Short: "Re-encrypts the secrets file with a new vault password",
+		Long:  "Re-encrypts the secrets file with a new vault password, replacing it atomically",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.SecretsPath,
+		"file",
+		"f",
+		cli.SecretsDefaultPath,
+		"path of the secrets file",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt secrets")
+	flags.NewVault.AddNewFlags(cmd.Flags(), "to encrypt secrets")
+	return cmd
+}
+
+func runE(logger log.Logger, flags *flagpole) error {
+	vaultPassword, err := flags.Vault.Get(false)
+	if err != nil {
+		return err
+	}
+	newVaultPassword, err := flags.NewVault.Get(true)
+	if err != nil {
+		return err
+	}
+	if err := commons.RekeySecretsFile(flags.SecretsPath, vaultPassword, newVaultPassword); err != nil {
+		return err
+	}
+	logger.V(0).Infof("%s encrypted with the new vault password\n", flags.SecretsPath)
+	return nil
+}
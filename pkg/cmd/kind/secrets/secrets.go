@@ -0,0 +1,57 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package secrets implements the `secrets` command
+package secrets
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/cmd/kind/secrets/edit"
+	"sigs.k8s.io/kind/pkg/cmd/kind/secrets/rekey"
+	"sigs.k8s.io/kind/pkg/cmd/kind/secrets/set"
+	"sigs.k8s.io/kind/pkg/cmd/kind/secrets/unset"
+	"sigs.k8s.io/kind/pkg/cmd/kind/secrets/validate"
+	"sigs.k8s.io/kind/pkg/cmd/kind/secrets/view"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+// NewCommand returns a new cobra.Command for the secrets file management
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "secrets",
+		Short: "Manages the secrets file, one of [view, edit, rekey, set, unset, validate]",
+		Long:  "Manages the ansible-vault encrypted secrets file (secrets.yml), one of [view, edit, rekey, set, unset, validate]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cmd.Help()
+			if err != nil {
+				return err
+			}
+			return errors.New("Subcommand is required")
+		},
+	}
+	cmd.AddCommand(view.NewCommand(logger, streams))
+	cmd.AddCommand(edit.NewCommand(logger, streams))
+	cmd.AddCommand(rekey.NewCommand(logger, streams))
+	cmd.AddCommand(set.NewCommand(logger, streams))
+	cmd.AddCommand(unset.NewCommand(logger, streams))
+	cmd.AddCommand(validate.NewCommand(logger, streams))
+	return cmd
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package set implements the `secrets set` command
+package set
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+type flagpole struct {
+	SecretsPath string
+	Vault       cli.VaultPassword
+}
+
+// NewCommand returns a new cobra.Command for setting a secret
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.RangeArgs(1, 2),
+		Use:   "set <key> [value]",
+		Short: "Sets a secret in the secrets file",
+		Long: "Sets the secret at key (e.g. aws.credentials.secret_key or docker_registries.0.pass) in the secrets file, " +
+			"creating the file if it does not exist. The value is requested without echoing it if not given",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, flags, args)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.SecretsPath,
+		"file",
+		"f",
+		cli.SecretsDefaultPath,
+		"path of the secrets file",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to encrypt secrets")
+	return cmd
+}
+
+func runE(logger log.Logger, flags *flagpole, args []string) error {
+	var err error
+	// the password is confirmed when the secrets file is created
+	_, statErr := os.Stat(flags.SecretsPath)
+	vaultPassword, err := flags.Vault.Get(os.IsNotExist(statErr))
+	if err != nil {
+		return err
+	}
+	var value string
+	if len(args) > 1 {
+		value = args[1]
+	} else {
+		value, err = cli.RequestPassword("Value: ")
+		if err != nil {
+			return err
+		}
+	}
+
+	raw := []byte{}
+	if _, err := os.Stat(flags.SecretsPath); err == nil {
+		raw, err = commons.ReadSecretsFile(flags.SecretsPath, vaultPassword)
+		if err != nil {
+			return err
+		}
+	}
+	raw, err = commons.SetSecret(raw, args[0], value)
+	if err != nil {
+		return err
+	}
+	if err := commons.WriteSecretsFile(flags.SecretsPath, raw, vaultPassword); err != nil {
+		return err
+	}
+	logger.V(0).Infof("%s set in %s\n", args[0], flags.SecretsPath)
+	return nil
+}
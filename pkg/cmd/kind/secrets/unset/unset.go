@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package unset implements the `secrets unset` command
+package unset
+
+import (
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+type flagpole struct {
+	SecretsPath string
+	Vault       cli.VaultPassword
+}
+
+// NewCommand returns a new cobra.Command for removing a secret
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "unset <key>",
+		Short: "Removes a secret from the secrets file",
+		Long:  "Removes the secret at key (e.g. github_token or docker_registries.1) from the secrets file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(logger, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.SecretsPath,
+		"file",
+		"f",
+		cli.SecretsDefaultPath,
+		"path of the secrets file",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt secrets")
+	return cmd
+}
+
+func runE(logger log.Logger, flags *flagpole, key string) error {
+	var err error
+	vaultPassword, err := flags.Vault.Get(false)
+	if err != nil {
+		return err
+	}
+	raw, err := commons.ReadSecretsFile(flags.SecretsPath, vaultPassword)
+	if err != nil {
+		return err
+	}
+	raw, err = commons.UnsetSecret(raw, key)
+	if err != nil {
+		return err
+	}
+	if err := commons.WriteSecretsFile(flags.SecretsPath, raw, vaultPassword); err != nil {
+		return err
+	}
+	logger.V(0).Infof("%s removed from %s\n", key, flags.SecretsPath)
+	return nil
+}
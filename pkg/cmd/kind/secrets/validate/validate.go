@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validate implements the `secrets validate` command
+package validate
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+type flagpole struct {
+	SecretsPath    string
+	Vault          cli.VaultPassword
+	DescriptorPath string
+}
+
+// NewCommand returns a new cobra.Command for validating the secrets file
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "validate",
+		Short: "Checks the secrets file against the cluster descriptor",
+		Long: "Checks that the secrets file has no unknown keys and holds every credential required by the cluster descriptor: " +
+			"the cloud provider ones and those of the docker_registries and helm_repository with auth_required",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(streams, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.SecretsPath,
+		"file",
+		"f",
+		cli.SecretsDefaultPath,
+		"path of the secrets file",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt secrets")
+	cmd.Flags().StringVarP(
+		&flags.DescriptorPath,
+		"descriptor",
+		"d",
+		cli.DescriptorDefaultPath,
+		"allows you to indicate the name of the descriptor located in current or other directory",
+	)
+	return cmd
+}
+
+func runE(streams cmd.IOStreams, flags *flagpole) error {
+	var err error
+	vaultPassword, err := flags.Vault.Get(false)
+	if err != nil {
+		return err
+	}
+
+	keosCluster, _, err := commons.GetClusterDescriptor(flags.DescriptorPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse cluster descriptor")
+	}
+	raw, err := commons.ReadSecretsFile(flags.SecretsPath, vaultPassword)
+	if err != nil {
+		return err
+	}
+	secretsFile, err := commons.ParseSecretsFile(raw)
+	if err != nil {
+		return err
+	}
+
+	err = commons.ValidateSecrets(keosCluster.Spec, secretsFile.Secrets)
+	for _, fieldErr := range commons.FieldErrors(err) {
+		fmt.Fprintln(streams.Out, string(fieldErr.Severity)+": "+fieldErr.Error())
+	}
+	if err != nil {
+		return errors.New("secrets file is invalid")
+	}
+	fmt.Fprintln(streams.Out, "Secrets file is valid")
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package view implements the `secrets view` command
+package view
+
+import (
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/internal/cli"
+	"sigs.k8s.io/kind/pkg/log"
+)
+
+type flagpole struct {
+	SecretsPath string
+	Vault       cli.VaultPassword
+}
+
+// NewCommand returns a new cobra.Command for viewing the secrets file
+func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "view",
+		Short: "Prints the decrypted secrets file",
+		Long:  "Prints the decrypted secrets file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(streams, flags)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&flags.SecretsPath,
+		"file",
+		"f",
+		cli.SecretsDefaultPath,
+		"path of the secrets file",
+	)
+	flags.Vault.AddFlags(cmd.Flags(), "to decrypt secrets")
+	return cmd
+}
+
+func runE(streams cmd.IOStreams, flags *flagpole) error {
+	var err error
+	vaultPassword, err := flags.Vault.Get(false)
+	if err != nil {
+		return err
+	}
+	raw, err := commons.ReadSecretsFile(flags.SecretsPath, vaultPassword)
+	if err != nil {
+		return err
+	}
+	_, err = streams.Out.Write(raw)
+	return err
+}
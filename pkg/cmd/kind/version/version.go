@@ -20,10 +20,12 @@ package version
 import (
 	"fmt"
 	"runtime"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/kind/pkg/commons"
 	"sigs.k8s.io/kind/pkg/log"
 )
 
@@ -73,14 +75,23 @@ var gitCommitCount = ""
 // It is injected at build time.
 var gitCommit = ""
 
+type flagpole struct {
+	Components bool
+	BOM        string
+}
+
 // NewCommand returns a new cobra.Command for version
 func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
+	flags := &flagpole{}
 	cmd := &cobra.Command{
 		Args:  cobra.NoArgs,
 		Use:   "version",
 		Short: "Prints the cloud-provisioner CLI version",
 		Long:  "Prints the cloud-provisioner CLI version",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.Components {
+				return printComponents(flags, streams)
+			}
 			if logger.V(0).Enabled() {
 				// if not -q / --quiet, show lots of info
 				fmt.Fprintln(streams.Out, DisplayVersion())
@@ -91,9 +102,38 @@ func NewCommand(logger log.Logger, streams cmd.IOStreams) *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(
+		&flags.Components,
+		"components",
+		false,
+		"prints the versions of the components installed by this release",
+	)
+	cmd.Flags().StringVar(
+		&flags.BOM,
+		"bom",
+		"",
+		"overrides the versions of the bill of materials with the ones set in this file",
+	)
 	return cmd
 }
 
+// printComponents prints the bill of materials, with the overrides of
+// flags.BOM if it is set
+func printComponents(flags *flagpole, streams cmd.IOStreams) error {
+	if flags.BOM != "" {
+		if err := commons.LoadBOM(flags.BOM); err != nil {
+			return err
+		}
+	}
+	bom := commons.GetBOM()
+	w := tabwriter.NewWriter(streams.Out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "BOM\t%s\n", versionCore)
+	for _, c := range bom.Components() {
+		fmt.Fprintf(w, "%s\t%s\n", c.Name, c.Version)
+	}
+	return w.Flush()
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) < maxLen {
 		return s
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	_ "embed"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+//go:embed bom.yaml
+var defaultBOM []byte
+
+// BOM is the bill of materials: the versions of every component installed by
+// the cloud-provisioner, its own version is the one of the binary
+type BOM struct {
+	Kubernetes struct {
+		// Supported are the supported minor versions (e.g. 1.27)
+		Supported []string `yaml:"supported" validate:"required,dive,required"`
+	} `yaml:"kubernetes"`
+	ClusterAPI struct {
+		// Version is the version of the core, bootstrap and control plane
+		// providers, and of clusterctl
+		Version string `yaml:"version" validate:"required"`
+		// Providers are the versions of the infrastructure providers, by
+		// infra_provider
+		Providers map[string]string `yaml:"providers" validate:"required,dive,required"`
+	} `yaml:"cluster_api"`
+	CertManager string `yaml:"cert_manager" validate:"required"`
+	// CertManagerCRDs is the version of the cert-manager CRDs, released
+	// apart from the chart
+	CertManagerCRDs string `yaml:"cert_manager_crds" validate:"required"`
+	ClusterOperator struct {
+		Chart string `yaml:"chart" validate:"required"`
+		Image string `yaml:"image" validate:"required"`
+	} `yaml:"cluster_operator"`
+	Calico struct {
+		Version  string `yaml:"version" validate:"required"`
+		Operator string `yaml:"operator" validate:"required"`
+	} `yaml:"calico"`
+	Helm   string            `yaml:"helm" validate:"required"`
+	Charts map[string]string `yaml:"charts" validate:"required,dive,required"`
+}
+
+// Component is the version of an installed component
+type Component struct {
+	Name    string
+	Version string
+}
+
+var bom = DefaultBOM()
+
+// DefaultBOM returns the embedded bill of materials, without the overrides of
+// LoadBOM
+func DefaultBOM() BOM {
+	return mustParseBOM(defaultBOM)
+}
+
+func mustParseBOM(raw []byte) BOM {
+	b := BOM{}
+	if err := yaml.Unmarshal(raw, &b); err != nil {
+		panic("invalid embedded bill of materials: " + err.Error())
+	}
+	return b
+}
+
+// GetBOM returns the bill of materials of this run: the embedded one, with
+// the overrides of LoadBOM if any
+func GetBOM() BOM {
+	return bom
+}
+
+// LoadBOM overrides the embedded bill of materials with the versions set in
+// the file at path, the rest are kept
+func LoadBOM(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the bill of materials")
+	}
+	b, err := overrideBOM(GetBOM(), raw)
+	if err != nil {
+		return err
+	}
+	bom = b
+	return nil
+}
+
+// overrideBOM returns b with the versions set in raw
+func overrideBOM(b BOM, raw []byte) (BOM, error) {
+	// copy the maps, yaml merges the decoded keys into them
+	b.ClusterAPI.Providers = copyStringMap(b.ClusterAPI.Providers)
+	b.Charts = copyStringMap(b.Charts)
+	if err := yaml.Unmarshal(raw, &b); err != nil {
+		return BOM{}, errors.Wrap(err, "failed to parse the bill of materials")
+	}
+	if err := validator.New().Struct(b); err != nil {
+		return BOM{}, errors.Wrap(err, "invalid bill of materials")
+	}
+	for _, infraProvider := range InfraProviderNames() {
+		if b.InfraProviderVersion(infraProvider) == "" {
+			return BOM{}, errors.New("invalid bill of materials: there is no version of the " + infraProvider + " infrastructure provider")
+		}
+	}
+	return b, nil
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	c := map[string]string{}
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// InfraProviderVersion returns the version of the Cluster API infrastructure
+// provider of infraProvider
+func (b BOM) InfraProviderVersion(infraProvider string) string {
+	return b.ClusterAPI.Providers[infraProvider]
+}
+
+// Components returns the versions of the bill of materials, sorted by name
+func (b BOM) Components() []Component {
+	components := []Component{
+		{"cluster-api", b.ClusterAPI.Version},
+		{"cert-manager", b.CertManager},
+		{"cert-manager-crds", b.CertManagerCRDs},
+		{"cluster-operator-chart", b.ClusterOperator.Chart},
+		{"cluster-operator-image", b.ClusterOperator.Image},
+		{"calico", b.Calico.Version},
+		{"tigera-operator", b.Calico.Operator},
+		{"helm", b.Helm},
+		{"kubernetes", strings.Join(b.Kubernetes.Supported, ",")},
+	}
+	for provider, version := range b.ClusterAPI.Providers {
+		components = append(components, Component{"cluster-api-provider-" + provider, version})
+	}
+	for chart, version := range b.Charts {
+		components = append(components, Component{chart + "-chart", version})
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
+	return components
+}
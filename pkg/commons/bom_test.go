@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestEmbeddedBOM(t *testing.T) {
+	t.Parallel()
+	_, err := overrideBOM(GetBOM(), []byte{})
+	assert.ExpectError(t, false, err)
+}
+
+func TestOverrideBOM(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name        string
+		Raw         string
+		ExpectError bool
+		Check       func(t *testing.T, b BOM)
+	}{
+		{
+			Name: "versions not overridden are kept",
+			Raw: `
+cert_manager: v1.13.2
+cluster_api:
+  providers:
+    aws: v2.3.0
+charts:
+  cluster-autoscaler: 9.34.0
+`,
+			Check: func(t *testing.T, b BOM) {
+				assert.StringEqual(t, "v1.13.2", b.CertManager)
+				assert.StringEqual(t, GetBOM().CertManagerCRDs, b.CertManagerCRDs)
+				assert.StringEqual(t, "v2.3.0", b.InfraProviderVersion("aws"))
+				assert.StringEqual(t, GetBOM().InfraProviderVersion("gcp"), b.InfraProviderVersion("gcp"))
+				assert.StringEqual(t, GetBOM().ClusterAPI.Version, b.ClusterAPI.Version)
+				assert.StringEqual(t, "9.34.0", b.Charts["cluster-autoscaler"])
+				assert.StringEqual(t, GetBOM().Charts["aws-ebs-csi-driver"], b.Charts["aws-ebs-csi-driver"])
+			},
+		},
+		{
+			Name: "the embedded bill of materials is not modified",
+			Raw: `
+cluster_api:
+  providers:
+    azure: v1.12.0
+`,
+			Check: func(t *testing.T, b BOM) {
+				assert.StringEqual(t, "v1.12.0", b.InfraProviderVersion("azure"))
+				if GetBOM().InfraProviderVersion("azure") == "v1.12.0" {
+					t.Errorf("the embedded bill of materials was modified")
+				}
+			},
+		},
+		{
+			Name:        "empty versions are rejected",
+			Raw:         "helm: \"\"\n",
+			ExpectError: true,
+		},
+		{
+			Name: "every infra provider needs a version",
+			Raw: `
+cluster_api:
+  providers:
+    gcp: ""
+`,
+			ExpectError: true,
+		},
+		{
+			Name:        "invalid yaml",
+			Raw:         "charts: [",
+			ExpectError: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			b, err := overrideBOM(GetBOM(), []byte(tc.Raw))
+			assert.ExpectError(t, tc.ExpectError, err)
+			if err == nil && tc.Check != nil {
+				tc.Check(t, b)
+			}
+		})
+	}
+}
+
+func TestBOMComponents(t *testing.T) {
+	t.Parallel()
+	components := GetBOM().Components()
+	for i := 1; i < len(components); i++ {
+		if components[i-1].Name >= components[i].Name {
+			t.Errorf("components are not sorted by name: %s before %s", components[i-1].Name, components[i].Name)
+		}
+	}
+	for _, infraProvider := range InfraProviderNames() {
+		found := false
+		for _, c := range components {
+			if c.Name == "cluster-api-provider-"+infraProvider {
+				found = true
+				assert.StringEqual(t, GetBOM().InfraProviderVersion(infraProvider), c.Version)
+			}
+		}
+		assert.BoolEqual(t, true, found)
+	}
+}
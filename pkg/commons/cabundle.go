@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// caBundle is the PEM bundle of extra CAs of this run, see LoadCABundle
+var caBundle string
+
+// GetCABundle returns the PEM bundle of extra CAs loaded by LoadCABundle, or
+// an empty string if there is none
+func GetCABundle() string {
+	return caBundle
+}
+
+// ReadCABundle reads the PEM bundle of extra CAs at path, failing if it has
+// no PEM certificate
+func ReadCABundle(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the CA bundle")
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(raw) {
+		return "", errors.Errorf("there isn't any PEM certificate in the CA bundle %s", path)
+	}
+	return string(raw), nil
+}
+
+// LoadCABundle loads the PEM bundle of extra CAs at path (the ca_bundle of
+// the descriptor), to be trusted along with the system CAs
+func LoadCABundle(path string) error {
+	bundle, err := ReadCABundle(path)
+	if err != nil {
+		return err
+	}
+	caBundle = bundle
+	return nil
+}
+
+// CertPool returns the system CAs along with the CA bundle, if any
+func CertPool() *x509.CertPool {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	roots.AppendCertsFromPEM([]byte(caBundle))
+	return roots
+}
+
+// HTTPClient returns the http client used to reach the cloud and registry
+// APIs: the default one, or one trusting the CA bundle too if there is any
+func HTTPClient() *http.Client {
+	if caBundle == "" {
+		return http.DefaultClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: CertPool(), MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestLoadCABundle(t *testing.T) {
+	t.Cleanup(func() { caBundle = "" })
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.pem")
+	valid := filepath.Join(dir, "ca.pem")
+	raw, err := os.ReadFile("testdata/ca.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := string(raw)
+	if err := os.WriteFile(invalid, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(valid, []byte(ca), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.ExpectError(t, true, LoadCABundle(filepath.Join(dir, "missing.pem")))
+	assert.ExpectError(t, true, LoadCABundle(invalid))
+	assert.StringEqual(t, "", GetCABundle())
+	assert.BoolEqual(t, true, HTTPClient() == http.DefaultClient)
+
+	assert.ExpectError(t, false, LoadCABundle(valid))
+	assert.StringEqual(t, ca, GetCABundle())
+	assert.BoolEqual(t, true, HTTPClient() != http.DefaultClient)
+	assert.BoolEqual(t, true, AzureClientOptions() != nil)
+
+	keosCluster := KeosCluster{}
+	keosCluster.Spec.CABundle = valid
+	keosCluster = KeosClusterObject(keosCluster, nil)
+	assert.StringEqual(t, "", keosCluster.Spec.CABundle)
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/go-playground/validator/v10"
 	vault "github.com/sosedoff/ansible-vault-go"
 	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/internal/redact"
 )
 
 type Resource struct {
@@ -36,6 +38,13 @@ type Resource struct {
 	Spec       interface{} `yaml:"spec" validate:"required"`
 }
 
+// ClusterNamespace returns the namespace of the CAPI objects of the cluster
+// name, where the KeosCluster and ClusterConfig are created too
+func ClusterNamespace(name string) string {
+	return "cluster-" + name
+}
+
+// ClusterConfig is the v1beta1 ClusterConfig descriptor
 type ClusterConfig struct {
 	APIVersion string            `yaml:"apiVersion" validate:"required"`
 	Kind       string            `yaml:"kind" validate:"required"`
@@ -43,6 +52,7 @@ type ClusterConfig struct {
 	Spec       ClusterConfigSpec `yaml:"spec" validate:"required"`
 }
 
+// KeosCluster is the v1beta1 KeosCluster descriptor
 type KeosCluster struct {
 	APIVersion string   `yaml:"apiVersion" validate:"required"`
 	Kind       string   `yaml:"kind" validate:"required"`
@@ -65,7 +75,8 @@ type ClusterConfigRef struct {
 	Name string `json:"name,omitempty"`
 }
 
-// Spec represents the YAML structure in the spec field of the descriptor file
+// KeosSpec represents the YAML structure in the spec field of the v1beta1
+// descriptor file
 type KeosSpec struct {
 	DeployAutoscaler bool `yaml:"deploy_autoscaler" validate:"boolean"`
 
@@ -75,7 +86,7 @@ type KeosSpec struct {
 
 	Credentials Credentials `yaml:"credentials,omitempty"`
 
-	InfraProvider string `yaml:"infra_provider" validate:"required,oneof='aws' 'gcp' 'azure'"`
+	InfraProvider string `yaml:"infra_provider" validate:"required,infra_provider"`
 
 	K8SVersion string `yaml:"k8s_version" validate:"required"`
 	Region     string `yaml:"region" validate:"required"`
@@ -91,6 +102,12 @@ type KeosSpec struct {
 
 	HelmRepository HelmRepository `yaml:"helm_repository" validate:"required"`
 
+	// CABundle is the path of a PEM bundle of extra CAs (e.g. of a TLS
+	// intercepting proxy) trusted by the local node, helm, the CAPx
+	// controllers and the cloud and registry clients, relative to the
+	// descriptor if it is not absolute
+	CABundle string `yaml:"ca_bundle,omitempty"`
+
 	ExternalDomain string `yaml:"external_domain" validate:"fqdn"`
 
 	Security Security `yaml:"security,omitempty"`
@@ -209,6 +226,111 @@ type ClusterCredentials struct {
 	GithubToken                 string
 }
 
+// secretCredentials are the credentials keys (lowercase, without
+// underscores) holding secrets rather than identifiers
+var secretCredentials = map[string]bool{
+	"accesskey":    true,
+	"secretkey":    true,
+	"clientsecret": true,
+	"privatekey":   true,
+	"privatekeyid": true,
+	"pass":         true,
+}
+
+// SecretValues returns the secrets (keys, passwords and tokens) among the
+// credentials, to be redacted from the output
+func (c ClusterCredentials) SecretValues() []string {
+	values := []string{c.GithubToken}
+	add := func(credentials map[string]interface{}) {
+		for key, value := range credentials {
+			if s, ok := value.(string); ok && isSecretCredential(key) {
+				values = append(values, s)
+			}
+		}
+	}
+	for _, credentials := range []map[string]string{c.ProviderCredentials, c.KeosRegistryCredentials, c.HelmRepositoryCredentials} {
+		add(convertStringMapToInterfaceMap(credentials))
+	}
+	for _, credentials := range c.DockerRegistriesCredentials {
+		add(credentials)
+	}
+	return values
+}
+
+// KeosClusterObject returns the KeosCluster object deployed in the cluster:
+// the descriptor without the credentials nor the settings only used by the
+// cloud-provisioner, referencing the ClusterConfig if any
+func KeosClusterObject(keosCluster KeosCluster, clusterConfig *ClusterConfig) KeosCluster {
+	keosCluster.Spec.Credentials = Credentials{}
+	keosCluster.Spec.StorageClass = StorageClass{}
+	keosCluster.Spec.Security.AWS = struct {
+		CreateIAM bool "yaml:\"create_iam\" validate:\"boolean\""
+	}{}
+	// Only the control_plane section of the managed control plane is kept
+	managedControlPlane := ""
+	if p, _ := GetInfraProvider(keosCluster.Spec.InfraProvider); keosCluster.Spec.ControlPlane.Managed {
+		managedControlPlane = p.ManagedControlPlane
+	}
+	if managedControlPlane != "azure" {
+		keosCluster.Spec.ControlPlane.Azure = AzureCP{}
+	}
+	if managedControlPlane != "aws" {
+		keosCluster.Spec.ControlPlane.AWS = AWSCP{}
+	}
+	if keosCluster.Spec.ControlPlane.Managed {
+		keosCluster.Spec.ControlPlane.HighlyAvailable = nil
+	}
+	keosCluster.Spec.Keos = Keos{}
+	// The cluster-operator doesn't support the CA bundle
+	keosCluster.Spec.CABundle = ""
+	if clusterConfig != nil {
+		keosCluster.Spec.ClusterConfigRef.Name = clusterConfig.Metadata.Name
+	}
+	return keosCluster
+}
+
+// Redacted returns a copy of the credentials with their secrets replaced by
+// the redaction placeholder
+func (c ClusterCredentials) Redacted() ClusterCredentials {
+	redactStrings := func(credentials map[string]string) map[string]string {
+		if credentials == nil {
+			return nil
+		}
+		redacted := map[string]string{}
+		for key, value := range credentials {
+			if isSecretCredential(key) {
+				value = redact.Placeholder
+			}
+			redacted[key] = value
+		}
+		return redacted
+	}
+	redacted := ClusterCredentials{
+		ProviderCredentials:       redactStrings(c.ProviderCredentials),
+		KeosRegistryCredentials:   redactStrings(c.KeosRegistryCredentials),
+		HelmRepositoryCredentials: redactStrings(c.HelmRepositoryCredentials),
+	}
+	for _, credentials := range c.DockerRegistriesCredentials {
+		registry := map[string]interface{}{}
+		for key, value := range credentials {
+			if _, ok := value.(string); ok && isSecretCredential(key) {
+				value = redact.Placeholder
+			}
+			registry[key] = value
+		}
+		redacted.DockerRegistriesCredentials = append(redacted.DockerRegistriesCredentials, registry)
+	}
+	if c.GithubToken != "" {
+		redacted.GithubToken = redact.Placeholder
+	}
+	return redacted
+}
+
+// isSecretCredential returns true if the credentials key holds a secret
+func isSecretCredential(key string) bool {
+	return secretCredentials[strings.ReplaceAll(strings.ToLower(key), "_", "")]
+}
+
 type Credentials struct {
 	AWS              AWSCredentials              `yaml:"aws" validate:"excluded_with=AZURE GCP"`
 	AZURE            AzureCredentials            `yaml:"azure" validate:"excluded_with=AWS GCP"`
@@ -248,7 +370,7 @@ type DockerRegistryCredentials struct {
 
 type DockerRegistry struct {
 	AuthRequired bool   `yaml:"auth_required" validate:"boolean"`
-	Type         string `yaml:"type" validate:"required,oneof='acr' 'ecr' 'gar' 'gcr' 'generic'"`
+	Type         string `yaml:"type" validate:"required,registry_type"`
 	URL          string `yaml:"url" validate:"required"`
 	KeosRegistry bool   `yaml:"keos_registry" validate:"boolean"`
 }
@@ -378,6 +500,13 @@ func (s KeosSpec) Init() KeosSpec {
 	return s
 }
 
+// IsMachinePool returns true if the workers of the cluster are machine pools
+// rather than machine deployments
+func (s KeosSpec) IsMachinePool() bool {
+	p, _ := GetInfraProvider(s.InfraProvider)
+	return p.ManagedMachinePools && s.ControlPlane.Managed
+}
+
 // Read descriptor file
 func GetClusterDescriptor(descriptorPath string) (*KeosCluster, *ClusterConfig, error) {
 	var keosCluster KeosCluster
@@ -394,10 +523,18 @@ func GetClusterDescriptor(descriptorPath string) (*KeosCluster, *ClusterConfig,
 		return nil, nil, err
 	}
 
+	// Older descriptors are read as if they had been migrated
+	descriptorRAW, _, err = MigrateDescriptor(descriptorRAW)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	validate := validator.New()
 	validate.RegisterValidation("gte_param_if_exists", gteParamIfExists)
 	validate.RegisterValidation("lte_param_if_exists", lteParamIfExists)
 	validate.RegisterValidation("required_if_for_bool", requiredIfForBool)
+	validate.RegisterValidation("infra_provider", isInfraProvider)
+	validate.RegisterValidation("registry_type", isRegistryType)
 
 	descriptorManifests := strings.Split(string(descriptorRAW), "---\n")
 	for _, manifest := range descriptorManifests {
@@ -0,0 +1,187 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/apis/keos/v1alpha1"
+	"sigs.k8s.io/kind/pkg/apis/keos/v1beta1"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// SupportedAPIVersions lists the descriptor apiVersions that can be read,
+// the last one being the latest
+var SupportedAPIVersions = []string{v1alpha1.APIVersion, v1beta1.APIVersion}
+
+// MigrateDescriptor converts every manifest of the raw descriptor to the
+// latest apiVersion, keeping its comments. raw is returned untouched (and
+// migrated is false) if it is already at the latest apiVersion
+func MigrateDescriptor(raw []byte) (out []byte, migrated bool, err error) {
+	docs := []*yaml.Node{}
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	for {
+		doc := &yaml.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, false, errors.Wrap(err, "failed to parse cluster descriptor")
+		}
+		converted, err := convertManifest(doc)
+		if err != nil {
+			return nil, false, err
+		}
+		migrated = migrated || converted
+		docs = append(docs, doc)
+	}
+	if !migrated {
+		return raw, false, nil
+	}
+
+	buf := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, false, errors.Wrap(err, "failed to write cluster descriptor")
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, false, errors.Wrap(err, "failed to write cluster descriptor")
+	}
+	return buf.Bytes(), true, nil
+}
+
+// convertManifest converts the manifest in doc to the latest apiVersion,
+// returning whether it was converted
+func convertManifest(doc *yaml.Node) (bool, error) {
+	tm := v1beta1.TypeMeta{}
+	if err := doc.Decode(&tm); err != nil {
+		return false, errors.Wrap(err, "could not determine kind / apiVersion for manifest")
+	}
+
+	switch tm.APIVersion {
+	// missing apiVersions are reported by the descriptor validation
+	case "", v1beta1.APIVersion:
+		return false, nil
+	case v1alpha1.APIVersion:
+		if tm.Kind != "KeosCluster" {
+			return false, errors.Errorf("unknown kind %s for apiVersion: %s", tm.Kind, tm.APIVersion)
+		}
+		return true, convertv1alpha1(doc)
+	}
+
+	return false, errors.Errorf("unknown apiVersion %s for %s, supported versions are: %s", tm.APIVersion, tm.Kind, strings.Join(SupportedAPIVersions, ", "))
+}
+
+// convertv1alpha1 converts a v1alpha1 KeosCluster manifest to v1beta1
+func convertv1alpha1(doc *yaml.Node) error {
+	in := v1alpha1.KeosCluster{}
+	if err := doc.Decode(&in); err != nil {
+		return errors.Wrap(err, "unable to decode v1alpha1 KeosCluster")
+	}
+	if in.Spec.ClusterID == "" {
+		return errors.New("spec.cluster_id is required in v1alpha1 KeosCluster")
+	}
+
+	root := doc
+	if root.Kind == yaml.DocumentNode {
+		root = root.Content[0]
+	}
+	spec := mappingValue(root, "spec")
+
+	setMappingValue(root, "apiVersion", v1beta1.APIVersion)
+
+	// the cluster name is moved to metadata.name
+	clusterID := removeMappingKey(spec, "cluster_id")
+	metadata := mappingValue(root, "metadata")
+	if metadata == nil {
+		metadata = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		insertMappingKey(root, 2, "metadata", metadata)
+	}
+	if mappingValue(metadata, "name") == nil {
+		insertMappingKey(metadata, 0, "name", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: in.Spec.ClusterID})
+		if clusterID != nil {
+			metadata.Content[0].HeadComment = clusterID.HeadComment
+		}
+	}
+
+	// the external domain is moved to the spec and the internal one is dropped
+	if keos := mappingValue(spec, "keos"); keos != nil {
+		removeMappingKey(keos, "domain")
+		removeMappingKey(keos, "external_domain")
+		if in.Spec.ExternalDomain == "" && in.Spec.Keos.ExternalDomain != "" {
+			setMappingValue(spec, "external_domain", in.Spec.Keos.ExternalDomain)
+		}
+		if len(keos.Content) == 0 {
+			removeMappingKey(spec, "keos")
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in the mapping node m, or nil
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key to the scalar value in the mapping node m,
+// appending it if missing
+func setMappingValue(m *yaml.Node, key string, value string) {
+	if v := mappingValue(m, key); v != nil {
+		v.Kind, v.Tag, v.Value, v.Content = yaml.ScalarNode, "!!str", value, nil
+		return
+	}
+	insertMappingKey(m, len(m.Content)/2, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+// insertMappingKey inserts key with value as the pos-th entry of the mapping node m
+func insertMappingKey(m *yaml.Node, pos int, key string, value *yaml.Node) {
+	if pos > len(m.Content)/2 {
+		pos = len(m.Content) / 2
+	}
+	entry := []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value}
+	m.Content = append(m.Content[:pos*2], append(entry, m.Content[pos*2:]...)...)
+}
+
+// removeMappingKey removes key from the mapping node m, returning its key node
+func removeMappingKey(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			k := m.Content[i]
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return k
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"os"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestMigrateDescriptor(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		TestName         string
+		Path             string
+		ExpectedPath     string
+		ExpectedMigrated bool
+		ExpectError      bool
+	}{
+		{
+			TestName:         "v1beta1 is left untouched",
+			Path:             "./testdata/v1beta1/keoscluster.yaml",
+			ExpectedPath:     "./testdata/v1beta1/keoscluster.yaml",
+			ExpectedMigrated: false,
+		},
+		{
+			TestName:         "v1alpha1 keoscluster",
+			Path:             "./testdata/v1alpha1/keoscluster.yaml",
+			ExpectedPath:     "./testdata/v1alpha1/keoscluster-migrated.yaml",
+			ExpectedMigrated: true,
+		},
+		{
+			TestName:         "v1alpha1 keoscluster with both external domains",
+			Path:             "./testdata/v1alpha1/keoscluster-both-domains.yaml",
+			ExpectedPath:     "./testdata/v1alpha1/keoscluster-both-domains-migrated.yaml",
+			ExpectedMigrated: true,
+		},
+		{
+			TestName:    "v1alpha1 without cluster_id",
+			Path:        "./testdata/v1alpha1/invalid-no-cluster-id.yaml",
+			ExpectError: true,
+		},
+		{
+			TestName:    "v1alpha1 unknown kind",
+			Path:        "./testdata/v1alpha1/invalid-kind.yaml",
+			ExpectError: true,
+		},
+		{
+			TestName:    "unknown apiVersion",
+			Path:        "./testdata/invalid-apiversion.yaml",
+			ExpectError: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc // capture variable
+		t.Run(tc.TestName, func(t *testing.T) {
+			t.Parallel()
+			raw, err := os.ReadFile(tc.Path)
+			if err != nil {
+				t.Fatalf("unexpected error reading %s: %v", tc.Path, err)
+			}
+			out, migrated, err := MigrateDescriptor(raw)
+			assert.ExpectError(t, tc.ExpectError, err)
+			if tc.ExpectError {
+				return
+			}
+			expected, err := os.ReadFile(tc.ExpectedPath)
+			if err != nil {
+				t.Fatalf("unexpected error reading %s: %v", tc.ExpectedPath, err)
+			}
+			assert.BoolEqual(t, tc.ExpectedMigrated, migrated)
+			assert.StringEqual(t, string(expected), string(out))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"encoding/json"
+)
+
+// FieldChange is a difference between the descriptor and the running cluster,
+// located by the path of the field (e.g. spec.worker_nodes[workers].quantity)
+type FieldChange struct {
+	Path string `json:"path"`
+	// Descriptor is the value in the descriptor, nil if unset
+	Descriptor interface{} `json:"descriptor"`
+	// Cluster is the value in the running cluster, nil if unset
+	Cluster interface{} `json:"cluster"`
+}
+
+// String returns the change as "path: descriptor -> cluster"
+func (c FieldChange) String() string {
+	return c.Path + ": " + changeValue(c.Descriptor) + " -> " + changeValue(c.Cluster)
+}
+
+func changeValue(value interface{}) string {
+	if value == nil {
+		return "<unset>"
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "<invalid>"
+	}
+	return string(b)
+}
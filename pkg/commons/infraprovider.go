@@ -0,0 +1,206 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/redact"
+)
+
+// InfraProvider holds the settings of an infrastructure provider of the
+// workload cluster, registered from its infraprovider_<name>.go file. Its
+// cluster builder and its validations are registered apart, by the
+// createworker and validate packages
+type InfraProvider struct {
+	// Name is the value of spec.infra_provider
+	Name string
+	// ManagedControlPlane is the spec.control_plane section with the
+	// settings of its managed control plane, if any
+	ManagedControlPlane string
+	// ManagedMachinePools is true if the workers of its managed control
+	// plane are machine pools rather than machine deployments, scaled by the
+	// managed autoscaler
+	ManagedMachinePools bool
+	// ManagedCNI is true if its managed control plane has its own CNI, so
+	// Calico is not needed
+	ManagedCNI bool
+	// ManagedCoreDNSConfigMap is the kube-system ConfigMap customizing the
+	// CoreDNS of its managed control plane, patched from its
+	// coredns_configmap-managed.tmpl template. Without it the DNS forwarders
+	// are not set in its managed control plane clusters
+	ManagedCoreDNSConfigMap string
+	// IAM is true if the provider creates the IAM security of the workload
+	// cluster when spec.security.aws.create_iam is set, and deletes it along
+	// with the cluster
+	IAM bool
+	// CloudProvider is true if its workload clusters without a managed
+	// control plane need its external cloud-provider
+	CloudProvider bool
+	// InternalLBRBAC is true if its workload clusters without a managed
+	// control plane need a RBAC to create internal load balancers
+	InternalLBRBAC bool
+	// IMDSNetworkPolicies is true if the egress of the workload cluster pods
+	// to the instance metadata service is restricted with network policies
+	IMDSNetworkPolicies bool
+	// CalicoVXLAN is true if Calico encapsulates the pod traffic with VXLAN
+	// rather than IPIP
+	CalicoVXLAN bool
+	// ControllerImage is the image of its Cluster API infrastructure
+	// provider, tagged with the version of the bill of materials
+	ControllerImage string
+	// PrivateImageTag is true if the private clusters pull its controller
+	// image with the version of the bill of materials rather than the tag of
+	// its components
+	PrivateImageTag bool
+	// PrivateImageDigests is true if its components reference images by
+	// digest, removed for the private clusters to pull them from the keos
+	// registry
+	PrivateImageDigests bool
+	// BuildArgs are the build arguments of the cloud-provisioner image set
+	// to its version of the bill of materials
+	BuildArgs []string
+	// Images are the image lists needed by its workload clusters, found in
+	// the images/<name> directory of the registry package
+	Images []string
+	// UnmanagedImages are the image lists only needed by its workload
+	// clusters without a managed control plane
+	UnmanagedImages []string
+	// RegistryTypes are the spec.docker_registries types hosted by the
+	// provider, whose credentials are obtained from the provider ones
+	RegistryTypes []string
+	// RegistryCredentials returns the user and password of the registry at
+	// url from the provider credentials, use GetRegistryCredentials to get
+	// them redacted from the output
+	RegistryCredentials func(providerCredentials map[string]string, url string) (string, string, error)
+	// WithoutCredentials is true if the provider needs no credentials (e.g.
+	// the local docker host)
+	WithoutCredentials bool
+	// HostDockerSocket is true if the local cluster needs the docker socket
+	// of the host to create the workload cluster nodes as containers
+	HostDockerSocket bool
+}
+
+var infraProviders = []InfraProvider{}
+
+// RegisterInfraProvider makes an infrastructure provider available, it panics
+// if a provider with the same name is already registered
+func RegisterInfraProvider(p InfraProvider) {
+	if _, ok := GetInfraProvider(p.Name); ok {
+		panic("infra provider " + p.Name + " is already registered")
+	}
+	infraProviders = append(infraProviders, p)
+}
+
+// GetInfraProvider returns the registered infrastructure provider name
+func GetInfraProvider(name string) (InfraProvider, bool) {
+	for _, p := range infraProviders {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return InfraProvider{}, false
+}
+
+// InfraProviderNames returns the supported values of spec.infra_provider
+func InfraProviderNames() []string {
+	names := []string{}
+	for _, p := range infraProviders {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// RegistryTypeNames returns the supported values of
+// spec.docker_registries[].type: generic and the types hosted by the providers
+func RegistryTypeNames() []string {
+	names := []string{"generic"}
+	for _, p := range infraProviders {
+		names = append(names, p.RegistryTypes...)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetRegistryCredentials returns the user and password of the docker registry
+// at url of registryType, obtained from the credentials of the provider
+// hosting that type of registry
+func GetRegistryCredentials(registryType string, providerCredentials map[string]string, url string) (string, string, error) {
+	for _, p := range infraProviders {
+		if Contains(p.RegistryTypes, registryType) {
+			return p.GetRegistryCredentials(providerCredentials, url)
+		}
+	}
+	return "", "", errors.New("there is no infra provider hosting the " + registryType + " registries")
+}
+
+// GetRegistryCredentials returns the user and password of the registry at url
+// hosted by the provider, obtained from the provider credentials. The
+// password, a token fetched at runtime, is redacted from the output
+func (p InfraProvider) GetRegistryCredentials(providerCredentials map[string]string, url string) (string, string, error) {
+	if p.RegistryCredentials == nil {
+		return "", "", errors.New("infra provider " + p.Name + " does not host registries")
+	}
+	user, pass, err := p.RegistryCredentials(providerCredentials, url)
+	if err != nil {
+		return "", "", err
+	}
+	redact.Add(pass)
+	return user, pass, nil
+}
+
+// ControllerImageRepository returns the repository of the controller image
+// of the provider in a registry, without the registry host
+func (p InfraProvider) ControllerImageRepository() string {
+	return path.Dir(strings.SplitN(p.ControllerImage, "/", 2)[1])
+}
+
+// DockerRegistryAuth returns the user and password of a docker registry of
+// the descriptor, empty if it needs none: the ones of the secrets file for
+// the auth_required generic registries and the ones obtained from the
+// provider credentials for the rest
+func DockerRegistryAuth(dockerRegistry DockerRegistry, clusterCredentials ClusterCredentials) (string, string, error) {
+	if dockerRegistry.Type != "generic" {
+		return GetRegistryCredentials(dockerRegistry.Type, clusterCredentials.ProviderCredentials, dockerRegistry.URL)
+	}
+	if !dockerRegistry.AuthRequired {
+		return "", "", nil
+	}
+	for _, registryCredentials := range clusterCredentials.DockerRegistriesCredentials {
+		if fmt.Sprint(registryCredentials["url"]) == dockerRegistry.URL {
+			return fmt.Sprint(registryCredentials["user"]), fmt.Sprint(registryCredentials["pass"]), nil
+		}
+	}
+	return "", "", nil
+}
+
+// isInfraProvider validates that the field is a registered infra provider
+func isInfraProvider(fl validator.FieldLevel) bool {
+	_, ok := GetInfraProvider(fl.Field().String())
+	return ok
+}
+
+// isRegistryType validates that the field is a supported registry type
+func isRegistryType(fl validator.FieldLevel) bool {
+	return Contains(RegistryTypeNames(), fl.Field().String())
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+)
+
+func init() {
+	RegisterInfraProvider(InfraProvider{
+		Name:                "aws",
+		ManagedControlPlane: "aws",
+		IAM:                 true,
+		CloudProvider:       true,
+		IMDSNetworkPolicies: true,
+		ControllerImage:     "registry.k8s.io/cluster-api-aws/cluster-api-aws-controller",
+		PrivateImageTag:     true,
+		BuildArgs:           []string{"CAPA", "CLUSTERAWSADM"},
+		Images:              []string{"capa"},
+		UnmanagedImages:     []string{"aws", "ebs-csi-driver", "eks-distro"},
+		RegistryTypes:       []string{"ecr"},
+		RegistryCredentials: ecrCredentials,
+	})
+}
+
+// ecrCredentials returns an authorization token of the ECR registry at url,
+// whose region is taken from its hostname
+func ecrCredentials(providerCredentials map[string]string, url string) (string, string, error) {
+	var registryUser = "AWS"
+	var registryPass string
+	var ctx = context.Background()
+
+	region := strings.Split(url, ".")[3]
+	cfg, err := AWSGetConfig(ctx, providerCredentials, region)
+	if err != nil {
+		return "", "", err
+	}
+	svc := ecr.NewFromConfig(cfg)
+	token, err := svc.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return "", "", err
+	}
+	authData := token.AuthorizationData[0].AuthorizationToken
+	data, err := base64.StdEncoding.DecodeString(*authData)
+	if err != nil {
+		return "", "", err
+	}
+	registryPass = strings.SplitN(string(data), ":", 2)[1]
+	return registryUser, registryPass, nil
+}
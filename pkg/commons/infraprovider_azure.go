@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+func init() {
+	RegisterInfraProvider(InfraProvider{
+		Name:                    "azure",
+		ManagedControlPlane:     "azure",
+		ManagedMachinePools:     true,
+		ManagedCNI:              true,
+		ManagedCoreDNSConfigMap: "coredns-custom",
+		CloudProvider:           true,
+		CalicoVXLAN:             true,
+		ControllerImage:         "registry.k8s.io/cluster-api-azure/cluster-api-azure-controller",
+		BuildArgs:               []string{"CAPZ"},
+		Images:                  []string{"capz"},
+		UnmanagedImages:         []string{"cloud-controller", "cloud-node", "csi-azure", "csi-azuredisk-node", "csi-azurefile-node"},
+		RegistryTypes:           []string{"acr"},
+		RegistryCredentials:     acrCredentials,
+	})
+}
+
+// AzureClientOptions returns the options of the Azure clients trusting the CA
+// bundle, or nil (the default options) if there is none
+func AzureClientOptions() *arm.ClientOptions {
+	if caBundle == "" {
+		return nil
+	}
+	return &arm.ClientOptions{ClientOptions: policy.ClientOptions{Transport: HTTPClient()}}
+}
+
+// acrCredentials exchanges an Azure AD token of the provider credentials for
+// a token of the ACR registry at registryURL
+func acrCredentials(providerCredentials map[string]string, registryURL string) (string, string, error) {
+	var registryUser = "00000000-0000-0000-0000-000000000000"
+	var registryPass string
+	var ctx = context.Background()
+	var response map[string]interface{}
+
+	cfg, err := AzureGetConfig(providerCredentials)
+	if err != nil {
+		return "", "", err
+	}
+	aadToken, err := cfg.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com/.default"}})
+	if err != nil {
+		return "", "", err
+	}
+	acrService := strings.Split(registryURL, "/")[0]
+	formData := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {acrService},
+		"tenant":       {providerCredentials["TenantID"]},
+		"access_token": {aadToken.Token},
+	}
+	jsonResponse, err := HTTPClient().PostForm(fmt.Sprintf("https://%s/oauth2/exchange", acrService), formData)
+	if err != nil {
+		return "", "", err
+	} else if jsonResponse.StatusCode == http.StatusUnauthorized {
+		return "", "", errors.New("Failed to obtain the ACR token with the provided credentials, please check the roles assigned to the correspondent Azure AD app")
+	}
+	json.NewDecoder(jsonResponse.Body).Decode(&response)
+	if response["access_token"] != nil {
+		registryPass = response["access_token"].(string)
+	} else if response["refresh_token"] != nil {
+		registryPass = response["refresh_token"].(string)
+	} else {
+		return "", "", errors.New("Failed to obtain the ACR token with the provided credentials, please check the roles assigned to the correspondent Azure AD app")
+	}
+	return registryUser, registryPass, nil
+}
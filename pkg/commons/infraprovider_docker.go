@@ -0,0 +1,32 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+// DockerSocketPath is the docker socket of the host, mounted in the local
+// cluster to let Cluster API Provider Docker create the workload cluster nodes
+const DockerSocketPath = "/var/run/docker.sock"
+
+func init() {
+	RegisterInfraProvider(InfraProvider{
+		Name:               "docker",
+		ControllerImage:    "registry.k8s.io/cluster-api/capd-manager",
+		BuildArgs:          []string{"CAPD"},
+		Images:             []string{"capd"},
+		WithoutCredentials: true,
+		HostDockerSocket:   true,
+	})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+const gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+
+func init() {
+	RegisterInfraProvider(InfraProvider{
+		Name:                "gcp",
+		ManagedMachinePools: true,
+		InternalLBRBAC:      true,
+		IMDSNetworkPolicies: true,
+		ControllerImage:     "registry.k8s.io/cluster-api-gcp/cluster-api-gcp-controller",
+		PrivateImageTag:     true,
+		PrivateImageDigests: true,
+		BuildArgs:           []string{"CAPG"},
+		Images:              []string{"capg"},
+		UnmanagedImages:     []string{"csi-gce", "csi-node"},
+		RegistryTypes:       []string{"gcr", "gar"},
+		RegistryCredentials: garCredentials,
+	})
+}
+
+// GCPCredentialsJSON returns the service account key file of the provider
+// credentials
+func GCPCredentialsJSON(providerCredentials map[string]string) []byte {
+	data := map[string]interface{}{
+		"type":                        "service_account",
+		"project_id":                  providerCredentials["ProjectID"],
+		"private_key_id":              providerCredentials["PrivateKeyID"],
+		"private_key":                 providerCredentials["PrivateKey"],
+		"client_email":                providerCredentials["ClientEmail"],
+		"client_id":                   providerCredentials["ClientID"],
+		"auth_uri":                    "https://accounts.google.com/o/oauth2/auth",
+		"token_uri":                   "https://accounts.google.com/o/oauth2/token",
+		"auth_provider_x509_cert_url": "https://www.googleapis.com/oauth2/v1/certs",
+		"client_x509_cert_url":        "https://www.googleapis.com/robot/v1/metadata/x509/" + url.QueryEscape(providerCredentials["ClientEmail"]),
+	}
+	jsonData, _ := json.Marshal(data)
+	return jsonData
+}
+
+// GCPClientOption returns the option of the GCP clients authenticating with
+// the service account key file credentialsJSON and trusting the CA bundle
+func GCPClientOption(ctx context.Context, credentialsJSON []byte) (option.ClientOption, error) {
+	if caBundle == "" {
+		return option.WithCredentialsJSON(credentialsJSON), nil
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, HTTPClient())
+	creds, err := google.CredentialsFromJSON(ctx, credentialsJSON, gcpScope)
+	if err != nil {
+		return nil, err
+	}
+	return option.WithHTTPClient(oauth2.NewClient(ctx, creds.TokenSource)), nil
+}
+
+// garCredentials returns an access token of the service account for the GCR
+// and Artifact Registry registries
+func garCredentials(providerCredentials map[string]string, registryURL string) (string, string, error) {
+	var registryUser = "oauth2accesstoken"
+	var ctx = context.WithValue(context.Background(), oauth2.HTTPClient, HTTPClient())
+
+	creds, err := google.CredentialsFromJSON(ctx, GCPCredentialsJSON(providerCredentials), gcpScope)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := creds.TokenSource.Token()
+	if err != nil {
+		return "", "", err
+	}
+	return registryUser, token.AccessToken, nil
+}
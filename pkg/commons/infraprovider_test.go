@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+	"sigs.k8s.io/kind/pkg/internal/redact"
+)
+
+func TestGetInfraProvider(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name               string
+		ExpectFound        bool
+		ExpectMachinePools bool
+		ExpectManagedPlane string
+	}{
+		{Name: "aws", ExpectFound: true, ExpectManagedPlane: "aws"},
+		{Name: "azure", ExpectFound: true, ExpectMachinePools: true, ExpectManagedPlane: "azure"},
+		{Name: "gcp", ExpectFound: true, ExpectMachinePools: true},
+		{Name: "docker", ExpectFound: true},
+		{Name: "openstack"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			p, ok := GetInfraProvider(tc.Name)
+			assert.BoolEqual(t, tc.ExpectFound, ok)
+			assert.BoolEqual(t, tc.ExpectMachinePools, p.ManagedMachinePools)
+			assert.StringEqual(t, tc.ExpectManagedPlane, p.ManagedControlPlane)
+		})
+	}
+}
+
+func TestRegistryTypeNames(t *testing.T) {
+	t.Parallel()
+	assert.DeepEqual(t, []string{"acr", "ecr", "gar", "gcr", "generic"}, RegistryTypeNames())
+}
+
+func TestGetRegistryCredentials(t *testing.T) {
+	t.Parallel()
+	_, _, err := GetRegistryCredentials("generic", map[string]string{}, "registry.example.com")
+	assert.ExpectError(t, true, err)
+}
+
+func TestGetRegistryCredentialsRedactsToken(t *testing.T) {
+	t.Parallel()
+	p := InfraProvider{
+		Name: "test",
+		RegistryCredentials: func(providerCredentials map[string]string, url string) (string, string, error) {
+			return "AWS", "runtime-registry-token", nil
+		},
+	}
+	user, pass, err := p.GetRegistryCredentials(map[string]string{}, "registry.example.com")
+	assert.ExpectError(t, false, err)
+	assert.StringEqual(t, "AWS", user)
+	assert.StringEqual(t, "runtime-registry-token", pass)
+	assert.StringEqual(t, "login --password "+redact.Placeholder, redact.String("login --password runtime-registry-token"))
+
+	_, _, err = InfraProvider{Name: "test"}.GetRegistryCredentials(map[string]string{}, "registry.example.com")
+	assert.ExpectError(t, true, err)
+}
+
+func TestControllerImageRepository(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name     string
+		Expected string
+	}{
+		{Name: "aws", Expected: "cluster-api-aws"},
+		{Name: "azure", Expected: "cluster-api-azure"},
+		{Name: "gcp", Expected: "cluster-api-gcp"},
+		{Name: "docker", Expected: "cluster-api"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			p, _ := GetInfraProvider(tc.Name)
+			assert.StringEqual(t, tc.Expected, p.ControllerImageRepository())
+		})
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+// Results of an image copy into the keos registry
+const (
+	// MirrorCopied is an image copied into the keos registry
+	MirrorCopied = "copied"
+	// MirrorPresent is an image that was already in the keos registry
+	MirrorPresent = "present"
+	// MirrorMissing is an image not in the keos registry, not copied because
+	// of a dry run
+	MirrorMissing = "missing"
+	// MirrorFailed is an image whose copy failed
+	MirrorFailed = "failed"
+)
+
+// MirroredImage is the result of copying an image required by the workload
+// cluster into the keos registry
+type MirroredImage struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Result      string `json:"result"`
+	Error       string `json:"error,omitempty"`
+}
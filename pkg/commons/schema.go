@@ -0,0 +1,319 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/apis/keos/v1beta1"
+)
+
+const (
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+	// cidrv4Pattern matches the values accepted by the cidrv4 validation
+	cidrv4Pattern = `^(25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])(\.(25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])){3}/([0-9]|[12][0-9]|3[0-2])$`
+)
+
+// Schema is a JSON Schema (draft 2020-12) document or subschema
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+
+	Const     interface{}   `json:"const,omitempty"`
+	Enum      []interface{} `json:"enum,omitempty"`
+	Format    string        `json:"format,omitempty"`
+	Pattern   string        `json:"pattern,omitempty"`
+	MinLength *int          `json:"minLength,omitempty"`
+	MaxLength *int          `json:"maxLength,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	AllOf []*Schema `json:"allOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+	If    *Schema   `json:"if,omitempty"`
+	Then  *Schema   `json:"then,omitempty"`
+}
+
+// DescriptorSchema returns the JSON Schema of the cluster descriptor
+// manifests (KeosCluster and ClusterConfig) at the latest apiVersion
+func DescriptorSchema() *Schema {
+	keosCluster := schemaForType(reflect.TypeOf(KeosCluster{}))
+	setManifestHeader(keosCluster, "KeosCluster")
+	keosCluster.Properties["spec"].AllOf = providerSchemas()
+
+	clusterConfig := schemaForType(reflect.TypeOf(ClusterConfig{}))
+	setManifestHeader(clusterConfig, "ClusterConfig")
+
+	return &Schema{
+		Schema:      jsonSchemaDraft,
+		Title:       "Cluster descriptor",
+		Description: "Manifests of the cluster descriptor (cluster.yaml)",
+		OneOf:       []*Schema{keosCluster, clusterConfig},
+	}
+}
+
+// SecretsSchema returns the JSON Schema of the decrypted secrets file
+func SecretsSchema() *Schema {
+	s := schemaForType(reflect.TypeOf(SecretsFile{}))
+	s.Schema = jsonSchemaDraft
+	s.Title = "Secrets file"
+	s.Description = "Decrypted content of the secrets file (secrets.yml)"
+	return s
+}
+
+func setManifestHeader(s *Schema, kind string) {
+	s.Title = kind
+	s.Properties["apiVersion"] = &Schema{Type: "string", Const: v1beta1.APIVersion}
+	s.Properties["kind"] = &Schema{Type: "string", Const: kind}
+}
+
+// providerSchemas returns the subschemas the KeosCluster spec must match for
+// each infra_provider: only the credentials and control plane settings of
+// the selected provider are allowed
+func providerSchemas() []*Schema {
+	schemas := []*Schema{}
+	for _, provider := range InfraProviderNames() {
+		others := []string{}
+		otherCPs := []string{}
+		for _, other := range infraProviders {
+			if other.Name == provider {
+				continue
+			}
+			others = append(others, other.Name)
+			if other.ManagedControlPlane != "" {
+				otherCPs = append(otherCPs, other.ManagedControlPlane)
+			}
+		}
+		then := &Schema{
+			Properties: map[string]*Schema{
+				"credentials": {Not: &Schema{AnyOf: requiredEach(others)}},
+			},
+		}
+		if len(otherCPs) > 0 {
+			then.Properties["control_plane"] = &Schema{Not: &Schema{AnyOf: requiredEach(otherCPs)}}
+		}
+		schemas = append(schemas, &Schema{
+			If: &Schema{
+				Properties: map[string]*Schema{"infra_provider": {Const: provider}},
+				Required:   []string{"infra_provider"},
+			},
+			Then: then,
+		})
+	}
+	return schemas
+}
+
+func requiredEach(names []string) []*Schema {
+	schemas := []*Schema{}
+	for _, name := range names {
+		schemas = append(schemas, &Schema{Required: []string{name}})
+	}
+	return schemas
+}
+
+// schemaForType returns the schema of t from its yaml and validate tags
+func schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaForType(t.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := yamlFieldName(field)
+			if !ok {
+				continue
+			}
+			fs := schemaForType(field.Type)
+			if applyValidateTag(fs, field.Tag.Get("validate")) {
+				s.Required = append(s.Required, name)
+			}
+			s.Properties[name] = fs
+		}
+		s.AllOf = append(s.AllOf, requiredIfSchemas(t)...)
+		return s
+	}
+	// interface{} and the like accept anything
+	return &Schema{}
+}
+
+// yamlFieldName returns the key of the struct field in yaml
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, true
+}
+
+// applyValidateTag sets in s the constraints of a validate tag, returning
+// whether the field is required. The rules after dive apply to the items
+func applyValidateTag(s *Schema, tag string) bool {
+	required := false
+	target := s
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = target == s
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+			} else if target.AdditionalProperties != nil {
+				target = target.AdditionalProperties
+			}
+		case "oneof":
+			target.Enum = oneofValues(param, target.Type)
+		case "infra_provider":
+			target.Enum = enumValues(InfraProviderNames())
+		case "registry_type":
+			target.Enum = enumValues(RegistryTypeNames())
+		case "cidrv4":
+			target.Format = "cidr"
+			target.Pattern = cidrv4Pattern
+		case "fqdn":
+			target.Format = "hostname"
+		case "ip_addr":
+			target.AnyOf = []*Schema{{Format: "ipv4"}, {Format: "ipv6"}}
+		case "gte", "gt", "lte", "min", "max":
+			applyBound(target, name, param)
+		}
+	}
+	return required
+}
+
+func enumValues(names []string) []interface{} {
+	values := []interface{}{}
+	for _, name := range names {
+		values = append(values, name)
+	}
+	return values
+}
+
+func oneofValues(param string, typ string) []interface{} {
+	values := []interface{}{}
+	for _, value := range strings.Fields(param) {
+		value = strings.Trim(value, "'")
+		if typ == "integer" {
+			if n, err := strconv.Atoi(value); err == nil {
+				values = append(values, n)
+				continue
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+func applyBound(s *Schema, rule string, param string) {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+	f := float64(n)
+	if s.Type == "string" {
+		switch rule {
+		case "min", "gte":
+			s.MinLength = &n
+		case "max", "lte":
+			s.MaxLength = &n
+		}
+		return
+	}
+	switch rule {
+	case "min", "gte":
+		s.Minimum = &f
+	case "gt":
+		s.ExclusiveMinimum = &f
+	case "max", "lte":
+		s.Maximum = &f
+	}
+}
+
+// requiredIfSchemas translates the required_if validations comparing a bool
+// field of t (e.g. Size `required_if=Managed false`) into if/then subschemas
+func requiredIfSchemas(t reflect.Type) []*Schema {
+	schemas := []*Schema{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := yamlFieldName(field)
+		if !ok {
+			continue
+		}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			ruleName, param, _ := strings.Cut(rule, "=")
+			if ruleName != "required_if" && ruleName != "required_if_for_bool" {
+				continue
+			}
+			params := strings.Fields(param)
+			if len(params) != 2 {
+				continue
+			}
+			other, found := t.FieldByName(params[0])
+			if !found || other.Type.Kind() != reflect.Bool {
+				continue
+			}
+			otherName, _ := yamlFieldName(other)
+			value, err := strconv.ParseBool(params[1])
+			if err != nil {
+				continue
+			}
+			// a missing bool is false for the validation
+			condition := &Schema{Properties: map[string]*Schema{otherName: {Const: value}}}
+			if value {
+				condition.Required = []string{otherName}
+			}
+			schemas = append(schemas, &Schema{If: condition, Then: &Schema{Required: []string{name}}})
+		}
+	}
+	return schemas
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestApplyValidateTag(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name             string
+		Schema           *Schema
+		Tag              string
+		ExpectedRequired bool
+		Expected         *Schema
+	}{
+		{
+			Name:             "required enum",
+			Schema:           &Schema{Type: "string"},
+			Tag:              "required,oneof='aws' 'gcp' 'azure'",
+			ExpectedRequired: true,
+			Expected:         &Schema{Type: "string", Enum: []interface{}{"aws", "gcp", "azure"}},
+		},
+		{
+			Name:     "optional cidr",
+			Schema:   &Schema{Type: "string"},
+			Tag:      "omitempty,cidrv4",
+			Expected: &Schema{Type: "string", Format: "cidr", Pattern: cidrv4Pattern},
+		},
+		{
+			Name:     "fqdn",
+			Schema:   &Schema{Type: "string"},
+			Tag:      "fqdn",
+			Expected: &Schema{Type: "string", Format: "hostname"},
+		},
+		{
+			Name:             "dive into items",
+			Schema:           &Schema{Type: "array", Items: &Schema{Type: "string"}},
+			Tag:              "required,dive,ip_addr",
+			ExpectedRequired: true,
+			Expected: &Schema{Type: "array", Items: &Schema{
+				Type:  "string",
+				AnyOf: []*Schema{{Format: "ipv4"}, {Format: "ipv6"}},
+			}},
+		},
+		{
+			Name:     "required after dive applies to the items",
+			Schema:   &Schema{Type: "array", Items: &Schema{Type: "string"}},
+			Tag:      "dive,required",
+			Expected: &Schema{Type: "array", Items: &Schema{Type: "string"}},
+		},
+		{
+			Name:             "string length",
+			Schema:           &Schema{Type: "string"},
+			Tag:              "required,min=3,max=100",
+			ExpectedRequired: true,
+			Expected:         &Schema{Type: "string", MinLength: intPtr(3), MaxLength: intPtr(100)},
+		},
+		{
+			Name:     "integer bounds",
+			Schema:   &Schema{Type: "integer"},
+			Tag:      "omitempty,gt=0",
+			Expected: &Schema{Type: "integer", ExclusiveMinimum: floatPtr(0)},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc // capture variable
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			required := applyValidateTag(tc.Schema, tc.Tag)
+			assert.BoolEqual(t, tc.ExpectedRequired, required)
+			assert.DeepEqual(t, tc.Expected, tc.Schema)
+		})
+	}
+}
+
+func TestDescriptorSchema(t *testing.T) {
+	t.Parallel()
+	s := DescriptorSchema()
+	if len(s.OneOf) != 2 {
+		t.Fatalf("expected a subschema per manifest kind, got %d", len(s.OneOf))
+	}
+	spec := s.OneOf[0].Properties["spec"]
+	assert.DeepEqual(t, []interface{}{"aws", "azure", "docker", "gcp"}, spec.Properties["infra_provider"].Enum)
+	assert.DeepEqual(t, []string{"infra_provider", "k8s_version", "region", "docker_registries", "helm_repository", "worker_nodes"}, spec.Required)
+	if len(spec.AllOf) != len(InfraProviderNames()) {
+		t.Errorf("expected a subschema per infra provider, got %d", len(spec.AllOf))
+	}
+	// control_plane.size is only required for unmanaged control planes
+	assert.DeepEqual(t, []*Schema{{
+		If:   &Schema{Properties: map[string]*Schema{"managed": {Const: false}}},
+		Then: &Schema{Required: []string{"size"}},
+	}}, spec.Properties["control_plane"].AllOf)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// SecretsProvider reads the secrets (cloud provider, registries and helm
+// repository credentials) from a backend
+type SecretsProvider interface {
+	// Source describes where the secrets are read from
+	Source() string
+	// Exists reports whether the backend holds secrets, without reading them
+	Exists() bool
+	// Read returns the secrets
+	Read() (*SecretsFile, error)
+}
+
+// DefaultSecretsPrefix is the prefix of the environment variables read by
+// the env secrets provider
+const DefaultSecretsPrefix = "CLOUD_PROVISIONER_SECRETS"
+
+// NewSecretsProvider returns the secrets provider for source, which is one of:
+//
+//	<path>, ansible-vault:<path>  ansible-vault encrypted file unlocked by vaultPassword
+//	sops:<path>                   SOPS (age, PGP, KMS...) encrypted YAML file
+//	env:[<prefix>]                environment variables (default prefix CLOUD_PROVISIONER_SECRETS)
+//	vault:<mount>/<path>          HashiCorp Vault KV v2 secret, using VAULT_ADDR and VAULT_TOKEN
+func NewSecretsProvider(source string, vaultPassword string) (SecretsProvider, error) {
+	kind, location, found := strings.Cut(source, ":")
+	if !found {
+		return NewAnsibleVaultSecrets(source, vaultPassword), nil
+	}
+	switch kind {
+	case "ansible-vault":
+		return NewAnsibleVaultSecrets(location, vaultPassword), nil
+	case "sops":
+		return &sopsSecrets{path: location}, nil
+	case "env":
+		if location == "" {
+			location = DefaultSecretsPrefix
+		}
+		return &envSecrets{prefix: location, environ: os.Environ}, nil
+	case "vault":
+		return newVaultSecrets(location)
+	}
+	return nil, errors.Errorf("unknown secrets source %q, it must be one of: ansible-vault, sops, env, vault", kind)
+}
+
+// AnsibleVaultPath returns the path of the ansible-vault encrypted file the
+// secrets source points at, or false if the secrets are read from elsewhere
+func AnsibleVaultPath(source string) (string, bool) {
+	kind, location, found := strings.Cut(source, ":")
+	if !found {
+		return source, true
+	}
+	return location, kind == "ansible-vault"
+}
+
+// NewAnsibleVaultSecrets returns the provider reading the ansible-vault
+// encrypted secrets file at path
+func NewAnsibleVaultSecrets(path string, vaultPassword string) SecretsProvider {
+	return &ansibleVaultSecrets{path: path, password: vaultPassword}
+}
+
+type ansibleVaultSecrets struct {
+	path     string
+	password string
+}
+
+func (s *ansibleVaultSecrets) Source() string {
+	return "secrets file"
+}
+
+func (s *ansibleVaultSecrets) Exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+func (s *ansibleVaultSecrets) Read() (*SecretsFile, error) {
+	return GetSecretsFile(s.path, s.password)
+}
+
+// sopsSecrets decrypts the file with the sops binary, which finds the keys
+// (e.g. SOPS_AGE_KEY_FILE) on its own
+type sopsSecrets struct {
+	path string
+}
+
+func (s *sopsSecrets) Source() string {
+	return "sops file"
+}
+
+func (s *sopsSecrets) Exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+func (s *sopsSecrets) Read() (*SecretsFile, error) {
+	raw, err := exec.Output(exec.Command("sops", "--decrypt", "--output-type", "yaml", s.path))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt the sops file "+s.path)
+	}
+	return parseSecrets(raw)
+}
+
+// envSecrets reads the secrets from the environment variables named after
+// their path joined by "__", e.g. <prefix>__AWS__CREDENTIALS__ACCESS_KEY or
+// <prefix>__DOCKER_REGISTRIES__0__URL
+type envSecrets struct {
+	prefix  string
+	environ func() []string
+}
+
+func (s *envSecrets) Source() string {
+	return "environment"
+}
+
+func (s *envSecrets) Exists() bool {
+	return len(s.variables()) > 0
+}
+
+func (s *envSecrets) variables() map[string]string {
+	variables := map[string]string{}
+	for _, env := range s.environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if key, found := strings.CutPrefix(name, s.prefix+"__"); found && key != "" {
+			variables[strings.ToLower(key)] = value
+		}
+	}
+	return variables
+}
+
+func (s *envSecrets) Read() (*SecretsFile, error) {
+	variables := s.variables()
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	secrets := map[string]interface{}{}
+	for _, key := range keys {
+		if err := setSecret(secrets, strings.Split(key, "__"), variables[key]); err != nil {
+			return nil, errors.Wrap(err, "invalid environment variable "+s.prefix+"__"+strings.ToUpper(key))
+		}
+	}
+	raw, err := yaml.Marshal(map[string]interface{}{"secrets": listsFromIndexes(secrets)})
+	if err != nil {
+		return nil, err
+	}
+	return parseSecrets(raw)
+}
+
+// setSecret sets value at path in secrets, creating the intermediate maps
+func setSecret(secrets map[string]interface{}, path []string, value string) error {
+	for _, name := range path[:len(path)-1] {
+		child, ok := secrets[name]
+		if !ok {
+			child = map[string]interface{}{}
+			secrets[name] = child
+		}
+		childMap, ok := child.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("%s is both a value and a map", name)
+		}
+		secrets = childMap
+	}
+	name := path[len(path)-1]
+	if _, ok := secrets[name]; ok {
+		return errors.Errorf("%s is both a value and a map", name)
+	}
+	secrets[name] = value
+	return nil
+}
+
+// listsFromIndexes converts the maps keyed by numbers (e.g. the
+// docker_registries) to lists
+func listsFromIndexes(value interface{}) interface{} {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	list := make([]interface{}, len(values))
+	for key, child := range values {
+		values[key] = listsFromIndexes(child)
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(list) {
+			list[i] = values[key]
+		} else {
+			list = nil
+		}
+	}
+	if len(list) == 0 {
+		return values
+	}
+	return list
+}
+
+// parseSecrets parses a secrets file with the secrets under the secrets key
+func parseSecrets(raw []byte) (*SecretsFile, error) {
+	var secretsFile SecretsFile
+	if err := yaml.Unmarshal(raw, &secretsFile); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the secrets")
+	}
+	return &secretsFile, nil
+}
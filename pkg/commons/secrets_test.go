@@ -0,0 +1,240 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	vault "github.com/sosedoff/ansible-vault-go"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+	"sigs.k8s.io/kind/pkg/internal/redact"
+)
+
+const secretsYAML = `secrets:
+  aws:
+    credentials:
+      access_key: AKIA
+      secret_key: secret
+  docker_registries:
+    - url: registry.example.com
+      user: user
+      pass: pass
+`
+
+func TestNewSecretsProvider(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name           string
+		Source         string
+		ExpectedSource string
+		ExpectError    bool
+	}{
+		{Name: "path", Source: "./secrets.yml", ExpectedSource: "secrets file"},
+		{Name: "ansible-vault", Source: "ansible-vault:./secrets.yml", ExpectedSource: "secrets file"},
+		{Name: "sops", Source: "sops:./secrets.enc.yaml", ExpectedSource: "sops file"},
+		{Name: "env", Source: "env:", ExpectedSource: "environment"},
+		{Name: "invalid vault secret", Source: "vault:secret", ExpectError: true},
+		{Name: "unknown", Source: "keychain:secrets", ExpectError: true},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			provider, err := NewSecretsProvider(tc.Source, "password")
+			assert.ExpectError(t, tc.ExpectError, err)
+			if err == nil {
+				assert.StringEqual(t, tc.ExpectedSource, provider.Source())
+			}
+		})
+	}
+}
+
+func TestAnsibleVaultPath(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name                 string
+		Source               string
+		ExpectedPath         string
+		ExpectedAnsibleVault bool
+	}{
+		{Name: "path", Source: "./secrets.yml", ExpectedPath: "./secrets.yml", ExpectedAnsibleVault: true},
+		{Name: "ansible-vault", Source: "ansible-vault:/etc/keos/secrets.yml", ExpectedPath: "/etc/keos/secrets.yml", ExpectedAnsibleVault: true},
+		{Name: "sops", Source: "sops:./secrets.enc.yaml", ExpectedAnsibleVault: false},
+		{Name: "env", Source: "env:", ExpectedAnsibleVault: false},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			path, ansibleVault := AnsibleVaultPath(tc.Source)
+			assert.BoolEqual(t, tc.ExpectedAnsibleVault, ansibleVault)
+			if ansibleVault {
+				assert.StringEqual(t, tc.ExpectedPath, path)
+			}
+		})
+	}
+}
+
+func TestAnsibleVaultSecrets(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "secrets.yml")
+	provider := NewAnsibleVaultSecrets(path, "password")
+	assert.BoolEqual(t, false, provider.Exists())
+
+	if err := vault.EncryptFile(path, secretsYAML, "password"); err != nil {
+		t.Fatalf("failed to encrypt secrets: %v", err)
+	}
+	assert.BoolEqual(t, true, provider.Exists())
+	secretsFile, err := provider.Read()
+	assert.ExpectError(t, false, err)
+	assert.StringEqual(t, "AKIA", secretsFile.Secrets.AWS.Credentials.AccessKey)
+
+	_, err = NewAnsibleVaultSecrets(path, "wrong").Read()
+	assert.ExpectError(t, true, err)
+}
+
+func TestEnvSecrets(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name        string
+		Environ     []string
+		Expected    Secrets
+		ExpectError bool
+	}{
+		{
+			Name: "credentials and registries",
+			Environ: []string{
+				"PATH=/usr/bin",
+				"SECRETS__AWS__CREDENTIALS__ACCESS_KEY=AKIA",
+				"SECRETS__AWS__CREDENTIALS__SECRET_KEY=secret=with=equals",
+				"SECRETS__DOCKER_REGISTRIES__1__URL=registry2.example.com",
+				"SECRETS__DOCKER_REGISTRIES__0__URL=registry.example.com",
+				"SECRETS__DOCKER_REGISTRIES__0__USER=user",
+				"SECRETS__GITHUB_TOKEN=ghp_token",
+				"OTHER__GITHUB_TOKEN=ghp_other",
+			},
+			Expected: Secrets{
+				AWS: AWS{Credentials: AWSCredentials{AccessKey: "AKIA", SecretKey: "secret=with=equals"}},
+				DockerRegistries: []DockerRegistryCredentials{
+					{URL: "registry.example.com", User: "user"},
+					{URL: "registry2.example.com"},
+				},
+				GithubToken: "ghp_token",
+			},
+		},
+		{
+			Name: "value and map",
+			Environ: []string{
+				"SECRETS__AWS=aws",
+				"SECRETS__AWS__CREDENTIALS__ACCESS_KEY=AKIA",
+			},
+			ExpectError: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			provider := &envSecrets{prefix: "SECRETS", environ: func() []string { return tc.Environ }}
+			assert.BoolEqual(t, true, provider.Exists())
+			secretsFile, err := provider.Read()
+			assert.ExpectError(t, tc.ExpectError, err)
+			if err == nil {
+				assert.DeepEqual(t, tc.Expected, secretsFile.Secrets)
+			}
+		})
+	}
+}
+
+func TestVaultSecrets(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/cloud-provisioner/cluster" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"aws":{"credentials":{"access_key":"AKIA","secret_key":"secret"}},"github_token":"ghp_token"},"metadata":{"version":1}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cases := []struct {
+		Name          string
+		Token         string
+		Path          string
+		ExpectedError string
+	}{
+		{Name: "secret", Token: "token", Path: "cloud-provisioner/cluster"},
+		{Name: "permission denied", Token: "wrong", Path: "cloud-provisioner/cluster", ExpectedError: "failed to read the vault secret secret/cloud-provisioner/cluster: 403 Forbidden: permission denied"},
+		{Name: "not found", Token: "token", Path: "cloud-provisioner/other", ExpectedError: "failed to read the vault secret secret/cloud-provisioner/other: 404 Not Found"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			provider := &vaultSecrets{address: server.URL, token: tc.Token, mount: "secret", path: tc.Path, client: server.Client()}
+			secretsFile, err := provider.Read()
+			assert.ExpectError(t, tc.ExpectedError != "", err)
+			if err != nil {
+				assert.StringEqual(t, tc.ExpectedError, err.Error())
+				return
+			}
+			assert.StringEqual(t, "AKIA", secretsFile.Secrets.AWS.Credentials.AccessKey)
+			assert.StringEqual(t, "ghp_token", secretsFile.Secrets.GithubToken)
+		})
+	}
+}
+
+func TestClusterCredentialsSecretValues(t *testing.T) {
+	t.Parallel()
+	creds := ClusterCredentials{
+		ProviderCredentials:         map[string]string{"AccessKey": "AKIA", "SecretKey": "secret", "Region": "eu-west-1"},
+		KeosRegistryCredentials:     map[string]string{"URL": "registry.example.com", "User": "user", "Pass": "registry-pass"},
+		DockerRegistriesCredentials: []map[string]interface{}{{"url": "registry.example.com", "user": "user", "pass": "registry-pass"}},
+		HelmRepositoryCredentials:   map[string]string{"URL": "https://charts.example.com", "Pass": "helm-pass"},
+		GithubToken:                 "ghp_token",
+	}
+	values := creds.SecretValues()
+	sort.Strings(values)
+	assert.DeepEqual(t, []string{"AKIA", "ghp_token", "helm-pass", "registry-pass", "registry-pass", "secret"}, values)
+}
+
+func TestClusterCredentialsRedacted(t *testing.T) {
+	t.Parallel()
+	creds := ClusterCredentials{
+		ProviderCredentials:         map[string]string{"AccessKey": "AKIA", "SecretKey": "secret", "Region": "eu-west-1"},
+		DockerRegistriesCredentials: []map[string]interface{}{{"url": "registry.example.com", "user": "user", "pass": "registry-pass"}},
+		GithubToken:                 "ghp_token",
+	}
+	redacted := creds.Redacted()
+	assert.DeepEqual(t, ClusterCredentials{
+		ProviderCredentials:         map[string]string{"AccessKey": redact.Placeholder, "SecretKey": redact.Placeholder, "Region": "eu-west-1"},
+		DockerRegistriesCredentials: []map[string]interface{}{{"url": "registry.example.com", "user": "user", "pass": redact.Placeholder}},
+		GithubToken:                 redact.Placeholder,
+	}, redacted)
+	assert.StringEqual(t, "secret", creds.ProviderCredentials["SecretKey"])
+}
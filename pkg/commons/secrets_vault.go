@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// vaultSecrets reads the secrets from a HashiCorp Vault KV v2 secret, whose
+// data holds the same keys as the secrets in a secrets file
+type vaultSecrets struct {
+	address   string
+	token     string
+	namespace string
+	mount     string
+	path      string
+	client    *http.Client
+}
+
+// newVaultSecrets returns the provider reading the secret at location
+// (<mount>/<path>) from the Vault server at VAULT_ADDR
+func newVaultSecrets(location string) (*vaultSecrets, error) {
+	mount, path, _ := strings.Cut(strings.Trim(location, "/"), "/")
+	if mount == "" || path == "" {
+		return nil, errors.Errorf("invalid vault secret %q, it must be <mount>/<path>", location)
+	}
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		return nil, errors.New("VAULT_ADDR must be set to read the secrets from vault")
+	}
+	return &vaultSecrets{
+		address:   strings.TrimSuffix(address, "/"),
+		token:     os.Getenv("VAULT_TOKEN"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		mount:     mount,
+		path:      path,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *vaultSecrets) Source() string {
+	return "vault secret " + s.mount + "/" + s.path
+}
+
+// Exists always returns true, the secret is only checked by Read
+func (s *vaultSecrets) Exists() bool {
+	return true
+}
+
+func (s *vaultSecrets) Read() (*SecretsFile, error) {
+	req, err := http.NewRequest(http.MethodGet, s.address+"/v1/"+s.mount+"/data/"+s.path, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build the vault request")
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+	if s.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to vault")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the vault response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to read the vault secret %s/%s: %s", s.mount, s.path, vaultErrors(resp.Status, body))
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the vault response")
+	}
+	raw, err := yaml.Marshal(map[string]interface{}{"secrets": secret.Data.Data})
+	if err != nil {
+		return nil, err
+	}
+	return parseSecrets(raw)
+}
+
+// vaultErrors returns the errors in a vault error response, or its status
+func vaultErrors(status string, body []byte) string {
+	var response struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Errors) == 0 {
+		return status
+	}
+	return status + ": " + strings.Join(response.Errors, ", ")
+}
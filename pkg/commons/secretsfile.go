@@ -0,0 +1,305 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/oleiade/reflections"
+	vault "github.com/sosedoff/ansible-vault-go"
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// ReadSecretsFile returns the decrypted content of the ansible-vault
+// encrypted secrets file at path
+func ReadSecretsFile(path string, vaultPassword string) ([]byte, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.Wrap(err, "failed to read the secrets file")
+	}
+	raw, err := vault.DecryptFile(path, vaultPassword)
+	if err != nil {
+		return nil, errors.New("the vaultPassword is incorrect")
+	}
+	return []byte(raw), nil
+}
+
+// WriteSecretsFile checks raw and writes it to the secrets file at path,
+// encrypted with the vault password
+func WriteSecretsFile(path string, raw []byte, vaultPassword string) error {
+	if _, err := ParseSecretsFile(raw); err != nil {
+		return err
+	}
+	return writeSecretsFile(path, raw, vaultPassword)
+}
+
+// writeSecretsFile encrypts raw with the vault password and replaces the
+// secrets file at path atomically, so it is never left half written
+func writeSecretsFile(path string, raw []byte, vaultPassword string) error {
+	encrypted, err := vault.Encrypt(string(raw), vaultPassword)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt the secrets file")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "failed to write the secrets file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(encrypted); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write the secrets file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write the secrets file")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(err, "failed to write the secrets file")
+	}
+	return nil
+}
+
+// RekeySecretsFile re-encrypts the secrets file at path with a new vault password
+func RekeySecretsFile(path string, vaultPassword string, newVaultPassword string) error {
+	raw, err := ReadSecretsFile(path, vaultPassword)
+	if err != nil {
+		return err
+	}
+	return WriteSecretsFile(path, raw, newVaultPassword)
+}
+
+// ParseSecretsFile parses raw, which must be a secrets file without unknown keys
+func ParseSecretsFile(raw []byte) (*SecretsFile, error) {
+	var secretsFile SecretsFile
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&secretsFile); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "invalid secrets file")
+	}
+	return &secretsFile, nil
+}
+
+// SetSecret sets the secret at key (e.g. aws.credentials.secret_key or
+// docker_registries.0.pass) to value in the secrets file raw, keeping the
+// rest of it. An index one past the end of a list appends to it
+func SetSecret(raw []byte, key string, value string) ([]byte, error) {
+	doc, err := secretsDocument(raw)
+	if err != nil {
+		return nil, err
+	}
+	node := doc.Content[0]
+	path := secretsPath(key)
+	for i, name := range path {
+		last := i == len(path)-1
+		child, err := secretsChild(node, name, !last && isIndex(path[i+1]))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid secret "+key)
+		}
+		if last {
+			*child = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		}
+		node = child
+	}
+	return encodeSecrets(doc)
+}
+
+// UnsetSecret removes the secret at key from the secrets file raw, removing
+// the maps and lists left empty
+func UnsetSecret(raw []byte, key string) ([]byte, error) {
+	doc, err := secretsDocument(raw)
+	if err != nil {
+		return nil, err
+	}
+	path := secretsPath(key)
+	found, err := unsetSecret(doc.Content[0], path)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid secret "+key)
+	}
+	if !found {
+		return nil, errors.Errorf("secret %s is not set", key)
+	}
+	return encodeSecrets(doc)
+}
+
+func secretsDocument(raw []byte) (*yaml.Node, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(raw, doc); err != nil {
+		return nil, errors.Wrap(err, "invalid secrets file")
+	}
+	if len(doc.Content) == 0 {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("invalid secrets file: it must be a map")
+	}
+	return doc, nil
+}
+
+func encodeSecrets(doc *yaml.Node) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if _, err := ParseSecretsFile(b.Bytes()); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// secretsPath splits key, which may start with the secrets key itself
+func secretsPath(key string) []string {
+	return append([]string{"secrets"}, strings.Split(strings.TrimPrefix(key, "secrets."), ".")...)
+}
+
+func isIndex(name string) bool {
+	_, err := strconv.Atoi(name)
+	return err == nil
+}
+
+// secretsChild returns the child of node called name, creating it (as a list
+// if list is set, as a map otherwise) if it does not exist
+func secretsChild(node *yaml.Node, name string, list bool) (*yaml.Node, error) {
+	kind := yaml.MappingNode
+	if list {
+		kind = yaml.SequenceNode
+	}
+	// an empty key (e.g. "secrets:") is null
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		*node = yaml.Node{Kind: yaml.MappingNode}
+		if isIndex(name) {
+			node.Kind = yaml.SequenceNode
+		}
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				return node.Content[i+1], nil
+			}
+		}
+		child := &yaml.Node{Kind: kind}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, child)
+		return child, nil
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 || i > len(node.Content) {
+			return nil, errors.Errorf("%s is not an index of the list", name)
+		}
+		if i == len(node.Content) {
+			node.Content = append(node.Content, &yaml.Node{Kind: kind})
+		}
+		return node.Content[i], nil
+	}
+	return nil, errors.Errorf("%s is a value", name)
+}
+
+// unsetSecret removes path from node, reporting if it was found
+func unsetSecret(node *yaml.Node, path []string) (bool, error) {
+	name := path[0]
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			if node.Content[i].Value != name {
+				continue
+			}
+			if len(path) > 1 {
+				found, err := unsetSecret(node.Content[i+1], path[1:])
+				if !found || err != nil || len(node.Content[i+1].Content) > 0 {
+					return found, err
+				}
+			}
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true, nil
+		}
+		return false, nil
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 || i >= len(node.Content) {
+			return false, nil
+		}
+		if len(path) > 1 {
+			found, err := unsetSecret(node.Content[i], path[1:])
+			if !found || err != nil || len(node.Content[i].Content) > 0 {
+				return found, err
+			}
+		}
+		node.Content = append(node.Content[:i], node.Content[i+1:]...)
+		return true, nil
+	}
+	return false, errors.Errorf("%s is a value", name)
+}
+
+// ValidateSecrets checks that the secrets hold every credential required by
+// the descriptor: the cloud provider ones and those of the docker_registries
+// and helm_repository with auth_required
+func ValidateSecrets(spec KeosSpec, secrets Secrets) error {
+	errs := []error{}
+	required := func(path string, credentials interface{}) {
+		value := reflect.ValueOf(credentials)
+		for i := 0; i < value.NumField(); i++ {
+			if value.Field(i).IsZero() {
+				name := strings.Split(value.Type().Field(i).Tag.Get("yaml"), ",")[0]
+				errs = append(errs, &FieldError{Path: path + "." + name, Severity: SeverityError, Message: "is required"})
+			}
+		}
+	}
+
+	// the secrets of each provider are in the field named after it
+	if providerSecrets, err := reflections.GetField(secrets, strings.ToUpper(spec.InfraProvider)); err == nil {
+		if credentials, err := reflections.GetField(providerSecrets, "Credentials"); err == nil {
+			required("secrets."+spec.InfraProvider+".credentials", credentials)
+		}
+	}
+
+	for i, dockerRegistry := range spec.DockerRegistries {
+		if !dockerRegistry.AuthRequired {
+			continue
+		}
+		found := false
+		for l, credentials := range secrets.DockerRegistries {
+			if credentials.URL == dockerRegistry.URL {
+				found = true
+				required("secrets.docker_registries["+strconv.Itoa(l)+"]", credentials)
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, &FieldError{Path: "spec.docker_registries[" + strconv.Itoa(i) + "].auth_required", Severity: SeverityError, Message: "there aren't credentials for the registry: " + dockerRegistry.URL, Hint: "add them to secrets.docker_registries"})
+		}
+	}
+
+	if spec.HelmRepository.AuthRequired {
+		if secrets.HelmRepository.URL != spec.HelmRepository.URL {
+			errs = append(errs, &FieldError{Path: "spec.helm_repository.auth_required", Severity: SeverityError, Message: "there aren't credentials for the repository: " + spec.HelmRepository.URL, Hint: "add them to secrets.helm_repository"})
+		} else {
+			required("secrets.helm_repository", secrets.HelmRepository)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.NewAggregate(errs)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestSetSecret(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name        string
+		Raw         string
+		Key         string
+		Value       string
+		Expected    string
+		ExpectError bool
+	}{
+		{
+			Name:     "empty file",
+			Raw:      "",
+			Key:      "aws.credentials.access_key",
+			Value:    "AKIA",
+			Expected: "secrets:\n  aws:\n    credentials:\n      access_key: AKIA\n",
+		},
+		{
+			Name:     "existing value",
+			Raw:      "secrets:\n  github_token: ghp_old\n  aws:\n    credentials:\n      access_key: AKIA\n",
+			Key:      "secrets.github_token",
+			Value:    "ghp_new",
+			Expected: "secrets:\n  github_token: ghp_new\n  aws:\n    credentials:\n      access_key: AKIA\n",
+		},
+		{
+			Name:     "appended to a list",
+			Raw:      "secrets:\n  docker_registries:\n    - url: registry.example.com\n",
+			Key:      "docker_registries.1.url",
+			Value:    "registry2.example.com",
+			Expected: "secrets:\n  docker_registries:\n    - url: registry.example.com\n    - url: registry2.example.com\n",
+		},
+		{
+			Name:     "numbers are kept as strings",
+			Raw:      "secrets:\n",
+			Key:      "aws.credentials.account_id",
+			Value:    "0123",
+			Expected: "secrets:\n  aws:\n    credentials:\n      account_id: \"0123\"\n",
+		},
+		{
+			Name:        "unknown key",
+			Raw:         "secrets:\n",
+			Key:         "aws.credentials.acess_key",
+			Value:       "AKIA",
+			ExpectError: true,
+		},
+		{
+			Name:        "index out of range",
+			Raw:         "secrets:\n  docker_registries:\n    - url: registry.example.com\n",
+			Key:         "docker_registries.3.url",
+			Value:       "registry2.example.com",
+			ExpectError: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			raw, err := SetSecret([]byte(tc.Raw), tc.Key, tc.Value)
+			assert.ExpectError(t, tc.ExpectError, err)
+			if err == nil {
+				assert.StringEqual(t, tc.Expected, string(raw))
+			}
+		})
+	}
+}
+
+func TestUnsetSecret(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Name        string
+		Raw         string
+		Key         string
+		Expected    string
+		ExpectError bool
+	}{
+		{
+			Name:     "empty maps are removed",
+			Raw:      "secrets:\n  github_token: ghp_token\n  aws:\n    credentials:\n      access_key: AKIA\n",
+			Key:      "aws.credentials.access_key",
+			Expected: "secrets:\n  github_token: ghp_token\n",
+		},
+		{
+			Name:     "list item",
+			Raw:      "secrets:\n  docker_registries:\n    - url: registry.example.com\n    - url: registry2.example.com\n",
+			Key:      "docker_registries.0",
+			Expected: "secrets:\n  docker_registries:\n    - url: registry2.example.com\n",
+		},
+		{
+			Name:        "not set",
+			Raw:         "secrets:\n  github_token: ghp_token\n",
+			Key:         "helm_repository.pass",
+			ExpectError: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			raw, err := UnsetSecret([]byte(tc.Raw), tc.Key)
+			assert.ExpectError(t, tc.ExpectError, err)
+			if err == nil {
+				assert.StringEqual(t, tc.Expected, string(raw))
+			}
+		})
+	}
+}
+
+func TestRekeySecretsFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "secrets.yml")
+	assert.ExpectError(t, false, WriteSecretsFile(path, []byte(secretsYAML), "old"))
+	assert.ExpectError(t, true, RekeySecretsFile(path, "wrong", "new"))
+	assert.ExpectError(t, false, RekeySecretsFile(path, "old", "new"))
+
+	_, err := ReadSecretsFile(path, "old")
+	assert.ExpectError(t, true, err)
+	raw, err := ReadSecretsFile(path, "new")
+	assert.ExpectError(t, false, err)
+	assert.StringEqual(t, secretsYAML, string(raw))
+
+	// the temporary file is renamed over the secrets file
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.ExpectError(t, false, err)
+	assert.DeepEqual(t, 1, len(entries))
+
+	assert.ExpectError(t, true, WriteSecretsFile(path, []byte("secrets:\n  unknown: value\n"), "new"))
+}
+
+func TestValidateSecrets(t *testing.T) {
+	t.Parallel()
+	spec := KeosSpec{
+		InfraProvider: "aws",
+		DockerRegistries: []DockerRegistry{
+			{URL: "registry.example.com", AuthRequired: true},
+			{URL: "public.example.com"},
+			{URL: "registry2.example.com", AuthRequired: true},
+		},
+		HelmRepository: HelmRepository{URL: "https://charts.example.com", AuthRequired: true},
+	}
+	secrets := Secrets{
+		AWS: AWS{Credentials: AWSCredentials{AccessKey: "AKIA", SecretKey: "secret", Region: "eu-west-1"}},
+		DockerRegistries: []DockerRegistryCredentials{
+			{URL: "registry.example.com", User: "user"},
+		},
+		HelmRepository: HelmRepositoryCredentials{URL: "https://charts.example.com", User: "user", Pass: "pass"},
+	}
+	paths := []string{}
+	for _, fieldErr := range FieldErrors(ValidateSecrets(spec, secrets)) {
+		paths = append(paths, fieldErr.Path)
+	}
+	assert.DeepEqual(t, []string{
+		"secrets.aws.credentials.account_id",
+		"secrets.docker_registries[0].pass",
+		"spec.docker_registries[2].auth_required",
+	}, paths)
+}
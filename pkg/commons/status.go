@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+// WorkloadClusterStatus is the state of a running workload cluster, as
+// reported by its Cluster API objects, its KeosCluster and its helm releases
+type WorkloadClusterStatus struct {
+	Name string `json:"name"`
+	// Phase is the phase of the Cluster API cluster, e.g. Provisioned
+	Phase        string              `json:"phase,omitempty"`
+	Conditions   []ClusterCondition  `json:"conditions"`
+	ControlPlane ControlPlaneStatus  `json:"controlPlane"`
+	Workers      []WorkerGroupStatus `json:"workers"`
+	// KeosCluster is the status reported by the keoscluster-controller
+	KeosCluster            map[string]interface{} `json:"keosCluster,omitempty"`
+	ClusterOperatorVersion string                 `json:"clusterOperatorVersion,omitempty"`
+	Addons                 []AddonStatus          `json:"addons"`
+}
+
+// ClusterCondition is a condition of the Cluster API cluster
+type ClusterCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ControlPlaneStatus is the state of the control plane, whose replicas are not
+// reported by the managed ones
+type ControlPlaneStatus struct {
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	Ready         bool   `json:"ready"`
+	Replicas      int    `json:"replicas,omitempty"`
+	ReadyReplicas int    `json:"readyReplicas,omitempty"`
+}
+
+// WorkerGroupStatus is the state of a MachineDeployment or MachinePool, only
+// the former reports the updated replicas
+type WorkerGroupStatus struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Desired int    `json:"desired"`
+	Ready   int    `json:"ready"`
+	Updated *int   `json:"updated,omitempty"`
+}
+
+// AddonStatus is a helm release installed in the cluster
+type AddonStatus struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Chart      string `json:"chart"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion,omitempty"`
+	Status     string `json:"status"`
+}
@@ -39,9 +39,6 @@ import (
 	"sigs.k8s.io/kind/pkg/errors"
 )
 
-const secretName = "secrets.yml"
-const secretPath = "./" + secretName
-
 func decryptFile(filePath string, vaultPassword string) (string, error) {
 	data, err := vault.DecryptFile(filePath, vaultPassword)
 	if err != nil {
@@ -60,7 +57,9 @@ func convertStringMapToInterfaceMap(inputMap map[string]string) map[string]inter
 	return outputMap
 }
 
-func EnsureSecretsFile(spec KeosSpec, vaultPassword string, clusterCredentials ClusterCredentials) error {
+// EnsureSecretsFile writes the credentials missing in the ansible-vault
+// encrypted secrets file at secretsPath, creating it if needed
+func EnsureSecretsFile(secretsPath string, spec KeosSpec, vaultPassword string, clusterCredentials ClusterCredentials) error {
 	var err error
 
 	edited := false
@@ -71,7 +70,7 @@ func EnsureSecretsFile(spec KeosSpec, vaultPassword string, clusterCredentials C
 	helmRepository := clusterCredentials.HelmRepositoryCredentials
 	github_token := clusterCredentials.GithubToken
 
-	_, err = os.Stat(secretPath)
+	_, err = os.Stat(secretsPath)
 	if err != nil {
 		secretMap := map[string]interface{}{}
 		if github_token != "" {
@@ -106,14 +105,14 @@ func EnsureSecretsFile(spec KeosSpec, vaultPassword string, clusterCredentials C
 			"secrets": secretMap,
 		}
 
-		err = encryptSecret(secretFileMap, vaultPassword)
+		err = encryptSecret(secretsPath, secretFileMap, vaultPassword)
 		if err != nil {
 			return err
 		}
 		return nil
 	}
 	// En caso de que exista
-	secretRaw, err := decryptFile(secretPath, vaultPassword)
+	secretRaw, err := decryptFile(secretsPath, vaultPassword)
 	if err != nil {
 		return err
 	}
@@ -140,7 +139,7 @@ func EnsureSecretsFile(spec KeosSpec, vaultPassword string, clusterCredentials C
 		edited = true
 		helmRepo := convertStringMapToInterfaceMap(helmRepository)
 		helmRepo = ConvertMapKeysToSnakeCase(helmRepo)
-		secretMap["secrets"]["docker_registry"] = helmRepo
+		secretMap["secrets"]["helm_repository"] = helmRepo
 	}
 	if secretMap["secrets"]["github_token"] == nil && github_token != "" {
 		edited = true
@@ -154,7 +153,7 @@ func EnsureSecretsFile(spec KeosSpec, vaultPassword string, clusterCredentials C
 		secretMap["secrets"]["docker_registries"] = dockerRegistries
 	}
 	if edited {
-		err = encryptSecret(secretMap, vaultPassword)
+		err = encryptSecret(secretsPath, secretMap, vaultPassword)
 		if err != nil {
 			return err
 		}
@@ -202,19 +201,14 @@ func RewriteDescriptorFile(descriptorPath string) error {
 	return nil
 }
 
-func encryptSecret(secretMap map[string]map[string]interface{}, vaultPassword string) error {
+func encryptSecret(secretsPath string, secretMap map[string]map[string]interface{}, vaultPassword string) error {
 
 	var b bytes.Buffer
 	yamlEncoder := yaml.NewEncoder(&b)
 	yamlEncoder.SetIndent(2)
 	yamlEncoder.Encode(&secretMap)
 
-	err := vault.EncryptFile(secretPath, b.String(), vaultPassword)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return writeSecretsFile(secretsPath, b.Bytes(), vaultPassword)
 }
 
 func removeKey(nodes []*yaml.Node, key string) []*yaml.Node {
@@ -307,11 +301,14 @@ func AWSGetConfig(ctx context.Context, secrets map[string]string, region string)
 	customProvider := credentials.NewStaticCredentialsProvider(
 		secrets["AccessKey"], secrets["SecretKey"], "",
 	)
-	cfg, err := config.LoadDefaultConfig(
-		ctx,
+	options := []func(*config.LoadOptions) error{
 		config.WithCredentialsProvider(customProvider),
 		config.WithRegion(region),
-	)
+	}
+	if caBundle != "" {
+		options = append(options, config.WithCustomCABundle(strings.NewReader(caBundle)))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
 	if err != nil {
 		return aws.Config{}, err
 	}
@@ -390,8 +387,12 @@ func AWSGetAZs(ctx context.Context, svc *ec2.Client) ([]string, error) {
 }
 
 func AzureGetConfig(secrets map[string]string) (*azidentity.ClientSecretCredential, error) {
+	var options *azidentity.ClientSecretCredentialOptions
+	if clientOptions := AzureClientOptions(); clientOptions != nil {
+		options = &azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions.ClientOptions}
+	}
 	cfg, err := azidentity.NewClientSecretCredential(
-		secrets["TenantID"], secrets["ClientID"], secrets["ClientSecret"], nil,
+		secrets["TenantID"], secrets["ClientID"], secrets["ClientSecret"], options,
 	)
 	if err != nil {
 		return &azidentity.ClientSecretCredential{}, err
@@ -0,0 +1,83 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commons
+
+import (
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// Severity classifies a FieldError
+type Severity string
+
+const (
+	// SeverityError makes the descriptor invalid
+	SeverityError Severity = "error"
+	// SeverityWarning is reported without making the descriptor invalid
+	SeverityWarning Severity = "warning"
+	// SeveritySkipped is a check not run by the offline validation
+	SeveritySkipped Severity = "skipped"
+)
+
+// FieldError is a problem found validating the descriptor, located by the
+// path of the field (e.g. spec.worker_nodes[2].quantity)
+type FieldError struct {
+	Path     string   `json:"path"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Hint     string   `json:"hint,omitempty"`
+}
+
+func (e *FieldError) Error() string {
+	msg := e.Message
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	return msg
+}
+
+// FieldErrors returns the FieldErrors aggregated in err, any other error is
+// returned as a FieldError without path
+func FieldErrors(err error) []*FieldError {
+	fieldErrors := []*FieldError{}
+	if err == nil {
+		return fieldErrors
+	}
+	errs := errors.Errors(err)
+	if len(errs) == 0 {
+		// a single error is not aggregated
+		cause := err
+		for {
+			causer, ok := cause.(errors.Causer)
+			if !ok {
+				break
+			}
+			cause = causer.Cause()
+		}
+		errs = []error{cause}
+	}
+	for _, e := range errs {
+		fe, ok := e.(*FieldError)
+		if !ok {
+			fe = &FieldError{Severity: SeverityError, Message: e.Error()}
+		}
+		fieldErrors = append(fieldErrors, fe)
+	}
+	return fieldErrors
+}
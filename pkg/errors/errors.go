@@ -18,14 +18,18 @@ package errors
 
 import (
 	stderrors "errors"
+	"fmt"
 
 	pkgerrors "github.com/pkg/errors"
+
+	"sigs.k8s.io/kind/pkg/internal/redact"
 )
 
 // New returns an error with the supplied message.
 // New also records the stack trace at the point it was called.
+// The secrets loaded (see pkg/internal/redact) are scrubbed from the message.
 func New(message string) error {
-	return pkgerrors.New(message)
+	return pkgerrors.New(redact.String(message))
 }
 
 // NewWithoutStack is like new but does NOT wrap with a stack
@@ -38,19 +42,20 @@ func NewWithoutStack(message string) error {
 // value that satisfies error. Errorf also records the stack trace at the
 // point it was called.
 func Errorf(format string, args ...interface{}) error {
-	return pkgerrors.Errorf(format, args...)
+	return pkgerrors.New(redact.String(fmt.Sprintf(format, args...)))
 }
 
 // Wrap returns an error annotating err with a stack trace at the point Wrap
 // is called, and the supplied message. If err is nil, Wrap returns nil.
+// The secrets loaded (see pkg/internal/redact) are scrubbed from the message.
 func Wrap(err error, message string) error {
-	return pkgerrors.Wrap(err, message)
+	return pkgerrors.Wrap(err, redact.String(message))
 }
 
 // Wrapf returns an error annotating err with a stack trace at the point Wrapf
 // is called, and the format specifier. If err is nil, Wrapf returns nil.
 func Wrapf(err error, format string, args ...interface{}) error {
-	return pkgerrors.Wrapf(err, format, args...)
+	return pkgerrors.Wrap(err, redact.String(fmt.Sprintf(format, args...)))
 }
 
 // WithStack annotates err with a stack trace at the point WithStack was called.
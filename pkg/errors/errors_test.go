@@ -22,6 +22,7 @@ import (
 	pkgerrors "github.com/pkg/errors"
 
 	"sigs.k8s.io/kind/pkg/internal/assert"
+	"sigs.k8s.io/kind/pkg/internal/redact"
 )
 
 func TestStackTrace(t *testing.T) {
@@ -40,3 +41,11 @@ func TestStackTrace(t *testing.T) {
 		assert.DeepEqual(t, expected, result)
 	})
 }
+
+func TestRedactedMessages(t *testing.T) {
+	t.Parallel()
+	redact.Add("errors-test-secret")
+	err := Wrapf(Wrap(Errorf("login --password %s", "errors-test-secret"), "Command Output: errors-test-secret"), "failed with %s", "errors-test-secret")
+	assert.StringEqual(t, "failed with [REDACTED]: Command Output: [REDACTED]: login --password [REDACTED]", err.Error())
+	assert.StringEqual(t, "[REDACTED]", New("errors-test-secret").Error())
+}
@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/internal/redact"
 )
 
 // LocalCmd wraps os/exec.Cmd, implementing the kind/pkg/exec.Cmd interface
@@ -121,9 +122,10 @@ func (cmd *LocalCmd) Run() error {
 	}
 	// TODO: should be in the caller or logger should be injected somehow ...
 	if err := cmd.Cmd.Run(); err != nil {
+		// the arguments and output may hold credentials (e.g. registry passwords)
 		return errors.WithStack(&RunError{
-			Command: cmd.Args,
-			Output:  combinedOutput.Bytes(),
+			Command: redact.Strings(cmd.Args),
+			Output:  redact.Bytes(combinedOutput.Bytes()),
 			Inner:   err,
 		})
 	}
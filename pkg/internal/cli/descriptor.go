@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kind/pkg/commons"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+// DescriptorDefaultPath is the default path of the cluster descriptor
+const DescriptorDefaultPath = "./cluster.yaml"
+
+// SecretsDefaultPath is the default path of the secrets file
+const SecretsDefaultPath = "./secrets.yml"
+
+// DescriptorOptions locate the cluster descriptor of a command, its secrets
+// and the bill of materials overriding the component versions
+type DescriptorOptions struct {
+	// DescriptorPath is the path of the descriptor, DescriptorDefaultPath if empty
+	DescriptorPath string
+	// Secrets is the source of the secrets, see commons.NewSecretsProvider
+	Secrets string
+	// BOM is the path of the bill of materials override, if any
+	BOM string
+	// Vault holds the flags setting the vault password of the secrets file
+	Vault *VaultPassword
+	// ConfirmVaultPassword requests the vault password twice if the secrets
+	// file does not exist yet, as it is going to be created
+	ConfirmVaultPassword bool
+	// Offline does not read the vault password, as no secret is going to be read
+	Offline bool
+}
+
+// Descriptor is the cluster descriptor of a command along with its secrets
+type Descriptor struct {
+	KeosCluster   *commons.KeosCluster
+	ClusterConfig *commons.ClusterConfig
+	// Path is the path the descriptor was read from
+	Path string
+	// SecretsPath is the path of the secrets file if it is ansible-vault
+	// encrypted, empty otherwise
+	SecretsPath   string
+	VaultPassword string
+	Secrets       commons.SecretsProvider
+}
+
+// LoadDescriptor loads the bill of materials override, reads the vault
+// password if the secrets are an ansible-vault encrypted file, parses the
+// cluster descriptor and loads its CA bundle, if any
+func LoadDescriptor(opts DescriptorOptions) (*Descriptor, error) {
+	if opts.BOM != "" {
+		if err := commons.LoadBOM(opts.BOM); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &Descriptor{Path: opts.DescriptorPath}
+	if d.Path == "" {
+		d.Path = DescriptorDefaultPath
+	}
+
+	// Only an ansible-vault secrets file needs the vault password
+	secretsPath, ansibleVault := commons.AnsibleVaultPath(opts.Secrets)
+	if ansibleVault {
+		d.SecretsPath = secretsPath
+		if !opts.Offline {
+			_, statErr := os.Stat(secretsPath)
+			password, err := opts.Vault.Get(opts.ConfirmVaultPassword && os.IsNotExist(statErr))
+			if err != nil {
+				return nil, err
+			}
+			d.VaultPassword = password
+		}
+	}
+	secrets, err := commons.NewSecretsProvider(opts.Secrets, d.VaultPassword)
+	if err != nil {
+		return nil, err
+	}
+	d.Secrets = secrets
+
+	d.KeosCluster, d.ClusterConfig, err = commons.GetClusterDescriptor(d.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse cluster descriptor")
+	}
+
+	// A relative ca_bundle is relative to the descriptor, not to the working directory
+	if caBundle := d.KeosCluster.Spec.CABundle; caBundle != "" {
+		if !filepath.IsAbs(caBundle) {
+			caBundle = filepath.Join(filepath.Dir(d.Path), caBundle)
+			d.KeosCluster.Spec.CABundle = caBundle
+		}
+		if err := commons.LoadCABundle(caBundle); err != nil {
+			return nil, errors.Wrap(err, "failed to load the CA bundle of the descriptor")
+		}
+	}
+	return d, nil
+}
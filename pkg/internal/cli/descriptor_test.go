@@ -0,0 +1,151 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+const testDescriptor = `apiVersion: installer.stratio.com/v1beta1
+kind: KeosCluster
+metadata:
+  name: test
+spec:
+  infra_provider: docker
+  k8s_version: v1.26.8
+  region: local
+  external_domain: domain.ext
+  docker_registries:
+    - url: registry.example.com/keos
+      type: generic
+      keos_registry: true
+  helm_repository:
+    url: https://charts.example.com
+  control_plane:
+    size: small
+  worker_nodes:
+    - name: worker1
+      quantity: 1
+      size: small
+`
+
+func TestLoadDescriptor(t *testing.T) {
+	dir := t.TempDir()
+	descriptor := filepath.Join(dir, "cluster.yaml")
+	if err := os.WriteFile(descriptor, []byte(testDescriptor), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secrets := filepath.Join(dir, "secrets.yml")
+
+	// the CA bundle of the descriptor is relative to it, not to the working directory
+	caDir := filepath.Join(dir, "ca")
+	if err := os.Mkdir(caDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	withCABundle := filepath.Join(caDir, "cluster.yaml")
+	if err := os.WriteFile(withCABundle, []byte(testDescriptor+"  ca_bundle: ca.pem\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	withInvalidCABundle := filepath.Join(caDir, "invalid.yaml")
+	if err := os.WriteFile(withInvalidCABundle, []byte(testDescriptor+"  ca_bundle: invalid.pem\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ca, err := os.ReadFile("../../commons/testdata/ca.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(caDir, "ca.pem"), ca, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(caDir, "invalid.pem"), []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name                  string
+		Options               DescriptorOptions
+		ExpectedPath          string
+		ExpectedSecretsPath   string
+		ExpectedVaultPassword string
+		ExpectedCABundle      string
+		ExpectError           bool
+	}{
+		{
+			Name:                  "ansible-vault secrets file",
+			Options:               DescriptorOptions{DescriptorPath: descriptor, Secrets: secrets, Vault: &VaultPassword{Password: "s3cr3t"}},
+			ExpectedPath:          descriptor,
+			ExpectedSecretsPath:   secrets,
+			ExpectedVaultPassword: "s3cr3t",
+		},
+		{
+			Name:                "offline ansible-vault secrets file",
+			Options:             DescriptorOptions{DescriptorPath: descriptor, Secrets: "ansible-vault:" + secrets, Vault: &VaultPassword{}, Offline: true},
+			ExpectedPath:        descriptor,
+			ExpectedSecretsPath: secrets,
+		},
+		{
+			Name:         "environment secrets",
+			Options:      DescriptorOptions{DescriptorPath: descriptor, Secrets: "env:", Vault: &VaultPassword{}},
+			ExpectedPath: descriptor,
+		},
+		{
+			Name:             "CA bundle relative to the descriptor",
+			Options:          DescriptorOptions{DescriptorPath: withCABundle, Secrets: "env:", Vault: &VaultPassword{}},
+			ExpectedPath:     withCABundle,
+			ExpectedCABundle: filepath.Join(caDir, "ca.pem"),
+		},
+		{
+			Name:        "invalid CA bundle",
+			Options:     DescriptorOptions{DescriptorPath: withInvalidCABundle, Secrets: "env:", Vault: &VaultPassword{}},
+			ExpectError: true,
+		},
+		{
+			Name:        "unknown secrets source",
+			Options:     DescriptorOptions{DescriptorPath: descriptor, Secrets: "unknown:", Vault: &VaultPassword{}},
+			ExpectError: true,
+		},
+		{
+			Name:        "missing descriptor",
+			Options:     DescriptorOptions{DescriptorPath: filepath.Join(dir, "missing.yaml"), Secrets: "env:", Vault: &VaultPassword{}},
+			ExpectError: true,
+		},
+		{
+			Name:        "missing bill of materials",
+			Options:     DescriptorOptions{DescriptorPath: descriptor, Secrets: "env:", BOM: filepath.Join(dir, "bom.yaml"), Vault: &VaultPassword{}},
+			ExpectError: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			d, err := LoadDescriptor(tc.Options)
+			assert.ExpectError(t, tc.ExpectError, err)
+			if err != nil {
+				return
+			}
+			assert.StringEqual(t, tc.ExpectedPath, d.Path)
+			assert.StringEqual(t, tc.ExpectedSecretsPath, d.SecretsPath)
+			assert.StringEqual(t, tc.ExpectedVaultPassword, d.VaultPassword)
+			assert.BoolEqual(t, true, d.KeosCluster != nil && d.Secrets != nil)
+			assert.StringEqual(t, tc.ExpectedCABundle, d.KeosCluster.Spec.CABundle)
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"sigs.k8s.io/kind/pkg/log"
 
 	"sigs.k8s.io/kind/pkg/internal/env"
+	"sigs.k8s.io/kind/pkg/internal/redact"
 )
 
 // Logger is the kind cli's log.Logger implementation
@@ -92,7 +93,7 @@ func (l *Logger) writeBuffer(buf *bytes.Buffer) {
 	}
 	// TODO: should we handle this somehow??
 	// Who logs for the logger? 🤔
-	_, _ = l.write(buf.Bytes())
+	_, _ = l.write(redact.Bytes(buf.Bytes()))
 }
 
 // print writes a simple string to the log writer
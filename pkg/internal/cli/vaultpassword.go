@@ -0,0 +1,202 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+
+	"sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+	"sigs.k8s.io/kind/pkg/internal/redact"
+)
+
+// VaultPasswordEnv is the environment variable the vault password is read
+// from if no flag sets it
+const VaultPasswordEnv = "CLOUD_PROVISIONER_VAULT_PASSWORD"
+
+// NewVaultPasswordEnv is the environment variable the new vault password is
+// read from if no flag sets it
+const NewVaultPasswordEnv = "CLOUD_PROVISIONER_NEW_VAULT_PASSWORD"
+
+// VaultPassword holds the flags setting the vault password of the secrets file
+type VaultPassword struct {
+	Password string
+	File     string
+	Client   string
+	// isNew makes it the new password of a rekey, with its own flags,
+	// environment variable and prompt
+	isNew bool
+}
+
+// AddFlags adds the vault password flags to fs, usage describes what the
+// password is used for (e.g. "to decrypt secrets")
+func (v *VaultPassword) AddFlags(fs *pflag.FlagSet, usage string) {
+	v.addFlags(fs, "p", usage)
+}
+
+// AddNewFlags adds the new vault password flags (e.g. --new-vault-password)
+// to fs, usage describes what the password is used for. The password is read
+// from the CLOUD_PROVISIONER_NEW_VAULT_PASSWORD environment variable if no
+// flag sets it
+func (v *VaultPassword) AddNewFlags(fs *pflag.FlagSet, usage string) {
+	v.isNew = true
+	v.addFlags(fs, "", usage)
+}
+
+func (v *VaultPassword) addFlags(fs *pflag.FlagSet, shorthand string, usage string) {
+	fs.StringVarP(
+		&v.Password,
+		v.flag("vault-password"),
+		shorthand,
+		"",
+		"sets "+v.name()+" "+usage+" (visible in the shell history, prefer the other sources)",
+	)
+	fs.StringVar(
+		&v.File,
+		v.flag("vault-password-file"),
+		"",
+		"file whose first line is the "+v.name()+" "+usage,
+	)
+	fs.StringVar(
+		&v.Client,
+		v.flag("vault-password-client"),
+		"",
+		"executable printing the "+v.name()+" "+usage+" (e.g. reading it from a password manager)",
+	)
+}
+
+// flag returns the name of the flag of the password
+func (v *VaultPassword) flag(name string) string {
+	if v.isNew {
+		return "new-" + name
+	}
+	return name
+}
+
+// name returns how the password is referred to in the usages and errors
+func (v *VaultPassword) name() string {
+	if v.isNew {
+		return "new vault password"
+	}
+	return "vault password"
+}
+
+func (v *VaultPassword) env() string {
+	if v.isNew {
+		return NewVaultPasswordEnv
+	}
+	return VaultPasswordEnv
+}
+
+func (v *VaultPassword) prompt() string {
+	if v.isNew {
+		return "New Vault Password: "
+	}
+	return "Vault Password: "
+}
+
+// Get returns the vault password from the first source set among the flags,
+// then the CLOUD_PROVISIONER_VAULT_PASSWORD environment variable, and finally
+// the terminal, where it is requested twice if confirm is set. The password is
+// redacted from the output
+func (v *VaultPassword) Get(confirm bool) (string, error) {
+	password, err := v.get(confirm)
+	if err != nil {
+		return "", err
+	}
+	redact.Add(password)
+	return password, nil
+}
+
+func (v *VaultPassword) get(confirm bool) (string, error) {
+	count := 0
+	for _, source := range []string{v.Password, v.File, v.Client} {
+		if source != "" {
+			count++
+		}
+	}
+	if count > 1 {
+		return "", errors.New("Flags --" + v.flag("vault-password") + ", --" + v.flag("vault-password-file") + " and --" + v.flag("vault-password-client") + " are mutually exclusive")
+	}
+
+	switch {
+	case v.Password != "":
+		return v.Password, nil
+	case v.File != "":
+		raw, err := os.ReadFile(v.File)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read the "+v.name()+" file")
+		}
+		return v.nonEmpty(firstLine(string(raw)), v.File)
+	case v.Client != "":
+		out, err := exec.Output(exec.Command(v.Client))
+		if err != nil {
+			return "", errors.Wrap(err, "failed to run the "+v.name()+" client")
+		}
+		return v.nonEmpty(firstLine(string(out)), v.Client)
+	}
+	if password, ok := os.LookupEnv(v.env()); ok {
+		return v.nonEmpty(password, v.env())
+	}
+
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return "", errors.New("the " + v.name() + " is required, set it with --" + v.flag("vault-password-file") + ", --" + v.flag("vault-password-client") + " or " + v.env())
+	}
+	password, err := RequestPassword(v.prompt())
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		confirmation, err := RequestPassword("Rewrite " + v.prompt())
+		if err != nil {
+			return "", err
+		}
+		if password != confirmation {
+			return "", errors.New("The passwords do not match.")
+		}
+	}
+	return password, nil
+}
+
+// RequestPassword prompts for a password without echoing it
+func RequestPassword(request string) (string, error) {
+	fmt.Print(request)
+	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+	if err != nil {
+		return "", err
+	}
+	fmt.Print("\n")
+	return string(bytePassword), nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSuffix(line, "\r")
+}
+
+func (v *VaultPassword) nonEmpty(password string, source string) (string, error) {
+	if password == "" {
+		return "", errors.New("the " + v.name() + " read from " + source + " is empty")
+	}
+	return password, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"sigs.k8s.io/kind/pkg/internal/assert"
+)
+
+func TestVaultPasswordGet(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "password")
+	if err := os.WriteFile(file, []byte("from-file\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client := filepath.Join(dir, "client.sh")
+	if err := os.WriteFile(client, []byte("#!/bin/sh\necho from-client\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name             string
+		VaultPassword    VaultPassword
+		Env              string
+		ExpectedPassword string
+		ExpectError      bool
+	}{
+		{Name: "flag", VaultPassword: VaultPassword{Password: "from-flag"}, Env: "from-env", ExpectedPassword: "from-flag"},
+		{Name: "file", VaultPassword: VaultPassword{File: file}, Env: "from-env", ExpectedPassword: "from-file"},
+		{Name: "client", VaultPassword: VaultPassword{Client: client}, ExpectedPassword: "from-client"},
+		{Name: "environment", Env: "from-env", ExpectedPassword: "from-env"},
+		{Name: "empty file", VaultPassword: VaultPassword{File: emptyFile}, ExpectError: true},
+		{Name: "missing file", VaultPassword: VaultPassword{File: filepath.Join(dir, "missing")}, ExpectError: true},
+		{Name: "mutually exclusive", VaultPassword: VaultPassword{Password: "from-flag", File: file}, ExpectError: true},
+		// go test does not run with a terminal in stdin
+		{Name: "no source", ExpectError: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			// t.Setenv restores the variable after unsetting it
+			t.Setenv(VaultPasswordEnv, tc.Env)
+			if tc.Env == "" {
+				os.Unsetenv(VaultPasswordEnv)
+			}
+			password, err := tc.VaultPassword.Get(false)
+			assert.ExpectError(t, tc.ExpectError, err)
+			assert.StringEqual(t, tc.ExpectedPassword, password)
+		})
+	}
+}
+
+func TestNewVaultPassword(t *testing.T) {
+	dir := t.TempDir()
+	client := filepath.Join(dir, "client.sh")
+	if err := os.WriteFile(client, []byte("#!/bin/sh\necho new-from-client\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	v := VaultPassword{}
+	fs := pflag.NewFlagSet("rekey", pflag.ContinueOnError)
+	v.AddNewFlags(fs, "to encrypt secrets")
+	if err := fs.Parse([]string{"--new-vault-password-client", client}); err != nil {
+		t.Fatal(err)
+	}
+	password, err := v.Get(true)
+	assert.ExpectError(t, false, err)
+	assert.StringEqual(t, "new-from-client", password)
+
+	// the new password has its own environment variable
+	t.Setenv(VaultPasswordEnv, "old-from-env")
+	t.Setenv(NewVaultPasswordEnv, "new-from-env")
+	v = VaultPassword{isNew: true}
+	password, err = v.Get(true)
+	assert.ExpectError(t, false, err)
+	assert.StringEqual(t, "new-from-env", password)
+
+	v = VaultPassword{Password: "new", File: client, isNew: true}
+	_, err = v.Get(true)
+	if err == nil || !strings.Contains(err.Error(), "--new-vault-password,") {
+		t.Errorf("expected the mutually exclusive error to name the new flags, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package redact scrubs the secrets (credentials, passwords, tokens) loaded
+// by the provisioner from the commands, errors and logs it prints
+package redact
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces the secrets
+const Placeholder = "[REDACTED]"
+
+// minLength is the length of the shortest secret redacted, shorter values
+// would scrub unrelated text
+const minLength = 4
+
+// minLineLength is the length of the shortest line of a multi-line secret
+// (e.g. a PEM private key) redacted on its own
+const minLineLength = 16
+
+// Redactor replaces the secrets added to it
+type Redactor struct {
+	mu       sync.RWMutex
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// New returns an empty Redactor
+func New() *Redactor {
+	return &Redactor{secrets: map[string]struct{}{}}
+}
+
+var global = New()
+
+// Add adds secrets to the global Redactor
+func Add(secrets ...string) {
+	global.Add(secrets...)
+}
+
+// String returns s with the secrets of the global Redactor replaced
+func String(s string) string {
+	return global.String(s)
+}
+
+// Bytes returns b with the secrets of the global Redactor replaced
+func Bytes(b []byte) []byte {
+	return global.Bytes(b)
+}
+
+// Strings returns ss with the secrets of the global Redactor replaced
+func Strings(ss []string) []string {
+	return global.Strings(ss)
+}
+
+// Add adds secrets, together with their JSON escaped form and, for
+// multi-line secrets, each of their lines
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	added := false
+	for _, secret := range secrets {
+		for _, variant := range variants(secret) {
+			if _, ok := r.secrets[variant]; len(variant) >= minLength && !ok {
+				r.secrets[variant] = struct{}{}
+				added = true
+			}
+		}
+	}
+	if !added {
+		return
+	}
+	// the longest secrets are replaced first, so a secret containing another
+	// one is not partially replaced
+	sorted := make([]string, 0, len(r.secrets))
+	for secret := range r.secrets {
+		sorted = append(sorted, secret)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	oldnew := make([]string, 0, 2*len(sorted))
+	for _, secret := range sorted {
+		oldnew = append(oldnew, secret, Placeholder)
+	}
+	r.replacer = strings.NewReplacer(oldnew...)
+}
+
+func variants(secret string) []string {
+	variants := []string{secret}
+	if escaped, err := json.Marshal(secret); err == nil {
+		variants = append(variants, string(escaped[1:len(escaped)-1]))
+	}
+	if strings.Contains(secret, "\n") {
+		for _, line := range strings.Split(secret, "\n") {
+			if line = strings.TrimSpace(line); len(line) >= minLineLength {
+				variants = append(variants, line)
+			}
+		}
+	}
+	return variants
+}
+
+// String returns s with the secrets replaced
+func (r *Redactor) String(s string) string {
+	r.mu.RLock()
+	replacer := r.replacer
+	r.mu.RUnlock()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// Bytes returns b with the secrets replaced
+func (r *Redactor) Bytes(b []byte) []byte {
+	r.mu.RLock()
+	replacer := r.replacer
+	r.mu.RUnlock()
+	if replacer == nil {
+		return b
+	}
+	return []byte(replacer.Replace(string(b)))
+}
+
+// Strings returns a copy of ss with the secrets replaced
+func (r *Redactor) Strings(ss []string) []string {
+	redacted := make([]string, len(ss))
+	for i, s := range ss {
+		redacted[i] = r.String(s)
+	}
+	return redacted
+}